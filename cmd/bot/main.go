@@ -8,6 +8,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/vitos/crypto_trade_level/internal/domain"
 	"github.com/vitos/crypto_trade_level/internal/infrastructure/exchange"
 	"github.com/vitos/crypto_trade_level/internal/infrastructure/logger"
 	"github.com/vitos/crypto_trade_level/internal/infrastructure/storage"
@@ -34,6 +35,22 @@ type Config struct {
 	Server struct {
 		Port int `yaml:"port"`
 	} `yaml:"server"`
+	Webhook struct {
+		Secret string `yaml:"secret"` // Shared secret for alert signatures, empty disables the webhook
+	} `yaml:"webhook"`
+	Discovery struct {
+		IntervalMinutes   int     `yaml:"interval_minutes"` // Background scan period, 0 scans on demand only
+		CandleInterval    string  `yaml:"candle_interval"`
+		AutoCreate        bool    `yaml:"auto_create"`
+		AutoMinConfidence float64 `yaml:"auto_min_confidence"`
+	} `yaml:"discovery"`
+	Risk             domain.RiskLimits              `yaml:"risk"` // Defaults, limits changed from the UI are persisted and win
+	LiquidationGuard usecase.LiquidationGuardConfig `yaml:"liquidation_guard"`
+	CircuitBreaker   usecase.CircuitBreakerConfig   `yaml:"circuit_breaker"`
+	Fees             usecase.FeeConfig              `yaml:"fees"`
+	Market           struct {
+		StatsWindowsSec []int `yaml:"stats_windows_sec"` // Long, mid and short window of market stats, default 60/30/10
+	} `yaml:"market"`
 }
 
 func loadConfig(path string) (*Config, error) {
@@ -80,7 +97,34 @@ func main() {
 
 	// 5. Init Service
 	marketService := usecase.NewMarketService(bybitAdapter, store)
-	svc := usecase.NewLevelService(store, store, bybitAdapter, marketService)
+	if w := cfg.Market.StatsWindowsSec; len(w) == 3 {
+		windows := usecase.StatsWindows{
+			Long:  time.Duration(w[0]) * time.Second,
+			Mid:   time.Duration(w[1]) * time.Second,
+			Short: time.Duration(w[2]) * time.Second,
+		}
+		if err := marketService.SetStatsWindows(windows); err != nil {
+			log.Error("Invalid market stats windows, using defaults", zap.Error(err))
+		}
+	}
+	// Every order path goes through the risk manager, and below it the circuit breakers
+	// that count what the exchange does with the orders the risk manager lets through
+	breakers := usecase.NewCircuitBreakers(cfg.CircuitBreaker)
+	riskManager := usecase.NewRiskManager(bybitAdapter, store, cfg.Risk)
+	tradingExchange := usecase.NewRiskManagedExchange(usecase.NewBreakerExchange(bybitAdapter, breakers), riskManager)
+
+	svc := usecase.NewLevelService(store, store, tradingExchange, marketService)
+	svc.SetShadowRepository(store)
+	svc.SetDecisionRepository(store)
+	svc.SetLiquidationGuard(cfg.LiquidationGuard)
+	svc.SetRiskManager(riskManager)
+	svc.SetCircuitBreakers(breakers)
+	feeModel, err := usecase.NewFeeModel(cfg.Fees)
+	if err != nil {
+		log.Error("Invalid fee config, using the base VIP tier", zap.Error(err))
+		feeModel = usecase.DefaultFeeModel
+	}
+	svc.SetFeeModel(feeModel)
 
 	// Init Cache
 	if err := svc.UpdateCache(context.Background()); err != nil {
@@ -91,14 +135,59 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
-	// 6. Connect WS and Start Processing (with Reload Loop)
-	// Register callback once
-	bybitAdapter.OnPriceUpdate(func(symbol string, price float64) {
-		if err := svc.ProcessTick(context.Background(), "bybit", symbol, price); err != nil {
-			log.Error("Error processing tick", zap.Error(err))
-		}
+	// 6. Strategy Runtime: owns the bots and fans market data out to them
+	runtime := usecase.NewStrategyRuntime(tradingExchange, marketService, store, log)
+	runtime.RegisterKind(usecase.StrategyKindLevel, func(spec usecase.StrategySpec) (usecase.Strategy, error) {
+		return usecase.NewLevelStrategy(svc, "bybit"), nil
 	})
 
+	// Init Speed Bot Service
+	speedBotService := usecase.NewSpeedBotService(runtime, tradingExchange, marketService, log)
+
+	// Init Funding Bot Service
+	fundingLogger, err := logger.NewFileLogger("funding_bot.log", "debug") // Force debug for now as requested
+	if err != nil {
+		log.Error("Failed to init funding logger, using default", zap.Error(err))
+		fundingLogger = log
+	}
+	fundingBotService := usecase.NewFundingBotService(runtime, tradingExchange, store, marketService, fundingLogger)
+	fundingBotService.SetExecutionManager(svc.ExecutionManager())
+	fundingBotService.SetFeeRates(feeModel.Rates)
+	// Start Auto-Scanner (Disabled by default)
+	// go fundingBotService.StartAutoScanner(context.Background())
+
+	// Bring back bots that were running before the restart, then make sure the level bot runs
+	if err := runtime.Restore(context.Background()); err != nil {
+		log.Error("Failed to restore strategies", zap.Error(err))
+	}
+	levelSpec := usecase.LevelStrategySpec("bybit")
+	if _, running := runtime.Get(levelSpec.ID); !running {
+		if err := runtime.Start(context.Background(), levelSpec); err != nil {
+			log.Fatal("Failed to start level strategy", zap.Error(err))
+		}
+	}
+
+	// Kill switch: halts trading, stops the bots, cancels all orders and flattens everything.
+	// Also reachable with `kill -USR1 <pid>` when the web UI is not.
+	killSwitch := usecase.NewKillSwitch(tradingExchange, riskManager, runtime, svc, fundingBotService)
+	killSignal := make(chan os.Signal, 1)
+	signal.Notify(killSignal, syscall.SIGUSR1)
+	go func() {
+		for range killSignal {
+			ctx, cancel := context.WithTimeout(context.Background(), usecase.KillSwitchTimeout)
+			if _, err := killSwitch.Trigger(ctx, "signal", "SIGUSR1"); err != nil {
+				log.Error("Kill switch did not complete", zap.Error(err))
+			}
+			cancel()
+		}
+	}()
+
+	// Connect WS and Start Processing (with Reload Loop)
+	// Register callbacks once
+	bybitAdapter.OnPriceUpdate(runtime.DispatchTick)
+	marketService.SetTickStats(runtime.TickStats)
+	bybitAdapter.OnTradeUpdate(runtime.DispatchTrade)
+
 	go func() {
 		ticker := time.NewTicker(time.Duration(cfg.Polling.LevelsReloadMs) * time.Millisecond)
 		defer ticker.Stop()
@@ -155,20 +244,7 @@ func main() {
 		}
 	}()
 
-	// Safety Monitor Loop (Every 1s)
-	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				svc.CheckSafety(context.Background())
-			case <-stop:
-				return
-			}
-		}
-	}()
+	// Safety Monitor runs as the level strategy timer (every 1s)
 
 	// 7. Init Web Server
 	if err := web.InitTemplates("internal/web/templates"); err != nil {
@@ -179,20 +255,27 @@ func main() {
 		port = 8080 // Default
 	}
 
-	// Init Speed Bot Service
-	speedBotService := usecase.NewSpeedBotService(bybitAdapter, marketService, log)
+	var webhookService *usecase.WebhookService
+	if cfg.Webhook.Secret != "" {
+		webhookService = usecase.NewWebhookService(cfg.Webhook.Secret, store, svc, speedBotService, fundingBotService)
+	}
 
-	// Init Funding Bot Service
-	fundingLogger, err := logger.NewFileLogger("funding_bot.log", "debug") // Force debug for now as requested
-	if err != nil {
-		log.Error("Failed to init funding logger, using default", zap.Error(err))
-		fundingLogger = log
+	// Level Discovery: proposals from candle history, reviewed in the UI
+	discoveryCfg := usecase.DefaultDiscoveryConfig()
+	if cfg.Discovery.CandleInterval != "" {
+		discoveryCfg.Interval = cfg.Discovery.CandleInterval
+	}
+	discoveryCfg.AutoCreate = cfg.Discovery.AutoCreate
+	if cfg.Discovery.AutoMinConfidence > 0 {
+		discoveryCfg.AutoMinConfidence = cfg.Discovery.AutoMinConfidence
+	}
+	discoveryService := usecase.NewLevelDiscoveryService(svc, marketService, discoveryCfg)
+	discoveryCtx, stopDiscovery := context.WithCancel(context.Background())
+	if cfg.Discovery.IntervalMinutes > 0 {
+		go discoveryService.Run(discoveryCtx, time.Duration(cfg.Discovery.IntervalMinutes)*time.Minute)
 	}
-	fundingBotService := usecase.NewFundingBotService(bybitAdapter, store, marketService, fundingLogger)
-	// Start Auto-Scanner (Disabled by default)
-	// go fundingBotService.StartAutoScanner(context.Background())
 
-	server := web.NewServer(port, store, store, svc, marketService, speedBotService, fundingBotService, log)
+	server := web.NewServer(port, store, store, svc, marketService, speedBotService, fundingBotService, runtime, webhookService, discoveryService, riskManager, killSwitch, log)
 
 	// 8. Start Server
 	go func() {
@@ -205,5 +288,7 @@ func main() {
 	<-stop
 
 	log.Info("Shutting down...")
+	stopDiscovery()
 	server.Shutdown(context.Background())
+	runtime.Shutdown(context.Background())
 }
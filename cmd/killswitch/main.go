@@ -0,0 +1,129 @@
+// Command killswitch triggers the kill switch of the running bot: trading is halted, all bots
+// are stopped, all orders cancelled and every position market closed. The halt survives
+// restarts and is lifted from the Risk Manager panel or POST /api/risk/reset.
+//
+//	killswitch -reason "exchange incident"
+//	killswitch -yes -reason "drawdown"
+//	killswitch -status
+//
+// If the web server does not answer, `kill -USR1 <pid>` (make kill-switch) does the same.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func main() {
+	addr := flag.String("addr", "http://localhost:8080", "Bot web server address")
+	reason := flag.String("reason", "", "Why the kill switch is pulled, shown with the halt")
+	yes := flag.Bool("yes", false, "Do not ask for confirmation")
+	status := flag.Bool("status", false, "Only show the halt state and the last run")
+	flag.Parse()
+
+	if *status {
+		showStatus(*addr)
+		return
+	}
+
+	if !*yes {
+		fmt.Print("This halts trading, stops every bot, cancels all orders and market closes ALL positions.\nType KILL to continue: ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(line) != "KILL" {
+			fatal(fmt.Errorf("aborted"))
+		}
+	}
+
+	by := "cli"
+	if user := os.Getenv("USER"); user != "" {
+		by = "cli:" + user
+	}
+	body, _ := json.Marshal(map[string]interface{}{"confirm": true, "reason": *reason, "by": by})
+	resp, err := http.Post(*addr+"/api/kill-switch", "application/json", bytes.NewReader(body))
+	if err != nil {
+		fatal(fmt.Errorf("failed to reach bot, try `kill -USR1 <pid>`: %w", err))
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	var report usecase.KillSwitchReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		fatal(fmt.Errorf("kill switch failed: %s: %s", resp.Status, strings.TrimSpace(string(data))))
+	}
+	printReport(&report)
+	if !report.Flat {
+		os.Exit(1)
+	}
+}
+
+func showStatus(addr string) {
+	resp, err := http.Get(addr + "/api/kill-switch")
+	if err != nil {
+		fatal(fmt.Errorf("failed to reach bot: %w", err))
+	}
+	defer resp.Body.Close()
+
+	var st struct {
+		Halted     bool                      `json:"halted"`
+		HaltReason string                    `json:"halt_reason"`
+		Running    bool                      `json:"running"`
+		LastReport *usecase.KillSwitchReport `json:"last_report"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		fatal(fmt.Errorf("status failed: %s", resp.Status))
+	}
+	if st.Halted {
+		fmt.Printf("HALTED: %s\n", st.HaltReason)
+	} else {
+		fmt.Println("Trading active")
+	}
+	if st.Running {
+		fmt.Println("Kill switch is running")
+	}
+	if st.LastReport != nil {
+		fmt.Println()
+		printReport(st.LastReport)
+	}
+}
+
+func printReport(r *usecase.KillSwitchReport) {
+	fmt.Printf("Kill switch by %s at %s: %s\n", r.By, r.StartedAt.Format("2006-01-02 15:04:05"), r.Reason)
+	for _, id := range r.StoppedStrategies {
+		fmt.Printf("stopped   %s\n", id)
+	}
+	if r.CancelError != "" {
+		fmt.Printf("error     cancel orders: %s\n", r.CancelError)
+	} else {
+		fmt.Printf("cancelled %d orders\n", r.CancelledOrders)
+	}
+	if r.PositionsError != "" {
+		fmt.Printf("error     positions: %s\n", r.PositionsError)
+	}
+	for _, p := range r.Positions {
+		state := "closed"
+		if !p.Closed {
+			state = "OPEN"
+		}
+		fmt.Printf("%-9s %-12s %-5s %g (%d attempts) %s\n", state, p.Symbol, p.Side, p.Size, p.Attempts, p.Error)
+	}
+
+	if r.Flat {
+		fmt.Printf("\nFlat, %d positions closed. Trading stays halted until reset.\n", len(r.Positions))
+	} else {
+		fmt.Println("\nNOT FLAT: close the remaining positions by hand. Trading stays halted.")
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,171 @@
+// Command levels exports and imports levels through the running bot's API, so imports go
+// through the same validation and position checks as the web UI.
+//
+//	levels export -format csv > levels.csv
+//	levels import -dry-run levels.csv
+//	levels import -prune levels.yaml
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "import":
+		runImport(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: levels export [-addr URL] [-format json|yaml|csv] [-o file]")
+	fmt.Fprintln(os.Stderr, "       levels import [-addr URL] [-format json|yaml|csv] [-dry-run] [-prune] file")
+	os.Exit(2)
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	addr := fs.String("addr", "http://localhost:8080", "Bot web server address")
+	format := fs.String("format", "", "Output format (default: from -o extension, else json)")
+	out := fs.String("o", "", "Output file (default: stdout)")
+	fs.Parse(args)
+
+	f := *format
+	if f == "" && *out != "" {
+		f = filepath.Ext(*out)
+	}
+	f, err := usecase.NormalizeFormat(f)
+	if err != nil {
+		fatal(err)
+	}
+
+	resp, err := http.Get(*addr + "/api/levels/export?format=" + url.QueryEscape(f))
+	if err != nil {
+		fatal(fmt.Errorf("failed to reach bot: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fatal(fmt.Errorf("export failed: %s: %s", resp.Status, body))
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fatal(err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		fatal(err)
+	}
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	addr := fs.String("addr", "http://localhost:8080", "Bot web server address")
+	format := fs.String("format", "", "Input format (default: from file extension)")
+	dryRun := fs.Bool("dry-run", false, "Only show the diff")
+	prune := fs.Bool("prune", false, "Delete levels missing from the file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	path := fs.Arg(0)
+
+	f := *format
+	if f == "" {
+		f = filepath.Ext(path)
+	}
+	f, err := usecase.NormalizeFormat(f)
+	if err != nil {
+		fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatal(err)
+	}
+
+	q := url.Values{}
+	q.Set("format", f)
+	q.Set("dry_run", strconv.FormatBool(*dryRun))
+	q.Set("prune", strconv.FormatBool(*prune))
+	req, err := http.NewRequest(http.MethodPost, *addr+"/api/levels/import?"+q.Encode(), bytes.NewReader(data))
+	if err != nil {
+		fatal(err)
+	}
+	if user := os.Getenv("USER"); user != "" {
+		req.Header.Set("X-Changed-By", "cli:"+user)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fatal(fmt.Errorf("failed to reach bot: %w", err))
+	}
+	defer resp.Body.Close()
+
+	var res usecase.ImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		fatal(fmt.Errorf("import failed: %s", resp.Status))
+	}
+	printResult(&res)
+	if len(res.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printResult(res *usecase.ImportResult) {
+	counts := make(map[string]int)
+	for _, c := range res.Changes {
+		counts[c.Action]++
+		if c.Action == usecase.ImportUnchanged {
+			continue
+		}
+		fmt.Printf("%-7s %-20s %-12s %s\n", c.Action, c.Key, c.Symbol, c.LevelID)
+		for _, fc := range c.Changes {
+			fmt.Printf("          %s: %s -> %s\n", fc.Field, fc.Old, fc.New)
+		}
+	}
+	for _, tc := range res.Tiers {
+		n := tc.New
+		fmt.Printf("tiers   %-20s %.4f%% / %.4f%% / %.4f%%\n", n.Symbol, n.Tier1Pct*100, n.Tier2Pct*100, n.Tier3Pct*100)
+	}
+	for _, e := range res.Errors {
+		fmt.Printf("error   row %d %s: %s\n", e.Row, e.Key, e.Error)
+	}
+
+	status := "applied"
+	if !res.Applied {
+		status = "not applied"
+	}
+	fmt.Printf("\n%d create, %d update, %d delete, %d unchanged, %d tier changes, %d errors (%s)\n",
+		counts[usecase.ImportCreate], counts[usecase.ImportUpdate], counts[usecase.ImportDelete], counts[usecase.ImportUnchanged],
+		len(res.Tiers), len(res.Errors), status)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Decision kinds recorded in the journal.
+const (
+	DecisionTierTrigger     = "tier_trigger"     // Engine crossed a tier and wanted to open or add
+	DecisionFilterRejection = "filter_rejection" // An entry filter vetoed the tier trigger
+	DecisionExit            = "exit"             // TP, SL, safety, sentiment, trailing, manual...
+	DecisionCooldown        = "cooldown"         // Level disabled after max base closes
+	DecisionSplit           = "split"            // Level split into a high and a low level
+	DecisionAutoLevel       = "auto_level"       // Auto-created level from liquidity clusters
+)
+
+// Decision outcomes.
+const (
+	OutcomeExecuted  = "executed"  // Order sent to the exchange
+	OutcomeSimulated = "simulated" // Filled in the shadow ledger
+	OutcomeSignaled  = "signaled"  // Alert-only level, nothing filled
+	OutcomeRejected  = "rejected"
+	OutcomeSkipped   = "skipped"
+	OutcomeFailed    = "failed"
+	OutcomeClosed    = "closed"
+	OutcomePartial   = "partial"
+	OutcomeDisabled  = "disabled"
+	OutcomeCreated   = "created"
+)
+
+// DecisionInputs holds what a decision was based on (sentiment, tiers, state snapshot...).
+type DecisionInputs map[string]interface{}
+
+// Decision is one journal entry: what the bot decided for a level, on which inputs, and what came of it.
+type Decision struct {
+	ID         int64          `json:"id"`
+	Kind       string         `json:"kind"`
+	LevelID    string         `json:"level_id"`
+	Exchange   string         `json:"exchange"`
+	Symbol     string         `json:"symbol"`
+	Side       Side           `json:"side,omitempty"`
+	Price      float64        `json:"price"` // Market price at decision time
+	LevelPrice float64        `json:"level_price,omitempty"`
+	Size       float64        `json:"size,omitempty"`
+	Reason     string         `json:"reason"`
+	Outcome    string         `json:"outcome"`
+	Inputs     DecisionInputs `json:"inputs,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// DecisionFilter selects journal entries, zero fields match everything.
+type DecisionFilter struct {
+	LevelID string
+	Symbol  string
+	Kind    string
+	Outcome string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+// DecisionRepository stores the decision journal.
+type DecisionRepository interface {
+	SaveDecision(ctx context.Context, d *Decision) error
+	ListDecisions(ctx context.Context, filter DecisionFilter) ([]*Decision, error) // Newest first
+}
@@ -6,6 +6,22 @@ type Instrument struct {
 	QuoteCoin  string `json:"quote_coin"`
 	Status     string `json:"status"`
 	LaunchTime int64  `json:"launch_time"`
+
+	// Order filters, zero when the exchange does not report them
+	TickSize    float64 `json:"tick_size"`
+	QtyStep     float64 `json:"qty_step"`
+	MinOrderQty float64 `json:"min_order_qty"`
+	MaxOrderQty float64 `json:"max_order_qty"` // Market orders
+	MinNotional float64 `json:"min_notional"`
+}
+
+// RiskLimitTier is one step of the exchange risk limits of a symbol. Positions up to
+// RiskLimitValue notional use this maintenance margin rate.
+type RiskLimitTier struct {
+	RiskLimitValue        float64 `json:"risk_limit_value"`
+	MaintenanceMarginRate float64 `json:"maintenance_margin_rate"` // e.g. 0.005 = 0.5%
+	MMDeduction           float64 `json:"mm_deduction"`            // Subtracted from notional * rate on higher tiers
+	MaxLeverage           float64 `json:"max_leverage"`
 }
 
 type Ticker struct {
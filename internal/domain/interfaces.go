@@ -8,12 +8,16 @@ type Exchange interface {
 	MarketBuy(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error
 	MarketSell(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error
 	ClosePosition(ctx context.Context, symbol string) error
+	ReducePosition(ctx context.Context, symbol string, size float64) error // Partial reduce-only close
+	SetTradingStop(ctx context.Context, symbol string, stop TradingStop) error
 	GetPosition(ctx context.Context, symbol string) (*Position, error)
 	GetPositions(ctx context.Context) ([]*Position, error)
+	GetEquity(ctx context.Context) (*AccountEquity, error)
 	GetCandles(ctx context.Context, symbol, interval string, limit int) ([]Candle, error)
 	GetOrderBook(ctx context.Context, symbol string, category string) (*OrderBook, error)
 	GetRecentTrades(ctx context.Context, symbol string, limit int) ([]PublicTrade, error)
 	GetInstruments(ctx context.Context, category string) ([]Instrument, error)
+	GetRiskLimits(ctx context.Context, symbol string) ([]RiskLimitTier, error) // Ascending by RiskLimitValue
 	GetTickers(ctx context.Context, category string) ([]Ticker, error)
 	OnTradeUpdate(callback func(symbol string, side string, size float64, price float64))
 	Subscribe(symbols []string) error
@@ -22,14 +26,43 @@ type Exchange interface {
 	PlaceOrder(ctx context.Context, order *Order) (*Order, error)
 	GetOrder(ctx context.Context, symbol, orderID string) (*Order, error)
 	CancelOrder(ctx context.Context, symbol, orderID string) error
+	CancelAllOrders(ctx context.Context) (int, error) // Every open order of the account, returns how many were cancelled
 	GetWSStatus() WSStatus
 }
 
+// TradingStop holds the protective stop settings attached to an open position on the exchange.
+// Zero values are left untouched.
+type TradingStop struct {
+	StopLoss         float64 // Absolute stop price
+	TrailingDistance float64 // Trailing distance in price units
+	ActivePrice      float64 // Price at which the exchange starts trailing
+}
+
 type WSStatus struct {
 	Connected    bool   `json:"connected"`
 	LatencyMS    int64  `json:"latency_ms"`
 	LastMessage  int64  `json:"last_message_ts"`
 	MessageCount uint64 `json:"message_count"`
+
+	// Tick processing, summed over the per-symbol queues of the strategy runtime
+	DroppedTicks  uint64           `json:"dropped_ticks"`
+	MaxQueueLagMS float64          `json:"max_queue_lag_ms"` // Worst last lag of any symbol
+	TickQueues    []TickQueueStats `json:"tick_queues,omitempty"`
+}
+
+// TickQueueStats describes the price queue of one symbol between the WS feed and the worker
+// of a strategy.
+type TickQueueStats struct {
+	Strategy  string  `json:"strategy,omitempty"`
+	Symbol    string  `json:"symbol"`
+	Received  uint64  `json:"received"`
+	Processed uint64  `json:"processed"`
+	Dropped   uint64  `json:"dropped"` // Replaced by a newer price before the worker got to it
+	Pending   bool    `json:"pending"`
+	LastLagMS float64 `json:"last_lag_ms"` // Time the last processed price waited in the queue
+	MaxLagMS  float64 `json:"max_lag_ms"`
+	AvgLagMS  float64 `json:"avg_lag_ms"`
+	LastTick  int64   `json:"last_tick_ts"`
 }
 
 type Candle struct {
@@ -63,12 +96,16 @@ type PublicTrade struct {
 // LevelRepository defines storage operations for levels and tiers.
 type LevelRepository interface {
 	SaveLevel(ctx context.Context, level *Level) error
+	UpdateLevel(ctx context.Context, level *Level) error
 	GetLevel(ctx context.Context, id string) (*Level, error)
 	ListLevels(ctx context.Context) ([]*Level, error)
 	GetLevelsBySymbol(ctx context.Context, symbol string) ([]*Level, error)
 	DeleteLevel(ctx context.Context, id string) error
 	CountActiveLevels(ctx context.Context, symbol string) (int, error)
 
+	SaveLevelRevision(ctx context.Context, rev *LevelRevision) error
+	ListLevelRevisions(ctx context.Context, levelID string, limit int) ([]*LevelRevision, error)
+
 	SaveSymbolTiers(ctx context.Context, tiers *SymbolTiers) error
 	GetSymbolTiers(ctx context.Context, exchange, symbol string) (*SymbolTiers, error)
 
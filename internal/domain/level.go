@@ -1,6 +1,13 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Level represents a price level to defend.
 type Level struct {
@@ -13,26 +20,464 @@ type Level struct {
 	MarginType               string // "isolated" or "cross"
 	CoolDownMs               int64
 	StopLossAtBase           bool
-	StopLossMode             string  // "exchange" or "app"
-	DisableSpeedClose        bool    // Disable sentiment/speed-based position closing
-	MaxConsecutiveBaseCloses int     // Max number of consecutive base closes before cooldown
-	BaseCloseCooldownMs      int64   // Cooldown duration in milliseconds after max base closes
-	TakeProfitPct            float64 // Take profit percentage (e.g. 0.02 for 2%)
-	TakeProfitMode           string  // "fixed" or "liquidity"
-	IsAuto                   bool    // Created automatically by the system
-	AutoModeEnabled          bool    // Enable auto-recreation on failure
+	StopLossMode             string           // "exchange" or "app"
+	DisableSpeedClose        bool             // Disable sentiment/speed-based position closing
+	MaxConsecutiveBaseCloses int              // Max number of consecutive base closes before cooldown
+	BaseCloseCooldownMs      int64            // Cooldown duration in milliseconds after max base closes
+	TakeProfitPct            float64          // Take profit percentage (e.g. 0.02 for 2%)
+	TakeProfitMode           string           // TakeProfitFixed, TakeProfitLiquidity or TakeProfitSentiment
+	TakeProfitLadder         []TakeProfitStep // Scale-out steps executed before the remainder exits via TakeProfitMode
+	TrailingActivationPct    float64          // Profit from entry that arms the trailing stop (0 arms immediately)
+	TrailingStopPct          float64          // Trail distance from the best price since activation (0 disables trailing)
+	BreakEvenTier            int              // Move the stop to entry once this tier has triggered (0 disables)
+	BreakEvenProfitPct       float64          // Move the stop to entry once profit reaches this percentage (0 disables)
+	TrailingStopMode         string           // "app" or "exchange" (trading-stop on the position)
+	EntryFilters             []string         // Ordered entry filter names, empty means DefaultEntryFilters
+	ExternalKey              string           // Stable key of bulk imports (spreadsheet row ID), empty for levels made in the UI
+	Mode                     string           // "live", "shadow" or "alert", empty means live
+	SizingMode               string           // "fixed", "risk_pct" or "risk_usd", empty means fixed
+	RiskPct                  float64          // Equity lost if price returns to the level (risk_pct), e.g. 0.005 = 0.5%
+	RiskUSD                  float64          // USD lost if price returns to the level (risk_usd)
+	MaxSlippagePct           float64          // Expected market order slippage vs the best price, e.g. 0.002 = 0.2%, 0 = no guard
+	SlippageAction           string           // "reject", "downsize" or "split" above MaxSlippagePct, empty means reject
+	ExecAlgo                 string           // "market", "chase", "twap" or "iceberg" for entries and take-profit exits, empty means market
+	ExecDurationSec          int              // TWAP duration, chase/iceberg deadline before the rest goes to ExecFallback, 0 = algorithm default
+	ExecClipSize             float64          // Iceberg visible clip, TWAP slice size, 0 = a fifth of the order
+	ExecMaxChasePct          float64          // Chase/iceberg follow the touch at most this far from the first quote, e.g. 0.001 = 0.1%, 0 = unbounded
+	ExecFallback             string           // "market" or "ioc" for what chase/iceberg left at the deadline, empty means market
+	IsAuto                   bool             // Created automatically by the system
+	AutoModeEnabled          bool             // Enable auto-recreation on failure
 	Source                   string
 	CreatedAt                time.Time
 }
 
+// Level modes. Shadow levels run the full engine and filters but fill against a simulated
+// ledger instead of the exchange, alert levels only record what they would have done.
+const (
+	LevelModeLive   = "live"
+	LevelModeShadow = "shadow"
+	LevelModeAlert  = "alert"
+)
+
+// ParseLevelMode validates a mode name, empty means live.
+func ParseLevelMode(raw string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case "", LevelModeLive:
+		return LevelModeLive, nil
+	case LevelModeShadow, LevelModeAlert:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown level mode %q (live, shadow or alert)", raw)
+}
+
+// EffectiveMode returns the level's mode with the live default applied.
+func (l *Level) EffectiveMode() string {
+	if l.Mode == "" {
+		return LevelModeLive
+	}
+	return l.Mode
+}
+
+// IsLive reports whether the level trades real money.
+func (l *Level) IsLive() bool {
+	return l.EffectiveMode() == LevelModeLive
+}
+
+// Take profit modes. Fixed closes TakeProfitPct away from the entry, liquidity at the next
+// liquidity cluster and sentiment scales TakeProfitPct with the market's conclusion score.
+const (
+	TakeProfitFixed     = "fixed"
+	TakeProfitLiquidity = "liquidity"
+	TakeProfitSentiment = "sentiment"
+)
+
+// ParseTakeProfitMode validates a take profit mode name, empty means fixed.
+func ParseTakeProfitMode(raw string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case "", TakeProfitFixed:
+		return TakeProfitFixed, nil
+	case TakeProfitLiquidity, TakeProfitSentiment:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown take_profit_mode %q (fixed, liquidity or sentiment)", raw)
+}
+
+// Sizing modes. Fixed levels trade BaseSize per tier unit, risk levels size the tier ladder
+// so that a return to the level loses the given share of equity or the given USD amount.
+const (
+	SizingFixed   = "fixed"
+	SizingRiskPct = "risk_pct"
+	SizingRiskUSD = "risk_usd"
+)
+
+// ParseSizingMode validates a sizing mode name, empty means fixed.
+func ParseSizingMode(raw string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case "", SizingFixed:
+		return SizingFixed, nil
+	case SizingRiskPct, SizingRiskUSD:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown sizing mode %q (fixed, risk_pct or risk_usd)", raw)
+}
+
+// EffectiveSizingMode returns the level's sizing mode with the fixed default applied.
+func (l *Level) EffectiveSizingMode() string {
+	if l.SizingMode == "" {
+		return SizingFixed
+	}
+	return l.SizingMode
+}
+
+// UsesRiskSizing reports whether the order size is computed from a risk budget.
+func (l *Level) UsesRiskSizing() bool {
+	return l.EffectiveSizingMode() != SizingFixed
+}
+
+// ValidateSizing checks that a risk sizing mode has its budget.
+func (l *Level) ValidateSizing() error {
+	switch l.EffectiveSizingMode() {
+	case SizingRiskPct:
+		if l.RiskPct <= 0 || l.RiskPct >= 1 {
+			return fmt.Errorf("risk_pct must be in (0%%, 100%%) for risk_pct sizing, got %s%%", formatPct(l.RiskPct))
+		}
+	case SizingRiskUSD:
+		if l.RiskUSD <= 0 {
+			return fmt.Errorf("risk_usd must be greater than 0 for risk_usd sizing")
+		}
+	}
+	return nil
+}
+
+// Slippage actions, what happens to a market order whose book walk exceeds MaxSlippagePct.
+// Downsize sends only what the book takes within the limit, split sends that and follows up
+// with more slices on the refreshed book until the order is filled.
+const (
+	SlippageReject   = "reject"
+	SlippageDownsize = "downsize"
+	SlippageSplit    = "split"
+)
+
+// ParseSlippageAction validates a slippage action name, empty means reject.
+func ParseSlippageAction(raw string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(raw)); action {
+	case "", SlippageReject:
+		return SlippageReject, nil
+	case SlippageDownsize, SlippageSplit:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown slippage action %q (reject, downsize or split)", raw)
+}
+
+// EffectiveSlippageAction returns the level's slippage action with the reject default applied.
+func (l *Level) EffectiveSlippageAction() string {
+	if l.SlippageAction == "" {
+		return SlippageReject
+	}
+	return l.SlippageAction
+}
+
+// Execution algorithms of the parent orders a level sends. Chase rests a post-only limit at
+// the touch and re-prices it as the market moves, iceberg does the same with only a clip of
+// the order visible, TWAP spreads market slices over the duration.
+const (
+	ExecMarket  = "market"
+	ExecChase   = "chase"
+	ExecTWAP    = "twap"
+	ExecIceberg = "iceberg"
+)
+
+// ParseExecAlgo validates an execution algorithm name, empty means market.
+func ParseExecAlgo(raw string) (string, error) {
+	switch algo := strings.ToLower(strings.TrimSpace(raw)); algo {
+	case "", ExecMarket:
+		return ExecMarket, nil
+	case ExecChase, ExecTWAP, ExecIceberg:
+		return algo, nil
+	}
+	return "", fmt.Errorf("unknown execution algorithm %q (market, chase, twap or iceberg)", raw)
+}
+
+// EffectiveExecAlgo returns the level's execution algorithm with the market default applied.
+func (l *Level) EffectiveExecAlgo() string {
+	if l.ExecAlgo == "" {
+		return ExecMarket
+	}
+	return l.ExecAlgo
+}
+
+// Fallbacks of chase and iceberg orders at their deadline. Market takes the rest at any
+// price, IOC only takes what is offered up to the chase limit and drops the remainder.
+const (
+	ExecFallbackMarket = "market"
+	ExecFallbackIOC    = "ioc"
+)
+
+// ParseExecFallback validates a deadline fallback, empty means market.
+func ParseExecFallback(raw string) (string, error) {
+	switch fallback := strings.ToLower(strings.TrimSpace(raw)); fallback {
+	case "", ExecFallbackMarket:
+		return ExecFallbackMarket, nil
+	case ExecFallbackIOC:
+		return fallback, nil
+	}
+	return "", fmt.Errorf("unknown execution fallback %q (market or ioc)", raw)
+}
+
+// EffectiveExecFallback returns the level's deadline fallback with the market default applied.
+func (l *Level) EffectiveExecFallback() string {
+	if l.ExecFallback == "" {
+		return ExecFallbackMarket
+	}
+	return l.ExecFallback
+}
+
+// TakeProfitStep is one rung of a scale-out take-profit ladder.
+// Example: {ProfitPct: 0.01, ClosePct: 0.4} closes 40% of the position at +1%.
+type TakeProfitStep struct {
+	ProfitPct float64 `json:"profit_pct"` // Distance from entry price (e.g. 0.01 for 1%)
+	ClosePct  float64 `json:"close_pct"`  // Fraction of the position size at ladder start (e.g. 0.4 for 40%)
+}
+
+// ParseTakeProfitLadder parses the compact "close%@profit%" form used by the UI,
+// e.g. "40@1, 30@2" -> close 40% at +1%, then 30% at +2%. Whatever is left
+// keeps running until the level's normal exit logic closes it.
+func ParseTakeProfitLadder(raw string) ([]TakeProfitStep, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var steps []TakeProfitStep
+	total := 0.0
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pieces := strings.Split(part, "@")
+		if len(pieces) != 2 {
+			return nil, fmt.Errorf("invalid ladder step %q, expected close%%@profit%%", part)
+		}
+		closePct, err := strconv.ParseFloat(strings.TrimSpace(pieces[0]), 64)
+		if err != nil || closePct <= 0 || closePct > 100 {
+			return nil, fmt.Errorf("invalid close percentage in ladder step %q", part)
+		}
+		profitPct, err := strconv.ParseFloat(strings.TrimSpace(pieces[1]), 64)
+		if err != nil || profitPct <= 0 {
+			return nil, fmt.Errorf("invalid profit percentage in ladder step %q", part)
+		}
+		total += closePct
+		steps = append(steps, TakeProfitStep{ProfitPct: profitPct / 100, ClosePct: closePct / 100})
+	}
+
+	if total > 100 {
+		return nil, fmt.Errorf("ladder closes %.2f%% of the position, max is 100%%", total)
+	}
+
+	sort.Slice(steps, func(i, j int) bool {
+		return steps[i].ProfitPct < steps[j].ProfitPct
+	})
+	return steps, nil
+}
+
+// FormatTakeProfitLadder is the inverse of ParseTakeProfitLadder.
+func FormatTakeProfitLadder(steps []TakeProfitStep) string {
+	parts := make([]string, 0, len(steps))
+	for _, st := range steps {
+		parts = append(parts, formatPct(st.ClosePct)+"@"+formatPct(st.ProfitPct))
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatPct renders a fraction as a percentage without float noise (0.07 -> "7").
+func formatPct(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100*1e6)/1e6, 'f', -1, 64)
+}
+
+// Entry filter names. Filters run in the order configured on the level and the
+// first rejection skips the entry.
+const (
+	EntryFilterSentiment  = "sentiment"  // Trade flow against the side
+	EntryFilterTrend      = "trend"      // Higher-timeframe EMA trend against the side
+	EntryFilterSlippage   = "slippage"   // Spread too wide or book too thin for the size
+	EntryFilterFunding    = "funding"    // Too close to the funding settlement
+	EntryFilterVolatility = "volatility" // Last candle range spikes far above normal
+	EntryFilterNone       = "none"       // Explicitly disable all filters
+)
+
+// DefaultEntryFilters is used by levels without their own filter list and matches the
+// original behavior (sentiment gate only).
+var DefaultEntryFilters = []string{EntryFilterSentiment}
+
+// ParseEntryFilters parses a comma separated filter list, e.g. "trend, sentiment".
+func ParseEntryFilters(raw string) ([]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" || seen[name] {
+			continue
+		}
+		switch name {
+		case EntryFilterSentiment, EntryFilterTrend, EntryFilterSlippage, EntryFilterFunding, EntryFilterVolatility, EntryFilterNone:
+		default:
+			return nil, fmt.Errorf("unknown entry filter %q", name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	if seen[EntryFilterNone] && len(names) > 1 {
+		return nil, fmt.Errorf("entry filter %q cannot be combined with other filters", EntryFilterNone)
+	}
+	return names, nil
+}
+
+// EntryFilterChain returns the filters to run for the level, in order.
+func (l *Level) EntryFilterChain() []string {
+	if len(l.EntryFilters) == 0 {
+		return DefaultEntryFilters
+	}
+	if len(l.EntryFilters) == 1 && l.EntryFilters[0] == EntryFilterNone {
+		return nil
+	}
+	return l.EntryFilters
+}
+
+// LevelRevision records one edit of a level: who changed which fields and when.
+type LevelRevision struct {
+	ID        int64              `json:"id"`
+	LevelID   string             `json:"level_id"`
+	ChangedBy string             `json:"changed_by"`
+	Changes   []LevelFieldChange `json:"changes"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// LevelFieldChange is one changed field, values are rendered as text.
+type LevelFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// levelEditableFields lists the fields that can change after creation, in display order.
+var levelEditableFields = []struct {
+	name  string
+	value func(l *Level) string
+}{
+	{"level_price", func(l *Level) string { return strconv.FormatFloat(l.LevelPrice, 'f', -1, 64) }},
+	{"base_size", func(l *Level) string { return strconv.FormatFloat(l.BaseSize, 'f', -1, 64) }},
+	{"leverage", func(l *Level) string { return strconv.Itoa(l.Leverage) }},
+	{"margin_type", func(l *Level) string { return l.MarginType }},
+	{"cool_down_ms", func(l *Level) string { return strconv.FormatInt(l.CoolDownMs, 10) }},
+	{"stop_loss_at_base", func(l *Level) string { return strconv.FormatBool(l.StopLossAtBase) }},
+	{"stop_loss_mode", func(l *Level) string { return l.StopLossMode }},
+	{"disable_speed_close", func(l *Level) string { return strconv.FormatBool(l.DisableSpeedClose) }},
+	{"max_consecutive_base_closes", func(l *Level) string { return strconv.Itoa(l.MaxConsecutiveBaseCloses) }},
+	{"base_close_cooldown_ms", func(l *Level) string { return strconv.FormatInt(l.BaseCloseCooldownMs, 10) }},
+	{"take_profit_pct", func(l *Level) string { return formatPct(l.TakeProfitPct) + "%" }},
+	{"take_profit_mode", func(l *Level) string { return l.TakeProfitMode }},
+	{"take_profit_ladder", func(l *Level) string { return FormatTakeProfitLadder(l.TakeProfitLadder) }},
+	{"trailing_activation_pct", func(l *Level) string { return formatPct(l.TrailingActivationPct) + "%" }},
+	{"trailing_stop_pct", func(l *Level) string { return formatPct(l.TrailingStopPct) + "%" }},
+	{"break_even_tier", func(l *Level) string { return strconv.Itoa(l.BreakEvenTier) }},
+	{"break_even_profit_pct", func(l *Level) string { return formatPct(l.BreakEvenProfitPct) + "%" }},
+	{"trailing_stop_mode", func(l *Level) string { return l.TrailingStopMode }},
+	{"entry_filters", func(l *Level) string { return strings.Join(l.EntryFilters, ",") }},
+	{"auto_mode_enabled", func(l *Level) string { return strconv.FormatBool(l.AutoModeEnabled) }},
+	{"mode", func(l *Level) string { return l.EffectiveMode() }},
+	{"sizing_mode", func(l *Level) string { return l.EffectiveSizingMode() }},
+	{"risk_pct", func(l *Level) string { return formatPct(l.RiskPct) + "%" }},
+	{"risk_usd", func(l *Level) string { return strconv.FormatFloat(l.RiskUSD, 'f', -1, 64) }},
+	{"max_slippage_pct", func(l *Level) string { return formatPct(l.MaxSlippagePct) + "%" }},
+	{"slippage_action", func(l *Level) string { return l.EffectiveSlippageAction() }},
+	{"exec_algo", func(l *Level) string { return l.EffectiveExecAlgo() }},
+	{"exec_duration_sec", func(l *Level) string { return strconv.Itoa(l.ExecDurationSec) }},
+	{"exec_clip_size", func(l *Level) string { return strconv.FormatFloat(l.ExecClipSize, 'f', -1, 64) }},
+	{"exec_max_chase_pct", func(l *Level) string { return formatPct(l.ExecMaxChasePct) + "%" }},
+	{"exec_fallback", func(l *Level) string { return l.EffectiveExecFallback() }},
+}
+
+// DiffLevels returns the editable fields that differ between two versions of a level.
+func DiffLevels(before, after *Level) []LevelFieldChange {
+	var changes []LevelFieldChange
+	for _, f := range levelEditableFields {
+		from, to := f.value(before), f.value(after)
+		if from != to {
+			changes = append(changes, LevelFieldChange{Field: f.name, Old: from, New: to})
+		}
+	}
+	return changes
+}
+
 // SymbolTiers defines the scaling tiers for a specific symbol on an exchange.
+// Tier1 is the farthest from the level, price passes T1, T2, T3 on its way to the level.
 type SymbolTiers struct {
-	Exchange  string
-	Symbol    string
-	Tier1Pct  float64
-	Tier2Pct  float64
-	Tier3Pct  float64
-	UpdatedAt time.Time
+	Exchange  string    `json:"exchange"`
+	Symbol    string    `json:"symbol"`
+	Tier1Pct  float64   `json:"tier1_pct"`
+	Tier2Pct  float64   `json:"tier2_pct"`
+	Tier3Pct  float64   `json:"tier3_pct"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaxTierPct bounds the tier distance from the level (10%).
+const MaxTierPct = 0.10
+
+// Validate checks that the tiers step towards the level (T1 > T2 > T3 > 0) and stay within MaxTierPct.
+func (t *SymbolTiers) Validate() error {
+	for i, pct := range []float64{t.Tier1Pct, t.Tier2Pct, t.Tier3Pct} {
+		if pct <= 0 || pct > MaxTierPct {
+			return fmt.Errorf("tier %d must be in (0%%, %s%%], got %s%%", i+1, formatPct(MaxTierPct), formatPct(pct))
+		}
+	}
+	if !(t.Tier1Pct > t.Tier2Pct && t.Tier2Pct > t.Tier3Pct) {
+		return fmt.Errorf("tier distances must get closer to the level (T1 > T2 > T3), got %s%% / %s%% / %s%%",
+			formatPct(t.Tier1Pct), formatPct(t.Tier2Pct), formatPct(t.Tier3Pct))
+	}
+	return nil
+}
+
+// DefaultSymbolTiers is used for symbols without saved tiers.
+func DefaultSymbolTiers(exchange, symbol string) *SymbolTiers {
+	return &SymbolTiers{Exchange: exchange, Symbol: symbol, Tier1Pct: 0.005, Tier2Pct: 0.003, Tier3Pct: 0.0015}
+}
+
+// TierPreset is a named set of tier distances that can be applied to many symbols.
+type TierPreset struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Tier1Pct    float64 `json:"tier1_pct"`
+	Tier2Pct    float64 `json:"tier2_pct"`
+	Tier3Pct    float64 `json:"tier3_pct"`
+}
+
+// TierPresets are the built-in presets, ordered from tight to wide.
+var TierPresets = []TierPreset{
+	{Name: "scalp", Description: "Tight tiers for liquid, calm pairs", Tier1Pct: 0.003, Tier2Pct: 0.002, Tier3Pct: 0.001},
+	{Name: "swing", Description: "Default spacing for majors", Tier1Pct: 0.01, Tier2Pct: 0.006, Tier3Pct: 0.003},
+	{Name: "volatile", Description: "Wide tiers for fast-moving alts", Tier1Pct: 0.03, Tier2Pct: 0.02, Tier3Pct: 0.01},
+}
+
+// FindTierPreset looks up a preset by name (case-insensitive).
+func FindTierPreset(name string) (TierPreset, bool) {
+	for _, p := range TierPresets {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return TierPreset{}, false
+}
+
+// Tiers returns the preset as tiers for one symbol.
+func (p TierPreset) Tiers(exchange, symbol string) *SymbolTiers {
+	return &SymbolTiers{Exchange: exchange, Symbol: symbol, Tier1Pct: p.Tier1Pct, Tier2Pct: p.Tier2Pct, Tier3Pct: p.Tier3Pct}
 }
 
 type LiquidityBucket struct {
@@ -40,8 +40,25 @@ type Order struct {
 	TakeProfit   float64
 	TriggerPrice float64
 	RealizedPnL  float64
+	Fee          float64 // Trading fee paid on the fill, in quote (actual when reported, else estimated)
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+
+	// Slippage analytics of market entries, zero when not measured
+	ExpectedPrice float64 // Average fill estimated from the order book before sending
+	FillPrice     float64 // Average fill derived from the exchange position
+
+	// Order management only, not stored with trades
+	Leverage     int     // Set before an opening limit order when > 0
+	MarginType   string  // "isolated" or "cross" for opening limit orders, empty means isolated
+	FilledSize   float64 // Executed quantity reported by GetOrder
+	AvgFillPrice float64 // Average execution price reported by GetOrder
+	CumFee       float64 // Cumulative fee of the executions reported by GetOrder
+}
+
+// NetPnL is the realized PnL of a close after its fees, entries only carry their fee.
+func (o Order) NetPnL() float64 {
+	return o.RealizedPnL - o.Fee
 }
 
 // PositionHistory represents a closed position.
@@ -53,12 +70,21 @@ type PositionHistory struct {
 	Size        float64
 	EntryPrice  float64
 	ExitPrice   float64
-	RealizedPnL float64
+	RealizedPnL float64 // Gross, before fees
+	Fees        float64 // Entry and exit fees of the closed size, in quote
 	Leverage    int
 	MarginType  string
+	LevelID     string // Level that owned the closed size (empty for legacy rows)
+	Reason      string // Exit reason, e.g. "Take Profit", "Take Profit (Ladder 1/2)"
+	Partial     bool   // True if only part of the position was closed
 	ClosedAt    time.Time
 }
 
+// NetPnL is the realized PnL after the entry and exit fees.
+func (h PositionHistory) NetPnL() float64 {
+	return h.RealizedPnL - h.Fees
+}
+
 type TickData struct {
 	Timestamp     int64            `json:"ts"`
 	Price         float64          `json:"p"`
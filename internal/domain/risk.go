@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AccountEquity is the account value as reported by the exchange.
+// WalletBalance moves with realized PnL, fees and funding, Equity also includes unrealized PnL.
+type AccountEquity struct {
+	Equity        float64 `json:"equity"`
+	WalletBalance float64 `json:"wallet_balance"`
+}
+
+// RiskLimits are the account wide limits every opening order is checked against.
+// Zero disables a limit.
+type RiskLimits struct {
+	MaxGrossNotional  float64 `json:"max_gross_notional" yaml:"max_gross_notional"`   // Sum of |notional| over all positions
+	MaxNetNotional    float64 `json:"max_net_notional" yaml:"max_net_notional"`       // |long notional - short notional|
+	MaxPositions      int     `json:"max_positions" yaml:"max_positions"`             // Symbols with an open position
+	MaxSymbolNotional float64 `json:"max_symbol_notional" yaml:"max_symbol_notional"` // |notional| of one symbol
+	MaxDailyLoss      float64 `json:"max_daily_loss" yaml:"max_daily_loss"`           // Wallet balance drop since UTC midnight
+	MaxDrawdownPct    float64 `json:"max_drawdown_pct" yaml:"max_drawdown_pct"`       // Equity drop from its peak, e.g. 0.1 = 10%
+}
+
+// RiskState is the persisted part of the risk manager. A breached loss limit latches
+// Halted until an operator resets it, restarts included.
+type RiskState struct {
+	Limits          RiskLimits `json:"limits"`
+	Halted          bool       `json:"halted"`
+	HaltReason      string     `json:"halt_reason,omitempty"`
+	HaltedAt        time.Time  `json:"halted_at,omitempty"`
+	PeakEquity      float64    `json:"peak_equity"`
+	Day             string     `json:"day"` // UTC date the day start balance belongs to, 2006-01-02
+	DayStartBalance float64    `json:"day_start_balance"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// RiskRepository persists the risk state, GetRiskState returns nil if nothing was saved yet.
+type RiskRepository interface {
+	GetRiskState(ctx context.Context) (*RiskState, error)
+	SaveRiskState(ctx context.Context, state *RiskState) error
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Shadow ledger actions.
+const (
+	ShadowActionOpen   = "open"
+	ShadowActionAdd    = "add"
+	ShadowActionReduce = "reduce"
+	ShadowActionClose  = "close"
+)
+
+// ShadowTrade is one simulated fill of a shadow level, or a signal of an alert level.
+// Price is the simulated fill against the order book, MarkPrice the tick that triggered it.
+type ShadowTrade struct {
+	ID          int64     `json:"id"`
+	LevelID     string    `json:"level_id"`
+	Exchange    string    `json:"exchange"`
+	Symbol      string    `json:"symbol"`
+	Mode        string    `json:"mode"` // LevelModeShadow or LevelModeAlert
+	Action      string    `json:"action"`
+	Side        Side      `json:"side"` // Side of the position, not of the order
+	Size        float64   `json:"size"`
+	Price       float64   `json:"price"`
+	MarkPrice   float64   `json:"mark_price"`
+	EntryPrice  float64   `json:"entry_price"`  // Average entry of the share, set on exits
+	RealizedPnL float64   `json:"realized_pnl"` // Before fees
+	Fee         float64   `json:"fee"`          // Simulated taker fee, exits include their part of the entry fee
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LevelPnL is the realized result of one level in one mode.
+type LevelPnL struct {
+	LevelID     string  `json:"level_id"`
+	Mode        string  `json:"mode"`
+	Exits       int     `json:"exits"`        // Closed or reduced shares, partials included
+	Wins        int     `json:"wins"`         // Exits with a positive net PnL
+	RealizedPnL float64 `json:"realized_pnl"` // Before fees
+	Fees        float64 `json:"fees"`
+}
+
+// NetPnL is the realized PnL after fees.
+func (p LevelPnL) NetPnL() float64 {
+	return p.RealizedPnL - p.Fees
+}
+
+// ShadowRepository stores the shadow ledger.
+type ShadowRepository interface {
+	SaveShadowTrade(ctx context.Context, trade *ShadowTrade) error
+	ListShadowTrades(ctx context.Context, levelID string, limit int) ([]*ShadowTrade, error) // Empty levelID lists all levels
+	// ListLevelPnL sums the live position history and the shadow exits per level and mode.
+	ListLevelPnL(ctx context.Context) ([]*LevelPnL, error)
+}
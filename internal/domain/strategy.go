@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// StrategyRecord is the persisted config and state of a strategy instance,
+// used by the strategy runtime to bring bots back after a restart.
+type StrategyRecord struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"` // "level", "speed", "funding", ...
+	Symbols   []string  `json:"symbols"`
+	Config    string    `json:"config"` // JSON, owned by the strategy kind
+	State     string    `json:"state"`  // JSON snapshot, empty if the strategy keeps no state
+	Running   bool      `json:"running"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StrategyRepository stores strategy configs and state snapshots.
+type StrategyRepository interface {
+	SaveStrategy(ctx context.Context, rec *StrategyRecord) error
+	GetStrategy(ctx context.Context, id string) (*StrategyRecord, error)
+	ListStrategies(ctx context.Context) ([]*StrategyRecord, error)
+	DeleteStrategy(ctx context.Context, id string) error
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Webhook alert statuses.
+const (
+	WebhookAlertPending = "pending"
+	WebhookAlertDone    = "done"
+	WebhookAlertFailed  = "failed"
+)
+
+// WebhookAlert is a received webhook request, keyed by the sender's alert ID so that
+// repeated deliveries of the same alert are only acted on once.
+type WebhookAlert struct {
+	AlertID    string    `json:"alert_id"`
+	Action     string    `json:"action"`
+	Symbol     string    `json:"symbol"`
+	Status     string    `json:"status"`
+	Result     string    `json:"result"` // Outcome or error message
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// WebhookRepository stores received webhook alerts.
+type WebhookRepository interface {
+	// ClaimWebhookAlert records the alert and reports whether the caller should process it.
+	// An alert that was seen before is only claimed again if it failed.
+	ClaimWebhookAlert(ctx context.Context, alert *WebhookAlert) (bool, error)
+	GetWebhookAlert(ctx context.Context, alertID string) (*WebhookAlert, error)
+	FinishWebhookAlert(ctx context.Context, alertID, status, result string) error
+	ListWebhookAlerts(ctx context.Context, limit int) ([]*WebhookAlert, error)
+}
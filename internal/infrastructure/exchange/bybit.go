@@ -12,6 +12,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,7 +37,7 @@ type BybitAdapter struct {
 	wsDone         chan struct{}
 	pingTicker     *time.Ticker
 	pingDone       chan struct{}
-	callbacks      []func(symbol string, price float64)
+	callbacks      []func(symbol string, price float64) // Run in readLoop, they must not block
 	tradeCallbacks []func(symbol string, side string, size float64, price float64)
 	mu             sync.Mutex
 
@@ -251,6 +252,61 @@ func (b *BybitAdapter) ClosePosition(ctx context.Context, symbol string) error {
 		return nil
 	}
 
+	return b.reduceOnlyOrder(ctx, pos, pos.Size)
+}
+
+// ReducePosition closes only part of the open position with a reduce-only market order.
+// The size is capped at the current position size so it can never flip the position.
+func (b *BybitAdapter) ReducePosition(ctx context.Context, symbol string, size float64) error {
+	pos, err := b.GetPosition(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if pos.Size == 0 {
+		return fmt.Errorf("no open position for %s", symbol)
+	}
+	if size > pos.Size {
+		size = pos.Size
+	}
+
+	return b.reduceOnlyOrder(ctx, pos, size)
+}
+
+// SetTradingStop attaches a stop loss and/or trailing stop to the open position (V5 trading-stop).
+func (b *BybitAdapter) SetTradingStop(ctx context.Context, symbol string, stop domain.TradingStop) error {
+	payload := map[string]interface{}{
+		"category":    "linear",
+		"symbol":      symbol,
+		"tpslMode":    "Full",
+		"positionIdx": 0, // One-way mode
+	}
+	if stop.StopLoss > 0 {
+		payload["stopLoss"] = fmt.Sprintf("%f", stop.StopLoss)
+	}
+	if stop.TrailingDistance > 0 {
+		payload["trailingStop"] = fmt.Sprintf("%f", stop.TrailingDistance)
+	}
+	if stop.ActivePrice > 0 {
+		payload["activePrice"] = fmt.Sprintf("%f", stop.ActivePrice)
+	}
+
+	resp, err := b.sendRequest(ctx, "POST", "/v5/position/trading-stop", payload)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+	}
+	json.Unmarshal(resp, &result)
+	if result.RetCode != 0 {
+		return fmt.Errorf("bybit trading-stop error: %s", result.RetMsg)
+	}
+	return nil
+}
+
+func (b *BybitAdapter) reduceOnlyOrder(ctx context.Context, pos *domain.Position, size float64) error {
 	closeSide := "Sell"
 	if pos.Side == domain.SideShort {
 		closeSide = "Buy"
@@ -258,10 +314,10 @@ func (b *BybitAdapter) ClosePosition(ctx context.Context, symbol string) error {
 
 	payload := map[string]interface{}{
 		"category":   "linear",
-		"symbol":     symbol,
+		"symbol":     pos.Symbol,
 		"side":       closeSide,
 		"orderType":  "Market",
-		"qty":        fmt.Sprintf("%f", pos.Size),
+		"qty":        fmt.Sprintf("%f", size),
 		"reduceOnly": true,
 	}
 
@@ -349,6 +405,38 @@ func (b *BybitAdapter) GetPosition(ctx context.Context, symbol string) (*domain.
 	}, nil
 }
 
+// GetEquity reads the unified account totals.
+func (b *BybitAdapter) GetEquity(ctx context.Context) (*domain.AccountEquity, error) {
+	resp, err := b.sendRequest(ctx, "GET", "/v5/account/wallet-balance?accountType=UNIFIED", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			List []struct {
+				TotalEquity        string `json:"totalEquity"`
+				TotalWalletBalance string `json:"totalWalletBalance"`
+			} `json:"list"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	if result.RetCode != 0 {
+		return nil, fmt.Errorf("Bybit API error (GetEquity): %d - %s", result.RetCode, result.RetMsg)
+	}
+	if len(result.Result.List) == 0 {
+		return nil, fmt.Errorf("no unified account in wallet balance")
+	}
+
+	equity, _ := strconv.ParseFloat(result.Result.List[0].TotalEquity, 64)
+	wallet, _ := strconv.ParseFloat(result.Result.List[0].TotalWalletBalance, 64)
+	return &domain.AccountEquity{Equity: equity, WalletBalance: wallet}, nil
+}
+
 func (b *BybitAdapter) GetPositions(ctx context.Context) ([]*domain.Position, error) {
 	var positions []*domain.Position
 	cursor := ""
@@ -438,8 +526,15 @@ func (b *BybitAdapter) PlaceOrder(ctx context.Context, order *domain.Order) (*do
 	// Set margin mode and leverage
 	if order.Type == "Limit" {
 		// For limit orders, we still need to set margin mode
-		// Leverage will be set when position is opened
-		b.setMarginMode(ctx, order.Symbol, "isolated") // Default to isolated for funding bot
+		// Leverage will be set when position is opened unless the order carries it
+		marginType := order.MarginType
+		if marginType == "" {
+			marginType = "isolated" // Default to isolated for funding bot
+		}
+		b.setMarginMode(ctx, order.Symbol, marginType)
+		if order.Leverage > 0 && !order.ReduceOnly {
+			b.setLeverage(ctx, order.Symbol, order.Leverage)
+		}
 	}
 
 	side := "Buy"
@@ -539,6 +634,9 @@ func (b *BybitAdapter) GetOrder(ctx context.Context, symbol, orderID string) (*d
 				OrderType   string `json:"orderType"`
 				Price       string `json:"price"`
 				Qty         string `json:"qty"`
+				CumExecQty  string `json:"cumExecQty"`
+				AvgPrice    string `json:"avgPrice"`
+				CumExecFee  string `json:"cumExecFee"`
 				OrderStatus string `json:"orderStatus"`
 				TimeInForce string `json:"timeInForce"`
 				ReduceOnly  bool   `json:"reduceOnly"`
@@ -563,6 +661,9 @@ func (b *BybitAdapter) GetOrder(ctx context.Context, symbol, orderID string) (*d
 	raw := result.Result.List[0]
 	price, _ := strconv.ParseFloat(raw.Price, 64)
 	qty, _ := strconv.ParseFloat(raw.Qty, 64)
+	filled, _ := strconv.ParseFloat(raw.CumExecQty, 64)
+	avgPrice, _ := strconv.ParseFloat(raw.AvgPrice, 64)
+	cumFee, _ := strconv.ParseFloat(raw.CumExecFee, 64)
 	createdTime, _ := strconv.ParseInt(raw.CreatedTime, 10, 64)
 	updatedTime, _ := strconv.ParseInt(raw.UpdatedTime, 10, 64)
 
@@ -572,17 +673,20 @@ func (b *BybitAdapter) GetOrder(ctx context.Context, symbol, orderID string) (*d
 	}
 
 	return &domain.Order{
-		OrderID:     raw.OrderID,
-		Symbol:      raw.Symbol,
-		Side:        side,
-		Type:        raw.OrderType,
-		Price:       price,
-		Size:        qty,
-		Status:      raw.OrderStatus,
-		TimeInForce: raw.TimeInForce,
-		ReduceOnly:  raw.ReduceOnly,
-		CreatedAt:   time.Unix(createdTime/1000, 0),
-		UpdatedAt:   time.Unix(updatedTime/1000, 0),
+		OrderID:      raw.OrderID,
+		Symbol:       raw.Symbol,
+		Side:         side,
+		Type:         raw.OrderType,
+		Price:        price,
+		Size:         qty,
+		Status:       raw.OrderStatus,
+		TimeInForce:  raw.TimeInForce,
+		ReduceOnly:   raw.ReduceOnly,
+		FilledSize:   filled,
+		AvgFillPrice: avgPrice,
+		CumFee:       cumFee,
+		CreatedAt:    time.Unix(createdTime/1000, 0),
+		UpdatedAt:    time.Unix(updatedTime/1000, 0),
 	}, nil
 }
 
@@ -615,14 +719,61 @@ func (b *BybitAdapter) CancelOrder(ctx context.Context, symbol, orderID string)
 	return nil
 }
 
+// CancelAllOrders cancels every open linear order settled in USDT, conditional ones included.
+func (b *BybitAdapter) CancelAllOrders(ctx context.Context) (int, error) {
+	payload := map[string]interface{}{
+		"category":   "linear",
+		"settleCoin": "USDT",
+	}
+
+	resp, err := b.sendRequest(ctx, "POST", "/v5/order/cancel-all", payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			List []struct {
+				OrderID string `json:"orderId"`
+			} `json:"list"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, err
+	}
+
+	if result.RetCode != 0 {
+		return 0, fmt.Errorf("bybit cancel all error: %s", result.RetMsg)
+	}
+
+	return len(result.Result.List), nil
+}
+
 // --- WebSocket ---
 
+// OnPriceUpdate registers a price callback. Callbacks run in the WS read loop, they hand the
+// price on (e.g. StrategyRuntime.DispatchTick) instead of processing it.
 func (b *BybitAdapter) OnPriceUpdate(callback func(symbol string, price float64)) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.callbacks = append(b.callbacks, callback)
 }
 
+// emitPrice runs the price callbacks, called from readLoop.
+func (b *BybitAdapter) emitPrice(symbol string, price float64) {
+	b.mu.Lock()
+	callbacks := make([]func(string, float64), len(b.callbacks))
+	copy(callbacks, b.callbacks)
+	b.mu.Unlock()
+
+	for _, cb := range callbacks {
+		cb(symbol, price)
+	}
+}
+
 func (b *BybitAdapter) OnTradeUpdate(callback func(symbol string, side string, size float64, price float64)) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -850,17 +1001,9 @@ func (b *BybitAdapter) readLoop() {
 			}
 			bid, _ := strconv.ParseFloat(bidStr, 64)
 
-			// Use mid price
+			// Use mid price, the strategy runtime queues it for processing
 			price := (ask + bid) / 2
-
-			b.mu.Lock()
-			callbacks := make([]func(string, float64), len(b.callbacks))
-			copy(callbacks, b.callbacks)
-			b.mu.Unlock()
-
-			for _, cb := range callbacks {
-				cb(symbol, price)
-			}
+			b.emitPrice(symbol, price)
 		} else if strings.HasPrefix(topic, "publicTrade.") {
 			data, ok := event["data"].([]interface{})
 			if !ok {
@@ -1074,7 +1217,88 @@ func (b *BybitAdapter) GetInstruments(ctx context.Context, category string) ([]d
 		category = "linear"
 	}
 
-	path := fmt.Sprintf("/v5/market/instruments-info?category=%s", category)
+	var instruments []domain.Instrument
+	cursor := ""
+	for {
+		path := fmt.Sprintf("/v5/market/instruments-info?category=%s&limit=1000", category)
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		resp, err := b.sendRequest(ctx, "GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			RetCode int    `json:"retCode"`
+			RetMsg  string `json:"retMsg"`
+			Result  struct {
+				List []struct {
+					Symbol      string `json:"symbol"`
+					BaseCoin    string `json:"baseCoin"`
+					QuoteCoin   string `json:"quoteCoin"`
+					Status      string `json:"status"`
+					LaunchTime  string `json:"launchTime"`
+					PriceFilter struct {
+						TickSize string `json:"tickSize"`
+					} `json:"priceFilter"`
+					LotSizeFilter struct {
+						QtyStep          string `json:"qtyStep"`
+						MinOrderQty      string `json:"minOrderQty"`
+						MaxOrderQty      string `json:"maxOrderQty"`
+						MaxMktOrderQty   string `json:"maxMktOrderQty"`
+						MinNotionalValue string `json:"minNotionalValue"`
+					} `json:"lotSizeFilter"`
+				} `json:"list"`
+				NextPageCursor string `json:"nextPageCursor"`
+			} `json:"result"`
+		}
+
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return nil, err
+		}
+
+		if result.RetCode != 0 {
+			return nil, fmt.Errorf("bybit api error: %s", result.RetMsg)
+		}
+
+		for _, item := range result.Result.List {
+			launchTime, _ := strconv.ParseInt(item.LaunchTime, 10, 64)
+			tickSize, _ := strconv.ParseFloat(item.PriceFilter.TickSize, 64)
+			qtyStep, _ := strconv.ParseFloat(item.LotSizeFilter.QtyStep, 64)
+			minQty, _ := strconv.ParseFloat(item.LotSizeFilter.MinOrderQty, 64)
+			minNotional, _ := strconv.ParseFloat(item.LotSizeFilter.MinNotionalValue, 64)
+			// Our entries are market orders, which have their own (lower) cap
+			maxQty, _ := strconv.ParseFloat(item.LotSizeFilter.MaxMktOrderQty, 64)
+			if maxQty == 0 {
+				maxQty, _ = strconv.ParseFloat(item.LotSizeFilter.MaxOrderQty, 64)
+			}
+			instruments = append(instruments, domain.Instrument{
+				Symbol:      item.Symbol,
+				BaseCoin:    item.BaseCoin,
+				QuoteCoin:   item.QuoteCoin,
+				Status:      item.Status,
+				LaunchTime:  launchTime,
+				TickSize:    tickSize,
+				QtyStep:     qtyStep,
+				MinOrderQty: minQty,
+				MaxOrderQty: maxQty,
+				MinNotional: minNotional,
+			})
+		}
+
+		cursor = result.Result.NextPageCursor
+		if cursor == "" || len(result.Result.List) == 0 {
+			break
+		}
+	}
+
+	return instruments, nil
+}
+
+// GetRiskLimits returns the risk limit tiers of a linear symbol, lowest first.
+func (b *BybitAdapter) GetRiskLimits(ctx context.Context, symbol string) ([]domain.RiskLimitTier, error) {
+	path := "/v5/market/risk-limit?category=linear&symbol=" + url.QueryEscape(symbol)
 	resp, err := b.sendRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -1085,36 +1309,34 @@ func (b *BybitAdapter) GetInstruments(ctx context.Context, category string) ([]d
 		RetMsg  string `json:"retMsg"`
 		Result  struct {
 			List []struct {
-				Symbol     string `json:"symbol"`
-				BaseCoin   string `json:"baseCoin"`
-				QuoteCoin  string `json:"quoteCoin"`
-				Status     string `json:"status"`
-				LaunchTime string `json:"launchTime"`
+				Symbol            string `json:"symbol"`
+				RiskLimitValue    string `json:"riskLimitValue"`
+				MaintenanceMargin string `json:"maintenanceMargin"`
+				MMDeduction       string `json:"mmDeduction"`
+				MaxLeverage       string `json:"maxLeverage"`
 			} `json:"list"`
 		} `json:"result"`
 	}
-
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, err
 	}
-
 	if result.RetCode != 0 {
-		return nil, fmt.Errorf("bybit api error: %s", result.RetMsg)
+		return nil, fmt.Errorf("Bybit API error (GetRiskLimits): %d - %s", result.RetCode, result.RetMsg)
 	}
 
-	var instruments []domain.Instrument
+	var tiers []domain.RiskLimitTier
 	for _, item := range result.Result.List {
-		launchTime, _ := strconv.ParseInt(item.LaunchTime, 10, 64)
-		instruments = append(instruments, domain.Instrument{
-			Symbol:     item.Symbol,
-			BaseCoin:   item.BaseCoin,
-			QuoteCoin:  item.QuoteCoin,
-			Status:     item.Status,
-			LaunchTime: launchTime,
-		})
-	}
-
-	return instruments, nil
+		if item.Symbol != symbol {
+			continue
+		}
+		limit, _ := strconv.ParseFloat(item.RiskLimitValue, 64)
+		mmr, _ := strconv.ParseFloat(item.MaintenanceMargin, 64)
+		deduction, _ := strconv.ParseFloat(item.MMDeduction, 64)
+		maxLev, _ := strconv.ParseFloat(item.MaxLeverage, 64)
+		tiers = append(tiers, domain.RiskLimitTier{RiskLimitValue: limit, MaintenanceMarginRate: mmr, MMDeduction: deduction, MaxLeverage: maxLev})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].RiskLimitValue < tiers[j].RiskLimitValue })
+	return tiers, nil
 }
 
 func (b *BybitAdapter) GetTickers(ctx context.Context, category string) ([]domain.Ticker, error) {
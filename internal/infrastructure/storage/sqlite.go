@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/vitos/crypto_trade_level/internal/domain"
@@ -46,6 +47,25 @@ func (s *SQLiteStore) initSchema() error {
 			base_close_cooldown_ms INTEGER NOT NULL DEFAULT 0,
 			take_profit_pct REAL NOT NULL DEFAULT 0.02,
 			take_profit_mode TEXT NOT NULL DEFAULT 'fixed',
+			take_profit_ladder TEXT NOT NULL DEFAULT '',
+			trailing_activation_pct REAL NOT NULL DEFAULT 0,
+			trailing_stop_pct REAL NOT NULL DEFAULT 0,
+			break_even_tier INTEGER NOT NULL DEFAULT 0,
+			break_even_profit_pct REAL NOT NULL DEFAULT 0,
+			trailing_stop_mode TEXT NOT NULL DEFAULT 'app',
+			entry_filters TEXT NOT NULL DEFAULT '',
+			external_key TEXT NOT NULL DEFAULT '',
+			mode TEXT NOT NULL DEFAULT 'live',
+			sizing_mode TEXT NOT NULL DEFAULT 'fixed',
+			risk_pct REAL NOT NULL DEFAULT 0,
+			risk_usd REAL NOT NULL DEFAULT 0,
+			max_slippage_pct REAL NOT NULL DEFAULT 0,
+			slippage_action TEXT NOT NULL DEFAULT 'reject',
+			exec_algo TEXT NOT NULL DEFAULT 'market',
+			exec_duration_sec INTEGER NOT NULL DEFAULT 0,
+			exec_clip_size REAL NOT NULL DEFAULT 0,
+			exec_max_chase_pct REAL NOT NULL DEFAULT 0,
+			exec_fallback TEXT NOT NULL DEFAULT 'market',
 			is_auto BOOLEAN NOT NULL DEFAULT 0,
 			auto_mode_enabled BOOLEAN NOT NULL DEFAULT 0,
 			source TEXT,
@@ -70,6 +90,9 @@ func (s *SQLiteStore) initSchema() error {
 			size REAL NOT NULL,
 			price REAL NOT NULL,
 			realized_pnl REAL NOT NULL DEFAULT 0,
+			expected_price REAL NOT NULL DEFAULT 0,
+			fill_price REAL NOT NULL DEFAULT 0,
+			fee REAL NOT NULL DEFAULT 0,
 			created_at DATETIME NOT NULL
 		);`,
 		`CREATE TABLE IF NOT EXISTS position_history (
@@ -83,6 +106,10 @@ func (s *SQLiteStore) initSchema() error {
 			realized_pnl REAL NOT NULL,
 			leverage INTEGER NOT NULL,
 			margin_type TEXT NOT NULL,
+			level_id TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			partial BOOLEAN NOT NULL DEFAULT 0,
+			fees REAL NOT NULL DEFAULT 0,
 			closed_at DATETIME NOT NULL
 		);`,
 		`CREATE TABLE IF NOT EXISTS liquidity_snapshots (
@@ -99,6 +126,71 @@ func (s *SQLiteStore) initSchema() error {
 			end_time INTEGER NOT NULL,
 			ticks_json TEXT NOT NULL
 		);`,
+		`CREATE TABLE IF NOT EXISTS level_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			level_id TEXT NOT NULL,
+			changed_by TEXT NOT NULL DEFAULT '',
+			changes_json TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_level_revisions_level ON level_revisions(level_id, created_at DESC);`,
+		`CREATE TABLE IF NOT EXISTS strategies (
+			id TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			symbols TEXT NOT NULL DEFAULT '',
+			config TEXT NOT NULL DEFAULT '',
+			state TEXT NOT NULL DEFAULT '',
+			running BOOLEAN NOT NULL DEFAULT 0,
+			updated_at DATETIME NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhook_alerts (
+			alert_id TEXT PRIMARY KEY,
+			action TEXT NOT NULL,
+			symbol TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			result TEXT NOT NULL DEFAULT '',
+			received_at DATETIME NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS shadow_trades (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			level_id TEXT NOT NULL,
+			exchange TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			mode TEXT NOT NULL,
+			action TEXT NOT NULL,
+			side TEXT NOT NULL,
+			size REAL NOT NULL,
+			price REAL NOT NULL,
+			mark_price REAL NOT NULL,
+			entry_price REAL NOT NULL DEFAULT 0,
+			realized_pnl REAL NOT NULL DEFAULT 0,
+			fee REAL NOT NULL DEFAULT 0,
+			reason TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_shadow_trades_level ON shadow_trades(level_id, created_at DESC);`,
+		`CREATE TABLE IF NOT EXISTS decisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			level_id TEXT NOT NULL DEFAULT '',
+			exchange TEXT NOT NULL DEFAULT '',
+			symbol TEXT NOT NULL DEFAULT '',
+			side TEXT NOT NULL DEFAULT '',
+			price REAL NOT NULL DEFAULT 0,
+			level_price REAL NOT NULL DEFAULT 0,
+			size REAL NOT NULL DEFAULT 0,
+			reason TEXT NOT NULL DEFAULT '',
+			outcome TEXT NOT NULL DEFAULT '',
+			inputs_json TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_decisions_level ON decisions(level_id, created_at DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_decisions_symbol ON decisions(symbol, created_at DESC);`,
+		`CREATE TABLE IF NOT EXISTS risk_state (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			state TEXT NOT NULL,
+			updated_at DATETIME NOT NULL
+		);`,
 	}
 
 	for _, q := range queries {
@@ -119,35 +211,127 @@ func (s *SQLiteStore) initSchema() error {
 	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN is_auto BOOLEAN NOT NULL DEFAULT 0`)
 	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN auto_mode_enabled BOOLEAN NOT NULL DEFAULT 0`)
 	_, _ = s.db.Exec(`ALTER TABLE trades ADD COLUMN realized_pnl REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN take_profit_ladder TEXT NOT NULL DEFAULT ''`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN trailing_activation_pct REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN trailing_stop_pct REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN break_even_tier INTEGER NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN break_even_profit_pct REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN trailing_stop_mode TEXT NOT NULL DEFAULT 'app'`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN entry_filters TEXT NOT NULL DEFAULT ''`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN external_key TEXT NOT NULL DEFAULT ''`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN mode TEXT NOT NULL DEFAULT 'live'`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN sizing_mode TEXT NOT NULL DEFAULT 'fixed'`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN risk_pct REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN risk_usd REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN max_slippage_pct REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN slippage_action TEXT NOT NULL DEFAULT 'reject'`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN exec_algo TEXT NOT NULL DEFAULT 'market'`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN exec_duration_sec INTEGER NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN exec_clip_size REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN exec_max_chase_pct REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE levels ADD COLUMN exec_fallback TEXT NOT NULL DEFAULT 'market'`)
+	_, _ = s.db.Exec(`ALTER TABLE trades ADD COLUMN expected_price REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE trades ADD COLUMN fill_price REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE position_history ADD COLUMN level_id TEXT NOT NULL DEFAULT ''`)
+	_, _ = s.db.Exec(`ALTER TABLE position_history ADD COLUMN reason TEXT NOT NULL DEFAULT ''`)
+	_, _ = s.db.Exec(`ALTER TABLE position_history ADD COLUMN partial BOOLEAN NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE trades ADD COLUMN fee REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE position_history ADD COLUMN fees REAL NOT NULL DEFAULT 0`)
+	_, _ = s.db.Exec(`ALTER TABLE shadow_trades ADD COLUMN fee REAL NOT NULL DEFAULT 0`)
 
 	return nil
 }
 
 // LevelRepository Implementation
 
-func (s *SQLiteStore) SaveLevel(ctx context.Context, level *domain.Level) error {
-	query := `INSERT INTO levels (id, exchange, symbol, level_price, base_size, leverage, margin_type, cool_down_ms, stop_loss_at_base, stop_loss_mode, disable_speed_close, max_consecutive_base_closes, base_close_cooldown_ms, take_profit_pct, take_profit_mode, is_auto, auto_mode_enabled, source, created_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := s.db.ExecContext(ctx, query,
+// levelColumns is shared by every level query so the column list and scanLevel stay in sync.
+const levelColumns = `id, exchange, symbol, level_price, base_size, leverage, margin_type, cool_down_ms, stop_loss_at_base, stop_loss_mode, disable_speed_close, max_consecutive_base_closes, base_close_cooldown_ms, take_profit_pct, take_profit_mode, take_profit_ladder, trailing_activation_pct, trailing_stop_pct, break_even_tier, break_even_profit_pct, trailing_stop_mode, entry_filters, external_key, mode, sizing_mode, risk_pct, risk_usd, max_slippage_pct, slippage_action, exec_algo, exec_duration_sec, exec_clip_size, exec_max_chase_pct, exec_fallback, is_auto, auto_mode_enabled, source, created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLevel(row rowScanner) (*domain.Level, error) {
+	var l domain.Level
+	var ladderJSON, entryFilters string
+	if err := row.Scan(
+		&l.ID, &l.Exchange, &l.Symbol, &l.LevelPrice, &l.BaseSize, &l.Leverage, &l.MarginType, &l.CoolDownMs,
+		&l.StopLossAtBase, &l.StopLossMode, &l.DisableSpeedClose, &l.MaxConsecutiveBaseCloses, &l.BaseCloseCooldownMs,
+		&l.TakeProfitPct, &l.TakeProfitMode, &ladderJSON,
+		&l.TrailingActivationPct, &l.TrailingStopPct, &l.BreakEvenTier, &l.BreakEvenProfitPct, &l.TrailingStopMode, &entryFilters, &l.ExternalKey, &l.Mode, &l.SizingMode, &l.RiskPct, &l.RiskUSD, &l.MaxSlippagePct, &l.SlippageAction, &l.ExecAlgo, &l.ExecDurationSec, &l.ExecClipSize, &l.ExecMaxChasePct, &l.ExecFallback,
+		&l.IsAuto, &l.AutoModeEnabled, &l.Source, &l.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if ladderJSON != "" {
+		if err := json.Unmarshal([]byte(ladderJSON), &l.TakeProfitLadder); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal take profit ladder for level %s: %w", l.ID, err)
+		}
+	}
+	if entryFilters != "" {
+		l.EntryFilters = strings.Split(entryFilters, ",")
+	}
+	return &l, nil
+}
+
+// levelArgs returns the values for levelColumns, in the same order.
+func levelArgs(level *domain.Level) ([]interface{}, error) {
+	ladderJSON := ""
+	if len(level.TakeProfitLadder) > 0 {
+		b, err := json.Marshal(level.TakeProfitLadder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal take profit ladder: %w", err)
+		}
+		ladderJSON = string(b)
+	}
+
+	return []interface{}{
 		level.ID, level.Exchange, level.Symbol, level.LevelPrice, level.BaseSize,
-		level.Leverage, level.MarginType, level.CoolDownMs, level.StopLossAtBase, level.StopLossMode, level.DisableSpeedClose, level.MaxConsecutiveBaseCloses, level.BaseCloseCooldownMs, level.TakeProfitPct, level.TakeProfitMode, level.IsAuto, level.AutoModeEnabled, level.Source, level.CreatedAt)
+		level.Leverage, level.MarginType, level.CoolDownMs, level.StopLossAtBase, level.StopLossMode, level.DisableSpeedClose, level.MaxConsecutiveBaseCloses, level.BaseCloseCooldownMs, level.TakeProfitPct, level.TakeProfitMode, ladderJSON,
+		level.TrailingActivationPct, level.TrailingStopPct, level.BreakEvenTier, level.BreakEvenProfitPct, level.TrailingStopMode, strings.Join(level.EntryFilters, ","), level.ExternalKey, level.EffectiveMode(), level.EffectiveSizingMode(), level.RiskPct, level.RiskUSD, level.MaxSlippagePct, level.EffectiveSlippageAction(), level.EffectiveExecAlgo(), level.ExecDurationSec, level.ExecClipSize, level.ExecMaxChasePct, level.EffectiveExecFallback(),
+		level.IsAuto, level.AutoModeEnabled, level.Source, level.CreatedAt,
+	}, nil
+}
+
+func (s *SQLiteStore) SaveLevel(ctx context.Context, level *domain.Level) error {
+	args, err := levelArgs(level)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO levels (` + levelColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = s.db.ExecContext(ctx, query, args...)
 	return err
 }
 
-func (s *SQLiteStore) GetLevel(ctx context.Context, id string) (*domain.Level, error) {
-	query := `SELECT id, exchange, symbol, level_price, base_size, leverage, margin_type, cool_down_ms, stop_loss_at_base, stop_loss_mode, disable_speed_close, max_consecutive_base_closes, base_close_cooldown_ms, take_profit_pct, take_profit_mode, is_auto, auto_mode_enabled, source, created_at FROM levels WHERE id = ?`
-	row := s.db.QueryRowContext(ctx, query, id)
+// UpdateLevel overwrites every column except id of an existing level.
+func (s *SQLiteStore) UpdateLevel(ctx context.Context, level *domain.Level) error {
+	args, err := levelArgs(level)
+	if err != nil {
+		return err
+	}
 
-	var l domain.Level
-	err := row.Scan(&l.ID, &l.Exchange, &l.Symbol, &l.LevelPrice, &l.BaseSize, &l.Leverage, &l.MarginType, &l.CoolDownMs, &l.StopLossAtBase, &l.StopLossMode, &l.DisableSpeedClose, &l.MaxConsecutiveBaseCloses, &l.BaseCloseCooldownMs, &l.TakeProfitPct, &l.TakeProfitMode, &l.IsAuto, &l.AutoModeEnabled, &l.Source, &l.CreatedAt)
+	// SET exchange = ?, symbol = ?, ... built from levelColumns minus the id
+	columns := strings.Split(levelColumns, ", ")[1:]
+	query := `UPDATE levels SET ` + strings.Join(columns, " = ?, ") + ` = ? WHERE id = ?`
+	res, err := s.db.ExecContext(ctx, query, append(args[1:], level.ID)...)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &l, nil
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("level %s not found", level.ID)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetLevel(ctx context.Context, id string) (*domain.Level, error) {
+	query := `SELECT ` + levelColumns + ` FROM levels WHERE id = ?`
+	return scanLevel(s.db.QueryRowContext(ctx, query, id))
 }
 
 func (s *SQLiteStore) ListLevels(ctx context.Context) ([]*domain.Level, error) {
-	query := `SELECT id, exchange, symbol, level_price, base_size, leverage, margin_type, cool_down_ms, stop_loss_at_base, stop_loss_mode, disable_speed_close, max_consecutive_base_closes, base_close_cooldown_ms, take_profit_pct, take_profit_mode, is_auto, auto_mode_enabled, source, created_at FROM levels`
+	query := `SELECT ` + levelColumns + ` FROM levels`
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -156,11 +340,11 @@ func (s *SQLiteStore) ListLevels(ctx context.Context) ([]*domain.Level, error) {
 
 	var levels []*domain.Level
 	for rows.Next() {
-		var l domain.Level
-		if err := rows.Scan(&l.ID, &l.Exchange, &l.Symbol, &l.LevelPrice, &l.BaseSize, &l.Leverage, &l.MarginType, &l.CoolDownMs, &l.StopLossAtBase, &l.StopLossMode, &l.DisableSpeedClose, &l.MaxConsecutiveBaseCloses, &l.BaseCloseCooldownMs, &l.TakeProfitPct, &l.TakeProfitMode, &l.IsAuto, &l.AutoModeEnabled, &l.Source, &l.CreatedAt); err != nil {
+		l, err := scanLevel(rows)
+		if err != nil {
 			return nil, err
 		}
-		levels = append(levels, &l)
+		levels = append(levels, l)
 	}
 	return levels, nil
 }
@@ -170,6 +354,46 @@ func (s *SQLiteStore) DeleteLevel(ctx context.Context, id string) error {
 	return err
 }
 
+func (s *SQLiteStore) SaveLevelRevision(ctx context.Context, rev *domain.LevelRevision) error {
+	changesJSON, err := json.Marshal(rev.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal level changes: %w", err)
+	}
+
+	query := `INSERT INTO level_revisions (level_id, changed_by, changes_json, created_at) VALUES (?, ?, ?, ?)`
+	res, err := s.db.ExecContext(ctx, query, rev.LevelID, rev.ChangedBy, string(changesJSON), rev.CreatedAt)
+	if err != nil {
+		return err
+	}
+	rev.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ListLevelRevisions returns the newest revisions of a level first.
+func (s *SQLiteStore) ListLevelRevisions(ctx context.Context, levelID string, limit int) ([]*domain.LevelRevision, error) {
+	query := `SELECT id, level_id, changed_by, changes_json, created_at FROM level_revisions
+			  WHERE level_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, levelID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*domain.LevelRevision
+	for rows.Next() {
+		var rev domain.LevelRevision
+		var changesJSON string
+		if err := rows.Scan(&rev.ID, &rev.LevelID, &rev.ChangedBy, &changesJSON, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changesJSON), &rev.Changes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal changes of revision %d: %w", rev.ID, err)
+		}
+		revisions = append(revisions, &rev)
+	}
+	return revisions, nil
+}
+
 func (s *SQLiteStore) SaveSymbolTiers(ctx context.Context, tiers *domain.SymbolTiers) error {
 	query := `INSERT INTO symbol_tiers (exchange, symbol, tier1_pct, tier2_pct, tier3_pct, updated_at)
 			  VALUES (?, ?, ?, ?, ?, ?)
@@ -198,15 +422,15 @@ func (s *SQLiteStore) GetSymbolTiers(ctx context.Context, exchange, symbol strin
 // TradeRepository Implementation
 
 func (s *SQLiteStore) SaveTrade(ctx context.Context, order *domain.Order) error {
-	query := `INSERT INTO trades (exchange, symbol, level_id, side, size, price, realized_pnl, created_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO trades (exchange, symbol, level_id, side, size, price, realized_pnl, expected_price, fill_price, fee, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	_, err := s.db.ExecContext(ctx, query,
-		order.Exchange, order.Symbol, order.LevelID, order.Side, order.Size, order.Price, order.RealizedPnL, order.CreatedAt)
+		order.Exchange, order.Symbol, order.LevelID, order.Side, order.Size, order.Price, order.RealizedPnL, order.ExpectedPrice, order.FillPrice, order.Fee, order.CreatedAt)
 	return err
 }
 
 func (s *SQLiteStore) ListTrades(ctx context.Context, limit int) ([]*domain.Order, error) {
-	query := `SELECT exchange, symbol, level_id, side, size, price, realized_pnl, created_at FROM trades ORDER BY id DESC LIMIT ?`
+	query := `SELECT exchange, symbol, level_id, side, size, price, realized_pnl, expected_price, fill_price, fee, created_at FROM trades ORDER BY id DESC LIMIT ?`
 	rows, err := s.db.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
@@ -216,7 +440,7 @@ func (s *SQLiteStore) ListTrades(ctx context.Context, limit int) ([]*domain.Orde
 	var trades []*domain.Order
 	for rows.Next() {
 		var o domain.Order
-		if err := rows.Scan(&o.Exchange, &o.Symbol, &o.LevelID, &o.Side, &o.Size, &o.Price, &o.RealizedPnL, &o.CreatedAt); err != nil {
+		if err := rows.Scan(&o.Exchange, &o.Symbol, &o.LevelID, &o.Side, &o.Size, &o.Price, &o.RealizedPnL, &o.ExpectedPrice, &o.FillPrice, &o.Fee, &o.CreatedAt); err != nil {
 			return nil, err
 		}
 		trades = append(trades, &o)
@@ -225,15 +449,15 @@ func (s *SQLiteStore) ListTrades(ctx context.Context, limit int) ([]*domain.Orde
 }
 
 func (s *SQLiteStore) SavePositionHistory(ctx context.Context, history *domain.PositionHistory) error {
-	query := `INSERT INTO position_history (exchange, symbol, side, size, entry_price, exit_price, realized_pnl, leverage, margin_type, closed_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO position_history (exchange, symbol, side, size, entry_price, exit_price, realized_pnl, fees, leverage, margin_type, level_id, reason, partial, closed_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	_, err := s.db.ExecContext(ctx, query,
-		history.Exchange, history.Symbol, history.Side, history.Size, history.EntryPrice, history.ExitPrice, history.RealizedPnL, history.Leverage, history.MarginType, history.ClosedAt)
+		history.Exchange, history.Symbol, history.Side, history.Size, history.EntryPrice, history.ExitPrice, history.RealizedPnL, history.Fees, history.Leverage, history.MarginType, history.LevelID, history.Reason, history.Partial, history.ClosedAt)
 	return err
 }
 
 func (s *SQLiteStore) ListPositionHistory(ctx context.Context, limit int) ([]*domain.PositionHistory, error) {
-	query := `SELECT id, exchange, symbol, side, size, entry_price, exit_price, realized_pnl, leverage, margin_type, closed_at FROM position_history ORDER BY id DESC LIMIT ?`
+	query := `SELECT id, exchange, symbol, side, size, entry_price, exit_price, realized_pnl, fees, leverage, margin_type, level_id, reason, partial, closed_at FROM position_history ORDER BY id DESC LIMIT ?`
 	rows, err := s.db.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
@@ -243,7 +467,7 @@ func (s *SQLiteStore) ListPositionHistory(ctx context.Context, limit int) ([]*do
 	var history []*domain.PositionHistory
 	for rows.Next() {
 		var h domain.PositionHistory
-		if err := rows.Scan(&h.ID, &h.Exchange, &h.Symbol, &h.Side, &h.Size, &h.EntryPrice, &h.ExitPrice, &h.RealizedPnL, &h.Leverage, &h.MarginType, &h.ClosedAt); err != nil {
+		if err := rows.Scan(&h.ID, &h.Exchange, &h.Symbol, &h.Side, &h.Size, &h.EntryPrice, &h.ExitPrice, &h.RealizedPnL, &h.Fees, &h.Leverage, &h.MarginType, &h.LevelID, &h.Reason, &h.Partial, &h.ClosedAt); err != nil {
 			return nil, err
 		}
 		history = append(history, &h)
@@ -251,7 +475,7 @@ func (s *SQLiteStore) ListPositionHistory(ctx context.Context, limit int) ([]*do
 	return history, nil
 }
 func (s *SQLiteStore) GetLevelsBySymbol(ctx context.Context, symbol string) ([]*domain.Level, error) {
-	query := `SELECT id, exchange, symbol, level_price, base_size, leverage, margin_type, cool_down_ms, stop_loss_at_base, stop_loss_mode, disable_speed_close, max_consecutive_base_closes, base_close_cooldown_ms, take_profit_pct, take_profit_mode, is_auto, auto_mode_enabled, source, created_at FROM levels WHERE symbol = ?`
+	query := `SELECT ` + levelColumns + ` FROM levels WHERE symbol = ?`
 	rows, err := s.db.QueryContext(ctx, query, symbol)
 	if err != nil {
 		return nil, err
@@ -260,15 +484,11 @@ func (s *SQLiteStore) GetLevelsBySymbol(ctx context.Context, symbol string) ([]*
 
 	var levels []*domain.Level
 	for rows.Next() {
-		var l domain.Level
-		if err := rows.Scan(
-			&l.ID, &l.Exchange, &l.Symbol, &l.LevelPrice, &l.BaseSize, &l.Leverage, &l.MarginType, &l.CoolDownMs,
-			&l.StopLossAtBase, &l.StopLossMode, &l.DisableSpeedClose, &l.MaxConsecutiveBaseCloses, &l.BaseCloseCooldownMs,
-			&l.TakeProfitPct, &l.TakeProfitMode, &l.IsAuto, &l.AutoModeEnabled, &l.Source, &l.CreatedAt,
-		); err != nil {
+		l, err := scanLevel(rows)
+		if err != nil {
 			return nil, err
 		}
-		levels = append(levels, &l)
+		levels = append(levels, l)
 	}
 	return levels, nil
 }
@@ -375,3 +595,294 @@ func (s *SQLiteStore) GetTradeSessionLog(ctx context.Context, id string) (*domai
 
 	return &l, nil
 }
+
+// StrategyRepository Implementation
+
+func (s *SQLiteStore) SaveStrategy(ctx context.Context, rec *domain.StrategyRecord) error {
+	query := `INSERT INTO strategies (id, kind, symbols, config, state, running, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET
+			  kind=excluded.kind,
+			  symbols=excluded.symbols,
+			  config=excluded.config,
+			  state=excluded.state,
+			  running=excluded.running,
+			  updated_at=excluded.updated_at`
+	_, err := s.db.ExecContext(ctx, query,
+		rec.ID, rec.Kind, strings.Join(rec.Symbols, ","), rec.Config, rec.State, rec.Running, rec.UpdatedAt)
+	return err
+}
+
+func scanStrategy(row rowScanner) (*domain.StrategyRecord, error) {
+	var rec domain.StrategyRecord
+	var symbols string
+	if err := row.Scan(&rec.ID, &rec.Kind, &symbols, &rec.Config, &rec.State, &rec.Running, &rec.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if symbols != "" {
+		rec.Symbols = strings.Split(symbols, ",")
+	}
+	return &rec, nil
+}
+
+func (s *SQLiteStore) GetStrategy(ctx context.Context, id string) (*domain.StrategyRecord, error) {
+	query := `SELECT id, kind, symbols, config, state, running, updated_at FROM strategies WHERE id = ?`
+	return scanStrategy(s.db.QueryRowContext(ctx, query, id))
+}
+
+func (s *SQLiteStore) ListStrategies(ctx context.Context) ([]*domain.StrategyRecord, error) {
+	query := `SELECT id, kind, symbols, config, state, running, updated_at FROM strategies ORDER BY id`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []*domain.StrategyRecord
+	for rows.Next() {
+		rec, err := scanStrategy(rows)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+func (s *SQLiteStore) DeleteStrategy(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM strategies WHERE id = ?", id)
+	return err
+}
+
+// WebhookRepository Implementation
+
+// ClaimWebhookAlert inserts the alert, or takes over a failed earlier delivery of it.
+func (s *SQLiteStore) ClaimWebhookAlert(ctx context.Context, alert *domain.WebhookAlert) (bool, error) {
+	query := `INSERT INTO webhook_alerts (alert_id, action, symbol, status, result, received_at)
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(alert_id) DO UPDATE SET
+			  action=excluded.action,
+			  symbol=excluded.symbol,
+			  status=excluded.status,
+			  result=excluded.result,
+			  received_at=excluded.received_at
+			  WHERE webhook_alerts.status = ?`
+	res, err := s.db.ExecContext(ctx, query,
+		alert.AlertID, alert.Action, alert.Symbol, alert.Status, alert.Result, alert.ReceivedAt, domain.WebhookAlertFailed)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) GetWebhookAlert(ctx context.Context, alertID string) (*domain.WebhookAlert, error) {
+	query := `SELECT alert_id, action, symbol, status, result, received_at FROM webhook_alerts WHERE alert_id = ?`
+	var a domain.WebhookAlert
+	if err := s.db.QueryRowContext(ctx, query, alertID).Scan(&a.AlertID, &a.Action, &a.Symbol, &a.Status, &a.Result, &a.ReceivedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *SQLiteStore) FinishWebhookAlert(ctx context.Context, alertID, status, result string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_alerts SET status = ?, result = ? WHERE alert_id = ?", status, result, alertID)
+	return err
+}
+
+// ListWebhookAlerts returns the most recent alerts first.
+func (s *SQLiteStore) ListWebhookAlerts(ctx context.Context, limit int) ([]*domain.WebhookAlert, error) {
+	query := `SELECT alert_id, action, symbol, status, result, received_at FROM webhook_alerts
+			  ORDER BY received_at DESC LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []*domain.WebhookAlert
+	for rows.Next() {
+		var a domain.WebhookAlert
+		if err := rows.Scan(&a.AlertID, &a.Action, &a.Symbol, &a.Status, &a.Result, &a.ReceivedAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &a)
+	}
+	return alerts, nil
+}
+
+// ShadowRepository Implementation
+
+func (s *SQLiteStore) SaveShadowTrade(ctx context.Context, t *domain.ShadowTrade) error {
+	query := `INSERT INTO shadow_trades (level_id, exchange, symbol, mode, action, side, size, price, mark_price, entry_price, realized_pnl, fee, reason, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := s.db.ExecContext(ctx, query,
+		t.LevelID, t.Exchange, t.Symbol, t.Mode, t.Action, t.Side, t.Size, t.Price, t.MarkPrice, t.EntryPrice, t.RealizedPnL, t.Fee, t.Reason, t.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		t.ID = id
+	}
+	return nil
+}
+
+// ListShadowTrades returns the most recent shadow trades first, of one level or of all.
+func (s *SQLiteStore) ListShadowTrades(ctx context.Context, levelID string, limit int) ([]*domain.ShadowTrade, error) {
+	query := `SELECT id, level_id, exchange, symbol, mode, action, side, size, price, mark_price, entry_price, realized_pnl, fee, reason, created_at
+			  FROM shadow_trades WHERE (? = '' OR level_id = ?) ORDER BY created_at DESC, id DESC LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, levelID, levelID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []*domain.ShadowTrade
+	for rows.Next() {
+		var t domain.ShadowTrade
+		if err := rows.Scan(&t.ID, &t.LevelID, &t.Exchange, &t.Symbol, &t.Mode, &t.Action, &t.Side, &t.Size, &t.Price,
+			&t.MarkPrice, &t.EntryPrice, &t.RealizedPnL, &t.Fee, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		trades = append(trades, &t)
+	}
+	return trades, nil
+}
+
+// ListLevelPnL sums realized PnL and fees per level: live from the position history, shadow
+// from the shadow exits. Wins are exits with a positive net PnL. Alert levels never fill, so
+// they have no rows.
+func (s *SQLiteStore) ListLevelPnL(ctx context.Context) ([]*domain.LevelPnL, error) {
+	query := `SELECT level_id, ?, COUNT(*), SUM(CASE WHEN realized_pnl - fees > 0 THEN 1 ELSE 0 END), SUM(realized_pnl), SUM(fees)
+			  FROM position_history WHERE level_id != '' GROUP BY level_id
+			  UNION ALL
+			  SELECT level_id, mode, COUNT(*), SUM(CASE WHEN realized_pnl - fee > 0 THEN 1 ELSE 0 END), SUM(realized_pnl), SUM(fee)
+			  FROM shadow_trades WHERE action IN (?, ?) GROUP BY level_id, mode`
+	rows, err := s.db.QueryContext(ctx, query, domain.LevelModeLive, domain.ShadowActionReduce, domain.ShadowActionClose)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*domain.LevelPnL
+	for rows.Next() {
+		var p domain.LevelPnL
+		if err := rows.Scan(&p.LevelID, &p.Mode, &p.Exits, &p.Wins, &p.RealizedPnL, &p.Fees); err != nil {
+			return nil, err
+		}
+		result = append(result, &p)
+	}
+	return result, nil
+}
+
+// DecisionRepository Implementation
+
+func (s *SQLiteStore) SaveDecision(ctx context.Context, d *domain.Decision) error {
+	inputsJSON := ""
+	if len(d.Inputs) > 0 {
+		b, err := json.Marshal(d.Inputs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal decision inputs: %w", err)
+		}
+		inputsJSON = string(b)
+	}
+
+	query := `INSERT INTO decisions (kind, level_id, exchange, symbol, side, price, level_price, size, reason, outcome, inputs_json, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := s.db.ExecContext(ctx, query,
+		d.Kind, d.LevelID, d.Exchange, d.Symbol, d.Side, d.Price, d.LevelPrice, d.Size, d.Reason, d.Outcome, inputsJSON, d.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		d.ID = id
+	}
+	return nil
+}
+
+// ListDecisions returns the journal entries matching the filter, newest first.
+func (s *SQLiteStore) ListDecisions(ctx context.Context, f domain.DecisionFilter) ([]*domain.Decision, error) {
+	var where []string
+	var args []interface{}
+	for _, c := range []struct{ column, value string }{
+		{"level_id", f.LevelID}, {"symbol", f.Symbol}, {"kind", f.Kind}, {"outcome", f.Outcome},
+	} {
+		if c.value != "" {
+			where = append(where, c.column+" = ?")
+			args = append(args, c.value)
+		}
+	}
+	if !f.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.From.UTC()) // Stored in UTC, the text comparison needs the same zone
+	}
+	if !f.To.IsZero() {
+		where = append(where, "created_at <= ?")
+		args = append(args, f.To.UTC())
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT id, kind, level_id, exchange, symbol, side, price, level_price, size, reason, outcome, inputs_json, created_at FROM decisions`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []*domain.Decision
+	for rows.Next() {
+		var d domain.Decision
+		var inputsJSON string
+		if err := rows.Scan(&d.ID, &d.Kind, &d.LevelID, &d.Exchange, &d.Symbol, &d.Side, &d.Price, &d.LevelPrice, &d.Size,
+			&d.Reason, &d.Outcome, &inputsJSON, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		if inputsJSON != "" {
+			if err := json.Unmarshal([]byte(inputsJSON), &d.Inputs); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal inputs of decision %d: %w", d.ID, err)
+			}
+		}
+		decisions = append(decisions, &d)
+	}
+	return decisions, nil
+}
+
+// RiskRepository Implementation
+
+// SaveRiskState keeps a single row, the state is stored as JSON.
+func (s *SQLiteStore) SaveRiskState(ctx context.Context, state *domain.RiskState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal risk state: %w", err)
+	}
+	query := `INSERT INTO risk_state (id, state, updated_at) VALUES (1, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET state=excluded.state, updated_at=excluded.updated_at`
+	_, err = s.db.ExecContext(ctx, query, string(b), state.UpdatedAt)
+	return err
+}
+
+func (s *SQLiteStore) GetRiskState(ctx context.Context) (*domain.RiskState, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, `SELECT state FROM risk_state WHERE id = 1`).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state domain.RiskState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal risk state: %w", err)
+	}
+	return &state, nil
+}
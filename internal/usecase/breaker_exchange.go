@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// BreakerExchange feeds the circuit breakers with the outcome of every order and position
+// fetch, and refuses orders that add exposure while a breaker of their symbol is open.
+// It sits between the adapter and the risk manager, so risk rejections never reach it and
+// do not count as exchange failures. Reducing orders are never blocked.
+type BreakerExchange struct {
+	domain.Exchange
+	breakers *CircuitBreakers
+}
+
+func NewBreakerExchange(exchange domain.Exchange, breakers *CircuitBreakers) *BreakerExchange {
+	return &BreakerExchange{Exchange: exchange, breakers: breakers}
+}
+
+func (e *BreakerExchange) MarketBuy(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error {
+	if err := e.breakers.AllowEntry(symbol); err != nil {
+		return fmt.Errorf("failed to buy %s: %w", symbol, err)
+	}
+	err := e.Exchange.MarketBuy(ctx, symbol, size, leverage, marginType, stopLoss)
+	e.recordOrder(symbol, err)
+	return err
+}
+
+func (e *BreakerExchange) MarketSell(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error {
+	if err := e.breakers.AllowEntry(symbol); err != nil {
+		return fmt.Errorf("failed to sell %s: %w", symbol, err)
+	}
+	err := e.Exchange.MarketSell(ctx, symbol, size, leverage, marginType, stopLoss)
+	e.recordOrder(symbol, err)
+	return err
+}
+
+func (e *BreakerExchange) PlaceOrder(ctx context.Context, order *domain.Order) (*domain.Order, error) {
+	if !order.ReduceOnly {
+		if err := e.breakers.AllowEntry(order.Symbol); err != nil {
+			return nil, fmt.Errorf("failed to place %s order on %s: %w", order.Side, order.Symbol, err)
+		}
+	}
+	placed, err := e.Exchange.PlaceOrder(ctx, order)
+	e.recordOrder(order.Symbol, err)
+	return placed, err
+}
+
+func (e *BreakerExchange) ClosePosition(ctx context.Context, symbol string) error {
+	err := e.Exchange.ClosePosition(ctx, symbol)
+	e.recordOrder(symbol, err)
+	return err
+}
+
+func (e *BreakerExchange) ReducePosition(ctx context.Context, symbol string, size float64) error {
+	err := e.Exchange.ReducePosition(ctx, symbol, size)
+	e.recordOrder(symbol, err)
+	return err
+}
+
+func (e *BreakerExchange) GetPosition(ctx context.Context, symbol string) (*domain.Position, error) {
+	pos, err := e.Exchange.GetPosition(ctx, symbol)
+	if !canceled(err) {
+		e.breakers.RecordPosition(symbol, err)
+	}
+	return pos, err
+}
+
+// recordOrder skips our own cancellations, they say nothing about the exchange. A cancelled
+// trial is given back so the breaker does not wait for it forever.
+func (e *BreakerExchange) recordOrder(symbol string, err error) {
+	if canceled(err) {
+		e.breakers.AbortTrial(symbol)
+		return
+	}
+	e.breakers.RecordOrder(symbol, err)
+}
+
+func canceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
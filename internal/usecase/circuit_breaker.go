@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is wrapped by every entry a circuit breaker blocks.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreakerConfig controls when breakers open and how they recover. Zero values take
+// the defaults.
+type CircuitBreakerConfig struct {
+	SymbolMaxFailures   int `yaml:"symbol_max_failures"`   // Consecutive order failures on a symbol that open it, default 3
+	ExchangeMaxFailures int `yaml:"exchange_max_failures"` // Consecutive order failures over all symbols that open the exchange, default 5
+	CooldownSec         int `yaml:"cooldown_sec"`          // Open time before a trial order, doubles after a failed trial, default 60
+	MaxCooldownSec      int `yaml:"max_cooldown_sec"`      // Cap of the doubled cooldown, default 900
+	MaxPriceAgeSec      int `yaml:"max_price_age_sec"`     // Older prices are stale and block decisions, default 10
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.SymbolMaxFailures <= 0 {
+		c.SymbolMaxFailures = 3
+	}
+	if c.ExchangeMaxFailures <= 0 {
+		c.ExchangeMaxFailures = 5
+	}
+	if c.CooldownSec <= 0 {
+		c.CooldownSec = 60
+	}
+	if c.MaxCooldownSec <= 0 {
+		c.MaxCooldownSec = 900
+	}
+	if c.MaxCooldownSec < c.CooldownSec {
+		c.MaxCooldownSec = c.CooldownSec
+	}
+	if c.MaxPriceAgeSec <= 0 {
+		c.MaxPriceAgeSec = 10
+	}
+	return c
+}
+
+// BreakerState is the state of one breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Trading normally
+	BreakerOpen     BreakerState = "open"      // Entries blocked
+	BreakerHalfOpen BreakerState = "half_open" // Cooldown over, the next order is the trial
+)
+
+// Breaker kinds, one breaker per kind and symbol (the exchange breaker has no symbol).
+const (
+	BreakerKindExchange = "exchange" // Order failures over all symbols, recovers by a trial order
+	BreakerKindOrders   = "orders"   // Order failures of one symbol, recovers by a trial order
+	BreakerKindPosition = "position" // Position fetch failed, recovers on the next good fetch
+	BreakerKindPrice    = "price"    // Last price too old, recovers on the next tick
+)
+
+// BreakerStatus is the API view of one breaker.
+type BreakerStatus struct {
+	Kind      string       `json:"kind"`
+	Symbol    string       `json:"symbol,omitempty"`
+	State     BreakerState `json:"state"`
+	Failures  int          `json:"failures"` // Consecutive
+	Trips     int          `json:"trips"`
+	LastError string       `json:"last_error,omitempty"`
+	OpenedAt  time.Time    `json:"opened_at,omitempty"`
+	RetryAt   time.Time    `json:"retry_at,omitempty"` // Order breakers: when the trial order is allowed
+	Recovery  string       `json:"recovery"`
+}
+
+// orderBreaker counts consecutive order failures. It opens at the limit, lets one trial
+// through after the cooldown and closes on its success, a failed trial doubles the cooldown.
+type orderBreaker struct {
+	state     BreakerState
+	failures  int
+	trips     int
+	lastError string
+	openedAt  time.Time
+	retryAt   time.Time
+	cooldown  time.Duration
+	trial     bool // The trial order is out, entries wait for its outcome
+}
+
+// CircuitBreakers stops entries when the exchange or the market data cannot be trusted:
+// repeated order failures per symbol or account wide, a failed position fetch (the exposure
+// is unknown, not flat) and a stale last price.
+type CircuitBreakers struct {
+	mu        sync.Mutex
+	cfg       CircuitBreakerConfig
+	exchange  orderBreaker
+	symbols   map[string]*orderBreaker
+	positions map[string]positionFailure // symbol -> last failed fetch, cleared by a good one
+	prices    map[string]time.Time       // symbol -> time of the last price
+	timeNow   func() time.Time
+}
+
+type positionFailure struct {
+	err      string
+	since    time.Time
+	failures int
+}
+
+func NewCircuitBreakers(cfg CircuitBreakerConfig) *CircuitBreakers {
+	return &CircuitBreakers{
+		cfg:       cfg.withDefaults(),
+		exchange:  orderBreaker{state: BreakerClosed},
+		symbols:   make(map[string]*orderBreaker),
+		positions: make(map[string]positionFailure),
+		prices:    make(map[string]time.Time),
+		timeNow:   time.Now,
+	}
+}
+
+func (b *CircuitBreakers) SetConfig(cfg CircuitBreakerConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cfg = cfg.withDefaults()
+}
+
+func (b *CircuitBreakers) Config() CircuitBreakerConfig {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cfg
+}
+
+// SetClock replaces the time source, for tests.
+func (b *CircuitBreakers) SetClock(now func() time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timeNow = now
+}
+
+// AllowEntry returns an ErrCircuitOpen error if new exposure on the symbol is blocked.
+// An order breaker past its cooldown turns half-open and lets this entry through as the
+// trial, the next ones wait for its outcome. Only the order path may call it, see EntryBlocked.
+func (b *CircuitBreakers) AllowEntry(symbol string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.entryBlocked(symbol); err != nil {
+		return err
+	}
+
+	// Nothing blocks, breakers past their cooldown take this entry as their trial
+	b.exchange.probe()
+	if ob := b.symbols[symbol]; ob != nil {
+		ob.probe()
+	}
+	return nil
+}
+
+// EntryBlocked is AllowEntry without taking the trial, for deciding whether to send an entry
+// at all. The order itself still goes through AllowEntry.
+func (b *CircuitBreakers) EntryBlocked(symbol string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.entryBlocked(symbol)
+}
+
+// entryBlocked must hold b.mu.
+func (b *CircuitBreakers) entryBlocked(symbol string) error {
+	now := b.timeNow()
+	if err := b.exchange.blocked(now); err != nil {
+		return fmt.Errorf("%w: exchange: %v", ErrCircuitOpen, err)
+	}
+	if ob := b.symbols[symbol]; ob != nil {
+		if err := ob.blocked(now); err != nil {
+			return fmt.Errorf("%w: %s orders: %v", ErrCircuitOpen, symbol, err)
+		}
+	}
+	if pf, ok := b.positions[symbol]; ok {
+		return fmt.Errorf("%w: %s position unknown since %s: %s", ErrCircuitOpen, symbol, pf.since.Format("15:04:05"), pf.err)
+	}
+	if at, ok := b.prices[symbol]; ok {
+		if age, max := now.Sub(at), b.maxPriceAge(); age > max {
+			return fmt.Errorf("%w: %s price is %s old (max %s)", ErrCircuitOpen, symbol, age.Round(time.Second), max)
+		}
+	}
+	return nil
+}
+
+// blocked must hold the CircuitBreakers lock.
+func (ob *orderBreaker) blocked(now time.Time) error {
+	switch {
+	case ob.state == BreakerOpen && now.Before(ob.retryAt):
+		return fmt.Errorf("%d consecutive failures, retry at %s: %s", ob.failures, ob.retryAt.Format("15:04:05"), ob.lastError)
+	case ob.state == BreakerHalfOpen && ob.trial:
+		return fmt.Errorf("trial order in flight after %d consecutive failures: %s", ob.failures, ob.lastError)
+	}
+	return nil
+}
+
+func (ob *orderBreaker) probe() {
+	if ob.state != BreakerClosed {
+		ob.state = BreakerHalfOpen
+		ob.trial = true
+	}
+}
+
+// AbortTrial frees the trial of the symbol when its order never reached the exchange, the
+// next entry becomes the trial.
+func (b *CircuitBreakers) AbortTrial(symbol string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.exchange.trial = false
+	if ob := b.symbols[symbol]; ob != nil {
+		ob.trial = false
+	}
+}
+
+// RecordOrder books the outcome of an order that reached the exchange.
+func (b *CircuitBreakers) RecordOrder(symbol string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.timeNow()
+
+	ob, ok := b.symbols[symbol]
+	if !ok {
+		ob = &orderBreaker{state: BreakerClosed}
+		b.symbols[symbol] = ob
+	}
+	if err == nil {
+		if ob.state != BreakerClosed {
+			log.Printf("BREAKER: %s orders recovered", symbol)
+		}
+		if b.exchange.state != BreakerClosed {
+			log.Printf("BREAKER: Exchange orders recovered")
+		}
+		ob.reset()
+		b.exchange.reset()
+		return
+	}
+
+	cooldown := time.Duration(b.cfg.CooldownSec) * time.Second
+	maxCooldown := time.Duration(b.cfg.MaxCooldownSec) * time.Second
+	if ob.record(err, now, b.cfg.SymbolMaxFailures, cooldown, maxCooldown) {
+		log.Printf("BREAKER: %s orders OPEN after %d consecutive failures, retry at %s: %v", symbol, ob.failures, ob.retryAt.Format("15:04:05"), err)
+	}
+	if b.exchange.record(err, now, b.cfg.ExchangeMaxFailures, cooldown, maxCooldown) {
+		log.Printf("BREAKER: Exchange orders OPEN after %d consecutive failures, retry at %s: %v", b.exchange.failures, b.exchange.retryAt.Format("15:04:05"), err)
+	}
+}
+
+// record counts a failure and reports whether the breaker (re)opened.
+func (ob *orderBreaker) record(err error, now time.Time, limit int, cooldown, maxCooldown time.Duration) bool {
+	ob.failures++
+	ob.lastError = err.Error()
+	switch {
+	case ob.state == BreakerHalfOpen:
+		// The trial failed, back off further
+		ob.cooldown *= 2
+		if ob.cooldown > maxCooldown {
+			ob.cooldown = maxCooldown
+		}
+	case ob.state == BreakerClosed && ob.failures >= limit:
+		ob.cooldown = cooldown
+	default:
+		return false
+	}
+	ob.state = BreakerOpen
+	ob.trial = false
+	ob.trips++
+	ob.openedAt = now
+	ob.retryAt = now.Add(ob.cooldown)
+	return true
+}
+
+func (ob *orderBreaker) reset() {
+	ob.state = BreakerClosed
+	ob.failures = 0
+	ob.lastError = ""
+	ob.openedAt = time.Time{}
+	ob.retryAt = time.Time{}
+	ob.cooldown = 0
+	ob.trial = false
+}
+
+// RecordPosition books a position fetch. A failure leaves the exposure of the symbol unknown
+// and blocks entries until a fetch succeeds again.
+func (b *CircuitBreakers) RecordPosition(symbol string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pf, unknown := b.positions[symbol]
+	if err == nil {
+		if unknown {
+			log.Printf("BREAKER: %s position known again", symbol)
+			delete(b.positions, symbol)
+		}
+		return
+	}
+	if !unknown {
+		pf.since = b.timeNow()
+		log.Printf("BREAKER: %s position UNKNOWN, entries blocked: %v", symbol, err)
+	}
+	pf.err = err.Error()
+	pf.failures++
+	b.positions[symbol] = pf
+}
+
+// RecordPrice books a fresh price of a symbol.
+func (b *CircuitBreakers) RecordPrice(symbol string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prices[symbol] = b.timeNow()
+}
+
+// PriceFresh reports whether the last price of a symbol is recent enough to act on, and its age.
+// A symbol without any price is not fresh.
+func (b *CircuitBreakers) PriceFresh(symbol string) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	at, ok := b.prices[symbol]
+	if !ok {
+		return false, 0
+	}
+	age := b.timeNow().Sub(at)
+	return age <= b.maxPriceAge(), age
+}
+
+// maxPriceAge must hold b.mu.
+func (b *CircuitBreakers) maxPriceAge() time.Duration {
+	return time.Duration(b.cfg.MaxPriceAgeSec) * time.Second
+}
+
+// Reset closes a breaker by hand: the exchange breaker, or the order and position breakers
+// of one symbol. An empty kind resets everything. Stale prices only recover with a new price.
+func (b *CircuitBreakers) Reset(kind, symbol string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if kind == "" || kind == BreakerKindExchange {
+		b.exchange.reset()
+	}
+	for s, ob := range b.symbols {
+		if (kind == "" || kind == BreakerKindOrders) && (symbol == "" || s == symbol) {
+			ob.reset()
+		}
+	}
+	for s := range b.positions {
+		if (kind == "" || kind == BreakerKindPosition) && (symbol == "" || s == symbol) {
+			delete(b.positions, s)
+		}
+	}
+	log.Printf("BREAKER: Reset %q %q by hand", kind, symbol)
+}
+
+// Status lists the exchange breaker and every symbol breaker that is not closed or still
+// counts failures, tripped ones first.
+func (b *CircuitBreakers) Status() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.timeNow()
+
+	const orderRecovery = "trial order after the cooldown, closes on success"
+	out := []BreakerStatus{b.exchange.status(BreakerKindExchange, "", orderRecovery)}
+	for symbol, ob := range b.symbols {
+		if ob.state == BreakerClosed && ob.failures == 0 {
+			continue
+		}
+		out = append(out, ob.status(BreakerKindOrders, symbol, orderRecovery))
+	}
+	for symbol, pf := range b.positions {
+		out = append(out, BreakerStatus{
+			Kind: BreakerKindPosition, Symbol: symbol, State: BreakerOpen, Failures: pf.failures,
+			LastError: pf.err, OpenedAt: pf.since, Recovery: "next successful position fetch",
+		})
+	}
+	maxAge := b.maxPriceAge()
+	for symbol, at := range b.prices {
+		if age := now.Sub(at); age > maxAge {
+			out = append(out, BreakerStatus{
+				Kind: BreakerKindPrice, Symbol: symbol, State: BreakerOpen, OpenedAt: at.Add(maxAge),
+				LastError: fmt.Sprintf("last price %s old (max %s)", age.Round(time.Second), maxAge),
+				Recovery:  "next price tick or REST refresh",
+			})
+		}
+	}
+
+	sort.SliceStable(out[1:], func(i, j int) bool {
+		a, c := out[1+i], out[1+j]
+		if (a.State == BreakerClosed) != (c.State == BreakerClosed) {
+			return a.State != BreakerClosed
+		}
+		if a.Symbol != c.Symbol {
+			return a.Symbol < c.Symbol
+		}
+		return a.Kind < c.Kind
+	})
+	return out
+}
+
+func (ob *orderBreaker) status(kind, symbol, recovery string) BreakerStatus {
+	return BreakerStatus{
+		Kind: kind, Symbol: symbol, State: ob.state, Failures: ob.failures, Trips: ob.trips,
+		LastError: ob.lastError, OpenedAt: ob.openedAt, RetryAt: ob.retryAt, Recovery: recovery,
+	}
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakers_FailedTrialDoublesCooldown(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreakers(CircuitBreakerConfig{SymbolMaxFailures: 2, ExchangeMaxFailures: 10, CooldownSec: 10, MaxCooldownSec: 15})
+	b.SetClock(func() time.Time { return now })
+	fail := errors.New("rejected")
+
+	b.RecordOrder("BTCUSDT", fail)
+	if err := b.AllowEntry("BTCUSDT"); err != nil {
+		t.Fatalf("Expected one failure to keep the breaker closed, got %v", err)
+	}
+	b.RecordOrder("BTCUSDT", fail)
+	if err := b.AllowEntry("BTCUSDT"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected the breaker open, got %v", err)
+	}
+	if err := b.AllowEntry("ETHUSDT"); err != nil {
+		t.Errorf("Expected other symbols to trade, got %v", err)
+	}
+
+	// The trial after the cooldown fails, the next wait is doubled up to the cap
+	now = now.Add(10 * time.Second)
+	if err := b.AllowEntry("BTCUSDT"); err != nil {
+		t.Fatalf("Expected the trial to be allowed, got %v", err)
+	}
+	b.RecordOrder("BTCUSDT", fail)
+	now = now.Add(14 * time.Second)
+	if err := b.AllowEntry("BTCUSDT"); err == nil {
+		t.Fatalf("Expected the breaker open after the failed trial")
+	}
+	now = now.Add(time.Second)
+	if err := b.AllowEntry("BTCUSDT"); err != nil {
+		t.Fatalf("Expected the second trial after the capped cooldown, got %v", err)
+	}
+
+	b.RecordOrder("BTCUSDT", nil)
+	st := b.Status()
+	if len(st) != 1 || st[0].Kind != BreakerKindExchange || st[0].State != BreakerClosed {
+		t.Errorf("Expected only the closed exchange breaker after the good trial, got %+v", st)
+	}
+}
+
+func TestCircuitBreakers_ExchangeTripsAcrossSymbolsAndResets(t *testing.T) {
+	b := NewCircuitBreakers(CircuitBreakerConfig{SymbolMaxFailures: 5, ExchangeMaxFailures: 3})
+	fail := errors.New("timeout")
+	for _, symbol := range []string{"A", "B", "C"} {
+		b.RecordOrder(symbol, fail)
+	}
+	if err := b.AllowEntry("D"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected the exchange breaker to block every symbol, got %v", err)
+	}
+
+	b.RecordPosition("A", fail)
+	b.Reset(BreakerKindExchange, "")
+	if err := b.AllowEntry("D"); err != nil {
+		t.Errorf("Expected entries after the exchange reset, got %v", err)
+	}
+	if err := b.AllowEntry("A"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the unknown position of A to still block, got %v", err)
+	}
+	b.RecordPosition("A", nil)
+	if err := b.AllowEntry("A"); err != nil {
+		t.Errorf("Expected A to trade once its position is known, got %v", err)
+	}
+}
+
+func TestCircuitBreakers_HalfOpenAllowsOneTrial(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreakers(CircuitBreakerConfig{SymbolMaxFailures: 1, ExchangeMaxFailures: 10, CooldownSec: 10})
+	b.SetClock(func() time.Time { return now })
+	b.RecordOrder("BTCUSDT", errors.New("rejected"))
+	now = now.Add(10 * time.Second)
+
+	// Peeking leaves the trial to the order itself
+	if err := b.EntryBlocked("BTCUSDT"); err != nil {
+		t.Fatalf("Expected the entry to be allowed after the cooldown, got %v", err)
+	}
+	if err := b.AllowEntry("BTCUSDT"); err != nil {
+		t.Fatalf("Expected the trial to be allowed, got %v", err)
+	}
+	if err := b.AllowEntry("BTCUSDT"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected entries to wait for the trial, got %v", err)
+	}
+	if err := b.EntryBlocked("BTCUSDT"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected the peek to see the trial in flight, got %v", err)
+	}
+
+	// A trial that never reached the exchange is given back
+	b.AbortTrial("BTCUSDT")
+	if err := b.AllowEntry("BTCUSDT"); err != nil {
+		t.Fatalf("Expected a new trial after the abort, got %v", err)
+	}
+	b.RecordOrder("BTCUSDT", nil)
+	if err := b.AllowEntry("BTCUSDT"); err != nil {
+		t.Errorf("Expected the breaker closed after the good trial, got %v", err)
+	}
+}
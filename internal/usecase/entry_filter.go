@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// EntryRequest describes an entry the sublevel engine wants to make.
+type EntryRequest struct {
+	Level              *domain.Level
+	Side               domain.Side
+	Size               float64
+	Price              float64
+	Sentiment          float64 // Trade flow score from -1 (sell) to 1 (buy)
+	SentimentThreshold float64 // Dynamic threshold computed by ProcessTick
+}
+
+// EntryDecision is the verdict of one filter, kept on the level state for the UI.
+type EntryDecision struct {
+	Filter string    `json:"filter"`
+	Accept bool      `json:"accept"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// EntryFilter decides whether an entry is allowed.
+// Filters should accept when their data is unavailable, a missing candle feed must not block trading.
+type EntryFilter interface {
+	Name() string
+	Check(ctx context.Context, req EntryRequest) EntryDecision
+}
+
+// EntryFilterPipeline runs the filters configured on a level in order.
+type EntryFilterPipeline struct {
+	mu      sync.RWMutex
+	filters map[string]EntryFilter
+}
+
+func NewEntryFilterPipeline(filters ...EntryFilter) *EntryFilterPipeline {
+	p := &EntryFilterPipeline{
+		filters: make(map[string]EntryFilter),
+	}
+	for _, f := range filters {
+		p.Register(f)
+	}
+	return p
+}
+
+// Register adds a filter or replaces the one with the same name.
+func (p *EntryFilterPipeline) Register(f EntryFilter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filters[f.Name()] = f
+}
+
+// Evaluate runs the named filters in order and stops at the first rejection.
+// Unknown names are skipped (logged), so a stale config cannot block entries.
+func (p *EntryFilterPipeline) Evaluate(ctx context.Context, names []string, req EntryRequest) (bool, []EntryDecision) {
+	var decisions []EntryDecision
+	for _, name := range names {
+		p.mu.RLock()
+		f, ok := p.filters[name]
+		p.mu.RUnlock()
+		if !ok {
+			log.Printf("ENTRY FILTER: Unknown filter %q on level %s, skipping", name, req.Level.ID)
+			continue
+		}
+
+		d := f.Check(ctx, req)
+		d.Filter = name
+		if d.Time.IsZero() {
+			d.Time = time.Now()
+		}
+		decisions = append(decisions, d)
+		if !d.Accept {
+			return false, decisions
+		}
+	}
+	return true, decisions
+}
+
+func acceptEntry(reason string) EntryDecision {
+	return EntryDecision{Accept: true, Reason: reason}
+}
+
+func rejectEntry(format string, args ...interface{}) EntryDecision {
+	return EntryDecision{Accept: false, Reason: fmt.Sprintf(format, args...)}
+}
+
+// --- Sentiment ---
+
+// SentimentFilter skips entries against strong trade flow (the original hardcoded gate).
+type SentimentFilter struct{}
+
+func NewSentimentFilter() *SentimentFilter {
+	return &SentimentFilter{}
+}
+
+func (f *SentimentFilter) Name() string { return domain.EntryFilterSentiment }
+
+func (f *SentimentFilter) Check(ctx context.Context, req EntryRequest) EntryDecision {
+	if req.Side == domain.SideLong && req.Sentiment < -req.SentimentThreshold {
+		return rejectEntry("Sentiment is Bearish (%.2f < -%.2f)", req.Sentiment, req.SentimentThreshold)
+	}
+	if req.Side == domain.SideShort && req.Sentiment > req.SentimentThreshold {
+		return rejectEntry("Sentiment is Bullish (%.2f > %.2f)", req.Sentiment, req.SentimentThreshold)
+	}
+	return acceptEntry(fmt.Sprintf("Sentiment %.2f within ±%.2f", req.Sentiment, req.SentimentThreshold))
+}
+
+// --- Higher-timeframe trend ---
+
+// TrendFilter only allows longs above and shorts below the EMA of a higher timeframe.
+type TrendFilter struct {
+	market   *MarketService
+	Interval string // Candle interval (Bybit format, "60" = 1h)
+	Period   int    // EMA period
+}
+
+func NewTrendFilter(market *MarketService) *TrendFilter {
+	return &TrendFilter{
+		market:   market,
+		Interval: "60",
+		Period:   50,
+	}
+}
+
+func (f *TrendFilter) Name() string { return domain.EntryFilterTrend }
+
+func (f *TrendFilter) Check(ctx context.Context, req EntryRequest) EntryDecision {
+	if f.market == nil {
+		return acceptEntry("No market data")
+	}
+	candles, err := f.market.GetCandles(ctx, req.Level.Symbol, f.Interval, f.Period*2)
+	if err != nil || len(candles) < f.Period {
+		return acceptEntry("Not enough candles for EMA")
+	}
+
+	ema := calculateEMA(candles, f.Period)
+	if req.Side == domain.SideLong && req.Price < ema {
+		return rejectEntry("Price %.6f below EMA%d(%s) %.6f", req.Price, f.Period, f.Interval, ema)
+	}
+	if req.Side == domain.SideShort && req.Price > ema {
+		return rejectEntry("Price %.6f above EMA%d(%s) %.6f", req.Price, f.Period, f.Interval, ema)
+	}
+	return acceptEntry(fmt.Sprintf("Trend agrees (EMA%d %.6f)", f.Period, ema))
+}
+
+// calculateEMA returns the EMA of the closes, seeded with the SMA of the first period candles.
+// Candles must be oldest first.
+func calculateEMA(candles []domain.Candle, period int) float64 {
+	if len(candles) < period || period <= 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, c := range candles[:period] {
+		sum += c.Close
+	}
+	ema := sum / float64(period)
+	k := 2.0 / float64(period+1)
+	for _, c := range candles[period:] {
+		ema = c.Close*k + ema*(1-k)
+	}
+	return ema
+}
+
+// --- Spread / depth ---
+
+// SlippageFilter rejects entries when the spread is wide or the book is too thin
+// to fill the order close to the touch.
+type SlippageFilter struct {
+	exchange       domain.Exchange
+	MaxSpreadPct   float64 // e.g. 0.001 = 0.1%
+	MaxSlippagePct float64 // Average fill vs best price, e.g. 0.002 = 0.2%
+}
+
+func NewSlippageFilter(exchange domain.Exchange) *SlippageFilter {
+	return &SlippageFilter{
+		exchange:       exchange,
+		MaxSpreadPct:   0.001,
+		MaxSlippagePct: 0.002,
+	}
+}
+
+func (f *SlippageFilter) Name() string { return domain.EntryFilterSlippage }
+
+func (f *SlippageFilter) Check(ctx context.Context, req EntryRequest) EntryDecision {
+	ob, err := f.exchange.GetOrderBook(ctx, req.Level.Symbol, "linear")
+	if err != nil || ob == nil || len(ob.Bids) == 0 || len(ob.Asks) == 0 {
+		return acceptEntry("No order book")
+	}
+
+	bestBid, bestAsk := ob.Bids[0].Price, ob.Asks[0].Price
+	mid := (bestBid + bestAsk) / 2
+	spread := (bestAsk - bestBid) / mid
+	if spread > f.MaxSpreadPct {
+		return rejectEntry("Spread %.3f%% > %.3f%%", spread*100, f.MaxSpreadPct*100)
+	}
+
+	// Walk the side we take liquidity from
+	walk := WalkOrderBook(ob, req.Side, req.Size)
+	if walk.Fillable < req.Size {
+		return rejectEntry("Book too thin: %.6f of %.6f unfilled", req.Size-walk.Fillable, req.Size)
+	}
+
+	slippage := walk.SlippagePct
+	if slippage > f.MaxSlippagePct {
+		return rejectEntry("Expected slippage %.3f%% > %.3f%%", slippage*100, f.MaxSlippagePct*100)
+	}
+	return acceptEntry(fmt.Sprintf("Spread %.3f%%, slippage %.3f%%", spread*100, slippage*100))
+}
+
+// --- Funding blackout ---
+
+// FundingBlackoutFilter avoids opening right before the funding settlement,
+// when prices tend to whip around and the position would pay funding immediately.
+type FundingBlackoutFilter struct {
+	exchange domain.Exchange
+	Window   time.Duration // Blackout before the next funding time
+	timeNow  func() time.Time
+}
+
+func NewFundingBlackoutFilter(exchange domain.Exchange) *FundingBlackoutFilter {
+	return &FundingBlackoutFilter{
+		exchange: exchange,
+		Window:   5 * time.Minute,
+		timeNow:  time.Now,
+	}
+}
+
+func (f *FundingBlackoutFilter) Name() string { return domain.EntryFilterFunding }
+
+func (f *FundingBlackoutFilter) Check(ctx context.Context, req EntryRequest) EntryDecision {
+	tickers, err := f.exchange.GetTickers(ctx, "linear")
+	if err != nil {
+		return acceptEntry("No ticker data")
+	}
+	for _, t := range tickers {
+		if t.Symbol != req.Level.Symbol || t.NextFundingTime == 0 {
+			continue
+		}
+		next := t.NextFundingTime
+		if next > 1000000000000 { // Milliseconds
+			next = next / 1000
+		}
+		untilFunding := time.Unix(next, 0).Sub(f.timeNow())
+		if untilFunding >= 0 && untilFunding < f.Window {
+			return rejectEntry("Funding in %s (blackout %s)", untilFunding.Round(time.Second), f.Window)
+		}
+		return acceptEntry(fmt.Sprintf("Funding in %s", untilFunding.Round(time.Second)))
+	}
+	return acceptEntry("No funding time")
+}
+
+// --- Volatility spike ---
+
+// VolatilityFilter skips entries while the latest candle range is a multiple of the recent average,
+// tiers get run over in such spikes instead of defending the level.
+type VolatilityFilter struct {
+	market      *MarketService
+	Interval    string  // Candle interval, "1" = 1m
+	Lookback    int     // Candles used for the average range
+	MaxMultiple float64 // Reject when last range > MaxMultiple * average
+}
+
+func NewVolatilityFilter(market *MarketService) *VolatilityFilter {
+	return &VolatilityFilter{
+		market:      market,
+		Interval:    "1",
+		Lookback:    20,
+		MaxMultiple: 3,
+	}
+}
+
+func (f *VolatilityFilter) Name() string { return domain.EntryFilterVolatility }
+
+func (f *VolatilityFilter) Check(ctx context.Context, req EntryRequest) EntryDecision {
+	if f.market == nil {
+		return acceptEntry("No market data")
+	}
+	candles, err := f.market.GetCandles(ctx, req.Level.Symbol, f.Interval, f.Lookback+1)
+	if err != nil || len(candles) < 2 {
+		return acceptEntry("Not enough candles")
+	}
+
+	last := candles[len(candles)-1]
+	lastRange := last.High - last.Low
+	sum := 0.0
+	prev := candles[:len(candles)-1]
+	for _, c := range prev {
+		sum += c.High - c.Low
+	}
+	avg := sum / float64(len(prev))
+	if avg <= 0 {
+		return acceptEntry("Flat candles")
+	}
+
+	multiple := lastRange / avg
+	if multiple > f.MaxMultiple {
+		return rejectEntry("Range spike %.1fx average (max %.1fx)", multiple, f.MaxMultiple)
+	}
+	return acceptEntry(fmt.Sprintf("Range %.1fx average", multiple))
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+type stubFilter struct {
+	name   string
+	accept bool
+	calls  int
+}
+
+func (f *stubFilter) Name() string { return f.name }
+
+func (f *stubFilter) Check(ctx context.Context, req EntryRequest) EntryDecision {
+	f.calls++
+	return EntryDecision{Accept: f.accept, Reason: "stub"}
+}
+
+func TestEntryFilterPipeline_StopsAtFirstReject(t *testing.T) {
+	a := &stubFilter{name: "a", accept: true}
+	b := &stubFilter{name: "b", accept: false}
+	c := &stubFilter{name: "c", accept: true}
+	p := NewEntryFilterPipeline(a, b, c)
+
+	req := EntryRequest{Level: &domain.Level{ID: "l1"}, Side: domain.SideLong}
+	ok, decisions := p.Evaluate(context.Background(), []string{"a", "unknown", "b", "c"}, req)
+	if ok {
+		t.Fatalf("Expected rejection from filter b")
+	}
+	if len(decisions) != 2 || decisions[1].Filter != "b" || decisions[1].Accept {
+		t.Errorf("Expected decisions [a accept, b reject], got %+v", decisions)
+	}
+	if c.calls != 0 {
+		t.Errorf("Filter after the rejection should not run")
+	}
+}
+
+func TestSentimentFilter(t *testing.T) {
+	f := NewSentimentFilter()
+	level := &domain.Level{ID: "l1"}
+
+	d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong, Sentiment: -0.7, SentimentThreshold: 0.6})
+	if d.Accept {
+		t.Errorf("Expected LONG to be rejected on bearish sentiment")
+	}
+	d = f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideShort, Sentiment: -0.7, SentimentThreshold: 0.6})
+	if !d.Accept {
+		t.Errorf("Expected SHORT to pass on bearish sentiment, got %s", d.Reason)
+	}
+}
+
+func TestTrendFilter_EMA(t *testing.T) {
+	var candles []domain.Candle
+	for i := 0; i < 100; i++ {
+		candles = append(candles, domain.Candle{Close: 100 + float64(i)}) // Steady uptrend
+	}
+	mockEx := &MockExchange{Candles: candles}
+	f := NewTrendFilter(NewMarketService(mockEx, nil))
+	level := &domain.Level{ID: "l1", Symbol: "BTCUSDT"}
+
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideShort, Price: 199}); d.Accept {
+		t.Errorf("Expected SHORT above the EMA to be rejected")
+	}
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong, Price: 199}); !d.Accept {
+		t.Errorf("Expected LONG above the EMA to pass, got %s", d.Reason)
+	}
+
+	// No candles -> fail open
+	mockEx.Candles = nil
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideShort, Price: 199}); !d.Accept {
+		t.Errorf("Expected filter to accept without data")
+	}
+}
+
+func TestSlippageFilter(t *testing.T) {
+	mockEx := &MockExchange{OrderBook: &domain.OrderBook{
+		Bids: []domain.OrderBookEntry{{Price: 99.99, Size: 1}},
+		Asks: []domain.OrderBookEntry{{Price: 100, Size: 1}, {Price: 101, Size: 5}},
+	}}
+	f := NewSlippageFilter(mockEx)
+	level := &domain.Level{ID: "l1", Symbol: "BTCUSDT"}
+
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong, Size: 1}); !d.Accept {
+		t.Errorf("Expected fill at the touch to pass, got %s", d.Reason)
+	}
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong, Size: 4}); d.Accept {
+		t.Errorf("Expected large order walking the book to be rejected")
+	}
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideShort, Size: 2}); d.Accept {
+		t.Errorf("Expected thin bid side to be rejected")
+	}
+}
+
+func TestFundingBlackoutFilter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	mockEx := &MockExchange{Tickers: []domain.Ticker{
+		{Symbol: "BTCUSDT", NextFundingTime: now.Add(2*time.Minute).Unix() * 1000},
+	}}
+	f := NewFundingBlackoutFilter(mockEx)
+	f.timeNow = func() time.Time { return now }
+	level := &domain.Level{ID: "l1", Symbol: "BTCUSDT"}
+
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong}); d.Accept {
+		t.Errorf("Expected entry 2m before funding to be rejected")
+	}
+	f.timeNow = func() time.Time { return now.Add(-time.Hour) }
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong}); !d.Accept {
+		t.Errorf("Expected entry 1h before funding to pass, got %s", d.Reason)
+	}
+}
+
+func TestVolatilityFilter(t *testing.T) {
+	var candles []domain.Candle
+	for i := 0; i < 20; i++ {
+		candles = append(candles, domain.Candle{High: 101, Low: 100})
+	}
+	mockEx := &MockExchange{Candles: append(candles, domain.Candle{High: 105, Low: 100})}
+	f := NewVolatilityFilter(NewMarketService(mockEx, nil))
+	level := &domain.Level{ID: "l1", Symbol: "BTCUSDT"}
+
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong}); d.Accept {
+		t.Errorf("Expected 5x range spike to be rejected")
+	}
+	mockEx.Candles = append(candles, domain.Candle{High: 102, Low: 100})
+	if d := f.Check(context.Background(), EntryRequest{Level: level, Side: domain.SideLong}); !d.Accept {
+		t.Errorf("Expected 2x range to pass, got %s", d.Reason)
+	}
+}
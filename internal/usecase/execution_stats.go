@@ -0,0 +1,76 @@
+package usecase
+
+import "sort"
+
+// ExecutionStats sums up how the parent orders of a symbol filled, maker against taker.
+type ExecutionStats struct {
+	Symbol      string  `json:"symbol,omitempty"`
+	Orders      int     `json:"orders"`
+	Filled      float64 `json:"filled"`
+	MakerFilled float64 `json:"maker_filled"`
+	TakerFilled float64 `json:"taker_filled"`
+	MakerRatio  float64 `json:"maker_ratio"` // Share of Filled filled as maker
+	FeesSaved   float64 `json:"fees_saved"`  // Against taking the maker fills, in quote
+}
+
+func (st *ExecutionStats) add(p ParentOrder) {
+	st.Orders++
+	st.Filled += p.Filled
+	st.MakerFilled += p.MakerFilled
+	st.TakerFilled += p.TakerFilled
+	st.FeesSaved += p.FeesSaved
+	if st.Filled > 0 {
+		st.MakerRatio = st.MakerFilled / st.Filled
+	}
+}
+
+// ExecutionReport is the maker/taker split of the parent orders finished since start.
+type ExecutionReport struct {
+	Fees    FeeRates         `json:"fees"`
+	Total   ExecutionStats   `json:"total"`
+	Symbols []ExecutionStats `json:"symbols"`
+}
+
+// SetFeeRates changes the fees the savings of maker fills are computed with.
+func (m *ExecutionManager) SetFeeRates(fees FeeRates) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fees = fees
+}
+
+// FeeRates returns the fees the savings of maker fills are computed with.
+func (m *ExecutionManager) FeeRates() FeeRates {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.fees
+}
+
+// record adds a finished order to the stats of its symbol. Callers hold m.mu.
+func (m *ExecutionManager) record(p ParentOrder) {
+	st, ok := m.stats[p.Symbol]
+	if !ok {
+		st = &ExecutionStats{Symbol: p.Symbol}
+		m.stats[p.Symbol] = st
+	}
+	st.add(p)
+}
+
+// Report returns the maker/taker split and the fees saved per symbol and in total.
+func (m *ExecutionManager) Report() ExecutionReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := ExecutionReport{Fees: m.fees, Symbols: make([]ExecutionStats, 0, len(m.stats))}
+	for _, st := range m.stats {
+		report.Symbols = append(report.Symbols, *st)
+		report.Total.Orders += st.Orders
+		report.Total.Filled += st.Filled
+		report.Total.MakerFilled += st.MakerFilled
+		report.Total.TakerFilled += st.TakerFilled
+		report.Total.FeesSaved += st.FeesSaved
+	}
+	if report.Total.Filled > 0 {
+		report.Total.MakerRatio = report.Total.MakerFilled / report.Total.Filled
+	}
+	sort.Slice(report.Symbols, func(i, j int) bool { return report.Symbols[i].Symbol < report.Symbols[j].Symbol })
+	return report
+}
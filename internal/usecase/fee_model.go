@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// FeeRates are the maker and taker fees of the account as fractions of the notional.
+type FeeRates struct {
+	Maker float64 `json:"maker"`
+	Taker float64 `json:"taker"`
+}
+
+// DefaultFeeRates are the linear perpetual fees of the base Bybit VIP tier.
+var DefaultFeeRates = FeeRates{Maker: 0.0002, Taker: 0.00055}
+
+// vipFeeRates are the Bybit linear perpetual fees per VIP tier.
+var vipFeeRates = map[string]FeeRates{
+	"vip0":    DefaultFeeRates,
+	"vip1":    {Maker: 0.00018, Taker: 0.0004},
+	"vip2":    {Maker: 0.00016, Taker: 0.000375},
+	"vip3":    {Maker: 0.00014, Taker: 0.00035},
+	"vip4":    {Maker: 0.00012, Taker: 0.00032},
+	"vip5":    {Maker: 0.0001, Taker: 0.00032},
+	"supreme": {Maker: 0, Taker: 0.0003},
+}
+
+// Fee returns the fee of a fill of the given notional.
+func (r FeeRates) Fee(notional float64, maker bool) float64 {
+	if maker {
+		return notional * r.Maker
+	}
+	return notional * r.Taker
+}
+
+// BreakEvenDistance returns the distance from the entry, as a fraction of the entry price,
+// an exit must be at so the trade keeps minNetPct of the entry notional after paying the
+// entry and the exit fee.
+func (r FeeRates) BreakEvenDistance(side domain.Side, minNetPct float64, entryMaker, exitMaker bool) float64 {
+	entry, exit := r.Taker, r.Taker
+	if entryMaker {
+		entry = r.Maker
+	}
+	if exitMaker {
+		exit = r.Maker
+	}
+	// Long:  size*(exitPx - entryPx) - entry*size*entryPx - exit*size*exitPx = minNet*size*entryPx
+	// Short: size*(entryPx - exitPx) - entry*size*entryPx - exit*size*exitPx = minNet*size*entryPx
+	if side == domain.SideShort {
+		return 1 - (1-entry-minNetPct)/(1+exit)
+	}
+	return (1+entry+minNetPct)/(1-exit) - 1
+}
+
+// FeeConfig sets the fees PnL and take profit targets are computed with. Zero values take
+// the defaults.
+type FeeConfig struct {
+	VIPTier         string  `yaml:"vip_tier"`           // vip0..vip5 or supreme, default vip0
+	MakerRate       float64 `yaml:"maker_rate"`         // Overrides the maker fee of the tier, e.g. 0.0002 = 0.02%
+	TakerRate       float64 `yaml:"taker_rate"`         // Overrides the taker fee of the tier, e.g. 0.00055 = 0.055%
+	MinNetProfitPct float64 `yaml:"min_net_profit_pct"` // Least profit a take profit keeps after round-trip fees, default 0.001 = 0.1%
+}
+
+// FeeModel turns fills into fees and fee-free distances into net ones.
+type FeeModel struct {
+	Rates           FeeRates `json:"rates"`
+	MinNetProfitPct float64  `json:"min_net_profit_pct"`
+}
+
+// DefaultFeeModel is the model of the base VIP tier.
+var DefaultFeeModel = FeeModel{Rates: DefaultFeeRates, MinNetProfitPct: 0.001}
+
+// NewFeeModel builds the fee model of the config.
+func NewFeeModel(cfg FeeConfig) (FeeModel, error) {
+	tier := strings.ToLower(strings.TrimSpace(cfg.VIPTier))
+	if tier == "" {
+		tier = "vip0"
+	}
+	rates, ok := vipFeeRates[tier]
+	if !ok {
+		return FeeModel{}, fmt.Errorf("unknown vip tier %q", cfg.VIPTier)
+	}
+	if cfg.MakerRate < 0 || cfg.TakerRate < 0 || cfg.MinNetProfitPct < 0 {
+		return FeeModel{}, fmt.Errorf("fee rates and min net profit must not be negative")
+	}
+	if cfg.MakerRate > 0 {
+		rates.Maker = cfg.MakerRate
+	}
+	if cfg.TakerRate > 0 {
+		rates.Taker = cfg.TakerRate
+	}
+	m := FeeModel{Rates: rates, MinNetProfitPct: cfg.MinNetProfitPct}
+	if m.MinNetProfitPct == 0 {
+		m.MinNetProfitPct = DefaultFeeModel.MinNetProfitPct
+	}
+	return m, nil
+}
+
+// MinTPDistance is the least distance from the entry a take profit may sit at, taking both
+// legs as taker fills so market and triggered exits are covered too.
+func (m FeeModel) MinTPDistance(side domain.Side) float64 {
+	return m.Rates.BreakEvenDistance(side, m.MinNetProfitPct, false, false)
+}
+
+// MinTPPrice moves a take profit price out to the least distance that keeps the minimum
+// net profit, prices already further out are returned as is.
+func (m FeeModel) MinTPPrice(side domain.Side, entry, tp float64) float64 {
+	if entry <= 0 || tp <= 0 {
+		return tp
+	}
+	dist := m.MinTPDistance(side)
+	if side == domain.SideShort {
+		if floor := entry * (1 - dist); tp > floor {
+			return floor
+		}
+		return tp
+	}
+	if floor := entry * (1 + dist); tp < floor {
+		return floor
+	}
+	return tp
+}
+
+// SetFeeModel changes the fees fills are estimated with and take profits are floored by.
+func (s *LevelService) SetFeeModel(m FeeModel) {
+	s.mu.Lock()
+	s.fees = m
+	s.mu.Unlock()
+	s.parents.SetFeeRates(m.Rates)
+}
+
+// FeeModel returns the fees fills are estimated with and take profits are floored by.
+func (s *LevelService) FeeModel() FeeModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fees
+}
+
+// entryFee returns the fee of an entry: the one the parent order booked when all its fills
+// are priced, else a taker fill of the size at the fill price.
+func (s *LevelService) entryFee(exec *Execution, size, fillPrice float64) float64 {
+	if exec != nil && exec.ParentID != "" && exec.FillPrice > 0 {
+		return exec.Fee
+	}
+	return s.FeeModel().Rates.Fee(size*fillPrice, false)
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+func TestNewFeeModel_TierAndOverrides(t *testing.T) {
+	m, err := NewFeeModel(FeeConfig{})
+	if err != nil || m.Rates != DefaultFeeRates || m.MinNetProfitPct != 0.001 {
+		t.Fatalf("Expected the vip0 defaults, got %+v %v", m, err)
+	}
+
+	m, err = NewFeeModel(FeeConfig{VIPTier: "VIP3", TakerRate: 0.0003, MinNetProfitPct: 0.002})
+	if err != nil {
+		t.Fatalf("Failed to build fee model: %v", err)
+	}
+	if m.Rates.Maker != 0.00014 || m.Rates.Taker != 0.0003 || m.MinNetProfitPct != 0.002 {
+		t.Errorf("Expected vip3 maker with the taker override, got %+v", m)
+	}
+
+	if _, err := NewFeeModel(FeeConfig{VIPTier: "vip9"}); err == nil {
+		t.Errorf("Expected an unknown tier to fail")
+	}
+	if _, err := NewFeeModel(FeeConfig{MakerRate: -0.0001}); err == nil {
+		t.Errorf("Expected a negative rate to fail")
+	}
+}
+
+func TestFeeRates_BreakEvenDistanceKeepsMinNetProfit(t *testing.T) {
+	rates := FeeRates{Maker: 0.0002, Taker: 0.00055}
+	const entry, size, minNet = 100.0, 2.0, 0.005
+
+	for _, side := range []domain.Side{domain.SideLong, domain.SideShort} {
+		dist := rates.BreakEvenDistance(side, minNet, false, true)
+		exit := entry * (1 + dist)
+		pnl := (exit - entry) * size
+		if side == domain.SideShort {
+			exit = entry * (1 - dist)
+			pnl = (entry - exit) * size
+		}
+		net := pnl - rates.Fee(entry*size, false) - rates.Fee(exit*size, true)
+		if math.Abs(net-minNet*entry*size) > 1e-9 {
+			t.Errorf("%s: expected net %f at distance %f, got %f", side, minNet*entry*size, dist, net)
+		}
+	}
+
+	if d := (FeeRates{}).BreakEvenDistance(domain.SideShort, minNet, false, false); math.Abs(d-minNet) > 1e-12 {
+		t.Errorf("Expected no fees to leave the bare distance, got %f", d)
+	}
+}
+
+func TestFeeModel_MinTPPriceOnlyWidens(t *testing.T) {
+	m := FeeModel{Rates: FeeRates{Maker: 0.0005, Taker: 0.001}, MinNetProfitPct: 0.001}
+	floor := m.MinTPDistance(domain.SideLong)
+
+	if got := m.MinTPPrice(domain.SideLong, 100, 100.1); math.Abs(got-100*(1+floor)) > 1e-9 {
+		t.Errorf("Expected a close long target moved out to %f, got %f", 100*(1+floor), got)
+	}
+	if got := m.MinTPPrice(domain.SideLong, 100, 101); got != 101 {
+		t.Errorf("Expected a far long target kept, got %f", got)
+	}
+	if got := m.MinTPPrice(domain.SideShort, 100, 99.9); got >= 99.9 {
+		t.Errorf("Expected a close short target moved down, got %f", got)
+	}
+	if got := m.MinTPPrice(domain.SideShort, 100, 99); got != 99 {
+		t.Errorf("Expected a far short target kept, got %f", got)
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"sync"
@@ -22,14 +23,21 @@ type FundingBotConfig struct {
 	WallThresholdMultiplier float64       `json:"wall_threshold_multiplier"` // Multiplier for average volume to define a wall (default 2.0)
 	StopLossPercentage      float64       `json:"stop_loss_percentage"`      // SL as percentage (e.g. 0.01 for 1%)
 	TakeProfitPercentage    float64       `json:"take_profit_percentage"`    // TP as percentage (e.g. 0.01 for 1%)
+	MakerFirst              bool          `json:"maker_first"`               // Enter post-only at the touch instead of with the sniper limit
+	MaxChasePct             float64       `json:"max_chase_pct"`             // How far the maker entry follows the touch (e.g. 0.001 for 0.1%), 0 = unbounded
+	MakerFallback           string        `json:"maker_fallback"`            // "market" or "ioc" for what the maker entry has not filled just before funding
 }
 
+// FundingBotService is the funding bot facade used by the web handlers and the auto-scanner.
+// The bots themselves run as strategies in the StrategyRuntime.
 type FundingBotService struct {
+	runtime           *StrategyRuntime
 	exchange          domain.Exchange
 	tradeRepo         domain.TradeRepository
 	marketService     *MarketService
-	bots              map[string]*FundingBot
 	logger            *zap.Logger
+	parents           *ExecutionManager // Works the maker-first entries
+	fees              FeeRates          // Widen the take profits of new bots
 	mu                sync.Mutex
 	autoScannerCtx    context.Context
 	autoScannerCancel context.CancelFunc
@@ -40,10 +48,12 @@ type FundingBot struct {
 	exchange            domain.Exchange
 	marketService       *MarketService
 	logger              *zap.Logger
+	reportFill          func(order *domain.Order)
+	parents             *ExecutionManager
+	fees                FeeRates // Zero leaves the take profit at the bare funding distance
 	running             bool
-	stopChan            chan struct{}
-	cancel              context.CancelFunc
 	currentOrder        *domain.Order
+	makerCancel         context.CancelFunc // Stops the maker-first entry being worked, nil when none
 	lastNextFundingTime int64
 	expectedFundingRate float64
 	fundingEventTime    time.Time
@@ -67,59 +77,100 @@ type FundingBotStatus struct {
 	FundingRate     float64          `json:"funding_rate"` // Current funding rate
 }
 
-func NewFundingBotService(exchange domain.Exchange, tradeRepo domain.TradeRepository, marketService *MarketService, logger *zap.Logger) *FundingBotService {
-	return &FundingBotService{
+func NewFundingBotService(runtime *StrategyRuntime, exchange domain.Exchange, tradeRepo domain.TradeRepository, marketService *MarketService, logger *zap.Logger) *FundingBotService {
+	s := &FundingBotService{
+		runtime:       runtime,
 		exchange:      exchange,
 		tradeRepo:     tradeRepo,
 		marketService: marketService,
-		bots:          make(map[string]*FundingBot),
 		logger:        logger,
+		parents:       NewExecutionManager(exchange, NewTradeExecutor(exchange)),
+		fees:          DefaultFeeRates,
 	}
+	runtime.RegisterKind(StrategyKindFunding, s.newBot)
+	return s
 }
 
-func (s *FundingBotService) StartBot(ctx context.Context, config FundingBotConfig) error {
+// SetExecutionManager shares the parent order manager of the levels with the bots, so maker-first
+// entries show up in the parent orders panel and are stopped by the kill switch.
+func (s *FundingBotService) SetExecutionManager(parents *ExecutionManager) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.parents = parents
+}
 
-	// Check if bot already running for this symbol
-	if bot, exists := s.bots[config.Symbol]; exists && bot.running {
-		return fmt.Errorf("funding bot already running for %s", config.Symbol)
-	}
+// SetFeeRates changes the fees the take profits of bots started from now on cover.
+func (s *FundingBotService) SetFeeRates(fees FeeRates) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fees = fees
+}
+
+// StrategyKindFunding is the runtime kind of funding bots.
+const StrategyKindFunding = "funding"
+
+func fundingBotID(symbol string) string {
+	return StrategyKindFunding + ":" + symbol
+}
 
-	// Create new bot
-	bot := &FundingBot{
+// newBot is the StrategyFactory of funding bots.
+func (s *FundingBotService) newBot(spec StrategySpec) (Strategy, error) {
+	var config FundingBotConfig
+	if err := json.Unmarshal(spec.Config, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode funding bot config: %w", err)
+	}
+	if _, err := domain.ParseExecFallback(config.MakerFallback); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	parents, fees := s.parents, s.fees
+	s.mu.Unlock()
+	return &FundingBot{
 		config:        config,
 		exchange:      s.exchange,
 		marketService: s.marketService,
 		tradeRepo:     s.tradeRepo,
 		logger:        s.logger,
-		running:       true,
-		stopChan:      make(chan struct{}),
+		parents:       parents,
+		fees:          fees,
+	}, nil
+}
+
+// getBot returns the running funding bot for a symbol, if any.
+func (s *FundingBotService) getBot(symbol string) (*FundingBot, bool) {
+	strategy, exists := s.runtime.Get(fundingBotID(symbol))
+	if !exists {
+		return nil, false
 	}
+	bot, ok := strategy.(*FundingBot)
+	return bot, ok
+}
 
-	s.bots[config.Symbol] = bot
+func (s *FundingBotService) StartBot(ctx context.Context, config FundingBotConfig) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode funding bot config: %w", err)
+	}
 
-	// Start bot loop with background context
-	botCtx, cancel := context.WithCancel(context.Background())
-	bot.cancel = cancel
-	go bot.run(botCtx)
+	if err := s.runtime.Start(ctx, StrategySpec{
+		ID:            fundingBotID(config.Symbol),
+		Kind:          StrategyKindFunding,
+		Symbols:       []string{config.Symbol},
+		TimerInterval: 1 * time.Second,
+		Config:        raw,
+	}); err != nil {
+		return err
+	}
 
 	s.logger.Info("Funding bot started", zap.String("symbol", config.Symbol))
 	return nil
 }
 
 func (s *FundingBotService) StopBot(symbol string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	bot, exists := s.bots[symbol]
-	if !exists || !bot.running {
+	if err := s.runtime.Stop(context.Background(), fundingBotID(symbol)); err != nil {
 		return fmt.Errorf("no running funding bot found for %s", symbol)
 	}
 
-	bot.stop()
-	delete(s.bots, symbol)
-
 	s.logger.Info("Funding bot stopped", zap.String("symbol", symbol))
 	return nil
 }
@@ -157,10 +208,8 @@ func (s *FundingBotService) StartAutoScanner(ctx context.Context) {
 }
 
 func (s *FundingBotService) IsBotRunning(symbol string) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	bot, exists := s.bots[symbol]
-	return exists && bot.running
+	_, exists := s.getBot(symbol)
+	return exists
 }
 
 func (s *FundingBotService) IsAutoScannerRunning() bool {
@@ -188,11 +237,13 @@ func (s *FundingBotService) checkAutoBots(ctx context.Context) error {
 	for _, t := range tickers {
 		// Only funding > 0.8% or < -0.8%
 		if math.Abs(t.FundingRate) >= 0.008 {
-			s.mu.Lock()
-			bot, exists := s.bots[t.Symbol]
-			s.mu.Unlock()
-
-			if !exists || !bot.running {
+			if !s.IsBotRunning(t.Symbol) {
+				// Another strategy trades the symbol, starting would only be refused
+				if owners := s.runtime.Leases().Owners(t.Symbol); len(owners) > 0 {
+					s.logger.Debug("Skipping auto-start, symbol is leased",
+						zap.String("symbol", t.Symbol), zap.Strings("owners", owners))
+					continue
+				}
 				s.logger.Info("Auto-starting funding bot",
 					zap.String("symbol", t.Symbol),
 					zap.Float64("rate_pct", t.FundingRate*100))
@@ -226,11 +277,7 @@ func (s *FundingBotService) checkAutoBots(ctx context.Context) error {
 				}(config)
 			}
 		} else if t.FundingRate < 0.007 {
-			s.mu.Lock()
-			bot, exists := s.bots[t.Symbol]
-			s.mu.Unlock()
-
-			if exists && bot.running {
+			if s.IsBotRunning(t.Symbol) {
 				// We don't stop the bot automatically if it's already running,
 				// but maybe we should if there's no position.
 				// However, StartBot might have been manual.
@@ -245,9 +292,7 @@ func (s *FundingBotService) checkAutoBots(ctx context.Context) error {
 }
 
 func (s *FundingBotService) GetBotStatus(ctx context.Context, symbol string) (*FundingBotStatus, error) {
-	s.mu.Lock()
-	bot, exists := s.bots[symbol]
-	s.mu.Unlock()
+	bot, exists := s.getBot(symbol)
 
 	var status *FundingBotStatus
 	if exists {
@@ -292,28 +337,74 @@ func (s *FundingBotService) GetBotStatus(ctx context.Context, symbol string) (*F
 	return status, nil
 }
 
-func (b *FundingBot) run(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+// --- Strategy implementation ---
 
+func (b *FundingBot) Init(ctx context.Context, env StrategyEnv) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if env.Logger != nil {
+		b.logger = env.Logger
+	}
+	// The bot opens and closes the whole symbol position, it must be the only one trading it
+	if env.Leases != nil {
+		if err := env.Leases.Acquire(b.config.Symbol, env.ID, ""); err != nil {
+			return err
+		}
+	}
+	b.reportFill = env.ReportFill
+	b.running = true
 	b.logger.Info("Funding bot evaluation loop started", zap.String("symbol", b.config.Symbol))
+	return nil
+}
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := b.evaluate(ctx); err != nil {
-				b.logger.Error("Funding bot evaluation error",
-					zap.Error(err),
-					zap.String("symbol", b.config.Symbol))
-			}
-		case <-b.stopChan:
-			b.logger.Info("Funding bot evaluation loop stopped", zap.String("symbol", b.config.Symbol))
-			return
-		case <-ctx.Done():
-			b.logger.Info("Funding bot evaluation loop cancelled", zap.String("symbol", b.config.Symbol))
-			return
-		}
+func (b *FundingBot) OnTick(ctx context.Context, symbol string, price float64) error { return nil }
+
+func (b *FundingBot) OnTrade(ctx context.Context, trade domain.PublicTrade) error { return nil }
+
+func (b *FundingBot) OnFill(ctx context.Context, order *domain.Order) error { return nil }
+
+// OnTimer runs the funding countdown evaluation once per second.
+func (b *FundingBot) OnTimer(ctx context.Context, now time.Time) error {
+	return b.evaluate(ctx)
+}
+
+func (b *FundingBot) Status(ctx context.Context) (interface{}, error) {
+	return b.getStatus(ctx)
+}
+
+func (b *FundingBot) Stop(ctx context.Context) error {
+	b.stop()
+	return nil
+}
+
+type fundingBotState struct {
+	LastNextFundingTime int64         `json:"last_next_funding_time"`
+	CurrentOrder        *domain.Order `json:"current_order,omitempty"`
+	TPOrder             *domain.Order `json:"tp_order,omitempty"`
+}
+
+// SnapshotState keeps the pending orders across restarts so they can still be cancelled or tracked.
+func (b *FundingBot) SnapshotState() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return json.Marshal(fundingBotState{
+		LastNextFundingTime: b.lastNextFundingTime,
+		CurrentOrder:        b.currentOrder,
+		TPOrder:             b.tpOrder,
+	})
+}
+
+func (b *FundingBot) RestoreState(data []byte) error {
+	var st fundingBotState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
 	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastNextFundingTime = st.LastNextFundingTime
+	b.currentOrder = st.CurrentOrder
+	b.tpOrder = st.TPOrder
+	return nil
 }
 
 func (b *FundingBot) stop() {
@@ -322,12 +413,13 @@ func (b *FundingBot) stop() {
 
 	if b.running {
 		b.running = false
-		if b.cancel != nil {
-			b.cancel()
-		}
-		close(b.stopChan)
+		b.logger.Info("Funding bot evaluation loop stopped", zap.String("symbol", b.config.Symbol))
 
 		// Cancel any pending orders
+		if b.makerCancel != nil {
+			b.makerCancel() // The entry cancels its resting child on the way out
+			b.makerCancel = nil
+		}
 		if b.currentOrder != nil {
 			ctx := context.Background()
 			if err := b.exchange.CancelOrder(ctx, b.config.Symbol, b.currentOrder.OrderID); err != nil {
@@ -629,7 +721,7 @@ func (b *FundingBot) handleFundingEvent(ctx context.Context) error {
 		return err
 	}
 
-	if position.Size > 0 {
+	if position != nil && position.Size > 0 {
 		b.logger.Info("Position already exists, skipping funding entry",
 			zap.String("symbol", b.config.Symbol),
 			zap.Float64("size", position.Size))
@@ -745,29 +837,53 @@ func (b *FundingBot) handleFundingEvent(ctx context.Context) error {
 		zap.Float64("current_price", ticker.LastPrice),
 		zap.Float64("funding_rate_pct", ticker.FundingRate*100))
 
-	// Entry Order (Limit order for "sniper" entry)
-	entryOrder := &domain.Order{
-		Symbol:      b.config.Symbol,
-		Side:        entrySide,
-		Type:        "Limit",
-		Size:        b.config.PositionSize,
-		Price:       entryPrice,
-		TimeInForce: "GoodTillCancel",
-		ReduceOnly:  false,
-	}
+	if b.config.MakerFirst {
+		if !b.startMakerEntry(ticker, entrySide, tpSide, entryPrice) {
+			return nil
+		}
+	} else {
+		// Entry Order (Limit order for "sniper" entry)
+		entryOrder := &domain.Order{
+			Symbol:      b.config.Symbol,
+			Side:        entrySide,
+			Type:        "Limit",
+			Size:        b.config.PositionSize,
+			Price:       entryPrice,
+			TimeInForce: "GoodTillCancel",
+			ReduceOnly:  false,
+		}
 
-	placedEntry, err := b.exchange.PlaceOrder(ctx, entryOrder)
-	if err != nil {
-		return fmt.Errorf("failed to place entry order: %w", err)
+		placedEntry, err := b.exchange.PlaceOrder(ctx, entryOrder)
+		if err != nil {
+			return fmt.Errorf("failed to place entry order: %w", err)
+		}
+		b.currentOrder = placedEntry
+		b.logger.Info("Placed entry sniper limit order", zap.String("order_id", placedEntry.OrderID), zap.String("side", string(entrySide)))
+
+		if err := b.placeTakeProfit(ctx, tpSide, b.config.PositionSize, entryPrice, ticker.FundingRate); err != nil {
+			return err
+		}
 	}
-	b.currentOrder = placedEntry
-	b.logger.Info("Placed entry sniper limit order", zap.String("order_id", placedEntry.OrderID), zap.String("side", string(entrySide)))
 
+	// LOG ORDER BOOK Snapshot
+	b.logOrderBook(ctx, "⏱️ Countdown Threshold")
+	b.needsNextCandleLog = true
+	b.initialLogMinute = time.Now().Minute()
+	b.monitoringActive = true
+	b.sessionStartTime = time.Now().Unix()
+	b.sessionTicks = nil
+
+	return nil
+}
+
+// placeTakeProfit places the reduce-only take profit limit of an entry. Callers hold b.mu.
+func (b *FundingBot) placeTakeProfit(ctx context.Context, tpSide domain.Side, size, entryPrice, fundingRate float64) error {
 	// Take Profit Order
-	// Formula: entryPrice * (1 - (math.Abs(fundingRate) + 0.5%))
+	// Formula: entryPrice * (1 - (math.Abs(fundingRate) + 0.5% net of fees))
 	// We always place the TP limit order BELOW the entry price
 	// For SHORT, this is a profit on price. For LONG, this is a partial give-back of funding.
-	tpDistance := math.Abs(ticker.FundingRate) + 0.005
+	// The entry is taken as a taker fill, the resting TP fills as maker.
+	tpDistance := math.Abs(fundingRate) + b.fees.BreakEvenDistance(domain.SideShort, 0.005, false, true)
 	tpPrice := entryPrice * (1 - tpDistance)
 	tpPrice = math.Round(tpPrice*10000) / 10000
 
@@ -775,7 +891,7 @@ func (b *FundingBot) handleFundingEvent(ctx context.Context) error {
 		Symbol:      b.config.Symbol,
 		Side:        tpSide,
 		Type:        "Limit",
-		Size:        b.config.PositionSize,
+		Size:        size,
 		Price:       tpPrice,
 		TimeInForce: "GoodTillCancel",
 		ReduceOnly:  true,
@@ -792,16 +908,82 @@ func (b *FundingBot) handleFundingEvent(ctx context.Context) error {
 		zap.String("side", string(tpSide)),
 		zap.Float64("tp_price", tpPrice),
 		zap.Float64("tp_distance_pct", tpDistance*100))
+	return nil
+}
 
-	// LOG ORDER BOOK Snapshot
-	b.logOrderBook(ctx, "⏱️ Countdown Threshold")
-	b.needsNextCandleLog = true
-	b.initialLogMinute = time.Now().Minute()
-	b.monitoringActive = true
-	b.sessionStartTime = time.Now().Unix()
-	b.sessionTicks = nil
+// makerEntryLeadSec is how long before funding the maker-first entry stops resting and hands
+// the rest to its fallback, the position has to be open when funding is settled.
+const makerEntryLeadSec = 1
 
-	return nil
+// startMakerEntry works the entry as a maker-first parent order in the background: a post-only
+// limit follows the touch (up to MaxChasePct) and what is not filled just before funding goes
+// to the fallback. The take profit follows for what filled, at its average price. Returns false
+// when an entry is already being worked. Callers hold b.mu.
+func (b *FundingBot) startMakerEntry(ticker domain.Ticker, entrySide, tpSide domain.Side, sniperPrice float64) bool {
+	if b.makerCancel != nil {
+		return false
+	}
+	if b.parents == nil {
+		b.parents = NewExecutionManager(b.exchange, NewTradeExecutor(b.exchange))
+	}
+	fundingTime := ticker.NextFundingTime
+	if fundingTime > 1000000000000 { // Milliseconds
+		fundingTime /= 1000
+	}
+	duration := int(fundingTime - time.Now().Unix() - makerEntryLeadSec)
+	if duration < 1 {
+		duration = 1
+	}
+	req := ParentRequest{
+		Symbol:      b.config.Symbol,
+		Purpose:     PurposeEntry,
+		Side:        entrySide,
+		Size:        b.config.PositionSize,
+		Leverage:    b.config.Leverage,
+		MarginType:  b.config.MarginType,
+		Algo:        domain.ExecChase,
+		DurationSec: duration,
+		MaxChasePct: b.config.MaxChasePct,
+		Fallback:    b.config.MakerFallback,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.makerCancel = cancel
+	b.expectedFundingRate = ticker.FundingRate
+
+	go func() {
+		defer cancel()
+		p, err := b.parents.Run(ctx, req)
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.makerCancel = nil
+		if err != nil {
+			b.logger.Warn("Maker-first entry stopped short",
+				zap.String("symbol", b.config.Symbol),
+				zap.String("parent_id", p.ID),
+				zap.Float64("filled", p.Filled),
+				zap.Error(err))
+		}
+		if p.Filled <= 0 || !b.running {
+			return
+		}
+		b.logger.Info("Maker-first entry done",
+			zap.String("symbol", b.config.Symbol),
+			zap.String("parent_id", p.ID),
+			zap.Float64("filled", p.Filled),
+			zap.Float64("avg_price", p.AvgPrice),
+			zap.Float64("maker_ratio", p.MakerRatio()),
+			zap.Float64("fees_saved", p.FeesSaved))
+
+		entryPrice := p.AvgPrice
+		if entryPrice <= 0 {
+			entryPrice = sniperPrice // None of the fills reported a price
+		}
+		if err := b.placeTakeProfit(context.Background(), tpSide, p.Filled, entryPrice, ticker.FundingRate); err != nil {
+			b.logger.Error("Failed to place TP after maker-first entry", zap.Error(err))
+		}
+	}()
+	return true
 }
 
 func (b *FundingBot) closePosition(ctx context.Context) error {
@@ -811,7 +993,7 @@ func (b *FundingBot) closePosition(ctx context.Context) error {
 		return err
 	}
 
-	if position.Size == 0 {
+	if position == nil || position.Size == 0 {
 		return nil // No position to close
 	}
 
@@ -822,6 +1004,14 @@ func (b *FundingBot) closePosition(ctx context.Context) error {
 
 	b.logger.Info("Position closed successfully",
 		zap.String("symbol", b.config.Symbol))
+	if b.reportFill != nil {
+		b.reportFill(&domain.Order{
+			Symbol:    b.config.Symbol,
+			Side:      position.Side,
+			Size:      0, // Close marker, market exit so no price known here
+			CreatedAt: time.Now(),
+		})
+	}
 
 	// Save session log
 	if b.monitoringActive && len(b.sessionTicks) > 0 {
@@ -881,7 +1071,7 @@ func (b *FundingBot) getStatus(ctx context.Context) (*FundingBotStatus, error) {
 
 	// Get current position
 	position, err := b.exchange.GetPosition(ctx, b.config.Symbol)
-	if err == nil && position.Size > 0 {
+	if err == nil && position != nil && position.Size > 0 {
 		status.Position = position
 	}
 
@@ -933,11 +1123,8 @@ func (b *FundingBot) getStatus(ctx context.Context) (*FundingBotStatus, error) {
 }
 
 func (s *FundingBotService) TriggerTestEvent(ctx context.Context, symbol string) error {
-	s.mu.Lock()
-	bot, exists := s.bots[symbol]
-	s.mu.Unlock()
-
-	if !exists || !bot.running {
+	bot, exists := s.getBot(symbol)
+	if !exists {
 		return fmt.Errorf("no running funding bot found for %s", symbol)
 	}
 
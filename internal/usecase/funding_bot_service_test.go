@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
@@ -41,14 +42,22 @@ func (m *MockFundingExchange) GetOrder(ctx context.Context, symbol, orderID stri
 	if m.GetOrderErr != nil {
 		return nil, m.GetOrderErr
 	}
-	// Return a filled order by default for tests
-	return &domain.Order{OrderID: orderID, Symbol: symbol, Status: "Filled"}, nil
+	// Return a filled order by default for tests, filled in full at the price of the last order placed
+	o := &domain.Order{OrderID: orderID, Symbol: symbol, Status: "Filled"}
+	if n := len(m.PlacedOrders); n > 0 {
+		o.FilledSize, o.AvgFillPrice = m.PlacedOrders[n-1].Size, m.PlacedOrders[n-1].Price
+	}
+	return o, nil
 }
 
 func (m *MockFundingExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
 	return nil
 }
 
+func (m *MockFundingExchange) CancelAllOrders(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
 func (m *MockFundingExchange) GetPosition(ctx context.Context, symbol string) (*domain.Position, error) {
 	return m.Position, nil
 }
@@ -65,6 +74,17 @@ func (m *MockFundingExchange) ClosePosition(ctx context.Context, symbol string)
 	return nil
 }
 
+func (m *MockFundingExchange) ReducePosition(ctx context.Context, symbol string, size float64) error {
+	if m.Position != nil {
+		m.Position.Size -= size
+	}
+	return nil
+}
+
+func (m *MockFundingExchange) SetTradingStop(ctx context.Context, symbol string, stop domain.TradingStop) error {
+	return nil
+}
+
 type MockTradeRepo struct {
 }
 
@@ -117,6 +137,14 @@ func (m *MockFundingExchange) MarketSell(ctx context.Context, symbol string, siz
 func (m *MockFundingExchange) OnTradeUpdate(callback func(symbol string, side string, size float64, price float64)) {
 }
 
+func (m *MockFundingExchange) GetEquity(ctx context.Context) (*domain.AccountEquity, error) {
+	return &domain.AccountEquity{}, nil
+}
+
+func (m *MockFundingExchange) GetRiskLimits(ctx context.Context, symbol string) ([]domain.RiskLimitTier, error) {
+	return nil, nil
+}
+
 func (m *MockFundingExchange) GetWSStatus() domain.WSStatus {
 	return domain.WSStatus{Connected: true}
 }
@@ -456,3 +484,71 @@ func TestEvaluate_DelayedClosure(t *testing.T) {
 		t.Fatal("fundingEventTime should be reset after closure")
 	}
 }
+
+func TestHandleFundingEvent_MakerFirstEntry(t *testing.T) {
+	now := time.Now().Unix()
+	mockEx := &MockFundingExchange{
+		Tickers: []domain.Ticker{
+			{Symbol: "BTCUSDT", LastPrice: 100, FundingRate: 0.01, NextFundingTime: now + 5},
+		},
+		OrderBook: &domain.OrderBook{
+			Bids: []domain.OrderBookEntry{{Price: 99.9, Size: 10}},
+			Asks: []domain.OrderBookEntry{{Price: 100.1, Size: 10}},
+		},
+	}
+	parents := NewExecutionManager(mockEx, NewTradeExecutor(mockEx))
+	parents.SetPollInterval(5 * time.Millisecond)
+
+	bot := &FundingBot{
+		config: FundingBotConfig{
+			Symbol:       "BTCUSDT",
+			PositionSize: 1,
+			MakerFirst:   true,
+			MaxChasePct:  0.001,
+		},
+		exchange:  mockEx,
+		tradeRepo: &MockTradeRepo{},
+		logger:    zap.NewNop(),
+		parents:   parents,
+		running:   true,
+	}
+
+	if err := bot.handleFundingEvent(context.Background()); err != nil {
+		t.Fatalf("HandleFundingEvent failed: %v", err)
+	}
+
+	// The entry is worked in the background, the TP follows once it filled
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		bot.mu.Lock()
+		done := bot.tpOrder != nil
+		bot.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the TP after the maker-first entry")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if len(mockEx.PlacedOrders) != 2 {
+		t.Fatalf("Expected the entry child and the TP, got %d orders", len(mockEx.PlacedOrders))
+	}
+	entry := mockEx.PlacedOrders[0]
+	if entry.Side != domain.SideShort || entry.TimeInForce != "PostOnly" || entry.Price != 100.1 || entry.Size != 1 {
+		t.Errorf("Expected a post-only short of 1 at the best ask, got %+v", entry)
+	}
+	tp := mockEx.PlacedOrders[1]
+	if !tp.ReduceOnly || tp.Side != domain.SideLong || tp.Price != 98.5985 {
+		t.Errorf("Expected the TP 1.5%% under the 100.1 fill, got %+v", tp)
+	}
+
+	orders := parents.List()
+	if len(orders) != 1 || orders[0].Status != ParentFilled || orders[0].MakerRatio() != 1 {
+		t.Fatalf("Expected one parent filled as maker, got %+v", orders)
+	}
+	if saved := 100.1 * (DefaultFeeRates.Taker - DefaultFeeRates.Maker); math.Abs(orders[0].FeesSaved-saved) > 1e-9 {
+		t.Errorf("Expected %f fees saved, got %f", saved, orders[0].FeesSaved)
+	}
+}
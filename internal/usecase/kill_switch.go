@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// ErrKillSwitchRunning is returned when the kill switch is triggered while it is still flattening.
+var ErrKillSwitchRunning = errors.New("kill switch is already running")
+
+const (
+	killSwitchCloseAttempts = 3
+	killSwitchRetryDelay    = 2 * time.Second
+)
+
+// KillSwitchTimeout bounds a kill switch run. Callers give it a context of its own, a
+// dropped HTTP connection must not stop the flattening halfway.
+const KillSwitchTimeout = 2 * time.Minute
+
+// KillSwitchClose is the outcome of flattening one position.
+type KillSwitchClose struct {
+	Symbol   string      `json:"symbol"`
+	Side     domain.Side `json:"side"`
+	Size     float64     `json:"size"`
+	Attempts int         `json:"attempts"`
+	Closed   bool        `json:"closed"` // Confirmed flat on the exchange
+	Error    string      `json:"error,omitempty"`
+}
+
+// KillSwitchReport is what a kill switch run did.
+type KillSwitchReport struct {
+	By                string            `json:"by"`
+	Reason            string            `json:"reason"`
+	StartedAt         time.Time         `json:"started_at"`
+	FinishedAt        time.Time         `json:"finished_at"`
+	StoppedStrategies []string          `json:"stopped_strategies"`
+	CancelledOrders   int               `json:"cancelled_orders"`
+	CancelledParents  int               `json:"cancelled_parent_orders"`
+	CancelError       string            `json:"cancel_error,omitempty"`
+	PositionsError    string            `json:"positions_error,omitempty"`
+	Positions         []KillSwitchClose `json:"positions"`
+	Flat              bool              `json:"flat"` // Every position confirmed closed
+}
+
+// KillSwitch takes the whole account out of the market in one go:
+//
+//  1. Halt the risk manager, so no order path can open anything. The halt is persisted,
+//     a restart stays halted until an operator resets it.
+//  2. Stop the funding auto-scanner and every bot strategy. Stopped strategies are not
+//     restored on the next start. The level strategy keeps running with its entries halted,
+//     so trading resumes once the halt is reset.
+//  3. Cancel the working parent orders of the levels, then all open orders.
+//  4. Market close every position and confirm it is flat, with retries.
+type KillSwitch struct {
+	exchange domain.Exchange
+	risk     *RiskManager
+	runtime  *StrategyRuntime
+	levels   *LevelService
+	funding  *FundingBotService // Optional, its auto-scanner is stopped
+
+	attempts   int
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	running bool // One run at a time
+	last    *KillSwitchReport
+}
+
+func NewKillSwitch(exchange domain.Exchange, risk *RiskManager, runtime *StrategyRuntime, levels *LevelService, funding *FundingBotService) *KillSwitch {
+	return &KillSwitch{
+		exchange:   exchange,
+		risk:       risk,
+		runtime:    runtime,
+		levels:     levels,
+		funding:    funding,
+		attempts:   killSwitchCloseAttempts,
+		retryDelay: killSwitchRetryDelay,
+	}
+}
+
+// SetRetryPolicy changes how often and how far apart a position close is attempted.
+func (k *KillSwitch) SetRetryPolicy(attempts int, delay time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if attempts < 1 {
+		attempts = 1
+	}
+	k.attempts, k.retryDelay = attempts, delay
+}
+
+// Trigger runs the kill switch. The report lists what was done even if some steps failed,
+// the error is only set when the account is not confirmed flat.
+func (k *KillSwitch) Trigger(ctx context.Context, by, reason string) (*KillSwitchReport, error) {
+	k.mu.Lock()
+	if k.running {
+		k.mu.Unlock()
+		return nil, ErrKillSwitchRunning
+	}
+	k.running = true
+	attempts, delay := k.attempts, k.retryDelay
+	k.mu.Unlock()
+	defer func() {
+		k.mu.Lock()
+		k.running = false
+		k.mu.Unlock()
+	}()
+
+	if reason == "" {
+		reason = "no reason given"
+	}
+	report := &KillSwitchReport{By: by, Reason: reason, StartedAt: time.Now()}
+	log.Printf("KILL SWITCH: Triggered by %s: %s", by, reason)
+
+	// 1. Halt first, anything still in flight is refused from here on
+	if k.risk != nil {
+		k.risk.Halt(ctx, fmt.Sprintf("kill switch by %s: %s", by, reason))
+	}
+
+	// 2. Stop the bots
+	if k.funding != nil && k.funding.IsAutoScannerRunning() {
+		k.funding.StopAutoScanner()
+	}
+	if k.runtime != nil {
+		for _, info := range k.runtime.List() {
+			if info.Kind == StrategyKindLevel {
+				continue
+			}
+			if err := k.runtime.Stop(ctx, info.ID); err != nil {
+				log.Printf("KILL SWITCH: Failed to stop strategy %s: %v", info.ID, err)
+				continue
+			}
+			report.StoppedStrategies = append(report.StoppedStrategies, info.ID)
+		}
+	}
+
+	// 3. Cancel open orders, a resting order could reopen a position after the close. The
+	// parent orders go first, they would keep placing children.
+	if k.levels != nil {
+		report.CancelledParents = k.levels.ExecutionManager().CancelAll(CancelledByKillSwitch)
+	}
+	n, err := k.exchange.CancelAllOrders(ctx)
+	if err != nil {
+		log.Printf("KILL SWITCH: Failed to cancel open orders: %v", err)
+		report.CancelError = err.Error()
+	}
+	report.CancelledOrders = n
+
+	// 4. Flatten
+	positions, err := k.exchange.GetPositions(ctx)
+	if err != nil {
+		report.PositionsError = err.Error()
+		report.FinishedAt = time.Now()
+		k.setLast(report)
+		log.Printf("KILL SWITCH: Failed to list positions, account NOT confirmed flat: %v", err)
+		return report, fmt.Errorf("failed to get positions: %w", err)
+	}
+	report.Flat = true
+	for _, pos := range positions {
+		if pos == nil || pos.Size == 0 {
+			continue
+		}
+		res := k.flatten(ctx, pos, attempts, delay)
+		if !res.Closed {
+			report.Flat = false
+		}
+		report.Positions = append(report.Positions, res)
+	}
+	report.FinishedAt = time.Now()
+	k.setLast(report)
+
+	if !report.Flat {
+		log.Printf("KILL SWITCH: Done, account NOT flat, close the remaining positions by hand")
+		return report, errors.New("kill switch could not close every position")
+	}
+	log.Printf("KILL SWITCH: Done in %v, %d positions closed, %d orders cancelled, %d strategies stopped",
+		report.FinishedAt.Sub(report.StartedAt), len(report.Positions), report.CancelledOrders, len(report.StoppedStrategies))
+	return report, nil
+}
+
+// flatten closes one position. The first attempt goes through the level service so the
+// close is booked like any other exit, retries go straight to the exchange. Every attempt
+// is confirmed by reading the position back.
+func (k *KillSwitch) flatten(ctx context.Context, pos *domain.Position, attempts int, delay time.Duration) KillSwitchClose {
+	res := KillSwitchClose{Symbol: pos.Symbol, Side: pos.Side, Size: pos.Size}
+	for res.Attempts < attempts {
+		if res.Attempts > 0 {
+			select {
+			case <-ctx.Done():
+				res.Error = ctx.Err().Error()
+				return res
+			case <-time.After(delay):
+			}
+		}
+		res.Attempts++
+
+		var err error
+		if res.Attempts == 1 && k.levels != nil {
+			err = k.levels.FlattenPosition(ctx, pos.Symbol, "Kill Switch", "kill-switch")
+		} else {
+			err = k.exchange.ClosePosition(ctx, pos.Symbol)
+		}
+		if err != nil {
+			res.Error = err.Error()
+			log.Printf("KILL SWITCH: Close of %s failed (attempt %d/%d): %v", pos.Symbol, res.Attempts, attempts, err)
+			continue
+		}
+
+		current, err := k.exchange.GetPosition(ctx, pos.Symbol)
+		if err != nil {
+			res.Error = fmt.Sprintf("failed to confirm close: %v", err)
+			continue
+		}
+		if current == nil || current.Size == 0 {
+			res.Closed = true
+			res.Error = ""
+			log.Printf("KILL SWITCH: %s %s %f closed", pos.Symbol, pos.Side, pos.Size)
+			return res
+		}
+		res.Error = fmt.Sprintf("position still open after close: %f", current.Size)
+		log.Printf("KILL SWITCH: %s still open after attempt %d/%d (size %f)", pos.Symbol, res.Attempts, attempts, current.Size)
+	}
+	return res
+}
+
+func (k *KillSwitch) setLast(report *KillSwitchReport) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.last = report
+}
+
+// LastReport returns the report of the last run, nil if it never ran since the start.
+func (k *KillSwitch) LastReport() *KillSwitchReport {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.last
+}
+
+// Running tells whether a run is in progress.
+func (k *KillSwitch) Running() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.running
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SetCircuitBreakers stops live entries while a breaker of their symbol is open and keeps
+// the safety check from acting on a stale price. The breakers are fed by the BreakerExchange
+// the service trades through.
+func (s *LevelService) SetCircuitBreakers(breakers *CircuitBreakers) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakers = breakers
+}
+
+// CircuitBreakers returns the breakers set on the service, nil if there are none.
+func (s *LevelService) CircuitBreakers() *CircuitBreakers {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.breakers
+}
+
+// breakerCheck returns the open breaker blocking entries on the symbol, nil if none is.
+// The trial order is taken by the BreakerExchange when the entry goes out.
+func (s *LevelService) breakerCheck(symbol string) error {
+	breakers := s.CircuitBreakers()
+	if breakers == nil {
+		return nil
+	}
+	return breakers.EntryBlocked(symbol)
+}
+
+// safetyPrice returns the price the safety check may act on. A price older than the
+// breaker limit (the WS feed died) is refreshed over REST, if that fails too the symbol is
+// not checked and false is returned.
+func (s *LevelService) safetyPrice(ctx context.Context, symbol string) (float64, bool) {
+	breakers := s.CircuitBreakers()
+	if breakers == nil {
+		return s.GetLatestPrice(symbol), true
+	}
+	fresh, age := breakers.PriceFresh(symbol)
+	if fresh {
+		return s.GetLatestPrice(symbol), true
+	}
+
+	price, err := s.exchange.GetCurrentPrice(ctx, symbol)
+	if err != nil || price <= 0 {
+		log.Printf("BREAKER: Safety check of %s skipped, price is %s old and the refresh failed: %v", symbol, age.Round(time.Second), err)
+		return 0, false
+	}
+	s.mu.Lock()
+	s.lastPrices[symbol] = price
+	s.mu.Unlock()
+	breakers.RecordPrice(symbol)
+	return price, true
+}
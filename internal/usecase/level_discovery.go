@@ -0,0 +1,439 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// Proposal statuses.
+const (
+	ProposalPending  = "pending"
+	ProposalAccepted = "accepted"
+	ProposalRejected = "rejected"
+	ProposalAuto     = "auto" // Created without review
+)
+
+var ErrProposalNotFound = errors.New("proposal not found")
+
+// DiscoveryConfig tunes the level discovery.
+type DiscoveryConfig struct {
+	Interval          string  // Candle interval, e.g. "60" for 1h
+	Candles           int     // Length of the analysed history
+	SwingLookback     int     // Candles on each side of a swing high/low
+	TolerancePct      float64 // Zone width as a fraction of price, points closer than this merge
+	MaxDistancePct    float64 // Candidates farther from the current price are ignored
+	MinConfidence     float64 // Candidates below are not proposed
+	MaxProposals      int     // Per symbol and run
+	AutoCreate        bool    // Create confident proposals without review
+	AutoMinConfidence float64 // Confidence needed for AutoCreate
+}
+
+func DefaultDiscoveryConfig() DiscoveryConfig {
+	return DiscoveryConfig{
+		Interval:          "60",
+		Candles:           500,
+		SwingLookback:     3,
+		TolerancePct:      0.003,
+		MaxDistancePct:    0.10,
+		MinConfidence:     0.5,
+		MaxProposals:      5,
+		AutoMinConfidence: 0.8,
+	}
+}
+
+// LevelCandidate is a price zone found in the candle history.
+type LevelCandidate struct {
+	Price       float64  `json:"price"`
+	Swings      int      `json:"swings"`       // Swing highs/lows inside the zone
+	Touches     int      `json:"touches"`      // Separate visits of the zone
+	VolumeShare float64  `json:"volume_share"` // Traded volume at the zone relative to the point of control
+	LastTouch   int64    `json:"last_touch"`   // Candle time of the latest visit
+	Confidence  float64  `json:"confidence"`   // 0..1
+	Reasons     []string `json:"reasons"`
+}
+
+// LevelProposal is a discovered level waiting for review.
+type LevelProposal struct {
+	LevelCandidate
+	ID        string    `json:"id"`
+	Exchange  string    `json:"exchange"`
+	Symbol    string    `json:"symbol"`
+	Side      string    `json:"side"` // "support" below the price, "resistance" above
+	Status    string    `json:"status"`
+	LevelID   string    `json:"level_id,omitempty"` // Set once a level was created from it
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FindLevelCandidates scores price zones of a candle history (oldest first). Swing points and
+// high volume nodes are merged into zones, each zone is scored by its swings, the number of
+// times price came back to it, its traded volume and how recently it was visited.
+func FindLevelCandidates(candles []domain.Candle, price float64, cfg DiscoveryConfig) []LevelCandidate {
+	n := cfg.SwingLookback
+	if len(candles) < 2*n+1 || price <= 0 || cfg.TolerancePct <= 0 {
+		return nil
+	}
+	tol := cfg.TolerancePct
+
+	type point struct {
+		price float64
+		swing bool
+	}
+	var points []point
+
+	// 1. Swing highs and lows
+	for i := n; i < len(candles)-n; i++ {
+		high, low := true, true
+		for j := i - n; j <= i+n && (high || low); j++ {
+			if j == i {
+				continue
+			}
+			// Ties on the left count, ties on the right don't, so flat tops yield one swing
+			if candles[j].High > candles[i].High || (j > i && candles[j].High == candles[i].High) {
+				high = false
+			}
+			if candles[j].Low < candles[i].Low || (j > i && candles[j].Low == candles[i].Low) {
+				low = false
+			}
+		}
+		if high {
+			points = append(points, point{price: candles[i].High, swing: true})
+		}
+		if low {
+			points = append(points, point{price: candles[i].Low, swing: true})
+		}
+	}
+
+	// 2. Volume at price, high volume nodes become candidates as well
+	profile := BuildVolumeProfile(candles, price*tol)
+	for _, node := range profile.HVN {
+		points = append(points, point{price: node})
+	}
+	if len(points) == 0 {
+		return nil
+	}
+
+	// 3. Merge points into zones
+	sort.Slice(points, func(i, j int) bool { return points[i].price < points[j].price })
+	type zone struct {
+		sum    float64
+		count  int
+		swings int
+		hvn    bool
+	}
+	var zones []*zone
+	for _, p := range points {
+		if len(zones) > 0 {
+			z := zones[len(zones)-1]
+			mean := z.sum / float64(z.count)
+			if math.Abs(p.price-mean)/mean <= tol {
+				z.sum += p.price
+				z.count++
+				if p.swing {
+					z.swings++
+				} else {
+					z.hvn = true
+				}
+				continue
+			}
+		}
+		z := &zone{sum: p.price, count: 1}
+		if p.swing {
+			z.swings = 1
+		} else {
+			z.hvn = true
+		}
+		zones = append(zones, z)
+	}
+
+	// 4. Score zones
+	var out []LevelCandidate
+	for _, z := range zones {
+		c := LevelCandidate{Price: z.sum / float64(z.count), Swings: z.swings}
+		dist := math.Abs(c.Price-price) / price
+		if dist < tol || dist > cfg.MaxDistancePct {
+			continue
+		}
+
+		inZone, lastIdx := false, -1
+		for i, k := range candles {
+			touching := k.High >= c.Price*(1-tol) && k.Low <= c.Price*(1+tol)
+			if touching && !inZone {
+				c.Touches++
+			}
+			if touching {
+				lastIdx = i
+				c.LastTouch = k.Time
+			}
+			inZone = touching
+		}
+		c.VolumeShare = profile.Share(c.Price)
+
+		swingScore := math.Min(float64(c.Swings)/3, 1)
+		touchScore := math.Min(float64(c.Touches)/4, 1)
+		recency := float64(lastIdx+1) / float64(len(candles))
+		c.Confidence = math.Round((0.35*swingScore+0.25*touchScore+0.3*c.VolumeShare+0.1*recency)*100) / 100
+
+		if c.Swings > 0 {
+			c.Reasons = append(c.Reasons, fmt.Sprintf("%d swing points", c.Swings))
+		}
+		if c.Touches > 1 {
+			c.Reasons = append(c.Reasons, fmt.Sprintf("%d touches", c.Touches))
+		}
+		if z.hvn {
+			c.Reasons = append(c.Reasons, fmt.Sprintf("high volume node (%.0f%% of POC)", c.VolumeShare*100))
+		}
+		out = append(out, c)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Confidence > out[j].Confidence })
+	return out
+}
+
+// LevelDiscoveryService proposes levels found in the candle history, as an alternative to
+// the order book based AutoCreateNextLevel.
+type LevelDiscoveryService struct {
+	levels *LevelService
+	market *MarketService
+	cfg    DiscoveryConfig
+
+	mu        sync.Mutex
+	proposals map[string]*LevelProposal // id -> proposal
+	seq       int
+}
+
+func NewLevelDiscoveryService(levels *LevelService, market *MarketService, cfg DiscoveryConfig) *LevelDiscoveryService {
+	return &LevelDiscoveryService{
+		levels:    levels,
+		market:    market,
+		cfg:       cfg,
+		proposals: make(map[string]*LevelProposal),
+	}
+}
+
+// Discover analyses a symbol and replaces its pending proposals. Zones that already have a
+// level are skipped. With AutoCreate, confident proposals become auto levels right away.
+func (d *LevelDiscoveryService) Discover(ctx context.Context, exchange, symbol string) ([]*LevelProposal, error) {
+	// 1. Candle history and current price
+	candles, err := d.market.GetCandles(ctx, symbol, d.cfg.Interval, d.cfg.Candles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch candles: %w", err)
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles for %s", symbol)
+	}
+	price := d.levels.GetLatestPrice(symbol)
+	if price == 0 {
+		price = candles[len(candles)-1].Close
+	}
+
+	existing, err := d.levels.levelRepo.GetLevelsBySymbol(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load levels: %w", err)
+	}
+
+	// 2. Score and filter
+	var found []*LevelProposal
+	now := time.Now()
+	d.mu.Lock()
+	for id, p := range d.proposals {
+		if p.Exchange == exchange && p.Symbol == symbol && p.Status == ProposalPending {
+			delete(d.proposals, id)
+		}
+	}
+	for _, c := range FindLevelCandidates(candles, price, d.cfg) {
+		if len(found) >= d.cfg.MaxProposals {
+			break
+		}
+		if c.Confidence < d.cfg.MinConfidence || d.hasLevelNear(existing, exchange, c.Price) {
+			continue
+		}
+		d.seq++
+		p := &LevelProposal{
+			LevelCandidate: c,
+			ID:             fmt.Sprintf("%d-%d", now.UnixNano(), d.seq),
+			Exchange:       exchange,
+			Symbol:         symbol,
+			Side:           "support",
+			Status:         ProposalPending,
+			CreatedAt:      now,
+		}
+		if c.Price > price {
+			p.Side = "resistance"
+		}
+		d.proposals[p.ID] = p
+		found = append(found, p)
+	}
+	d.mu.Unlock()
+	log.Printf("DISCOVERY: %s %s: %d proposals from %d candles", exchange, symbol, len(found), len(candles))
+
+	// 3. Auto-create
+	if d.cfg.AutoCreate {
+		for _, p := range found {
+			if p.Confidence < d.cfg.AutoMinConfidence {
+				continue
+			}
+			if _, err := d.createLevel(ctx, p, LevelPatch{}, true); err != nil {
+				log.Printf("DISCOVERY: Auto-create of %s %.4f skipped: %v", symbol, p.Price, err)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (d *LevelDiscoveryService) hasLevelNear(levels []*domain.Level, exchange string, price float64) bool {
+	for _, l := range levels {
+		if l.Exchange == exchange && math.Abs(l.LevelPrice-price)/price <= d.cfg.TolerancePct {
+			return true
+		}
+	}
+	return false
+}
+
+// Proposals lists proposals of a symbol (all symbols if empty), most confident first.
+func (d *LevelDiscoveryService) Proposals(symbol string) []*LevelProposal {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var out []*LevelProposal
+	for _, p := range d.proposals {
+		if symbol == "" || p.Symbol == symbol {
+			copied := *p
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Symbol != out[j].Symbol {
+			return out[i].Symbol < out[j].Symbol
+		}
+		return out[i].Confidence > out[j].Confidence
+	})
+	return out
+}
+
+// AcceptProposal creates a reviewed level. The patch overrides the settings copied from an
+// existing level of the symbol, symbols without levels need at least base_size and leverage.
+func (d *LevelDiscoveryService) AcceptProposal(ctx context.Context, id string, patch LevelPatch) (*domain.Level, error) {
+	d.mu.Lock()
+	p, ok := d.proposals[id]
+	if !ok || p.Status != ProposalPending {
+		d.mu.Unlock()
+		return nil, ErrProposalNotFound
+	}
+	copied := *p
+	d.mu.Unlock()
+
+	return d.createLevel(ctx, &copied, patch, false)
+}
+
+// RejectProposal hides a proposal until the next discovery run of its symbol.
+func (d *LevelDiscoveryService) RejectProposal(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p, ok := d.proposals[id]
+	if !ok || p.Status != ProposalPending {
+		return ErrProposalNotFound
+	}
+	p.Status = ProposalRejected
+	return nil
+}
+
+// createLevel turns a proposal into a level, copying the settings of a manual level of the
+// symbol where there is one.
+func (d *LevelDiscoveryService) createLevel(ctx context.Context, p *LevelProposal, patch LevelPatch, auto bool) (*domain.Level, error) {
+	// 1. Settings template
+	existing, err := d.levels.levelRepo.GetLevelsBySymbol(ctx, p.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load levels: %w", err)
+	}
+	var template *domain.Level
+	for _, l := range existing {
+		if l.Exchange == p.Exchange && (template == nil || template.IsAuto && !l.IsAuto) {
+			template = l
+		}
+	}
+
+	id := fmt.Sprintf("%d", time.Now().UnixNano())
+	var level *domain.Level
+	if template != nil {
+		level = deriveLevel(template, id, p.Price, "discovery")
+		level.AutoModeEnabled = template.AutoModeEnabled
+	} else {
+		level = &domain.Level{
+			ID:               id,
+			Exchange:         p.Exchange,
+			Symbol:           p.Symbol,
+			LevelPrice:       p.Price,
+			MarginType:       "isolated",
+			StopLossMode:     "exchange",
+			TakeProfitPct:    0.02,
+			TakeProfitMode:   "fixed",
+			TrailingStopMode: "app",
+			Source:           "discovery",
+			CreatedAt:        time.Now(),
+		}
+	}
+	level.IsAuto = auto
+
+	// 2. Overrides
+	if err := patch.Apply(level); err != nil {
+		return nil, err
+	}
+	if (level.BaseSize <= 0 && !level.UsesRiskSizing()) || level.Leverage <= 0 {
+		return nil, fmt.Errorf("base_size and leverage are required, %s has no level to copy settings from", p.Symbol)
+	}
+
+	// 3. Save
+	if err := d.levels.CreateLevel(ctx, level); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	if stored, ok := d.proposals[p.ID]; ok {
+		stored.Status = ProposalAccepted
+		if auto {
+			stored.Status = ProposalAuto
+		}
+		stored.LevelID = level.ID
+	}
+	d.mu.Unlock()
+	log.Printf("DISCOVERY: Created level %s on %s at %f (confidence %.2f, auto: %v)", level.ID, level.Symbol, level.LevelPrice, p.Confidence, auto)
+	return level, nil
+}
+
+// Run rediscovers every symbol that has levels, until ctx is done.
+func (d *LevelDiscoveryService) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		levels, err := d.levels.levelRepo.ListLevels(ctx)
+		if err != nil {
+			log.Printf("DISCOVERY: Failed to list levels: %v", err)
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, l := range levels {
+			key := l.Exchange + "/" + l.Symbol
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, err := d.Discover(ctx, l.Exchange, l.Symbol); err != nil {
+				log.Printf("DISCOVERY: %s failed: %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// zigzagCandles swings between low and high, legs candles per leg.
+func zigzagCandles(low, high float64, legs, perLeg int) []domain.Candle {
+	var candles []domain.Candle
+	prev := low
+	for leg := 0; leg < legs; leg++ {
+		from, to := low, high
+		if leg%2 == 1 {
+			from, to = high, low
+		}
+		for i := 1; i <= perLeg; i++ {
+			price := from + (to-from)*float64(i)/float64(perLeg)
+			candles = append(candles, domain.Candle{
+				Time:   int64(len(candles)) * 60000,
+				Open:   prev,
+				Close:  price,
+				High:   math.Max(prev, price) + 0.05,
+				Low:    math.Min(prev, price) - 0.05,
+				Volume: 10,
+			})
+			prev = price
+		}
+	}
+	return candles
+}
+
+func TestFindLevelCandidates_SwingZones(t *testing.T) {
+	candles := zigzagCandles(100, 110, 8, 10)
+	cfg := DefaultDiscoveryConfig()
+
+	got := FindLevelCandidates(candles, 105, cfg)
+	if len(got) < 2 {
+		t.Fatalf("Expected support and resistance candidates, got %+v", got)
+	}
+
+	var support, resistance *LevelCandidate
+	for i := range got {
+		c := &got[i]
+		if math.Abs(c.Price-100) < 0.5 && support == nil {
+			support = c
+		}
+		if math.Abs(c.Price-110) < 0.5 && resistance == nil {
+			resistance = c
+		}
+	}
+	if support == nil || resistance == nil {
+		t.Fatalf("Expected zones near 100 and 110, got %+v", got)
+	}
+	if support.Swings < 3 || support.Touches < 3 || support.Confidence < 0.5 {
+		t.Errorf("Expected a confident support zone, got %+v", support)
+	}
+	if resistance.Swings < 3 || resistance.Touches < 3 || resistance.Confidence < 0.5 {
+		t.Errorf("Expected a confident resistance zone, got %+v", resistance)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Confidence > got[i-1].Confidence {
+			t.Fatalf("Expected candidates ordered by confidence, got %+v", got)
+		}
+	}
+}
+
+func TestFindLevelCandidates_DistanceFilter(t *testing.T) {
+	candles := zigzagCandles(100, 110, 8, 10)
+	cfg := DefaultDiscoveryConfig()
+	cfg.MaxDistancePct = 0.02 // 110 is ~4.8% away from 105, 100 is ~4.8%
+
+	if got := FindLevelCandidates(candles, 105, cfg); len(got) != 0 {
+		for _, c := range got {
+			if math.Abs(c.Price-105)/105 > cfg.MaxDistancePct {
+				t.Fatalf("Expected candidates within %.0f%%, got %+v", cfg.MaxDistancePct*100, c)
+			}
+		}
+	}
+	if got := FindLevelCandidates(candles[:3], 105, cfg); got != nil {
+		t.Errorf("Expected no candidates from a too short history, got %+v", got)
+	}
+}
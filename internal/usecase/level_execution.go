@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// ExecutionManager returns the manager working the parent orders of the levels.
+func (s *LevelService) ExecutionManager() *ExecutionManager {
+	return s.parents
+}
+
+// WaitEntries waits until the parent order entries being worked are booked.
+func (s *LevelService) WaitEntries() {
+	s.entries.Wait()
+}
+
+// WaitExits waits until the take profit exits being worked are booked.
+func (s *LevelService) WaitExits() {
+	s.exits.Wait()
+}
+
+// executeEntryAlgo works a tier entry with the level's execution algorithm. It runs in its
+// own goroutine, processLevel books the result once the parent order is done. Partial fills
+// count as the entry, unless an exit cancelled it: the exit then closed the partial fill
+// along with the rest of the position.
+func (s *LevelService) executeEntryAlgo(ctx context.Context, level *domain.Level, side domain.Side, size, stopLoss float64) (*Execution, error) {
+	req := ParentRequest{
+		Symbol:         level.Symbol,
+		LevelID:        level.ID,
+		Purpose:        PurposeEntry,
+		Side:           side,
+		Size:           size,
+		Leverage:       level.Leverage,
+		MarginType:     level.MarginType,
+		StopLoss:       stopLoss,
+		Algo:           level.EffectiveExecAlgo(),
+		DurationSec:    level.ExecDurationSec,
+		ClipSize:       level.ExecClipSize,
+		MaxSlippagePct: level.MaxSlippagePct,
+		SlippageAction: level.EffectiveSlippageAction(),
+		MaxChasePct:    level.ExecMaxChasePct,
+		Fallback:       level.EffectiveExecFallback(),
+	}
+	if inst, err := s.sizer.instrument(ctx, level.Symbol); err == nil {
+		req.QtyStep, req.MinQty, req.TickSize = inst.QtyStep, inst.MinOrderQty, inst.TickSize
+	}
+
+	p, err := s.parents.Run(ctx, req)
+	exec := p.Execution()
+	if p.CancelledBy == CancelledByExit {
+		return exec, fmt.Errorf("entry %s cancelled by an exit with %f of %f filled", p.ID, p.Filled, p.Size)
+	}
+	if p.Filled > 0 {
+		if err != nil {
+			log.Printf("EXEC: %s %s entry %s filled %f of %f (level %s): %v", level.Symbol, side, p.ID, p.Filled, size, level.ID, err)
+		}
+		return exec, nil
+	}
+	return exec, err
+}
+
+// exitAlgo returns the execution algorithm an exit of the level is worked with. Only take
+// profits wait on an algorithm, stops, safety exits and manual closes go at market.
+func (s *LevelService) exitAlgo(symbol, levelID, reason string) (*domain.Level, string) {
+	level := s.cachedLevel(symbol, levelID)
+	if level == nil || !strings.HasPrefix(reason, "Take Profit") {
+		return level, domain.ExecMarket
+	}
+	return level, level.EffectiveExecAlgo()
+}
+
+// workExit closes size of the position with the level's execution algorithm and returns the
+// parent order, zero when the exit goes at market. The caller sends whatever is left at market, so an exit never stays half
+// done; cancelling an exit parent order just sends the rest at market sooner.
+func (s *LevelService) workExit(ctx context.Context, symbol, levelID, reason string, pos *domain.Position, size float64) ParentOrder {
+	level, algo := s.exitAlgo(symbol, levelID, reason)
+	if algo == domain.ExecMarket || pos == nil || pos.Size <= 0 || size <= 0 {
+		return ParentOrder{}
+	}
+
+	side := domain.SideShort
+	if pos.Side == domain.SideShort {
+		side = domain.SideLong
+	}
+	req := ParentRequest{
+		Symbol:      symbol,
+		LevelID:     levelID,
+		Purpose:     PurposeExit,
+		Side:        side,
+		Size:        size,
+		ReduceOnly:  true,
+		Algo:        algo,
+		DurationSec: level.ExecDurationSec,
+		ClipSize:    level.ExecClipSize,
+		MaxChasePct: level.ExecMaxChasePct,
+		Fallback:    level.EffectiveExecFallback(),
+	}
+	if inst, err := s.sizer.instrument(ctx, symbol); err == nil {
+		req.QtyStep, req.MinQty, req.TickSize = inst.QtyStep, inst.MinOrderQty, inst.TickSize
+	}
+	p, err := s.parents.Run(ctx, req)
+	if err != nil {
+		log.Printf("EXEC: %s exit %s closed %f of %f (%s), the rest goes at market: %v", symbol, p.ID, p.Filled, size, reason, err)
+	}
+	return p
+}
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// Import actions.
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportDelete    = "delete"
+	ImportUnchanged = "unchanged"
+)
+
+// ImportOptions controls a bulk import.
+type ImportOptions struct {
+	DryRun    bool   // Only compute the diff
+	Prune     bool   // Delete levels that are not in the import
+	ChangedBy string // Recorded on the level revisions
+}
+
+// ImportChange is one planned (or applied) level change.
+type ImportChange struct {
+	Action  string                    `json:"action"`
+	Key     string                    `json:"key"`
+	LevelID string                    `json:"level_id,omitempty"`
+	Symbol  string                    `json:"symbol"`
+	Changes []domain.LevelFieldChange `json:"changes,omitempty"`
+}
+
+// ImportTierChange is a planned change of symbol tiers, Old is nil for new symbols.
+type ImportTierChange struct {
+	Old *domain.SymbolTiers `json:"old"`
+	New *domain.SymbolTiers `json:"new"`
+}
+
+// ImportRowError is a validation error of one input row.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportResult is the diff of an import. Nothing is applied when there are row errors.
+type ImportResult struct {
+	DryRun  bool               `json:"dry_run"`
+	Applied bool               `json:"applied"`
+	Changes []ImportChange     `json:"changes"`
+	Tiers   []ImportTierChange `json:"tiers"`
+	Errors  []ImportRowError   `json:"errors"`
+}
+
+// ExportLevels returns all levels as records with their symbol tiers, ordered by symbol and price.
+func (s *LevelService) ExportLevels(ctx context.Context) ([]LevelRecord, error) {
+	levels, err := s.levelRepo.ListLevels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list levels: %w", err)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].Symbol != levels[j].Symbol {
+			return levels[i].Symbol < levels[j].Symbol
+		}
+		return levels[i].LevelPrice < levels[j].LevelPrice
+	})
+
+	tiersBySymbol := make(map[string]*domain.SymbolTiers)
+	recs := make([]LevelRecord, 0, len(levels))
+	for _, l := range levels {
+		key := l.Exchange + "/" + l.Symbol
+		tiers, ok := tiersBySymbol[key]
+		if !ok {
+			tiers, _ = s.levelRepo.GetSymbolTiers(ctx, l.Exchange, l.Symbol) // Missing tiers export as empty columns
+			tiersBySymbol[key] = tiers
+		}
+		recs = append(recs, NewLevelRecord(l, tiers))
+	}
+	return recs, nil
+}
+
+// ImportLevelsFrom decodes and imports records. Rows that fail to parse turn the import into a
+// dry run, so the caller sees every problem at once.
+func (s *LevelService) ImportLevelsFrom(ctx context.Context, r io.Reader, format string, opts ImportOptions) (*ImportResult, error) {
+	recs, rowErrs, err := DecodeLevelRecords(r, format)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.ImportLevels(ctx, recs, ImportOptions{DryRun: opts.DryRun || len(rowErrs) > 0, Prune: opts.Prune, ChangedBy: opts.ChangedBy})
+	if err != nil {
+		return nil, err
+	}
+	res.Errors = append(rowErrs, res.Errors...)
+	sort.SliceStable(res.Errors, func(i, j int) bool { return res.Errors[i].Row < res.Errors[j].Row })
+	res.DryRun = opts.DryRun
+	return res, nil
+}
+
+// ImportLevels upserts levels by their external key (falling back to the level ID, so an
+// export can be re-imported as is) and optionally deletes levels missing from the input.
+// The whole input is validated first, nothing is written when any row fails.
+func (s *LevelService) ImportLevels(ctx context.Context, recs []LevelRecord, opts ImportOptions) (*ImportResult, error) {
+	existing, err := s.levelRepo.ListLevels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list levels: %w", err)
+	}
+	byKey := make(map[string]*domain.Level, len(existing))
+	for _, l := range existing {
+		byKey[l.ID] = l
+	}
+	for _, l := range existing {
+		if l.ExternalKey != "" {
+			byKey[l.ExternalKey] = l // External keys win over IDs
+		}
+	}
+
+	res := &ImportResult{DryRun: opts.DryRun}
+	rowErr := func(rec LevelRecord, format string, args ...interface{}) {
+		res.Errors = append(res.Errors, ImportRowError{Row: rec.Row, Key: rec.Key, Error: fmt.Sprintf(format, args...)})
+	}
+
+	type plannedLevel struct {
+		change  ImportChange
+		current *domain.Level
+		level   *domain.Level
+	}
+	var planned []plannedLevel
+	seenKeys := make(map[string]int)
+	touched := make(map[string]bool) // Level IDs present in the input
+	tiersByPair := make(map[string]*domain.SymbolTiers)
+	tiersRow := make(map[string]int)
+	now := time.Now()
+
+	// 1. Validate rows and diff them against the stored levels
+	for i, rec := range recs {
+		if rec.Row == 0 {
+			rec.Row = i + 1
+		}
+		if rec.Key == "" {
+			rowErr(rec, "key is required")
+			continue
+		}
+		if row, dup := seenKeys[rec.Key]; dup {
+			rowErr(rec, "duplicate key, first used in row %d", row)
+			continue
+		}
+		seenKeys[rec.Key] = rec.Row
+
+		current := byKey[rec.Key]
+		level := &domain.Level{
+			ID:        fmt.Sprintf("%d", now.UnixNano()+int64(i)),
+			Source:    "import",
+			CreatedAt: now,
+		}
+		if current != nil {
+			copied := *current
+			level = &copied
+		}
+		if err := rec.ApplyTo(level); err != nil {
+			rowErr(rec, "%v", err)
+			continue
+		}
+
+		change := ImportChange{Key: rec.Key, Symbol: level.Symbol, LevelID: level.ID}
+		switch {
+		case current == nil:
+			change.Action = ImportCreate
+		default:
+			touched[current.ID] = true
+			change.Changes = domain.DiffLevels(current, level)
+			change.Action = ImportUpdate
+			if len(change.Changes) == 0 {
+				change.Action = ImportUnchanged
+			}
+			if level.LevelPrice != current.LevelPrice && s.levelHasPosition(ctx, current) {
+				rowErr(rec, "cannot change level price: %v", ErrLevelHasPosition)
+				continue
+			}
+		}
+
+		// Symbol tiers, every row of a symbol must agree
+		if tiers := rec.Tiers(); tiers != nil {
+			pair := tiers.Exchange + "/" + tiers.Symbol
+			if prev, ok := tiersByPair[pair]; ok {
+				if !sameTiers(prev, tiers) {
+					rowErr(rec, "tiers differ from row %d of the same symbol", tiersRow[pair])
+					continue
+				}
+			} else if err := tiers.Validate(); err != nil {
+				rowErr(rec, "%v", err)
+				continue
+			} else {
+				tiersByPair[pair] = tiers
+				tiersRow[pair] = rec.Row
+			}
+		}
+
+		planned = append(planned, plannedLevel{change: change, current: current, level: level})
+	}
+
+	// 2. Tier diff
+	pairs := make([]string, 0, len(tiersByPair))
+	for pair := range tiersByPair {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	for _, pair := range pairs {
+		tiers := tiersByPair[pair]
+		old, err := s.levelRepo.GetSymbolTiers(ctx, tiers.Exchange, tiers.Symbol)
+		if err != nil {
+			old = nil
+		}
+		if old != nil && sameTiers(old, tiers) {
+			continue
+		}
+		if s.TiersActive(tiers.Symbol) {
+			res.Errors = append(res.Errors, ImportRowError{Row: tiersRow[pair], Error: fmt.Sprintf("cannot change tiers of %s: %v", tiers.Symbol, ErrTiersActive)})
+			continue
+		}
+		res.Tiers = append(res.Tiers, ImportTierChange{Old: old, New: tiers})
+	}
+
+	// 3. Levels missing from the input
+	var deletes []*domain.Level
+	if opts.Prune {
+		for _, l := range existing {
+			if touched[l.ID] {
+				continue
+			}
+			if s.levelHasPosition(ctx, l) {
+				res.Errors = append(res.Errors, ImportRowError{Key: l.ExternalKey, Error: fmt.Sprintf("cannot delete level %s (%s): %v", l.ID, l.Symbol, ErrLevelHasPosition)})
+				continue
+			}
+			deletes = append(deletes, l)
+		}
+	}
+
+	for _, p := range planned {
+		res.Changes = append(res.Changes, p.change)
+	}
+	for _, l := range deletes {
+		res.Changes = append(res.Changes, ImportChange{Action: ImportDelete, Key: l.ExternalKey, LevelID: l.ID, Symbol: l.Symbol})
+	}
+
+	if opts.DryRun || len(res.Errors) > 0 {
+		return res, nil
+	}
+
+	// 4. Apply. Tiers first, so new levels start with the imported boundaries.
+	for _, tc := range res.Tiers {
+		tiers := *tc.New
+		tiers.UpdatedAt = now
+		if err := s.levelRepo.SaveSymbolTiers(ctx, &tiers); err != nil {
+			return res, fmt.Errorf("failed to save tiers for %s: %w", tiers.Symbol, err)
+		}
+	}
+	for _, p := range planned {
+		switch p.change.Action {
+		case ImportCreate:
+			if err := s.levelRepo.SaveLevel(ctx, p.level); err != nil {
+				return res, fmt.Errorf("failed to create level %s: %w", p.change.Key, err)
+			}
+		case ImportUpdate:
+			if _, err := s.saveLevelEdit(ctx, p.current, p.level, opts.ChangedBy); err != nil {
+				return res, fmt.Errorf("failed to update level %s: %w", p.change.Key, err)
+			}
+		case ImportUnchanged:
+			if p.current.ExternalKey != p.level.ExternalKey {
+				// Matched by ID, adopt the key so later imports match on it
+				if err := s.levelRepo.UpdateLevel(ctx, p.level); err != nil {
+					return res, fmt.Errorf("failed to set key of level %s: %w", p.level.ID, err)
+				}
+			}
+		}
+	}
+	for _, l := range deletes {
+		if err := s.levelRepo.DeleteLevel(ctx, l.ID); err != nil {
+			return res, fmt.Errorf("failed to delete level %s: %w", l.ID, err)
+		}
+	}
+	res.Applied = true
+	log.Printf("AUDIT: Import by %s applied %d level changes, %d tier changes", opts.ChangedBy, len(res.Changes), len(res.Tiers))
+
+	if err := s.UpdateCache(ctx); err != nil {
+		return res, fmt.Errorf("failed to refresh cache: %w", err)
+	}
+	return res, nil
+}
+
+// sameTiers compares tier distances, ignoring float noise from the percent round trip.
+func sameTiers(a, b *domain.SymbolTiers) bool {
+	const eps = 1e-9
+	return math.Abs(a.Tier1Pct-b.Tier1Pct) < eps && math.Abs(a.Tier2Pct-b.Tier2Pct) < eps && math.Abs(a.Tier3Pct-b.Tier3Pct) < eps
+}
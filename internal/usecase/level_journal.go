@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// SetDecisionRepository enables the decision journal, without it decisions only go to the log.
+func (s *LevelService) SetDecisionRepository(repo domain.DecisionRepository) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal = repo
+}
+
+// ListDecisions returns journal entries matching the filter, newest first.
+func (s *LevelService) ListDecisions(ctx context.Context, filter domain.DecisionFilter) ([]*domain.Decision, error) {
+	s.mu.RLock()
+	repo := s.journal
+	s.mu.RUnlock()
+	if repo == nil {
+		return nil, nil
+	}
+	return repo.ListDecisions(ctx, filter)
+}
+
+// recordDecision writes a journal entry. Journal failures never block trading.
+func (s *LevelService) recordDecision(ctx context.Context, d *domain.Decision) {
+	s.mu.RLock()
+	repo := s.journal
+	s.mu.RUnlock()
+	if repo == nil {
+		return
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+	if err := repo.SaveDecision(ctx, d); err != nil {
+		log.Printf("JOURNAL: Failed to record %s decision for level %s: %v", d.Kind, d.LevelID, err)
+	}
+}
+
+// levelDecision starts a journal entry for a level.
+func levelDecision(kind string, level *domain.Level, price float64) *domain.Decision {
+	return &domain.Decision{
+		Kind:       kind,
+		LevelID:    level.ID,
+		Exchange:   level.Exchange,
+		Symbol:     level.Symbol,
+		Price:      price,
+		LevelPrice: level.LevelPrice,
+	}
+}
+
+// entryInputs collects what an entry decision was based on.
+func entryInputs(level *domain.Level, tiers *domain.SymbolTiers, boundaries []float64, sentiment, sentimentThreshold float64, state LevelState) domain.DecisionInputs {
+	inputs := domain.DecisionInputs{
+		"mode":                level.EffectiveMode(),
+		"sentiment":           sentiment,
+		"sentiment_threshold": sentimentThreshold,
+		"boundaries":          boundaries,
+		"state":               stateSnapshot(state),
+	}
+	if tiers != nil {
+		inputs["tiers"] = []float64{tiers.Tier1Pct, tiers.Tier2Pct, tiers.Tier3Pct}
+	}
+	return inputs
+}
+
+// exitInputs merges the trigger inputs of an exit with its result. The trigger map is
+// shared between the levels of one close, so it is copied.
+func exitInputs(trigger domain.DecisionInputs, pnl, fees float64, partial bool, state map[string]interface{}) domain.DecisionInputs {
+	inputs := domain.DecisionInputs{}
+	for k, v := range trigger {
+		inputs[k] = v
+	}
+	inputs["realized_pnl"] = pnl
+	inputs["fees"] = fees
+	inputs["net_pnl"] = pnl - fees
+	inputs["partial"] = partial
+	if state != nil {
+		inputs["state"] = state
+	}
+	return inputs
+}
+
+// stateSnapshot is the part of the runtime state worth keeping with a decision.
+func stateSnapshot(st LevelState) map[string]interface{} {
+	snap := map[string]interface{}{
+		"tier1_triggered":         st.Tier1Triggered,
+		"tier2_triggered":         st.Tier2Triggered,
+		"tier3_triggered":         st.Tier3Triggered,
+		"active_side":             st.ActiveSide,
+		"consecutive_wins":        st.ConsecutiveWins,
+		"consecutive_base_closes": st.ConsecutiveBaseCloses,
+		"take_profit_steps_done":  st.TakeProfitStepsDone,
+	}
+	if !st.DisabledUntil.IsZero() {
+		snap["disabled_until"] = st.DisabledUntil
+	}
+	if st.TrailingActive {
+		snap["trailing_stop_price"] = st.TrailingStopPrice
+	}
+	if st.BreakEvenActive {
+		snap["break_even_price"] = st.BreakEvenPrice
+	}
+	if st.RangeHigh > 0 {
+		snap["range_high"] = st.RangeHigh
+		snap["range_low"] = st.RangeLow
+	}
+	return snap
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// SetSymbolLeases makes the service trade only symbols it holds a lease on, as owner.
+// Without a registry (nil) every symbol is considered owned.
+func (s *LevelService) SetSymbolLeases(leases *SymbolLeaseRegistry, owner string) {
+	s.mu.Lock()
+	s.leases = leases
+	s.leaseOwner = owner
+	s.leaseConflicts = make(map[string]string)
+	s.mu.Unlock()
+}
+
+// ownsSymbol reports whether the service may trade the exchange position of a symbol.
+// A free symbol is taken on the spot, so a symbol released by another strategy is picked
+// up on the next tick. Conflicts are logged once per owner.
+func (s *LevelService) ownsSymbol(symbol string) bool {
+	s.mu.RLock()
+	leases, owner := s.leases, s.leaseOwner
+	s.mu.RUnlock()
+	if leases == nil {
+		return true
+	}
+
+	err := leases.Acquire(symbol, owner, "")
+	holder := ""
+	if err != nil {
+		holder = s.symbolHolders(symbol)
+	}
+
+	s.mu.Lock()
+	if s.leaseConflicts[symbol] != holder {
+		if holder != "" {
+			log.Printf("LEASE: Live levels on %s are on hold, the symbol is owned by %s", symbol, holder)
+		} else {
+			log.Printf("LEASE: Live levels on %s resumed", symbol)
+		}
+	}
+	if holder == "" {
+		delete(s.leaseConflicts, symbol)
+	} else {
+		s.leaseConflicts[symbol] = holder
+	}
+	s.mu.Unlock()
+	return err == nil
+}
+
+// symbolHolders names the strategies holding a symbol, for logs and decisions.
+func (s *LevelService) symbolHolders(symbol string) string {
+	s.mu.RLock()
+	leases := s.leases
+	s.mu.RUnlock()
+	if leases == nil {
+		return ""
+	}
+	return strings.Join(leases.Owners(symbol), ", ")
+}
+
+// checkSymbolLease returns an ErrSymbolLeased error if another strategy owns the symbol.
+func (s *LevelService) checkSymbolLease(symbol string) error {
+	s.mu.RLock()
+	leases, owner := s.leases, s.leaseOwner
+	s.mu.RUnlock()
+	if leases == nil {
+		return nil
+	}
+	for _, o := range leases.Owners(symbol) {
+		if o != owner {
+			return fmt.Errorf("%s is owned by %s: %w", symbol, o, ErrSymbolLeased)
+		}
+	}
+	return nil
+}
+
+// syncLeases takes the symbols with live levels and hands back the ones without.
+// Shadow and alert levels never touch the exchange, they do not need a lease.
+func (s *LevelService) syncLeases(levels map[string][]*domain.Level) {
+	s.mu.RLock()
+	leases, owner := s.leases, s.leaseOwner
+	s.mu.RUnlock()
+	if leases == nil {
+		return
+	}
+
+	for symbol, ls := range levels {
+		if len(liveLevels(ls)) > 0 {
+			s.ownsSymbol(symbol)
+		}
+	}
+	for _, lease := range leases.List() {
+		if lease.Owner == owner && len(liveLevels(levels[lease.Symbol])) == 0 {
+			leases.Release(lease.Symbol, owner)
+		}
+	}
+}
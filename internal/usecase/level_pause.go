@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// SymbolPause is an active entry pause of a symbol.
+type SymbolPause struct {
+	Symbol string    `json:"symbol"`
+	Until  time.Time `json:"until"` // Zero until resumed
+}
+
+// PauseSymbol stops new entries on a symbol for the given duration (0 = until resumed).
+// Open positions are still managed, exits and stops keep working.
+func (s *LevelService) PauseSymbol(symbol string, d time.Duration) {
+	var until time.Time
+	if d > 0 {
+		until = time.Now().Add(d)
+	}
+	s.mu.Lock()
+	s.paused[symbol] = until
+	s.mu.Unlock()
+	log.Printf("AUDIT: Entries on %s paused (duration: %v)", symbol, d)
+}
+
+// ResumeSymbol lifts a pause.
+func (s *LevelService) ResumeSymbol(symbol string) {
+	s.mu.Lock()
+	delete(s.paused, symbol)
+	s.mu.Unlock()
+	log.Printf("AUDIT: Entries on %s resumed", symbol)
+}
+
+// IsSymbolPaused reports whether entries on the symbol are paused.
+func (s *LevelService) IsSymbolPaused(symbol string) bool {
+	s.mu.RLock()
+	until, ok := s.paused[symbol]
+	s.mu.RUnlock()
+	return ok && (until.IsZero() || time.Now().Before(until))
+}
+
+// PausedSymbols lists the active pauses, ordered by symbol.
+func (s *LevelService) PausedSymbols() []SymbolPause {
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pauses := make([]SymbolPause, 0, len(s.paused))
+	for symbol, until := range s.paused {
+		if until.IsZero() || now.Before(until) {
+			pauses = append(pauses, SymbolPause{Symbol: symbol, Until: until})
+		}
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i].Symbol < pauses[j].Symbol })
+	return pauses
+}
+
+// SetRiskManager stops live entries while the risk manager is halted, by a loss limit or the
+// kill switch. The order would be refused anyway, this keeps it out of the executor and
+// journals it as skipped.
+func (s *LevelService) SetRiskManager(risk *RiskManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.risk = risk
+}
+
+// haltReason returns why trading is halted, empty if it is not.
+func (s *LevelService) haltReason() string {
+	s.mu.RLock()
+	risk := s.risk
+	s.mu.RUnlock()
+	if risk == nil {
+		return ""
+	}
+	return risk.HaltReason()
+}
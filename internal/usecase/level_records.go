@@ -0,0 +1,405 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// Bulk transfer formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatCSV  = "csv"
+)
+
+// LevelRecord is the flat, spreadsheet friendly form of a level used by import/export.
+// Units follow the add-level form: percentages are in percent (2 = 2%). The tier columns
+// carry the symbol tiers, all zero leaves the tiers of the symbol untouched.
+type LevelRecord struct {
+	Key                      string  `json:"key" yaml:"key"` // Stable external key, upserts match on it
+	ID                       string  `json:"id" yaml:"id"`   // Export only, ignored on import
+	Exchange                 string  `json:"exchange" yaml:"exchange"`
+	Symbol                   string  `json:"symbol" yaml:"symbol"`
+	LevelPrice               float64 `json:"level_price" yaml:"level_price"`
+	BaseSize                 float64 `json:"base_size" yaml:"base_size"`
+	Leverage                 int     `json:"leverage" yaml:"leverage"`
+	MarginType               string  `json:"margin_type" yaml:"margin_type"`
+	CoolDownMs               int64   `json:"cool_down_ms" yaml:"cool_down_ms"`
+	StopLossAtBase           bool    `json:"stop_loss_at_base" yaml:"stop_loss_at_base"`
+	StopLossMode             string  `json:"stop_loss_mode" yaml:"stop_loss_mode"`
+	DisableSpeedClose        bool    `json:"disable_speed_close" yaml:"disable_speed_close"`
+	MaxConsecutiveBaseCloses int     `json:"max_consecutive_base_closes" yaml:"max_consecutive_base_closes"`
+	BaseCloseCooldownMs      int64   `json:"base_close_cooldown_ms" yaml:"base_close_cooldown_ms"`
+	TakeProfitPct            float64 `json:"take_profit_pct" yaml:"take_profit_pct"`
+	TakeProfitMode           string  `json:"take_profit_mode" yaml:"take_profit_mode"`
+	TakeProfitLadder         string  `json:"take_profit_ladder" yaml:"take_profit_ladder"` // "40@1,30@2"
+	TrailingActivationPct    float64 `json:"trailing_activation_pct" yaml:"trailing_activation_pct"`
+	TrailingStopPct          float64 `json:"trailing_stop_pct" yaml:"trailing_stop_pct"`
+	BreakEvenTier            int     `json:"break_even_tier" yaml:"break_even_tier"`
+	BreakEvenProfitPct       float64 `json:"break_even_profit_pct" yaml:"break_even_profit_pct"`
+	TrailingStopMode         string  `json:"trailing_stop_mode" yaml:"trailing_stop_mode"`
+	EntryFilters             string  `json:"entry_filters" yaml:"entry_filters"` // "trend,sentiment"
+	AutoModeEnabled          bool    `json:"auto_mode_enabled" yaml:"auto_mode_enabled"`
+	Mode                     string  `json:"mode" yaml:"mode"`               // live, shadow or alert
+	SizingMode               string  `json:"sizing_mode" yaml:"sizing_mode"` // fixed, risk_pct or risk_usd
+	RiskPct                  float64 `json:"risk_pct" yaml:"risk_pct"`
+	RiskUSD                  float64 `json:"risk_usd" yaml:"risk_usd"`
+	MaxSlippagePct           float64 `json:"max_slippage_pct" yaml:"max_slippage_pct"` // Percent, 0.2 = 0.2%
+	SlippageAction           string  `json:"slippage_action" yaml:"slippage_action"`   // reject, downsize or split
+	ExecAlgo                 string  `json:"exec_algo" yaml:"exec_algo"`               // market, chase, twap or iceberg
+	ExecDurationSec          int     `json:"exec_duration_sec" yaml:"exec_duration_sec"`
+	ExecClipSize             float64 `json:"exec_clip_size" yaml:"exec_clip_size"`
+	ExecMaxChasePct          float64 `json:"exec_max_chase_pct" yaml:"exec_max_chase_pct"` // Percent, 0.1 = 0.1%
+	ExecFallback             string  `json:"exec_fallback" yaml:"exec_fallback"`           // market or ioc
+	IsAuto                   bool    `json:"is_auto" yaml:"is_auto"`
+	Source                   string  `json:"source" yaml:"source"`
+	CreatedAt                string  `json:"created_at" yaml:"created_at"` // Export only, RFC3339
+	Tier1Pct                 float64 `json:"tier1_pct" yaml:"tier1_pct"`
+	Tier2Pct                 float64 `json:"tier2_pct" yaml:"tier2_pct"`
+	Tier3Pct                 float64 `json:"tier3_pct" yaml:"tier3_pct"`
+
+	Row int `json:"-" yaml:"-"` // Position in the input, for error messages
+}
+
+// pct converts a stored fraction to the percent used in records, without float noise.
+func pct(v float64) float64 {
+	return math.Round(v*100*1e6) / 1e6
+}
+
+// NewLevelRecord flattens a level and the tiers of its symbol (tiers may be nil).
+func NewLevelRecord(l *domain.Level, tiers *domain.SymbolTiers) LevelRecord {
+	key := l.ExternalKey
+	if key == "" {
+		key = l.ID
+	}
+	rec := LevelRecord{
+		Key:                      key,
+		ID:                       l.ID,
+		Exchange:                 l.Exchange,
+		Symbol:                   l.Symbol,
+		LevelPrice:               l.LevelPrice,
+		BaseSize:                 l.BaseSize,
+		Leverage:                 l.Leverage,
+		MarginType:               l.MarginType,
+		CoolDownMs:               l.CoolDownMs,
+		StopLossAtBase:           l.StopLossAtBase,
+		StopLossMode:             l.StopLossMode,
+		DisableSpeedClose:        l.DisableSpeedClose,
+		MaxConsecutiveBaseCloses: l.MaxConsecutiveBaseCloses,
+		BaseCloseCooldownMs:      l.BaseCloseCooldownMs,
+		TakeProfitPct:            pct(l.TakeProfitPct),
+		TakeProfitMode:           l.TakeProfitMode,
+		TakeProfitLadder:         domain.FormatTakeProfitLadder(l.TakeProfitLadder),
+		TrailingActivationPct:    pct(l.TrailingActivationPct),
+		TrailingStopPct:          pct(l.TrailingStopPct),
+		BreakEvenTier:            l.BreakEvenTier,
+		BreakEvenProfitPct:       pct(l.BreakEvenProfitPct),
+		TrailingStopMode:         l.TrailingStopMode,
+		EntryFilters:             strings.Join(l.EntryFilters, ","),
+		AutoModeEnabled:          l.AutoModeEnabled,
+		Mode:                     l.EffectiveMode(),
+		SizingMode:               l.EffectiveSizingMode(),
+		RiskPct:                  pct(l.RiskPct),
+		RiskUSD:                  l.RiskUSD,
+		MaxSlippagePct:           pct(l.MaxSlippagePct),
+		SlippageAction:           l.EffectiveSlippageAction(),
+		ExecAlgo:                 l.EffectiveExecAlgo(),
+		ExecDurationSec:          l.ExecDurationSec,
+		ExecClipSize:             l.ExecClipSize,
+		ExecMaxChasePct:          pct(l.ExecMaxChasePct),
+		ExecFallback:             l.EffectiveExecFallback(),
+		IsAuto:                   l.IsAuto,
+		Source:                   l.Source,
+		CreatedAt:                l.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if tiers != nil {
+		rec.Tier1Pct = pct(tiers.Tier1Pct)
+		rec.Tier2Pct = pct(tiers.Tier2Pct)
+		rec.Tier3Pct = pct(tiers.Tier3Pct)
+	}
+	return rec
+}
+
+// ApplyTo validates the record and writes it into the level. Blank modes get the same
+// defaults as the add-level form, numbers are kept as they are: a zero take profit stays
+// zero so an exported level re-imports unchanged.
+func (r LevelRecord) ApplyTo(l *domain.Level) error {
+	if r.Exchange == "" || r.Symbol == "" {
+		return fmt.Errorf("exchange and symbol are required")
+	}
+	if l.Exchange != "" && (l.Exchange != r.Exchange || l.Symbol != r.Symbol) {
+		return fmt.Errorf("cannot move level from %s/%s to %s/%s, delete and re-create it", l.Exchange, l.Symbol, r.Exchange, r.Symbol)
+	}
+	if r.BaseCloseCooldownMs < 0 {
+		return fmt.Errorf("base_close_cooldown_ms must not be negative")
+	}
+
+	patch := LevelPatch{
+		LevelPrice:               &r.LevelPrice,
+		BaseSize:                 &r.BaseSize,
+		Leverage:                 &r.Leverage,
+		MarginType:               &r.MarginType,
+		CoolDownMs:               &r.CoolDownMs,
+		StopLossAtBase:           &r.StopLossAtBase,
+		StopLossMode:             stringOr(r.StopLossMode, "exchange"),
+		DisableSpeedClose:        &r.DisableSpeedClose,
+		MaxConsecutiveBaseCloses: &r.MaxConsecutiveBaseCloses,
+		TakeProfitMode:           stringOr(r.TakeProfitMode, domain.TakeProfitFixed),
+		TakeProfitLadder:         &r.TakeProfitLadder,
+		TrailingActivationPct:    &r.TrailingActivationPct,
+		TrailingStopPct:          &r.TrailingStopPct,
+		BreakEvenTier:            &r.BreakEvenTier,
+		BreakEvenProfitPct:       &r.BreakEvenProfitPct,
+		TrailingStopMode:         stringOr(r.TrailingStopMode, "app"),
+		EntryFilters:             &r.EntryFilters,
+		AutoModeEnabled:          &r.AutoModeEnabled,
+		Mode:                     stringOr(r.Mode, domain.LevelModeLive),
+		SizingMode:               stringOr(r.SizingMode, domain.SizingFixed),
+		RiskPct:                  &r.RiskPct,
+		RiskUSD:                  &r.RiskUSD,
+		MaxSlippagePct:           &r.MaxSlippagePct,
+		SlippageAction:           stringOr(r.SlippageAction, domain.SlippageReject),
+		ExecAlgo:                 stringOr(r.ExecAlgo, domain.ExecMarket),
+		ExecDurationSec:          &r.ExecDurationSec,
+		ExecClipSize:             &r.ExecClipSize,
+		ExecMaxChasePct:          &r.ExecMaxChasePct,
+		ExecFallback:             stringOr(r.ExecFallback, domain.ExecFallbackMarket),
+	}
+	// Edits refuse a zero take profit, an imported one is written after the patch
+	if r.TakeProfitPct != 0 {
+		patch.TakeProfitPct = &r.TakeProfitPct
+	}
+	if err := patch.Apply(l); err != nil {
+		return err
+	}
+
+	if r.TakeProfitPct == 0 {
+		l.TakeProfitPct = 0
+	}
+	l.Exchange = r.Exchange
+	l.Symbol = r.Symbol
+	l.BaseCloseCooldownMs = r.BaseCloseCooldownMs
+	l.ExternalKey = r.Key
+	return nil
+}
+
+// Tiers returns the symbol tiers of the record, nil when the tier columns are empty.
+func (r LevelRecord) Tiers() *domain.SymbolTiers {
+	if r.Tier1Pct == 0 && r.Tier2Pct == 0 && r.Tier3Pct == 0 {
+		return nil
+	}
+	return &domain.SymbolTiers{
+		Exchange: r.Exchange,
+		Symbol:   r.Symbol,
+		Tier1Pct: r.Tier1Pct / 100,
+		Tier2Pct: r.Tier2Pct / 100,
+		Tier3Pct: r.Tier3Pct / 100,
+	}
+}
+
+func stringOr(v, def string) *string {
+	if v == "" {
+		v = def
+	}
+	return &v
+}
+
+// NormalizeFormat maps a format name or file extension to one of the Format constants.
+func NormalizeFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "", "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unsupported format %q (json, yaml or csv)", format)
+}
+
+// EncodeLevelRecords writes records in the given format.
+func EncodeLevelRecords(w io.Writer, format string, recs []LevelRecord) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recs)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(recs)
+	case FormatCSV:
+		return encodeLevelCSV(w, recs)
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// DecodeLevelRecords reads records in the given format. Rows that cannot be parsed are
+// returned as row errors, the other rows are still returned.
+func DecodeLevelRecords(r io.Reader, format string) ([]LevelRecord, []ImportRowError, error) {
+	var recs []LevelRecord
+	switch format {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&recs); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode json: %w", err)
+		}
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&recs); err != nil && err != io.EOF {
+			return nil, nil, fmt.Errorf("failed to decode yaml: %w", err)
+		}
+	case FormatCSV:
+		return decodeLevelCSV(r)
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q", format)
+	}
+	for i := range recs {
+		recs[i].Row = i + 1
+	}
+	return recs, nil, nil
+}
+
+// csvColumns returns the json names and field indexes of the record columns, in struct order.
+func csvColumns() ([]string, []int) {
+	t := reflect.TypeOf(LevelRecord{})
+	var names []string
+	var idx []int
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+		idx = append(idx, i)
+	}
+	return names, idx
+}
+
+func encodeLevelCSV(w io.Writer, recs []LevelRecord) error {
+	names, idx := csvColumns()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(names); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		v := reflect.ValueOf(rec)
+		row := make([]string, len(idx))
+		for i, fi := range idx {
+			row[i] = formatCSVValue(v.Field(fi))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSVValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return ""
+}
+
+// decodeLevelCSV matches columns by header name, unknown columns are ignored and
+// missing ones keep their zero value. Row numbers count data rows (the header is row 0).
+func decodeLevelCSV(r io.Reader) ([]LevelRecord, []ImportRowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	names, idx := csvColumns()
+	fieldByName := make(map[string]int, len(names))
+	for i, name := range names {
+		fieldByName[name] = idx[i]
+	}
+
+	var recs []LevelRecord
+	var rowErrs []ImportRowError
+	for row := 1; ; row++ {
+		cells, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		var rec LevelRecord
+		v := reflect.ValueOf(&rec).Elem()
+		var cellErr error
+		for col, cell := range cells {
+			if col >= len(header) {
+				break
+			}
+			fi, ok := fieldByName[strings.ToLower(strings.TrimSpace(header[col]))]
+			cell = strings.TrimSpace(cell)
+			if !ok || cell == "" {
+				continue
+			}
+			if err := setCSVValue(v.Field(fi), cell); err != nil {
+				cellErr = fmt.Errorf("column %s: %w", header[col], err)
+				break
+			}
+		}
+		rec.Row = row
+		if cellErr != nil {
+			rowErrs = append(rowErrs, ImportRowError{Row: row, Key: rec.Key, Error: cellErr.Error()})
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rowErrs, nil
+}
+
+func setCSVValue(v reflect.Value, cell string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(cell)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	}
+	return nil
+}
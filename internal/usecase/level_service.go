@@ -2,9 +2,12 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,9 +23,32 @@ type LevelService struct {
 	evaluator *LevelEvaluator
 	engine    *SublevelEngine
 	executor  *TradeExecutor
+	parents   *ExecutionManager // Works the parent orders of levels with an execution algorithm
+	ledger    *PositionLedger   // Per-level shares of the exchange positions
+	shadow    *PositionLedger   // Simulated shares of shadow levels
+	filters   *EntryFilterPipeline
+	sizer     *PositionSizer            // Risk sized levels
+	liqGuard  *LiquidationGuard         // Keeps the liquidation price of tier entries beyond the level
+	risk      *RiskManager              // Optional, live entries are skipped while it is halted
+	breakers  *CircuitBreakers          // Optional, live entries are skipped while one is open
+	fees      FeeModel                  // Fees of estimated fills and the take profit floor
+	onFill    func(order *domain.Order) // Set by the level strategy to report entries to the runtime
+	entries   sync.WaitGroup            // Parent order entries being worked in the background
+	exits     sync.WaitGroup            // Take profit exits being worked in the background
+
+	leases         *SymbolLeaseRegistry // Set by the level strategy, nil trades every symbol
+	leaseOwner     string
+	leaseConflicts map[string]string // symbol -> owner live levels wait for, for logging
+
+	shadowRepo domain.ShadowRepository   // Optional, persists the shadow ledger
+	journal    domain.DecisionRepository // Optional, the decision journal
 
 	mu         sync.RWMutex
-	lastPrices map[string]float64 // symbol -> price
+	lastPrices map[string]float64                // symbol -> price
+	paused     map[string]time.Time              // symbol -> pause end, zero until resumed
+	entering   map[string]map[string]domain.Side // symbol -> levelID -> side of the parent order entry being worked
+	exiting    map[string]bool                   // levelID -> take profit exit being worked
+	exitLocks  map[string]*sync.Mutex            // symbol -> held while an exit closes and books
 
 	// Cache
 	levelsCache map[string][]*domain.Level     // symbol -> levels
@@ -43,15 +69,33 @@ func NewLevelService(
 	exchange domain.Exchange,
 	market *MarketService,
 ) *LevelService {
+	executor := NewTradeExecutor(exchange)
 	return &LevelService{
-		levelRepo:     levelRepo,
-		tradeRepo:     tradeRepo,
-		exchange:      exchange,
-		market:        market,
-		evaluator:     NewLevelEvaluator(),
-		engine:        NewSublevelEngine(),
-		executor:      NewTradeExecutor(exchange),
+		levelRepo: levelRepo,
+		tradeRepo: tradeRepo,
+		exchange:  exchange,
+		market:    market,
+		evaluator: NewLevelEvaluator(),
+		engine:    NewSublevelEngine(),
+		executor:  executor,
+		parents:   NewExecutionManager(exchange, executor),
+		ledger:    NewPositionLedger(),
+		shadow:    NewPositionLedger(),
+		filters: NewEntryFilterPipeline(
+			NewSentimentFilter(),
+			NewTrendFilter(market),
+			NewSlippageFilter(exchange),
+			NewFundingBlackoutFilter(exchange),
+			NewVolatilityFilter(market),
+		),
+		sizer:         NewPositionSizer(exchange),
+		liqGuard:      NewLiquidationGuard(exchange),
+		fees:          DefaultFeeModel,
 		lastPrices:    make(map[string]float64),
+		paused:        make(map[string]time.Time),
+		entering:      make(map[string]map[string]domain.Side),
+		exiting:       make(map[string]bool),
+		exitLocks:     make(map[string]*sync.Mutex),
 		levelsCache:   make(map[string][]*domain.Level),
 		tiersCache:    make(map[string]*domain.SymbolTiers),
 		positionCache: make(map[string]*domain.Position),
@@ -66,6 +110,18 @@ func (s *LevelService) GetLatestPrice(symbol string) float64 {
 	return s.lastPrices[symbol]
 }
 
+// RegisterEntryFilter adds a custom entry filter (or replaces a built-in one with the same name).
+// Levels opt in by listing its name in EntryFilters.
+func (s *LevelService) RegisterEntryFilter(f EntryFilter) {
+	s.filters.Register(f)
+}
+
+// GetLevelPosition returns the level's share of the exchange position (zero size if flat)
+func (s *LevelService) GetLevelPosition(levelID string) LevelPosition {
+	p, _ := s.ledger.Get(levelID)
+	return p
+}
+
 // GetLevelState returns the current runtime state of a level
 func (s *LevelService) GetLevelState(levelID string) LevelState {
 	return s.engine.GetState(levelID)
@@ -125,11 +181,18 @@ func (s *LevelService) UpdateCache(ctx context.Context) error {
 	s.tiersCache = newTiersCache
 	s.mu.Unlock()
 
+	s.syncLeases(newLevelsCache)
 	return nil
 }
 
 // CreateLevel creates a new level
 func (s *LevelService) CreateLevel(ctx context.Context, level *domain.Level) error {
+	// 1. A live level trades the symbol position, another strategy must not own it
+	if level.IsLive() {
+		if err := s.checkSymbolLease(level.Symbol); err != nil {
+			return fmt.Errorf("cannot create live level: %w", err)
+		}
+	}
 
 	// 2. Save Level
 	if err := s.levelRepo.SaveLevel(ctx, level); err != nil {
@@ -140,6 +203,20 @@ func (s *LevelService) CreateLevel(ctx context.Context, level *domain.Level) err
 	return s.UpdateCache(ctx)
 }
 
+// DeleteLevel removes a level that does not hold a position. The simulated share of a
+// shadow level is simply dropped.
+func (s *LevelService) DeleteLevel(ctx context.Context, level *domain.Level) error {
+	if level.IsLive() && s.levelHasPosition(ctx, level) {
+		return fmt.Errorf("cannot delete level %s: %w", level.ID, ErrLevelHasPosition)
+	}
+	if err := s.levelRepo.DeleteLevel(ctx, level.ID); err != nil {
+		return fmt.Errorf("failed to delete level: %w", err)
+	}
+	s.engine.ResetState(level.ID)
+	s.shadow.Discard(level.ID)
+	return s.UpdateCache(ctx)
+}
+
 // GetAllSymbols returns all available symbols from the exchange
 func (s *LevelService) GetAllSymbols(ctx context.Context) ([]string, error) {
 	s.mu.RLock()
@@ -187,6 +264,9 @@ func (s *LevelService) getPosition(ctx context.Context, symbol string) (*domain.
 	if err != nil {
 		return nil, err
 	}
+	if pos == nil {
+		pos = &domain.Position{Symbol: symbol} // Flat
+	}
 
 	s.mu.Lock()
 	s.positionCache[symbol] = pos
@@ -211,11 +291,15 @@ func (s *LevelService) ProcessTick(ctx context.Context, exchangeName, symbol str
 	s.mu.Lock()
 	prevPrice, ok := s.lastPrices[symbol]
 	s.lastPrices[symbol] = price
+	breakers := s.breakers
 
 	// Read from cache while locked
 	levels := s.levelsCache[symbol]
 	tiers := s.tiersCache[symbol]
 	s.mu.Unlock()
+	if breakers != nil {
+		breakers.RecordPrice(symbol)
+	}
 
 	// if !ok {
 	// 	return nil
@@ -253,10 +337,10 @@ func (s *LevelService) ProcessTick(ctx context.Context, exchangeName, symbol str
 	sentimentThreshold := 0.6
 
 	// --- SENTIMENT-BASED EXIT LOGIC ---
-	// Check if any level for this symbol has DisableSpeedClose enabled
+	// Check if any live level for this symbol has DisableSpeedClose enabled
 	speedCloseDisabled := false
 	for _, l := range relevantLevels {
-		if l.DisableSpeedClose {
+		if l.DisableSpeedClose && l.IsLive() {
 			speedCloseDisabled = true
 			break
 		}
@@ -271,154 +355,19 @@ func (s *LevelService) ProcessTick(ctx context.Context, exchangeName, symbol str
 		})
 	}
 
-	// Check Position for Exit Logic (TP and Sentiment)
+	// Check Position for Exit Logic (TP and Sentiment). Shadow and alert levels never own
+	// part of the exchange position and do not move the live thresholds. The position belongs
+	// to whichever strategy owns the symbol, only that one may exit it.
+	live := liveLevels(relevantLevels)
+	owned := len(live) > 0 && s.ownsSymbol(symbol)
 	pos, err := s.getPosition(ctx, symbol)
-	if err == nil && pos.Size > 0 {
-
-		// --- STOP LOSS AT BASE LOGIC ---
-		// Find relevant level (closest to entry)
-		// We already found tpLevel, let's reuse or find again if needed.
-		// Actually, we need to check ALL relevant levels or just the closest?
-		// Usually just the one we are trading against.
-		// Let's reuse tpLevel logic but rename to relevantLevel for clarity.
-		var activeLevel *domain.Level
-		minDiff := 1e9
-		for _, l := range relevantLevels {
-			diff := pos.EntryPrice - l.LevelPrice
-			if diff < 0 {
-				diff = -diff
-			}
-			if diff < minDiff {
-				minDiff = diff
-				activeLevel = l
-			}
-		}
-
-		if activeLevel != nil {
-
-			// Check TP
-			// Check TP
-			if activeLevel.TakeProfitPct > 0 || activeLevel.TakeProfitMode == "liquidity" || activeLevel.TakeProfitMode == "sentiment" {
-				shouldTP := false
-				var tpPrice float64
-
-				if activeLevel.TakeProfitMode == "liquidity" {
-					// Dynamic TP based on liquidity
-					dynamicTP, err := s.CalculateLiquidityTP(ctx, symbol, pos.Side, pos.EntryPrice)
-					if err == nil && dynamicTP > 0 {
-						tpPrice = dynamicTP
-					} else {
-						// Fallback to fixed % if liquidity TP fails
-						if pos.Side == domain.SideLong {
-							tpPrice = pos.EntryPrice * (1 + activeLevel.TakeProfitPct)
-						} else {
-							tpPrice = pos.EntryPrice * (1 - activeLevel.TakeProfitPct)
-						}
-					}
-				} else if activeLevel.TakeProfitMode == "sentiment" {
-					// Sentiment-Adjusted TP
-					// TargetTP = BaseTP * (1 + (ConclusionScore * Factor))
-					// Factor = 0.5 (Adjustable? Hardcoded for now per plan)
-					baseTP := activeLevel.TakeProfitPct
-					if baseTP <= 0 {
-						baseTP = 0.02 // Default 2% if not set
-					}
+	if owned && err == nil && pos.Size > 0 {
 
-					stats, err := s.market.GetMarketStats(ctx, symbol)
-					score := 0.0
-					if err == nil && stats != nil {
-						score = stats.ConclusionScore
-					}
-
-					// Adjust TP
-					// If Long: Positive Score (Bullish) -> Increase TP. Negative Score (Bearish) -> Decrease TP.
-					// If Short: Negative Score (Bearish) -> Increase TP. Positive Score (Bullish) -> Decrease TP.
-					// Wait, Score is -1 (Bear) to 1 (Bull).
-					// For Long: Multiplier = 1 + (Score * 0.5)
-					//   Score 0.8 -> 1 + 0.4 = 1.4x TP.
-					//   Score -0.5 -> 1 - 0.25 = 0.75x TP.
-					// For Short: We want to INCREASE TP if Bearish (Score < 0).
-					//   Score -0.8 -> We want larger TP.
-					//   Multiplier = 1 - (Score * 0.5)
-					//   Score -0.8 -> 1 - (-0.4) = 1.4x TP.
-					//   Score 0.5 -> 1 - 0.25 = 0.75x TP.
-
-					factor := 0.5
-					multiplier := 1.0
-					if pos.Side == domain.SideLong {
-						multiplier = 1 + (score * factor)
-					} else {
-						multiplier = 1 - (score * factor)
-					}
-
-					// Clamp multiplier to avoid negative or too small TP?
-					// If score is extreme, e.g. -1. Multiplier = 0.5. TP becomes half.
-					// Seems safe.
-
-					adjustedPct := baseTP * multiplier
-					if adjustedPct < 0.001 {
-						adjustedPct = 0.001 // Minimum 0.1% TP
-					}
-
-					if pos.Side == domain.SideLong {
-						tpPrice = pos.EntryPrice * (1 + adjustedPct)
-					} else {
-						tpPrice = pos.EntryPrice * (1 - adjustedPct)
-					}
-
-				} else {
-					// Fixed TP
-					if pos.Side == domain.SideLong {
-						tpPrice = pos.EntryPrice * (1 + activeLevel.TakeProfitPct)
-					} else {
-						tpPrice = pos.EntryPrice * (1 - activeLevel.TakeProfitPct)
-					}
-				}
-
-				if pos.Side == domain.SideLong {
-					if price >= tpPrice {
-						log.Printf("TAKE PROFIT: LONG on %s. Price %f >= TP %f. Closing...", symbol, price, tpPrice)
-						shouldTP = true
-					}
-				} else if pos.Side == domain.SideShort {
-					if price <= tpPrice {
-						log.Printf("TAKE PROFIT: SHORT on %s. Price %f <= TP %f. Closing...", symbol, price, tpPrice)
-						shouldTP = true
-					}
-				}
-
-				if shouldTP {
-					if _, err := s.finalizePosition(ctx, symbol, "Take Profit", activeLevel.ID, price); err != nil {
-						log.Printf("Failed to finalize position on TP: %v", err)
-					}
-					// State update is now handled in finalizePosition
-					return nil
-				}
-			}
-
-			// Check Stop Loss at Base
-			if activeLevel.StopLossAtBase {
-				shouldSL := false
-				if pos.Side == domain.SideLong {
-					// Long: Close if Price <= LevelPrice
-					if price <= activeLevel.LevelPrice {
-						log.Printf("STOP LOSS (Base): LONG on %s. Price %f <= Level %f. Closing...", symbol, price, activeLevel.LevelPrice)
-						shouldSL = true
-					}
-				} else if pos.Side == domain.SideShort {
-					// Short: Close if Price >= LevelPrice
-					if price >= activeLevel.LevelPrice {
-						log.Printf("STOP LOSS (Base): SHORT on %s. Price %f >= Level %f. Closing...", symbol, price, activeLevel.LevelPrice)
-						shouldSL = true
-					}
-				}
-
-				if shouldSL {
-					if _, err := s.finalizePosition(ctx, symbol, "Stop Loss (Base)", activeLevel.ID, price); err != nil {
-						log.Printf("Failed to finalize position on SL: %v", err)
-					}
-					return nil
-				}
+		// Exits are evaluated per level, each against the share of the position it owns.
+		// One exit per tick, remaining levels are checked again on the next tick.
+		for _, exp := range s.attributePosition(symbol, pos, live) {
+			if s.checkLevelExits(ctx, exp.level, exp.pos, price) {
+				return nil
 			}
 		}
 
@@ -426,7 +375,7 @@ func (s *LevelService) ProcessTick(ctx context.Context, exchangeName, symbol str
 		if !speedCloseDisabled {
 			// Determine if in strict zone (within 1% of any level)
 			inStrictZone := false
-			for _, l := range relevantLevels {
+			for _, l := range live {
 				distance := (price - l.LevelPrice) / l.LevelPrice
 				if distance < 0 {
 					distance = -distance
@@ -453,12 +402,20 @@ func (s *LevelService) ProcessTick(ctx context.Context, exchangeName, symbol str
 			}
 
 			if shouldClose {
-				if _, err := s.finalizePosition(ctx, symbol, "Sentiment Exit", "sentiment-exit", price); err != nil {
+				inputs := domain.DecisionInputs{"sentiment": sentiment, "sentiment_threshold": sentimentThreshold, "strict_zone": inStrictZone}
+				if _, err := s.finalizePosition(ctx, symbol, "Sentiment Exit", "sentiment-exit", price, inputs); err != nil {
 					log.Printf("Failed to finalize position on sentiment: %v", err)
 				}
 				return nil
 			}
 		}
+	} else if owned && err == nil {
+		s.reconcileLedger(ctx, symbol)
+	}
+
+	// Simulated exits of shadow levels
+	if s.checkShadowExits(ctx, relevantLevels, price) {
+		return nil
 	}
 
 	if !ok {
@@ -482,18 +439,118 @@ func (s *LevelService) processLevel(ctx context.Context, level *domain.Level, ti
 	// 2. Calculate Boundaries
 	boundaries := s.evaluator.CalculateBoundaries(level, tiers, side)
 
-	// 3. Evaluate Trigger
-	action, size := s.engine.Evaluate(level, boundaries, prevPrice, currPrice, side)
+	// 3. Evaluate Trigger. Risk sized levels evaluate with a unit base size and scale below.
+	evalLevel := level
+	if level.UsesRiskSizing() {
+		unit := *level
+		unit.BaseSize = 1
+		evalLevel = &unit
+	}
+	action, size := s.engine.Evaluate(evalLevel, boundaries, prevPrice, currPrice, side)
 
 	if action != ActionNone {
-		// --- ENTRY FILTER ---
+		decision := levelDecision(domain.DecisionTierTrigger, level, currPrice)
+		decision.Side = side
+		decision.Size = size
+		decision.Reason = string(action)
+		decision.Inputs = entryInputs(level, tiers, boundaries, sentiment, sentimentThreshold, s.engine.GetState(level.ID))
+
+		// Another strategy owns the symbol position, live levels neither open nor close it
+		if level.IsLive() && !s.ownsSymbol(level.Symbol) {
+			decision.Outcome = domain.OutcomeSkipped
+			decision.Reason += ": symbol owned by " + s.symbolHolders(level.Symbol)
+			s.recordDecision(ctx, decision)
+			return
+		}
+
+		// --- ENTRY FILTERS & SIZING ---
 		if action == ActionOpen || action == ActionAddToPosition {
-			if side == domain.SideLong && sentiment < -sentimentThreshold {
-				log.Printf("SENTIMENT: Skipping LONG on %s. Sentiment is Bearish (%f).", level.Symbol, sentiment)
+			if reason := s.haltReason(); reason != "" && level.IsLive() {
+				log.Printf("Entry on %s skipped, trading is halted (Level: %s): %s", level.Symbol, level.ID, reason)
+				decision.Outcome = domain.OutcomeSkipped
+				decision.Reason += ": trading halted: " + reason
+				s.recordDecision(ctx, decision)
 				return
 			}
-			if side == domain.SideShort && sentiment > sentimentThreshold {
-				log.Printf("SENTIMENT: Skipping SHORT on %s. Sentiment is Bullish (%f).", level.Symbol, sentiment)
+			if err := s.breakerCheck(level.Symbol); err != nil && level.IsLive() {
+				log.Printf("BREAKER: Entry on %s skipped (Level: %s): %v", level.Symbol, level.ID, err)
+				decision.Outcome = domain.OutcomeSkipped
+				decision.Reason += ": " + err.Error()
+				s.recordDecision(ctx, decision)
+				return
+			}
+			if s.IsSymbolPaused(level.Symbol) {
+				log.Printf("Entry on %s skipped, symbol is paused (Level: %s)", level.Symbol, level.ID)
+				decision.Outcome = domain.OutcomeSkipped
+				decision.Reason += ": symbol paused"
+				s.recordDecision(ctx, decision)
+				return
+			}
+			if holder := s.opposingLevel(level.Symbol, side); holder != "" && level.IsLive() {
+				log.Printf("Entry on %s skipped, level %s holds the opposite side (Level: %s)", level.Symbol, holder, level.ID)
+				decision.Outcome = domain.OutcomeSkipped
+				decision.Reason += ": opposite side held by level " + holder
+				s.recordDecision(ctx, decision)
+				return
+			}
+			// Risk sized levels get their quantity before the filters, slippage checks the real size
+			if level.UsesRiskSizing() {
+				sizing, err := s.sizer.SizeOrder(ctx, level, boundaries, size, currPrice)
+				if sizing != nil {
+					decision.Inputs["sizing"] = sizing
+				}
+				if err != nil {
+					log.Printf("SIZING: Entry on %s skipped (level %s): %v", level.Symbol, level.ID, err)
+					decision.Outcome = domain.OutcomeSkipped
+					decision.Reason += ": sizing: " + err.Error()
+					s.recordDecision(ctx, decision)
+					return
+				}
+				log.Printf("SIZING: Level %s %s budget %.2f over ladder risk %f per unit -> %f %s", level.ID, sizing.Mode, sizing.RiskBudget, sizing.LadderRisk, sizing.Size, level.Symbol)
+				size = sizing.Size
+				decision.Size = size
+			}
+			// The level is the stop, liquidation must not come first
+			verdict, err := s.checkLiquidation(ctx, level, side, size, currPrice)
+			if err != nil {
+				log.Printf("LIQUIDATION: Check of %s entry failed, skipping (level %s): %v", level.Symbol, level.ID, err)
+				decision.Outcome = domain.OutcomeSkipped
+				decision.Reason += ": liquidation check: " + err.Error()
+				s.recordDecision(ctx, decision)
+				return
+			}
+			if verdict.LiquidationPrice > 0 {
+				decision.Inputs["liquidation"] = verdict
+			}
+			if !verdict.Allowed {
+				log.Printf("LIQUIDATION: Entry on %s refused (level %s): %s", level.Symbol, level.ID, verdict.Reason)
+				decision.Outcome = domain.OutcomeRejected
+				decision.Reason += ": liquidation guard: " + verdict.Reason
+				s.recordDecision(ctx, decision)
+				return
+			}
+			if verdict.Resized {
+				log.Printf("LIQUIDATION: Entry on %s %s (level %s)", level.Symbol, verdict.Reason, level.ID)
+				size = verdict.Size
+				decision.Size = size
+			}
+			if !s.checkEntryFilters(ctx, EntryRequest{
+				Level:              level,
+				Side:               side,
+				Size:               size,
+				Price:              currPrice,
+				Sentiment:          sentiment,
+				SentimentThreshold: sentimentThreshold,
+			}) {
+				verdicts := s.engine.GetState(level.ID).LastEntryDecisions
+				decision.Kind = domain.DecisionFilterRejection
+				decision.Outcome = domain.OutcomeRejected
+				if len(verdicts) > 0 {
+					last := verdicts[len(verdicts)-1]
+					decision.Reason = last.Filter + ": " + last.Reason
+				}
+				decision.Inputs["filters"] = verdicts
+				s.recordDecision(ctx, decision)
 				return
 			}
 		}
@@ -503,40 +560,106 @@ func (s *LevelService) processLevel(ctx context.Context, level *domain.Level, ti
 
 		if action == ActionClose {
 			// Close Position
-			_, err := s.finalizePosition(ctx, level.Symbol, "Level Cross", level.ID, currPrice)
+			_, err := s.finalizePosition(ctx, level.Symbol, "Level Cross", level.ID, currPrice, decision.Inputs)
 			if err != nil {
 				log.Printf("WARNING: Failed to finalize position for %s: %v", level.Symbol, err)
 			}
 			return
 		}
 
+		// Shadow and alert levels stop here, their fills go to the shadow ledger
+		if !level.IsLive() {
+			trade := s.recordShadowEntry(ctx, level, action, side, size, currPrice)
+			decision.Outcome = domain.OutcomeSignaled
+			if trade.Mode == domain.LevelModeShadow {
+				decision.Outcome = domain.OutcomeSimulated
+				decision.Inputs["fill_price"] = trade.Price
+			}
+			s.recordDecision(ctx, decision)
+			return
+		}
+
 		// 4. Execute Trade
 		stopLoss := 0.0
 		if level.StopLossAtBase && level.StopLossMode == "exchange" {
 			stopLoss = level.LevelPrice
 		}
 
-		err := s.executor.Execute(ctx, level.Symbol, side, size, level.Leverage, level.MarginType, stopLoss)
-		if err != nil {
-			log.Printf("Failed to execute trade: %v", err)
+		// Parent orders work for up to minutes, the symbol's ticks and exits must not wait on them
+		if level.EffectiveExecAlgo() != domain.ExecMarket {
+			s.setEntering(level, side, true)
+			s.entries.Add(1)
+			go func() {
+				defer s.entries.Done()
+				exec, err := s.executeEntryAlgo(ctx, level, side, size, stopLoss)
+				// Booked even when the runtime stopped meanwhile, the fill is on the exchange
+				s.bookEntry(context.WithoutCancel(ctx), level, side, size, currPrice, decision, exec, err)
+				s.setEntering(level, side, false)
+			}()
 			return
 		}
-		s.invalidatePositionCache(level.Symbol)
+		exec, err := s.executeEntry(ctx, level, side, size, stopLoss)
+		s.bookEntry(ctx, level, side, size, currPrice, decision, exec, err)
+	}
+}
 
-		// 5. Save Trade
-		order := &domain.Order{
-			Exchange:  level.Exchange,
-			Symbol:    level.Symbol,
-			LevelID:   level.ID,
-			Side:      side,
-			Size:      size,
-			Price:     currPrice,
-			CreatedAt: time.Now(),
+// bookEntry journals a tier entry and books what filled in the ledger and the trade history.
+func (s *LevelService) bookEntry(ctx context.Context, level *domain.Level, side domain.Side, size, currPrice float64, decision *domain.Decision, exec *Execution, err error) {
+	if exec != nil {
+		decision.Inputs["execution"] = exec
+	}
+	if errors.Is(err, ErrSlippageRejected) {
+		log.Printf("SLIPPAGE: Entry on %s rejected (level %s): %s", level.Symbol, level.ID, exec.Reason)
+		decision.Outcome = domain.OutcomeRejected
+		decision.Reason += ": slippage guard: " + exec.Reason
+		s.recordDecision(ctx, decision)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to execute trade: %v", err)
+		decision.Outcome = domain.OutcomeFailed
+		decision.Reason += ": " + err.Error()
+		s.recordDecision(ctx, decision)
+		return
+	}
+	fillPrice := currPrice
+	if exec != nil {
+		logFillSlippage(level.Symbol, side, exec, currPrice)
+		size = exec.Filled
+		decision.Size = size
+		if exec.FillPrice > 0 {
+			fillPrice = exec.FillPrice
 		}
+	}
+	decision.Outcome = domain.OutcomeExecuted
+	s.recordDecision(ctx, decision)
+	s.invalidatePositionCache(level.Symbol)
+	fee := s.entryFee(exec, size, fillPrice)
+	s.ledger.RecordFill(level.ID, level.Symbol, side, size, fillPrice, fee)
+
+	// Save Trade
+	order := &domain.Order{
+		Exchange:  level.Exchange,
+		Symbol:    level.Symbol,
+		LevelID:   level.ID,
+		Side:      side,
+		Size:      size,
+		Price:     currPrice,
+		Fee:       fee,
+		CreatedAt: time.Now(),
+	}
+	if exec != nil {
+		order.ExpectedPrice, order.FillPrice = exec.ExpectedPrice, exec.FillPrice
+	}
 
-		if err := s.tradeRepo.SaveTrade(ctx, order); err != nil {
-			log.Printf("Failed to save trade: %v", err)
-		}
+	if err := s.tradeRepo.SaveTrade(ctx, order); err != nil {
+		log.Printf("Failed to save trade: %v", err)
+	}
+	s.mu.RLock()
+	onFill := s.onFill
+	s.mu.RUnlock()
+	if onFill != nil {
+		onFill(order)
 	}
 }
 
@@ -558,63 +681,61 @@ func (s *LevelService) CheckSafety(ctx context.Context) {
 		if len(levels) == 0 {
 			continue
 		}
-
-		pos, err := s.getPosition(ctx, symbol)
-		if err != nil {
-			log.Printf("SAFETY: Failed to get position for %s: %v", symbol, err)
+		// A stale price says nothing about the level, no decision is better than a wrong one
+		price, fresh := s.safetyPrice(ctx, symbol)
+		if !fresh {
 			continue
 		}
+		s.checkShadowSafety(ctx, levels, price)
 
-		if pos.Size == 0 {
+		levels = liveLevels(levels)
+		if len(levels) == 0 || !s.ownsSymbol(symbol) {
 			continue
 		}
 
-		// Find the level closest to the Entry Price
-		var relevantLevel *domain.Level
-		minDiff := 1e9 // Infinity
-		for _, l := range levels {
-			diff := pos.EntryPrice - l.LevelPrice
-			if diff < 0 {
-				diff = -diff
-			}
-			if diff < minDiff {
-				minDiff = diff
-				relevantLevel = l
-			}
+		pos, err := s.getPosition(ctx, symbol)
+		if err != nil {
+			log.Printf("SAFETY: Failed to get position for %s: %v", symbol, err)
+			continue
 		}
 
-		if relevantLevel == nil {
+		if pos.Size == 0 {
 			continue
 		}
 
-		// Check Safety against this relevant level
-		price := s.GetLatestPrice(symbol)
+		// Check Safety against every level that owns part of the position
 		if price == 0 {
 			continue
 		}
 
-		shouldClose := false
-		if pos.Side == domain.SideLong {
-			// Long: Price should be > Level
-			// If Price < Level, we are losing and below base.
-			if price < relevantLevel.LevelPrice {
-				log.Printf("SAFETY: UNSAFE LONG on %s. Price %f < Level %f. Closing...", symbol, price, relevantLevel.LevelPrice)
-				shouldClose = true
-			}
-		} else if pos.Side == domain.SideShort {
-			// Short: Price should be < Level
-			// If Price > Level, we are losing and above base.
-			if price > relevantLevel.LevelPrice {
-				log.Printf("SAFETY: UNSAFE SHORT on %s. Price %f > Level %f. Closing...", symbol, price, relevantLevel.LevelPrice)
-				shouldClose = true
+		for _, exp := range s.attributePosition(symbol, pos, levels) {
+			relevantLevel := exp.level
+
+			shouldClose := false
+			if exp.pos.Side == domain.SideLong {
+				// Long: Price should be > Level
+				// If Price < Level, we are losing and below base.
+				if price < relevantLevel.LevelPrice {
+					log.Printf("SAFETY: UNSAFE LONG on %s (level %s). Price %f < Level %f. Closing...", symbol, relevantLevel.ID, price, relevantLevel.LevelPrice)
+					shouldClose = true
+				}
+			} else if exp.pos.Side == domain.SideShort {
+				// Short: Price should be < Level
+				// If Price > Level, we are losing and above base.
+				if price > relevantLevel.LevelPrice {
+					log.Printf("SAFETY: UNSAFE SHORT on %s (level %s). Price %f > Level %f. Closing...", symbol, relevantLevel.ID, price, relevantLevel.LevelPrice)
+					shouldClose = true
+				}
 			}
-		}
 
-		if shouldClose {
-			if _, err := s.finalizePosition(ctx, symbol, "Safety Exit", "safety-exit", price); err != nil {
-				log.Printf("SAFETY: Failed to finalize position for %s: %v", symbol, err)
-			} else {
-				log.Printf("SAFETY: Closed position for %s", symbol)
+			if shouldClose {
+				if _, err := s.finalizePosition(ctx, symbol, "Safety Exit", relevantLevel.ID, price, nil); err != nil {
+					log.Printf("SAFETY: Failed to finalize position for %s: %v", symbol, err)
+				} else {
+					log.Printf("SAFETY: Closed level %s share of %s", relevantLevel.ID, symbol)
+				}
+				// The position changed, remaining levels are checked on the next run
+				break
 			}
 		}
 	}
@@ -622,71 +743,562 @@ func (s *LevelService) CheckSafety(ctx context.Context) {
 
 // ClosePosition manually closes a position for a symbol
 func (s *LevelService) ClosePosition(ctx context.Context, symbol string) error {
-	_, err := s.finalizePosition(ctx, symbol, "Manual Close", "manual-close", s.GetLatestPrice(symbol))
+	_, err := s.finalizePosition(ctx, symbol, "Manual Close", "manual-close", s.GetLatestPrice(symbol), nil)
+	return err
+}
+
+// FlattenPosition closes the whole position of a symbol on behalf of source (e.g. the kill
+// switch), with the level bookkeeping a regular exit gets. Symbols without a tick yet are
+// priced at the mark price.
+func (s *LevelService) FlattenPosition(ctx context.Context, symbol, reason, source string) error {
+	price := s.GetLatestPrice(symbol)
+	if price == 0 {
+		if pos, err := s.getPosition(ctx, symbol); err == nil && pos != nil {
+			price = pos.CurrentPrice
+		}
+	}
+	_, err := s.finalizePosition(ctx, symbol, reason, source, price, nil)
 	return err
 }
 
+// checkEntryFilters runs the level's filter chain and records the decisions on the level state.
+func (s *LevelService) checkEntryFilters(ctx context.Context, req EntryRequest) bool {
+	chain := req.Level.EntryFilterChain()
+	if len(chain) == 0 {
+		return true
+	}
+
+	ok, decisions := s.filters.Evaluate(ctx, chain, req)
+	s.engine.UpdateState(req.Level.ID, func(ls *LevelState) {
+		ls.LastEntryDecisions = decisions
+	})
+
+	if !ok {
+		last := decisions[len(decisions)-1]
+		log.Printf("ENTRY FILTER: Skipping %s on %s (level %s). %s: %s", req.Side, req.Level.Symbol, req.Level.ID, last.Filter, last.Reason)
+	}
+	return ok
+}
+
+// levelExposure pairs a level with the part of the exchange position it owns.
+type levelExposure struct {
+	level *domain.Level
+	pos   *domain.Position
+}
+
+// attributePosition splits the exchange position into the shares owned by each level.
+// If the ledger has nothing for this symbol (e.g. the position predates a restart),
+// the whole position is attributed to the level closest to the entry price.
+func (s *LevelService) attributePosition(symbol string, pos *domain.Position, levels []*domain.Level) []levelExposure {
+	byID := make(map[string]*domain.Level, len(levels))
+	for _, l := range levels {
+		byID[l.ID] = l
+	}
+
+	s.syncSoleShare(symbol, pos)
+
+	var exposures []levelExposure
+	for _, share := range s.ledger.OpenBySymbol(symbol) {
+		l, ok := byID[share.LevelID]
+		if !ok {
+			continue // Level deleted while holding a share
+		}
+		sharePos := *pos
+		sharePos.Side = share.Side
+		sharePos.Size = share.Size
+		sharePos.EntryPrice = share.EntryPrice
+		exposures = append(exposures, levelExposure{level: l, pos: &sharePos})
+	}
+	if len(exposures) > 0 {
+		return exposures
+	}
+
+	// Fallback: the level closest to the entry owns everything
+	var closest *domain.Level
+	minDiff := 1e9 // Infinity
+	for _, l := range levels {
+		diff := pos.EntryPrice - l.LevelPrice
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < minDiff {
+			minDiff = diff
+			closest = l
+		}
+	}
+	if closest == nil {
+		return nil
+	}
+	return []levelExposure{{level: closest, pos: pos}}
+}
+
+// opposingLevel returns a level that holds, or is still working an entry for, the other side
+// of side on symbol, "" if none. The exchange nets a one-way position, an entry against another
+// level's share would close that share instead of opening one of its own.
+func (s *LevelService) opposingLevel(symbol string, side domain.Side) string {
+	if shares := s.ledger.Opposing(symbol, side); len(shares) > 0 {
+		return shares[0].LevelID
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for id, entering := range s.entering[symbol] {
+		if entering != side {
+			return id
+		}
+	}
+	return ""
+}
+
+// setEntering marks the parent order entry of a level as being worked, or as booked.
+func (s *LevelService) setEntering(level *domain.Level, side domain.Side, working bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !working {
+		delete(s.entering[level.Symbol], level.ID)
+		return
+	}
+	if s.entering[level.Symbol] == nil {
+		s.entering[level.Symbol] = make(map[string]domain.Side)
+	}
+	s.entering[level.Symbol][level.ID] = side
+}
+
+// syncSoleShare uses the exchange entry price for a share that owns the whole position.
+// Our fills are recorded at the tick price, the exchange knows the real average fill.
+func (s *LevelService) syncSoleShare(symbol string, pos *domain.Position) {
+	if pos == nil || pos.Size == 0 {
+		return
+	}
+	shares := s.ledger.OpenBySymbol(symbol)
+	if len(shares) != 1 || shares[0].Side != pos.Side {
+		return
+	}
+	const sizeTolerance = 1e-9
+	if diff := shares[0].Size - pos.Size; diff > sizeTolerance || diff < -sizeTolerance {
+		return
+	}
+	s.ledger.SyncEntry(shares[0].LevelID, pos.EntryPrice)
+}
+
+// reconcileLedger settles the shares of a symbol the exchange reports as flat. A share whose
+// level set an exchange-side stop was closed by it and is booked at the stop price, the stop
+// was only set once the exchange reported the position. Other shares were closed outside the
+// bot (by hand, liquidation) and are dropped. Recent fills are left alone because the
+// position endpoint can lag behind the order.
+func (s *LevelService) reconcileLedger(ctx context.Context, symbol string) {
+	lock := s.exitLock(symbol)
+	if !lock.TryLock() {
+		return // An exit is closing the shares
+	}
+	defer lock.Unlock()
+
+	const settleTime = 5 * time.Second
+	for _, share := range s.ledger.OpenBySymbol(symbol) {
+		if st := s.engine.GetState(share.LevelID); st.ExchangeStopSet {
+			s.bookExchangeStop(ctx, share, st)
+			continue
+		}
+		if time.Since(share.UpdatedAt) < settleTime {
+			continue
+		}
+		log.Printf("LEDGER: %s is flat on the exchange, dropping %s share of level %s (%f @ %f)", symbol, share.Side, share.LevelID, share.Size, share.EntryPrice)
+		s.ledger.Discard(share.LevelID)
+	}
+}
+
+// bookExchangeStop books a share the level's exchange-side stop closed, at the stop price.
+func (s *LevelService) bookExchangeStop(ctx context.Context, share LevelPosition, st LevelState) {
+	price, reason := protectiveStop(st, share.Side == domain.SideLong)
+	if price == 0 {
+		price = s.GetLatestPrice(share.Symbol)
+	}
+	log.Printf("LEDGER: %s is flat on the exchange, booking %s share of level %s (%f @ %f) as closed by its %s at %f", share.Symbol, share.Side, share.LevelID, share.Size, share.EntryPrice, reason, price)
+
+	ex := exitClose{symbol: share.Symbol, levelID: share.LevelID, reason: reason, price: price, exchange: "unknown"}
+	if level := s.cachedLevel(share.Symbol, share.LevelID); level != nil {
+		ex.exchange, ex.leverage, ex.marginType = level.Exchange, level.Leverage, level.MarginType
+	}
+	ex.exitFeeRate = s.FeeModel().Rates.Fee(price, false) // The stop fills at market
+	snapshots := map[string]map[string]interface{}{share.LevelID: stateSnapshot(st)}
+	s.engine.ResetState(share.LevelID)
+
+	c := s.bookShareClose(ctx, ex, share)
+	inputs := domain.DecisionInputs{"stop_price": price, "entry_price": share.EntryPrice, "exchange_stop": true}
+	s.journalExit(ctx, ex, share.Side, []levelClose{c}, inputs, snapshots)
+	s.recordExitOutcome(share.Symbol, share.LevelID, reason, c.pnl-c.fees, price)
+}
+
 // finalizePosition handles the common logic for closing a position, calculating PnL, and saving history.
-func (s *LevelService) finalizePosition(ctx context.Context, symbol, reason, levelID string, price float64) (float64, error) {
-	// 1. Fetch position details
-	pos, err := s.getPosition(ctx, symbol)
-	if err != nil || pos == nil || pos.Size == 0 {
+// If levelID owns a share of the position and other levels still hold theirs, only that share is
+// reduced and only that level is reset. Symbol-wide exits (manual, sentiment) close everything and
+// write one history row per level share. History and trade rows keep the gross PnL next to the
+// fees, the returned PnL and the win streaks are net of fees.
+// Take profits worked by an execution algorithm close in the background and return a zero PnL,
+// the exits of a symbol close and book one at a time.
+func (s *LevelService) finalizePosition(ctx context.Context, symbol, reason, levelID string, price float64, inputs domain.DecisionInputs) (float64, error) {
+	// Exits of shadow and alert levels never touch the exchange
+	if level := s.cachedLevel(symbol, levelID); level != nil && !level.IsLive() {
+		return s.closeShadowShare(ctx, level, reason, price, inputs)
+	}
+
+	// Parent orders work for up to minutes, the symbol's ticks and stops must not wait on them
+	if _, algo := s.exitAlgo(symbol, levelID, reason); algo != domain.ExecMarket {
+		if !s.startExit(levelID) {
+			return 0, nil // Already being worked
+		}
+		s.exits.Add(1)
+		go func() {
+			defer s.exits.Done()
+			defer s.finishExit(levelID)
+			// Booked even when the runtime stopped meanwhile, the fills are on the exchange
+			if _, err := s.closeShares(context.WithoutCancel(ctx), symbol, reason, levelID, price, inputs); err != nil {
+				log.Printf("FINALIZE: %s exit of level %s failed: %v", symbol, levelID, err)
+			}
+		}()
+		return 0, nil
+	}
+
+	// An exit still being worked sends its rest at market now instead of holding this one up
+	s.parents.CancelExits(symbol, CancelledByMarketExit)
+	return s.closeShares(ctx, symbol, reason, levelID, price, inputs)
+}
+
+// closeShares closes and books the shares of an exit, see finalizePosition.
+func (s *LevelService) closeShares(ctx context.Context, symbol, reason, levelID string, price float64, inputs domain.DecisionInputs) (float64, error) {
+	lock := s.exitLock(symbol)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// 1. Stop entries still being worked, then fetch position details
+	s.parents.CancelEntries(symbol, CancelledByExit)
+	pos, posErr := s.getPosition(ctx, symbol)
+	if posErr != nil {
+		log.Printf("FINALIZE: Warning: Position of %s unknown when closing (%s): %v. Proceeding to ensure close.", symbol, reason, posErr)
+	} else if pos == nil || pos.Size == 0 {
 		log.Printf("FINALIZE: Warning: No active position found for %s when closing (%s). Proceeding to ensure close.", symbol, reason)
 		// We still try to close on exchange to be safe
 	}
 
-	// 2. Close on Exchange
-	if err := s.exchange.ClosePosition(ctx, symbol); err != nil {
+	// 2. Work out which level shares this exit closes. A level exit that waited on another
+	// exit may find its share closed already.
+	closing, partial := s.exitShares(symbol, levelID, pos)
+	if s.shareClosed(symbol, levelID, pos, posErr, closing) {
+		log.Printf("FINALIZE: Level %s holds no share of %s anymore, nothing to close (%s)", levelID, symbol, reason)
+		return 0, nil
+	}
+
+	// 3. Close on Exchange, the level's execution algorithm first, the rest at market
+	exit, closeErr := s.closeOnExchange(ctx, symbol, levelID, reason, pos, closing, partial)
+	if partial && closeErr != nil {
+		// The share is still on the exchange next to the other levels' shares. Only what the
+		// execution algorithm closed is booked, the rest stays so the exit is tried again.
+		s.invalidatePositionCache(symbol)
+		s.bookPartialExit(ctx, symbol, levelID, reason, pos, closing[0], exit, price)
+		return 0, fmt.Errorf("failed to reduce %s by the share of level %s: %w", symbol, levelID, closeErr)
+	}
+	if posErr != nil && closeErr != nil {
+		// Neither read nor closed, the position may well still be open. Keep the state so the
+		// exit is tried again instead of booking it as flat.
+		s.invalidatePositionCache(symbol)
+		return 0, fmt.Errorf("failed to close %s, position unknown: %w", symbol, closeErr)
+	}
+
+	// 4. Invalidate Cache
+	s.invalidatePositionCache(symbol)
+
+	// 5. Reset State, keeping what the journal needs first
+	levels, snapshots := s.resetExitState(symbol, levelID, closing, partial)
+
+	// 6. Calculate PnL and Save History, per level share
+	ex := exitClose{symbol: symbol, levelID: levelID, reason: reason, price: price, partial: partial, exchange: "unknown"}
+	if pos != nil {
+		ex.exchange, ex.leverage, ex.marginType = pos.Exchange, pos.Leverage, pos.MarginType
+	} else if len(levels) > 0 {
+		ex.exchange = levels[0].Exchange
+	}
+	ex.exitFeeRate = s.exitFeeRate(closing, partial, pos, exit, price)
+
+	var closes []levelClose
+	side := domain.Side("UNKNOWN")
+	if len(closing) > 0 {
+		side = closing[0].Side
+		for _, share := range closing {
+			closes = append(closes, s.bookShareClose(ctx, ex, share))
+		}
+	} else {
+		// Unattributed position (e.g. opened before a restart), the whole thing goes to levelID
+		if pos != nil && pos.Size > 0 {
+			side = pos.Side
+		}
+		closes = append(closes, s.bookUnattributedClose(ctx, ex, pos, side))
+	}
+
+	var realizedPnL, fees float64
+	for _, c := range closes {
+		realizedPnL += c.pnl
+		fees += c.fees
+	}
+	netPnL := realizedPnL - fees
+	log.Printf("FINALIZE: Closed %s on %s. Reason: %s. PnL: %f, fees: %f, net: %f (partial: %v)", side, symbol, reason, realizedPnL, fees, netPnL, partial)
+
+	// 7. Journal the exit per level
+	s.journalExit(ctx, ex, side, closes, inputs, snapshots)
+
+	// 8. Update Level State (Centralized Logic)
+	for _, c := range closes {
+		s.recordExitOutcome(symbol, c.levelID, reason, c.pnl-c.fees, price)
+	}
+
+	return netPnL, nil
+}
+
+// exitClose is what every level share of one exit is booked with.
+type exitClose struct {
+	symbol      string
+	levelID     string // The level the exit is for
+	reason      string
+	price       float64
+	partial     bool // Other levels keep their shares
+	exchange    string
+	leverage    int
+	marginType  string
+	exitFeeRate float64 // Per unit closed
+}
+
+// levelClose is the booked result of one level in an exit.
+type levelClose struct {
+	levelID string
+	size    float64 // Share closed, zero for an unattributed position
+	pnl     float64 // Before fees
+	fees    float64 // Entry and exit
+}
+
+// shareClosed reports whether a live level's exit finds nothing of its own to close: the
+// ledger holds the shares of other levels only, or the exchange is flat and the ledger empty.
+func (s *LevelService) shareClosed(symbol, levelID string, pos *domain.Position, posErr error, closing []LevelPosition) bool {
+	if s.cachedLevel(symbol, levelID) == nil {
+		return false // Symbol-wide exit
+	}
+	for _, share := range closing {
+		if share.LevelID == levelID {
+			return false
+		}
+	}
+	if len(closing) > 0 {
+		return true
+	}
+	return posErr == nil && (pos == nil || pos.Size == 0)
+}
+
+// startExit marks a level's take profit exit as being worked, false if it already is.
+func (s *LevelService) startExit(levelID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exiting[levelID] {
+		return false
+	}
+	s.exiting[levelID] = true
+	return true
+}
+
+func (s *LevelService) finishExit(levelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exiting, levelID)
+}
+
+// exitWorking reports whether a level's take profit exit is being worked.
+func (s *LevelService) exitWorking(levelID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exiting[levelID]
+}
+
+// exitLock returns the lock a symbol's exits close and book under.
+func (s *LevelService) exitLock(symbol string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lock, ok := s.exitLocks[symbol]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.exitLocks[symbol] = lock
+	}
+	return lock
+}
+
+// exitShares returns the level shares an exit closes: the level's own share when other
+// levels hold shares too (a partial close), every share otherwise.
+func (s *LevelService) exitShares(symbol, levelID string, pos *domain.Position) ([]LevelPosition, bool) {
+	s.syncSoleShare(symbol, pos)
+	shares := s.ledger.OpenBySymbol(symbol)
+	for _, share := range shares {
+		if share.LevelID == levelID {
+			return []LevelPosition{share}, len(shares) > 1
+		}
+	}
+	return shares, false
+}
+
+// closeOnExchange closes the shares with the level's execution algorithm and sends what it
+// left at market. Returns the exit parent order, zero when the exit went at market.
+func (s *LevelService) closeOnExchange(ctx context.Context, symbol, levelID, reason string, pos *domain.Position, closing []LevelPosition, partial bool) (ParentOrder, error) {
+	if partial {
+		exit := s.workExit(ctx, symbol, levelID, reason, pos, closing[0].Size)
+		rest := closing[0].Size - exit.Filled
+		if rest <= 1e-9 {
+			return exit, nil
+		}
+		err := s.exchange.ReducePosition(ctx, symbol, rest)
+		if err != nil {
+			log.Printf("FINALIZE: Failed to reduce position for %s by %f: %v. Keeping the share to retry.", symbol, rest, err)
+		}
+		return exit, err
+	}
+
+	var exit ParentOrder
+	if pos != nil {
+		exit = s.workExit(ctx, symbol, levelID, reason, pos, pos.Size)
+	}
+	// Closes what the algorithm left, a no-op when it closed everything
+	err := s.exchange.ClosePosition(ctx, symbol)
+	if err != nil {
 		log.Printf("FINALIZE: Failed to close position for %s: %v. Proceeding with state reset.", symbol, err)
 		// We proceed to reset state to avoid getting stuck, assuming the position might be closed manually or liquidated.
 	}
+	return exit, err
+}
 
-	// 3. Invalidate Cache
-	s.invalidatePositionCache(symbol)
+// bookPartialExit books the part of a level's share the execution algorithm closed before the
+// rest of a partial exit failed to reduce.
+func (s *LevelService) bookPartialExit(ctx context.Context, symbol, levelID, reason string, pos *domain.Position, share LevelPosition, exit ParentOrder, price float64) {
+	if exit.Filled <= 0 {
+		return
+	}
+	level := s.cachedLevel(symbol, levelID)
+	if level == nil {
+		level = &domain.Level{ID: levelID, Symbol: symbol}
+		if pos != nil {
+			level.Exchange = pos.Exchange
+		}
+	}
+	sharePos := &domain.Position{Symbol: symbol, Side: share.Side, Size: share.Size, EntryPrice: share.EntryPrice}
+	if pos != nil {
+		sharePos.Leverage, sharePos.MarginType = pos.Leverage, pos.MarginType
+	}
+	s.recordPartialClose(ctx, level, sharePos, exit.Filled, price, reason)
+}
 
-	// 4. Reset State for all levels of this symbol
+// resetExitState resets the tier state of the levels an exit closes and returns the live
+// levels of the symbol and the state of each closed level from before the reset.
+func (s *LevelService) resetExitState(symbol, levelID string, closing []LevelPosition, partial bool) ([]*domain.Level, map[string]map[string]interface{}) {
 	s.mu.RLock()
-	levels := s.levelsCache[symbol]
+	levels := liveLevels(s.levelsCache[symbol])
 	s.mu.RUnlock()
 
-	for _, l := range levels {
-		// ResetState clears triggers and active side but PRESERVES ConsecutiveWins.
-		// This is safe to call here as we want to reset the level for a fresh start after a position close.
-		s.engine.ResetState(l.ID)
+	snapshots := make(map[string]map[string]interface{})
+	for _, share := range closing {
+		snapshots[share.LevelID] = stateSnapshot(s.engine.GetState(share.LevelID))
+	}
+	if _, ok := snapshots[levelID]; !ok {
+		snapshots[levelID] = stateSnapshot(s.engine.GetState(levelID))
 	}
 
-	// 5. Calculate PnL and Save History
-	var realizedPnL float64
-	var side domain.Side = "UNKNOWN"
-	var size float64
-	var entryPrice float64
-	var leverage int
-	var marginType string
+	if partial {
+		// The other levels keep their tiers, only the owner starts fresh
+		s.engine.ResetState(levelID)
+	} else {
+		for _, l := range levels {
+			// ResetState clears triggers and active side but PRESERVES ConsecutiveWins.
+			// This is safe to call here as we want to reset the level for a fresh start after a position close.
+			s.engine.ResetState(l.ID)
+		}
+	}
+	return levels, snapshots
+}
 
-	if pos != nil && pos.Size > 0 {
-		side = pos.Side
-		size = pos.Size
-		entryPrice = pos.EntryPrice
-		leverage = pos.Leverage
-		marginType = pos.MarginType
+// exitFeeRate is the exit fee per unit: the algorithm's fills as booked, the rest as a
+// taker fill at price.
+func (s *LevelService) exitFeeRate(closing []LevelPosition, partial bool, pos *domain.Position, exit ParentOrder, price float64) float64 {
+	exited := 0.0
+	for _, share := range closing {
+		exited += share.Size
+	}
+	if !partial && pos != nil && pos.Size > exited {
+		exited = pos.Size
+	}
+	if exited <= 0 {
+		return 0
+	}
+	rest := math.Max(exited-exit.PricedSize, 0)
+	return (exit.Fees + s.FeeModel().Rates.Fee(rest*price, false)) / exited
+}
+
+// bookShareClose takes a level share out of the ledger and saves its history row and close
+// trade.
+func (s *LevelService) bookShareClose(ctx context.Context, ex exitClose, share LevelPosition) levelClose {
+	size, pnl, fees := s.ledger.Reduce(share.LevelID, share.Size, ex.price, ex.exitFeeRate*share.Size)
+
+	history := &domain.PositionHistory{
+		Exchange:    ex.exchange,
+		Symbol:      ex.symbol,
+		Side:        share.Side,
+		Size:        size,
+		EntryPrice:  share.EntryPrice,
+		ExitPrice:   ex.price,
+		RealizedPnL: pnl,
+		Fees:        fees,
+		Leverage:    ex.leverage,
+		MarginType:  ex.marginType,
+		LevelID:     share.LevelID,
+		Reason:      ex.reason,
+		Partial:     ex.partial,
+		ClosedAt:    time.Now(),
+	}
+	if err := s.tradeRepo.SavePositionHistory(ctx, history); err != nil {
+		log.Printf("Failed to save position history: %v", err)
+	}
 
+	s.tradeRepo.SaveTrade(ctx, &domain.Order{
+		Exchange:    ex.exchange,
+		Symbol:      ex.symbol,
+		LevelID:     share.LevelID,
+		Side:        share.Side,
+		Size:        0, // Close marker
+		Price:       ex.price,
+		RealizedPnL: pnl,
+		Fee:         fees,
+		CreatedAt:   time.Now(),
+	})
+	return levelClose{levelID: share.LevelID, size: share.Size, pnl: pnl, fees: fees}
+}
+
+// bookUnattributedClose books a position no level share accounts for to the exit's level,
+// from the exchange's entry price.
+func (s *LevelService) bookUnattributedClose(ctx context.Context, ex exitClose, pos *domain.Position, side domain.Side) levelClose {
+	c := levelClose{levelID: ex.levelID}
+	if pos != nil && pos.Size > 0 {
 		if side == domain.SideLong {
-			realizedPnL = (price - entryPrice) * size
+			c.pnl = (ex.price - pos.EntryPrice) * pos.Size
 		} else {
-			realizedPnL = (entryPrice - price) * size
+			c.pnl = (pos.EntryPrice - ex.price) * pos.Size
 		}
+		// The entry fee is unknown, taken as a taker fill
+		c.fees = s.FeeModel().Rates.Fee(pos.Size*pos.EntryPrice, false) + ex.exitFeeRate*pos.Size
 
 		// Save Position History
 		history := &domain.PositionHistory{
 			Exchange:    pos.Exchange,
 			Symbol:      pos.Symbol,
 			Side:        side,
-			Size:        size,
-			EntryPrice:  entryPrice,
-			ExitPrice:   price,
-			RealizedPnL: realizedPnL,
-			Leverage:    leverage,
-			MarginType:  marginType,
+			Size:        pos.Size,
+			EntryPrice:  pos.EntryPrice,
+			ExitPrice:   ex.price,
+			RealizedPnL: c.pnl,
+			Fees:        c.fees,
+			Leverage:    ex.leverage,
+			MarginType:  ex.marginType,
+			LevelID:     ex.levelID,
+			Reason:      ex.reason,
 			ClosedAt:    time.Now(),
 		}
 		if err := s.tradeRepo.SavePositionHistory(ctx, history); err != nil {
@@ -694,103 +1306,565 @@ func (s *LevelService) finalizePosition(ctx context.Context, symbol, reason, lev
 		}
 	}
 
-	// 6. Log Trade (Close)
-	exchangeName := "unknown"
-	if pos != nil {
-		exchangeName = pos.Exchange
-	} else if len(levels) > 0 {
-		exchangeName = levels[0].Exchange
-	}
-
+	// Log Trade (Close)
 	s.tradeRepo.SaveTrade(ctx, &domain.Order{
-		Exchange:    exchangeName,
-		Symbol:      symbol,
-		LevelID:     levelID,
+		Exchange:    ex.exchange,
+		Symbol:      ex.symbol,
+		LevelID:     ex.levelID,
 		Side:        side,
 		Size:        0, // Close marker
-		Price:       price,
-		RealizedPnL: realizedPnL,
+		Price:       ex.price,
+		RealizedPnL: c.pnl,
+		Fee:         c.fees,
 		CreatedAt:   time.Now(),
 	})
+	return c
+}
 
-	log.Printf("FINALIZE: Closed %s on %s. Reason: %s. PnL: %f", side, symbol, reason, realizedPnL)
+// journalExit records the exit decision of every level it closed.
+func (s *LevelService) journalExit(ctx context.Context, ex exitClose, side domain.Side, closes []levelClose, inputs domain.DecisionInputs, snapshots map[string]map[string]interface{}) {
+	outcome := domain.OutcomeClosed
+	if ex.partial {
+		outcome = domain.OutcomePartial
+	}
+	for _, c := range closes {
+		decision := &domain.Decision{
+			Kind:     domain.DecisionExit,
+			LevelID:  c.levelID,
+			Exchange: ex.exchange,
+			Symbol:   ex.symbol,
+			Side:     side,
+			Price:    ex.price,
+			Reason:   ex.reason,
+			Outcome:  outcome,
+			Size:     c.size,
+		}
+		if level := s.cachedLevel(ex.symbol, c.levelID); level != nil {
+			decision.LevelPrice = level.LevelPrice
+		}
+		decision.Inputs = exitInputs(inputs, c.pnl, c.fees, ex.partial, snapshots[c.levelID])
+		s.recordDecision(ctx, decision)
+	}
+}
 
-	// 7. Update Level State (Centralized Logic)
+// recordExitOutcome updates the win and base-close streaks of a level after an exit,
+// and kicks off the auto split once the level hits its max base closes.
+func (s *LevelService) recordExitOutcome(symbol, levelID, reason string, realizedPnL, price float64) {
 	// We only update state if we have a valid levelID
-	if levelID != "" && levelID != "unknown" {
-		// Find the activeLevel before entering the callback to avoid nested locking
-		var activeLevel *domain.Level
-		s.mu.RLock()
-		if levels, ok := s.levelsCache[symbol]; ok {
-			for _, l := range levels {
-				if l.ID == levelID {
-					activeLevel = l
-					break
-				}
+	if levelID == "" || levelID == "unknown" {
+		return
+	}
+
+	// Find the activeLevel before entering the callback to avoid nested locking
+	var activeLevel *domain.Level
+	s.mu.RLock()
+	if levels, ok := s.levelsCache[symbol]; ok {
+		for _, l := range levels {
+			if l.ID == levelID {
+				activeLevel = l
+				break
 			}
 		}
-		s.mu.RUnlock()
+	}
+	s.mu.RUnlock()
 
-		s.engine.UpdateState(levelID, func(ls *LevelState) {
-			// 1. Check for Base Close (Priority)
-			isBaseClose := false
-			if activeLevel != nil {
-				// Use 0.2% tolerance to account for slippage/spread
-				const epsilon = 0.002
-				dist := (price - activeLevel.LevelPrice) / activeLevel.LevelPrice
-				if dist < 0 {
-					dist = -dist
-				}
-				if dist <= epsilon {
-					isBaseClose = true
+	var disabledUntil time.Time
+	s.engine.UpdateState(levelID, func(ls *LevelState) {
+		// 1. Check for Base Close (Priority)
+		isBaseClose := false
+		if activeLevel != nil {
+			// Use 0.2% tolerance to account for slippage/spread
+			const epsilon = 0.002
+			dist := (price - activeLevel.LevelPrice) / activeLevel.LevelPrice
+			if dist < 0 {
+				dist = -dist
+			}
+			if dist <= epsilon {
+				isBaseClose = true
+			}
+		}
+
+		if isBaseClose && (reason == "Stop Loss (Base)" || reason == "Level Cross" || reason == "Safety Exit") {
+			ls.ConsecutiveBaseCloses++
+			if realizedPnL > 0 {
+				ls.ConsecutiveWins++
+				log.Printf("AUDIT: Base Close (Win) recorded for Level %s. Consecutive Wins: %d", levelID, ls.ConsecutiveWins)
+			} else {
+				ls.ConsecutiveWins = 0 // Reset wins on loss
+			}
+			log.Printf("AUDIT: Base Close recorded for Level %s. Count: %d (PnL: %f)", levelID, ls.ConsecutiveBaseCloses, realizedPnL)
+
+			if activeLevel != nil && activeLevel.MaxConsecutiveBaseCloses > 0 && ls.ConsecutiveBaseCloses >= activeLevel.MaxConsecutiveBaseCloses {
+				ls.DisabledUntil = time.Now().Add(time.Duration(activeLevel.BaseCloseCooldownMs) * time.Millisecond)
+				ls.ConsecutiveBaseCloses = 0
+				disabledUntil = ls.DisabledUntil
+				log.Printf("AUDIT: Level %s disabled until %v due to max base closes.", activeLevel.ID, ls.DisabledUntil)
+
+				// --- AUTO-LEVEL SPLIT LOGIC ---
+				if activeLevel.AutoModeEnabled && ls.RangeHigh > 0 && ls.RangeLow > 0 {
+					go func(oldLevel *domain.Level, high, low float64) {
+						// Ensure high > low to avoid errors, though logic implies it
+						if high > low {
+							if err := s.SplitLevel(context.Background(), oldLevel, high, low); err != nil {
+								log.Printf("AUTO-LEVEL: Failed to split level %s: %v", oldLevel.ID, err)
+							}
+						}
+					}(activeLevel, ls.RangeHigh, ls.RangeLow)
 				}
 			}
+		} else {
+			// Not a Base Close
+			ls.ConsecutiveBaseCloses = 0 // Reset base close streak
+			ls.RangeHigh = 0
+			ls.RangeLow = 0
+
+			if realizedPnL > 0 {
+				ls.ConsecutiveWins++
+				log.Printf("AUDIT: Win recorded for Level %s. Consecutive Wins: %d", levelID, ls.ConsecutiveWins)
+			} else {
+				ls.ConsecutiveWins = 0
+				log.Printf("AUDIT: Loss recorded for Level %s. Streak reset.", levelID)
+			}
+		}
+	})
+
+	if !disabledUntil.IsZero() && activeLevel != nil {
+		decision := levelDecision(domain.DecisionCooldown, activeLevel, price)
+		decision.Reason = reason
+		decision.Outcome = domain.OutcomeDisabled
+		decision.Inputs = domain.DecisionInputs{
+			"disabled_until":      disabledUntil,
+			"max_base_closes":     activeLevel.MaxConsecutiveBaseCloses,
+			"base_close_cooldown": activeLevel.BaseCloseCooldownMs,
+			"realized_pnl":        realizedPnL,
+		}
+		s.recordDecision(context.Background(), decision)
+	}
+}
 
-			if isBaseClose && (reason == "Stop Loss (Base)" || reason == "Level Cross" || reason == "Safety Exit") {
-				ls.ConsecutiveBaseCloses++
-				if realizedPnL > 0 {
-					ls.ConsecutiveWins++
-					log.Printf("AUDIT: Base Close (Win) recorded for Level %s. Consecutive Wins: %d", levelID, ls.ConsecutiveWins)
+// checkLevelExits runs the exit checks of one level against the part of the position it owns:
+// take-profit ladder, take profit, stop loss at base and trailing/break-even stops.
+// Returns true if the level's share was (partially) closed on this tick.
+func (s *LevelService) checkLevelExits(ctx context.Context, level *domain.Level, pos *domain.Position, price float64) bool {
+	symbol := level.Symbol
+
+	// Scale-out ladder runs before the full TP so the partial targets are taken first.
+	// Only one step is executed per tick, the next tick picks up the following step.
+	if len(level.TakeProfitLadder) > 0 {
+		if s.checkTakeProfitLadder(ctx, level, pos, price) {
+			return true
+		}
+	}
+
+	// Check TP
+	if level.TakeProfitPct > 0 || level.TakeProfitMode == domain.TakeProfitLiquidity || level.TakeProfitMode == domain.TakeProfitSentiment {
+		shouldTP := false
+		var tpPrice float64
+
+		if level.TakeProfitMode == domain.TakeProfitLiquidity {
+			// Dynamic TP based on liquidity
+			dynamicTP, err := s.CalculateLiquidityTP(ctx, symbol, pos.Side, pos.EntryPrice)
+			if err == nil && dynamicTP > 0 {
+				tpPrice = dynamicTP
+			} else {
+				// Fallback to fixed % if liquidity TP fails
+				if pos.Side == domain.SideLong {
+					tpPrice = pos.EntryPrice * (1 + level.TakeProfitPct)
 				} else {
-					ls.ConsecutiveWins = 0 // Reset wins on loss
-				}
-				log.Printf("AUDIT: Base Close recorded for Level %s. Count: %d (PnL: %f)", levelID, ls.ConsecutiveBaseCloses, realizedPnL)
-
-				if activeLevel != nil && activeLevel.MaxConsecutiveBaseCloses > 0 && ls.ConsecutiveBaseCloses >= activeLevel.MaxConsecutiveBaseCloses {
-					ls.DisabledUntil = time.Now().Add(time.Duration(activeLevel.BaseCloseCooldownMs) * time.Millisecond)
-					ls.ConsecutiveBaseCloses = 0
-					log.Printf("AUDIT: Level %s disabled until %v due to max base closes.", activeLevel.ID, ls.DisabledUntil)
-
-					// --- AUTO-LEVEL SPLIT LOGIC ---
-					if activeLevel.AutoModeEnabled && ls.RangeHigh > 0 && ls.RangeLow > 0 {
-						go func(oldLevel *domain.Level, high, low float64) {
-							// Ensure high > low to avoid errors, though logic implies it
-							if high > low {
-								if err := s.SplitLevel(context.Background(), oldLevel, high, low); err != nil {
-									log.Printf("AUTO-LEVEL: Failed to split level %s: %v", oldLevel.ID, err)
-								}
-							}
-						}(activeLevel, ls.RangeHigh, ls.RangeLow)
-					}
+					tpPrice = pos.EntryPrice * (1 - level.TakeProfitPct)
 				}
+			}
+		} else if level.TakeProfitMode == domain.TakeProfitSentiment {
+			// Sentiment-Adjusted TP
+			// TargetTP = BaseTP * (1 + (ConclusionScore * Factor))
+			// Factor = 0.5 (Adjustable? Hardcoded for now per plan)
+			baseTP := level.TakeProfitPct
+			if baseTP <= 0 {
+				baseTP = 0.02 // Default 2% if not set
+			}
+
+			stats, err := s.market.GetMarketStats(ctx, symbol)
+			score := 0.0
+			if err == nil && stats != nil {
+				score = stats.ConclusionScore
+			}
+
+			// Adjust TP
+			// If Long: Positive Score (Bullish) -> Increase TP. Negative Score (Bearish) -> Decrease TP.
+			// If Short: Negative Score (Bearish) -> Increase TP. Positive Score (Bullish) -> Decrease TP.
+			// Wait, Score is -1 (Bear) to 1 (Bull).
+			// For Long: Multiplier = 1 + (Score * 0.5)
+			//   Score 0.8 -> 1 + 0.4 = 1.4x TP.
+			//   Score -0.5 -> 1 - 0.25 = 0.75x TP.
+			// For Short: We want to INCREASE TP if Bearish (Score < 0).
+			//   Score -0.8 -> We want larger TP.
+			//   Multiplier = 1 - (Score * 0.5)
+			//   Score -0.8 -> 1 - (-0.4) = 1.4x TP.
+			//   Score 0.5 -> 1 - 0.25 = 0.75x TP.
+
+			factor := 0.5
+			multiplier := 1.0
+			if pos.Side == domain.SideLong {
+				multiplier = 1 + (score * factor)
+			} else {
+				multiplier = 1 - (score * factor)
+			}
+
+			// Clamp multiplier to avoid negative or too small TP?
+			// If score is extreme, e.g. -1. Multiplier = 0.5. TP becomes half.
+			// Seems safe.
+
+			adjustedPct := baseTP * multiplier
+			if adjustedPct < 0.001 {
+				adjustedPct = 0.001 // Minimum 0.1% TP
+			}
+
+			if pos.Side == domain.SideLong {
+				tpPrice = pos.EntryPrice * (1 + adjustedPct)
+			} else {
+				tpPrice = pos.EntryPrice * (1 - adjustedPct)
+			}
+
+		} else {
+			// Fixed TP
+			if pos.Side == domain.SideLong {
+				tpPrice = pos.EntryPrice * (1 + level.TakeProfitPct)
 			} else {
-				// Not a Base Close
-				ls.ConsecutiveBaseCloses = 0 // Reset base close streak
-				ls.RangeHigh = 0
-				ls.RangeLow = 0
-
-				if realizedPnL > 0 {
-					ls.ConsecutiveWins++
-					log.Printf("AUDIT: Win recorded for Level %s. Consecutive Wins: %d", levelID, ls.ConsecutiveWins)
+				tpPrice = pos.EntryPrice * (1 - level.TakeProfitPct)
+			}
+		}
+		// Never closer than the round-trip fees plus the minimum net profit
+		tpPrice = s.FeeModel().MinTPPrice(pos.Side, pos.EntryPrice, tpPrice)
+
+		if pos.Side == domain.SideLong {
+			if price >= tpPrice {
+				log.Printf("TAKE PROFIT: LONG on %s. Price %f >= TP %f. Closing...", symbol, price, tpPrice)
+				shouldTP = true
+			}
+		} else if pos.Side == domain.SideShort {
+			if price <= tpPrice {
+				log.Printf("TAKE PROFIT: SHORT on %s. Price %f <= TP %f. Closing...", symbol, price, tpPrice)
+				shouldTP = true
+			}
+		}
+
+		// A take profit being worked is left to finish, the stops below still apply
+		if shouldTP && !s.exitWorking(level.ID) {
+			inputs := domain.DecisionInputs{"tp_price": tpPrice, "take_profit_mode": level.TakeProfitMode, "entry_price": pos.EntryPrice}
+			if _, err := s.finalizePosition(ctx, symbol, "Take Profit", level.ID, price, inputs); err != nil {
+				log.Printf("Failed to finalize position on TP: %v", err)
+			}
+			// State update is now handled in finalizePosition
+			return true
+		}
+	}
+
+	// Check Stop Loss at Base
+	if level.StopLossAtBase {
+		shouldSL := false
+		if pos.Side == domain.SideLong {
+			// Long: Close if Price <= LevelPrice
+			if price <= level.LevelPrice {
+				log.Printf("STOP LOSS (Base): LONG on %s. Price %f <= Level %f. Closing...", symbol, price, level.LevelPrice)
+				shouldSL = true
+			}
+		} else if pos.Side == domain.SideShort {
+			// Short: Close if Price >= LevelPrice
+			if price >= level.LevelPrice {
+				log.Printf("STOP LOSS (Base): SHORT on %s. Price %f >= Level %f. Closing...", symbol, price, level.LevelPrice)
+				shouldSL = true
+			}
+		}
+
+		if shouldSL {
+			inputs := domain.DecisionInputs{"entry_price": pos.EntryPrice}
+			if _, err := s.finalizePosition(ctx, symbol, "Stop Loss (Base)", level.ID, price, inputs); err != nil {
+				log.Printf("Failed to finalize position on SL: %v", err)
+			}
+			return true
+		}
+	}
+
+	// Check Trailing / Break-Even Stops
+	if level.TrailingStopPct > 0 || level.BreakEvenTier > 0 || level.BreakEvenProfitPct > 0 {
+		if s.checkProtectiveStops(ctx, level, pos, price) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkTakeProfitLadder executes the next due step of the level's scale-out ladder.
+// Step sizes are fractions of the position size at the moment the ladder started,
+// so "40@1,30@2" closes 40% and then 30% of the original size, leaving 30% running.
+// Returns true if the position was touched on this tick.
+func (s *LevelService) checkTakeProfitLadder(ctx context.Context, level *domain.Level, pos *domain.Position, price float64) bool {
+	state := s.engine.GetState(level.ID)
+	stepIdx := state.TakeProfitStepsDone
+	if stepIdx >= len(level.TakeProfitLadder) {
+		return false
+	}
+	step := level.TakeProfitLadder[stepIdx]
+
+	// Steps closer than the round-trip fees would close at a net loss, they wait for the floor
+	var target float64
+	reached := false
+	if pos.Side == domain.SideLong {
+		target = s.FeeModel().MinTPPrice(pos.Side, pos.EntryPrice, pos.EntryPrice*(1+step.ProfitPct))
+		reached = price >= target
+	} else {
+		target = s.FeeModel().MinTPPrice(pos.Side, pos.EntryPrice, pos.EntryPrice*(1-step.ProfitPct))
+		reached = price <= target
+	}
+	if !reached {
+		return false
+	}
+
+	baseSize := state.LadderBaseSize
+	if baseSize == 0 {
+		baseSize = pos.Size
+	}
+	qty := baseSize * step.ClosePct
+	reason := fmt.Sprintf("Take Profit (Ladder %d/%d)", stepIdx+1, len(level.TakeProfitLadder))
+
+	log.Printf("TAKE PROFIT: Ladder step %d/%d for %s %s on %s. Price %f reached target %f. Closing %f of %f.",
+		stepIdx+1, len(level.TakeProfitLadder), level.ID, pos.Side, level.Symbol, price, target, qty, pos.Size)
+
+	inputs := domain.DecisionInputs{"target": target, "step": stepIdx + 1, "entry_price": pos.EntryPrice, "close_pct": step.ClosePct}
+
+	// Nothing meaningful would be left, close the whole thing through the normal path
+	const dustEpsilon = 1e-9
+	if pos.Size-qty <= dustEpsilon {
+		if _, err := s.finalizePosition(ctx, level.Symbol, reason, level.ID, price, inputs); err != nil {
+			log.Printf("Failed to finalize position on ladder TP: %v", err)
+		}
+		return true
+	}
+
+	if level.IsLive() {
+		if err := s.exchange.ReducePosition(ctx, level.Symbol, qty); err != nil {
+			log.Printf("TAKE PROFIT: Failed to reduce position for %s: %v", level.Symbol, err)
+			return true
+		}
+		s.invalidatePositionCache(level.Symbol)
+	}
+
+	s.engine.UpdateState(level.ID, func(ls *LevelState) {
+		ls.TakeProfitStepsDone = stepIdx + 1
+		ls.LadderBaseSize = baseSize
+	})
+
+	var pnl, fees float64
+	if level.IsLive() {
+		pnl, fees = s.recordPartialClose(ctx, level, pos, qty, price, reason)
+	} else {
+		pnl, fees = s.reduceShadowShare(ctx, level, qty, price, reason)
+	}
+
+	decision := levelDecision(domain.DecisionExit, level, price)
+	decision.Side = pos.Side
+	decision.Size = qty
+	decision.Reason = reason
+	decision.Outcome = domain.OutcomePartial
+	inputs["realized_pnl"] = pnl
+	inputs["fees"] = fees
+	inputs["net_pnl"] = pnl - fees
+	inputs["state"] = stateSnapshot(s.engine.GetState(level.ID))
+	decision.Inputs = inputs
+	s.recordDecision(ctx, decision)
+	return true
+}
+
+// checkProtectiveStops arms and enforces the trailing and break-even stops of a level.
+// Break-even arms once profit reaches BreakEvenProfitPct, or once BreakEvenTier has filled
+// and price is back in profit (tiers add on adverse moves, so arming right on the fill
+// would stop us out at a loss). The trailing stop arms at TrailingActivationPct and then
+// follows the best price by TrailingStopPct. The tighter of the two stops wins.
+// In "exchange" mode the stop is attached to the position via trading-stop once armed
+// and the exchange does the closing, as long as the level is the only live level of the
+// symbol. Returns true if the position was closed.
+func (s *LevelService) checkProtectiveStops(ctx context.Context, level *domain.Level, pos *domain.Position, price float64) bool {
+	if pos.EntryPrice <= 0 {
+		return false
+	}
+	isLong := pos.Side == domain.SideLong
+	profitPct := (price - pos.EntryPrice) / pos.EntryPrice
+	if !isLong {
+		profitPct = -profitPct
+	}
+
+	var st LevelState
+	s.engine.UpdateState(level.ID, func(ls *LevelState) {
+		// 1. Break-Even
+		if !ls.BreakEvenActive {
+			tierFilled := false
+			switch level.BreakEvenTier {
+			case 1:
+				tierFilled = ls.Tier1Triggered
+			case 2:
+				tierFilled = ls.Tier2Triggered
+			case 3:
+				tierFilled = ls.Tier3Triggered
+			}
+			if (tierFilled && profitPct > 0) || (level.BreakEvenProfitPct > 0 && profitPct >= level.BreakEvenProfitPct) {
+				ls.BreakEvenActive = true
+				ls.ExchangeStopSet = false
+				log.Printf("BREAK-EVEN: Armed for %s %s on %s. Entry %f, Price %f.", level.ID, pos.Side, level.Symbol, pos.EntryPrice, price)
+			}
+		}
+		if ls.BreakEvenActive {
+			ls.BreakEvenPrice = pos.EntryPrice // Follows the average entry if a tier adds later
+		}
+
+		// 2. Trailing
+		if level.TrailingStopPct > 0 {
+			if !ls.TrailingActive && profitPct >= level.TrailingActivationPct {
+				ls.TrailingActive = true
+				ls.TrailingPeak = price
+				ls.ExchangeStopSet = false
+				log.Printf("TRAILING: Armed for %s %s on %s at %f (profit %.4f%%).", level.ID, pos.Side, level.Symbol, price, profitPct*100)
+			}
+			if ls.TrailingActive {
+				if (isLong && price > ls.TrailingPeak) || (!isLong && price < ls.TrailingPeak) {
+					ls.TrailingPeak = price
+				}
+				if isLong {
+					ls.TrailingStopPrice = ls.TrailingPeak * (1 - level.TrailingStopPct)
 				} else {
-					ls.ConsecutiveWins = 0
-					log.Printf("AUDIT: Loss recorded for Level %s. Streak reset.", levelID)
+					ls.TrailingStopPrice = ls.TrailingPeak * (1 + level.TrailingStopPct)
 				}
 			}
-		})
+		}
+		st = *ls
+	})
+
+	// 3. Pick the tighter stop
+	stopPrice, reason := protectiveStop(st, isLong)
+	if stopPrice == 0 {
+		return false
+	}
+
+	// Shadow levels have nothing on the exchange to attach the stop to, they enforce it in the
+	// app. So do levels sharing the symbol with other live levels: the exchange stop covers the
+	// whole position and would close their shares too.
+	if level.TrailingStopMode == "exchange" && level.IsLive() && s.soleLiveLevel(level) {
+		if !st.ExchangeStopSet {
+			stop := domain.TradingStop{}
+			if st.BreakEvenActive {
+				stop.StopLoss = st.BreakEvenPrice
+			}
+			if st.TrailingActive {
+				stop.TrailingDistance = st.TrailingPeak * level.TrailingStopPct
+			}
+			if err := s.exchange.SetTradingStop(ctx, level.Symbol, stop); err != nil {
+				log.Printf("TRAILING: Failed to set exchange trading-stop for %s: %v", level.Symbol, err)
+			} else {
+				s.engine.UpdateState(level.ID, func(ls *LevelState) {
+					ls.ExchangeStopSet = true
+				})
+				log.Printf("TRAILING: Exchange trading-stop set for %s. SL: %f, Trail: %f", level.Symbol, stop.StopLoss, stop.TrailingDistance)
+			}
+		}
+		return false
+	}
+
+	if (isLong && price <= stopPrice) || (!isLong && price >= stopPrice) {
+		log.Printf("%s: %s on %s. Price %f crossed stop %f. Closing...", strings.ToUpper(reason), pos.Side, level.Symbol, price, stopPrice)
+		inputs := domain.DecisionInputs{"stop_price": stopPrice, "entry_price": pos.EntryPrice, "trailing_peak": st.TrailingPeak}
+		if _, err := s.finalizePosition(ctx, level.Symbol, reason, level.ID, price, inputs); err != nil {
+			log.Printf("Failed to finalize position on %s: %v", reason, err)
+		}
+		return true
+	}
+	return false
+}
+
+// protectiveStop returns the tighter of the armed break-even and trailing stops and its exit
+// reason, 0 if neither is armed.
+func protectiveStop(st LevelState, isLong bool) (float64, string) {
+	stopPrice := 0.0
+	reason := ""
+	if st.BreakEvenActive {
+		stopPrice = st.BreakEvenPrice
+		reason = "Break-Even Stop"
+	}
+	if st.TrailingActive {
+		if stopPrice == 0 || (isLong && st.TrailingStopPrice > stopPrice) || (!isLong && st.TrailingStopPrice < stopPrice) {
+			stopPrice = st.TrailingStopPrice
+			reason = "Trailing Stop"
+		}
+	}
+	return stopPrice, reason
+}
+
+// soleLiveLevel reports whether the level is the only live level of its symbol.
+func (s *LevelService) soleLiveLevel(level *domain.Level) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, l := range liveLevels(s.levelsCache[level.Symbol]) {
+		if l.ID != level.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// recordPartialClose saves the history row and trade for a partial realization and returns its
+// PnL before fees and the fees of the closed size.
+func (s *LevelService) recordPartialClose(ctx context.Context, level *domain.Level, pos *domain.Position, qty, price float64, reason string) (float64, float64) {
+	var realizedPnL float64
+	if pos.Side == domain.SideLong {
+		realizedPnL = (price - pos.EntryPrice) * qty
+	} else {
+		realizedPnL = (pos.EntryPrice - price) * qty
+	}
+	// Keep the level's share in sync, the reduce went at market. Unattributed positions take
+	// a taker entry too.
+	rates := s.FeeModel().Rates
+	exitFee := rates.Fee(qty*price, false)
+	closed, _, fees := s.ledger.Reduce(level.ID, qty, price, exitFee)
+	if closed == 0 {
+		fees = rates.Fee(qty*pos.EntryPrice, false) + exitFee
+	}
+
+	history := &domain.PositionHistory{
+		Exchange:    level.Exchange,
+		Symbol:      level.Symbol,
+		Side:        pos.Side,
+		Size:        qty,
+		EntryPrice:  pos.EntryPrice,
+		ExitPrice:   price,
+		RealizedPnL: realizedPnL,
+		Fees:        fees,
+		Leverage:    pos.Leverage,
+		MarginType:  pos.MarginType,
+		LevelID:     level.ID,
+		Reason:      reason,
+		Partial:     true,
+		ClosedAt:    time.Now(),
+	}
+	if err := s.tradeRepo.SavePositionHistory(ctx, history); err != nil {
+		log.Printf("Failed to save partial position history: %v", err)
 	}
 
-	return realizedPnL, nil
+	if err := s.tradeRepo.SaveTrade(ctx, &domain.Order{
+		Exchange:    level.Exchange,
+		Symbol:      level.Symbol,
+		LevelID:     level.ID,
+		Side:        pos.Side,
+		Size:        qty,
+		Price:       price,
+		ReduceOnly:  true,
+		RealizedPnL: realizedPnL,
+		Fee:         fees,
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		log.Printf("Failed to save partial close trade: %v", err)
+	}
+
+	log.Printf("TAKE PROFIT: Partial close %s on %s. Reason: %s. Size: %f. PnL: %f, fees: %f", pos.Side, level.Symbol, reason, qty, realizedPnL, fees)
+	return realizedPnL, fees
 }
 
 // AutoCreateNextLevel attempts to find a better level based on liquidity and create it.
@@ -914,6 +1988,11 @@ func (s *LevelService) AutoCreateNextLevel(ctx context.Context, oldLevelID strin
 	}
 
 	if len(selected) == 0 {
+		decision := levelDecision(domain.DecisionAutoLevel, oldLevel, oldLevel.LevelPrice)
+		decision.Reason = "no suitable candidates"
+		decision.Outcome = domain.OutcomeSkipped
+		decision.Inputs = domain.DecisionInputs{"clusters": len(clusters), "min_distance_pct": minDistancePct}
+		s.recordDecision(ctx, decision)
 		return fmt.Errorf("no suitable candidates found")
 	}
 
@@ -941,37 +2020,29 @@ func (s *LevelService) AutoCreateNextLevel(ctx context.Context, oldLevelID strin
 
 	// 7. Create New Levels
 	for _, c := range selected {
-		newLevel := &domain.Level{
-			ID:                       fmt.Sprintf("%d", time.Now().UnixNano()),
-			Exchange:                 oldLevel.Exchange,
-			Symbol:                   oldLevel.Symbol,
-			LevelPrice:               c.Price,
-			BaseSize:                 oldLevel.BaseSize,
-			Leverage:                 oldLevel.Leverage,
-			MarginType:               oldLevel.MarginType,
-			CoolDownMs:               oldLevel.CoolDownMs,
-			StopLossAtBase:           oldLevel.StopLossAtBase,
-			StopLossMode:             oldLevel.StopLossMode,
-			DisableSpeedClose:        oldLevel.DisableSpeedClose,
-			MaxConsecutiveBaseCloses: oldLevel.MaxConsecutiveBaseCloses,
-			BaseCloseCooldownMs:      oldLevel.BaseCloseCooldownMs,
-			TakeProfitPct:            oldLevel.TakeProfitPct,
-			TakeProfitMode:           oldLevel.TakeProfitMode,
-			IsAuto:                   true,
-			AutoModeEnabled:          true,
-			Source:                   "auto-next-" + c.Type,
-			CreatedAt:                time.Now(),
-		}
+		newLevel := deriveLevel(oldLevel, fmt.Sprintf("%d", time.Now().UnixNano()), c.Price, "auto-next-"+c.Type)
 		// Ensure unique ID
 		// Ensure unique ID using atomic counter or just high precision
 		// Using a simple suffix to ensure uniqueness if called rapidly
 		newLevel.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), time.Now().UnixMicro()%1000)
 
+		decision := levelDecision(domain.DecisionAutoLevel, oldLevel, c.Price)
+		decision.Reason = "auto-next-" + c.Type
+		decision.Inputs = domain.DecisionInputs{
+			"new_level_id":   newLevel.ID,
+			"cluster_type":   c.Type,
+			"volume":         c.Volume,
+			"deleted_levels": levelsToDelete,
+		}
 		if err := s.levelRepo.SaveLevel(ctx, newLevel); err != nil {
 			log.Printf("AUTO-LEVEL: Failed to save new %s level: %v", c.Type, err)
+			decision.Outcome = domain.OutcomeFailed
+			decision.Inputs["error"] = err.Error()
 		} else {
 			log.Printf("AUTO-LEVEL: Created new %s level %s at %f (Vol: %f)", c.Type, newLevel.ID, newLevel.LevelPrice, c.Volume)
+			decision.Outcome = domain.OutcomeCreated
 		}
+		s.recordDecision(ctx, decision)
 	}
 
 	// Refresh cache
@@ -991,8 +2062,9 @@ func (s *LevelService) CalculateLiquidityTP(ctx context.Context, symbol string,
 
 	var bestCluster *LiquidityCluster
 	// We want to find a cluster that is at least some distance away to cover fees/profit.
-	// Let's say min 0.5% profit.
-	const minProfitPct = 0.005
+	// Let's say min 0.5% profit after the round-trip taker fees.
+	const minNetProfitPct = 0.005
+	minProfitPct := s.FeeModel().Rates.BreakEvenDistance(side, minNetProfitPct, false, false)
 
 	for _, c := range clusters {
 		if side == domain.SideLong {
@@ -1024,14 +2096,22 @@ func (s *LevelService) CalculateLiquidityTP(ctx context.Context, symbol string,
 		}
 	}
 
-	if bestCluster == nil {
-		return 0, fmt.Errorf("no suitable liquidity cluster found for TP")
+	var target float64
+	if bestCluster != nil {
+		target = bestCluster.Price
+	} else {
+		// No wall in range, fall back to the volume profile: price tends to stall at
+		// the nearest high volume node (or value area edge) in the trade direction.
+		target = s.volumeProfileTarget(ctx, symbol, side, entryPrice, minProfitPct)
+		if target == 0 {
+			return 0, fmt.Errorf("no suitable liquidity cluster found for TP")
+		}
 	}
 
 	// Apply small offset to exit BEFORE the wall
 	// 0.1% offset
 	const offsetPct = 0.001
-	tpPrice := bestCluster.Price
+	tpPrice := target
 	if side == domain.SideLong {
 		tpPrice = tpPrice * (1 - offsetPct)
 	} else {
@@ -1041,6 +2121,29 @@ func (s *LevelService) CalculateLiquidityTP(ctx context.Context, symbol string,
 	return tpPrice, nil
 }
 
+// volumeProfileTarget returns the nearest volume node at least minProfitPct and at most 5%
+// away from the entry in the trade direction, 0 if there is none.
+func (s *LevelService) volumeProfileTarget(ctx context.Context, symbol string, side domain.Side, entryPrice, minProfitPct float64) float64 {
+	window, _ := FindVolumeProfileWindow(DefaultVolumeProfileWindow)
+	vp, err := s.market.GetVolumeProfile(ctx, symbol, window, DefaultVolumeProfileBins)
+	if err != nil {
+		return 0
+	}
+
+	candidates := append([]float64{vp.POC, vp.ValueAreaHigh, vp.ValueAreaLow}, vp.HVN...)
+	best := 0.0
+	for _, p := range candidates {
+		if side == domain.SideLong {
+			if p > entryPrice*(1+minProfitPct) && p <= entryPrice*1.05 && (best == 0 || p < best) {
+				best = p
+			}
+		} else if p > 0 && p < entryPrice*(1-minProfitPct) && p >= entryPrice*0.95 && p > best {
+			best = p
+		}
+	}
+	return best
+}
+
 // SplitLevel splits a level into two new levels based on the range, deleting the original and other auto levels.
 func (s *LevelService) SplitLevel(ctx context.Context, originalLevel *domain.Level, high, low float64) error {
 	// 1. Cleanup Old Auto Levels
@@ -1052,6 +2155,7 @@ func (s *LevelService) SplitLevel(ctx context.Context, originalLevel *domain.Lev
 		return fmt.Errorf("failed to get levels for symbol %s: %w", originalLevel.Symbol, err)
 	}
 
+	var deleted []string
 	for _, l := range existingLevels {
 		// Delete if it's the original level OR if it's an auto level
 		if l.ID == originalLevel.ID || l.IsAuto {
@@ -1060,55 +2164,14 @@ func (s *LevelService) SplitLevel(ctx context.Context, originalLevel *domain.Lev
 				// Continue trying to delete others
 			} else {
 				log.Printf("SPLIT: Deleted level %s (IsAuto: %v)", l.ID, l.IsAuto)
+				deleted = append(deleted, l.ID)
 			}
 		}
 	}
 
-	// Create High Level
-	highLevel := &domain.Level{
-		ID:                       fmt.Sprintf("auto-split-%d-high", time.Now().UnixNano()),
-		Exchange:                 originalLevel.Exchange,
-		Symbol:                   originalLevel.Symbol,
-		LevelPrice:               high,
-		BaseSize:                 originalLevel.BaseSize,
-		Leverage:                 originalLevel.Leverage,
-		MarginType:               originalLevel.MarginType,
-		CoolDownMs:               originalLevel.CoolDownMs,
-		StopLossAtBase:           originalLevel.StopLossAtBase,
-		StopLossMode:             originalLevel.StopLossMode,
-		DisableSpeedClose:        originalLevel.DisableSpeedClose,
-		MaxConsecutiveBaseCloses: originalLevel.MaxConsecutiveBaseCloses,
-		BaseCloseCooldownMs:      originalLevel.BaseCloseCooldownMs,
-		TakeProfitPct:            originalLevel.TakeProfitPct,
-		TakeProfitMode:           originalLevel.TakeProfitMode,
-		IsAuto:                   true,
-		AutoModeEnabled:          true,
-		Source:                   "auto-split",
-		CreatedAt:                time.Now(),
-	}
-
-	// Create Low Level
-	lowLevel := &domain.Level{
-		ID:                       fmt.Sprintf("auto-split-%d-low", time.Now().UnixNano()),
-		Exchange:                 originalLevel.Exchange,
-		Symbol:                   originalLevel.Symbol,
-		LevelPrice:               low,
-		BaseSize:                 originalLevel.BaseSize,
-		Leverage:                 originalLevel.Leverage,
-		MarginType:               originalLevel.MarginType,
-		CoolDownMs:               originalLevel.CoolDownMs,
-		StopLossAtBase:           originalLevel.StopLossAtBase,
-		StopLossMode:             originalLevel.StopLossMode,
-		DisableSpeedClose:        originalLevel.DisableSpeedClose,
-		MaxConsecutiveBaseCloses: originalLevel.MaxConsecutiveBaseCloses,
-		BaseCloseCooldownMs:      originalLevel.BaseCloseCooldownMs,
-		TakeProfitPct:            originalLevel.TakeProfitPct,
-		TakeProfitMode:           originalLevel.TakeProfitMode,
-		IsAuto:                   true,
-		AutoModeEnabled:          true,
-		Source:                   "auto-split",
-		CreatedAt:                time.Now(),
-	}
+	// Create High and Low Levels
+	highLevel := deriveLevel(originalLevel, fmt.Sprintf("auto-split-%d-high", time.Now().UnixNano()), high, "auto-split")
+	lowLevel := deriveLevel(originalLevel, fmt.Sprintf("auto-split-%d-low", time.Now().UnixNano()), low, "auto-split")
 
 	// Save both levels
 	if err := s.levelRepo.SaveLevel(ctx, highLevel); err != nil {
@@ -1118,10 +2181,36 @@ func (s *LevelService) SplitLevel(ctx context.Context, originalLevel *domain.Lev
 		return fmt.Errorf("failed to save low split level: %w", err)
 	}
 
+	decision := levelDecision(domain.DecisionSplit, originalLevel, originalLevel.LevelPrice)
+	decision.Reason = "max base closes"
+	decision.Outcome = domain.OutcomeCreated
+	decision.Inputs = domain.DecisionInputs{
+		"high":           high,
+		"low":            low,
+		"high_level_id":  highLevel.ID,
+		"low_level_id":   lowLevel.ID,
+		"deleted_levels": deleted,
+	}
+	s.recordDecision(ctx, decision)
+
 	// Update cache
 	return s.UpdateCache(ctx)
 }
 
+// deriveLevel copies every setting of parent into a new auto level at price.
+// Copying the struct keeps newly added level settings inherited without touching this code.
+func deriveLevel(parent *domain.Level, id string, price float64, source string) *domain.Level {
+	l := *parent
+	l.ID = id
+	l.LevelPrice = price
+	l.IsAuto = true
+	l.AutoModeEnabled = true
+	l.Source = source
+	l.ExternalKey = "" // Keys identify one level, imports would otherwise match the copy
+	l.CreatedAt = time.Now()
+	return &l
+}
+
 // IncrementBaseCloses manually increments the base close counter for a level
 // and triggers the split logic if the max is reached.
 func (s *LevelService) IncrementBaseCloses(ctx context.Context, levelID string) error {
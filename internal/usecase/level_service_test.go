@@ -15,7 +15,8 @@ type MockLevelRepo struct {
 	Tiers  *domain.SymbolTiers
 }
 
-func (m *MockLevelRepo) SaveLevel(ctx context.Context, level *domain.Level) error { return nil }
+func (m *MockLevelRepo) SaveLevel(ctx context.Context, level *domain.Level) error   { return nil }
+func (m *MockLevelRepo) UpdateLevel(ctx context.Context, level *domain.Level) error { return nil }
 func (m *MockLevelRepo) GetLevel(ctx context.Context, id string) (*domain.Level, error) {
 	return m.Levels[0], nil
 }
@@ -53,6 +54,12 @@ func (m *MockLevelRepo) GetLevelsBySymbol(ctx context.Context, symbol string) ([
 func (m *MockLevelRepo) SaveLiquiditySnapshot(ctx context.Context, snap *domain.LiquiditySnapshot) error {
 	return nil
 }
+func (m *MockLevelRepo) SaveLevelRevision(ctx context.Context, rev *domain.LevelRevision) error {
+	return nil
+}
+func (m *MockLevelRepo) ListLevelRevisions(ctx context.Context, levelID string, limit int) ([]*domain.LevelRevision, error) {
+	return nil, nil
+}
 func (m *MockLevelRepo) ListLiquiditySnapshots(ctx context.Context, symbol string, limit int) ([]*domain.LiquiditySnapshot, error) {
 	return nil, nil
 }
@@ -112,6 +119,13 @@ func (m *MockExchange) MarketSell(ctx context.Context, symbol string, size float
 func (m *MockExchange) ClosePosition(ctx context.Context, symbol string) error {
 	return nil
 }
+func (m *MockExchange) ReducePosition(ctx context.Context, symbol string, size float64) error {
+	return nil
+}
+
+func (m *MockExchange) SetTradingStop(ctx context.Context, symbol string, stop domain.TradingStop) error {
+	return nil
+}
 func (m *MockExchange) GetPosition(ctx context.Context, symbol string) (*domain.Position, error) {
 	return &domain.Position{Symbol: symbol, Size: 0}, nil
 }
@@ -153,6 +167,18 @@ func (m *MockExchange) CancelOrder(ctx context.Context, symbol, orderID string)
 	return nil
 }
 
+func (m *MockExchange) CancelAllOrders(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockExchange) GetEquity(ctx context.Context) (*domain.AccountEquity, error) {
+	return &domain.AccountEquity{}, nil
+}
+
+func (m *MockExchange) GetRiskLimits(ctx context.Context, symbol string) ([]domain.RiskLimitTier, error) {
+	return nil, nil
+}
+
 func (m *MockExchange) GetWSStatus() domain.WSStatus {
 	return domain.WSStatus{Connected: true}
 }
@@ -169,6 +195,10 @@ func (m *MockExchangeForService) CancelOrder(ctx context.Context, symbol, orderI
 	return nil
 }
 
+func (m *MockExchangeForService) CancelAllOrders(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
 func TestLevelService_ClosePositionFailure_ResetsState(t *testing.T) {
 	// Setup
 	level := &domain.Level{
@@ -242,6 +272,7 @@ type MockExchangeForService struct {
 	BuyCalled    bool
 	SellCalled   bool
 	CloseCalled  bool
+	ReducedSize  float64
 	LastStopLoss float64
 	CloseError   error
 
@@ -266,6 +297,13 @@ func (m *MockExchangeForService) ClosePosition(ctx context.Context, symbol strin
 	m.CloseCalled = true
 	return m.CloseError
 }
+func (m *MockExchangeForService) ReducePosition(ctx context.Context, symbol string, size float64) error {
+	m.ReducedSize += size
+	return m.CloseError
+}
+func (m *MockExchangeForService) SetTradingStop(ctx context.Context, symbol string, stop domain.TradingStop) error {
+	return nil
+}
 func (m *MockExchangeForService) GetPosition(ctx context.Context, symbol string) (*domain.Position, error) {
 	if m.Position != nil {
 		return m.Position, nil
@@ -302,6 +340,14 @@ func (m *MockExchangeForService) Subscribe(symbols []string) error {
 	return nil
 }
 
+func (m *MockExchangeForService) GetEquity(ctx context.Context) (*domain.AccountEquity, error) {
+	return &domain.AccountEquity{}, nil
+}
+
+func (m *MockExchangeForService) GetRiskLimits(ctx context.Context, symbol string) ([]domain.RiskLimitTier, error) {
+	return nil, nil
+}
+
 func (m *MockExchangeForService) GetWSStatus() domain.WSStatus {
 	return domain.WSStatus{Connected: true}
 }
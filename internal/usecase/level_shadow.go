@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// Shadow levels run through processLevel and the exit checks like live levels, but their
+// fills go into a separate PositionLedger at a price simulated against the current book.
+// Alert levels only record the signal. Symbol-wide exits (sentiment, manual close) act on
+// the exchange position and leave shadow shares alone.
+
+// SetShadowRepository persists the shadow ledger, without it shadow fills are kept in memory only.
+func (s *LevelService) SetShadowRepository(repo domain.ShadowRepository) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shadowRepo = repo
+}
+
+// GetShadowPosition returns the level's simulated share (zero size if flat).
+func (s *LevelService) GetShadowPosition(levelID string) LevelPosition {
+	p, _ := s.shadow.Get(levelID)
+	return p
+}
+
+// simulateFill walks the book side a market order of size would take and returns the
+// average price. Without a usable book it fills at the tick price, a thin book fills
+// the rest at the last visible level.
+func (s *LevelService) simulateFill(ctx context.Context, symbol string, buy bool, size, price float64) float64 {
+	ob, err := s.exchange.GetOrderBook(ctx, symbol, "linear")
+	if err != nil || ob == nil {
+		return price
+	}
+	side := domain.SideLong
+	if !buy {
+		side = domain.SideShort
+	}
+	walk := WalkOrderBook(ob, side, size)
+	if walk.Fillable <= 0 {
+		return price
+	}
+	book := bookSide(ob, side)
+	cost := walk.AvgPrice*walk.Fillable + (size-walk.Fillable)*book[len(book)-1].Price
+	return cost / size
+}
+
+// recordShadowEntry books a would-be entry of a shadow or alert level.
+func (s *LevelService) recordShadowEntry(ctx context.Context, level *domain.Level, action Action, side domain.Side, size, price float64) *domain.ShadowTrade {
+	trade := &domain.ShadowTrade{
+		LevelID:   level.ID,
+		Exchange:  level.Exchange,
+		Symbol:    level.Symbol,
+		Mode:      level.EffectiveMode(),
+		Action:    domain.ShadowActionOpen,
+		Side:      side,
+		Size:      size,
+		Price:     price,
+		MarkPrice: price,
+		Reason:    fmt.Sprintf("Tier %s", action),
+		CreatedAt: time.Now(),
+	}
+	if action == ActionAddToPosition {
+		trade.Action = domain.ShadowActionAdd
+	}
+
+	if trade.Mode == domain.LevelModeShadow {
+		trade.Price = s.simulateFill(ctx, level.Symbol, side == domain.SideLong, size, price)
+		trade.Fee = s.FeeModel().Rates.Fee(size*trade.Price, false)
+		s.shadow.RecordFill(level.ID, level.Symbol, side, size, trade.Price, trade.Fee)
+		log.Printf("SHADOW: %s %s %f on %s at %f (mark %f, level %s)", trade.Action, side, size, level.Symbol, trade.Price, price, level.ID)
+	} else {
+		log.Printf("ALERT-ONLY: Would %s %s %f on %s at %f (level %s)", trade.Action, side, size, level.Symbol, price, level.ID)
+	}
+	s.saveShadowTrade(ctx, trade)
+	return trade
+}
+
+// closeShadowShare is finalizePosition for shadow and alert levels: the share (if any) is
+// closed at a simulated fill, the level starts fresh and the streaks are updated as if live.
+func (s *LevelService) closeShadowShare(ctx context.Context, level *domain.Level, reason string, price float64, inputs domain.DecisionInputs) (float64, error) {
+	share, _ := s.shadow.Get(level.ID)
+	snapshot := stateSnapshot(s.engine.GetState(level.ID))
+	trade := &domain.ShadowTrade{
+		LevelID:    level.ID,
+		Exchange:   level.Exchange,
+		Symbol:     level.Symbol,
+		Mode:       level.EffectiveMode(),
+		Action:     domain.ShadowActionClose,
+		Side:       share.Side,
+		Price:      price,
+		MarkPrice:  price,
+		EntryPrice: share.EntryPrice,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}
+	if share.Size > 0 {
+		trade.Price = s.simulateFill(ctx, level.Symbol, share.Side == domain.SideShort, share.Size, price)
+		exitFee := s.FeeModel().Rates.Fee(share.Size*trade.Price, false)
+		trade.Size, trade.RealizedPnL, trade.Fee = s.shadow.Reduce(level.ID, share.Size, trade.Price, exitFee)
+	}
+	netPnL := trade.RealizedPnL - trade.Fee
+
+	s.engine.ResetState(level.ID)
+	log.Printf("SHADOW: Closed %s %f on %s at %f. Reason: %s. PnL: %f, fees: %f (level %s, %s)",
+		trade.Side, trade.Size, level.Symbol, trade.Price, reason, trade.RealizedPnL, trade.Fee, level.ID, trade.Mode)
+	s.saveShadowTrade(ctx, trade)
+
+	decision := levelDecision(domain.DecisionExit, level, price)
+	decision.Side = trade.Side
+	decision.Size = trade.Size
+	decision.Reason = reason
+	decision.Inputs = exitInputs(inputs, trade.RealizedPnL, trade.Fee, false, snapshot)
+	decision.Outcome = domain.OutcomeSignaled
+	if trade.Mode == domain.LevelModeShadow {
+		decision.Outcome = domain.OutcomeSimulated
+		decision.Inputs["fill_price"] = trade.Price
+	}
+	s.recordDecision(ctx, decision)
+
+	if share.Size > 0 {
+		s.recordExitOutcome(level.Symbol, level.ID, reason, netPnL, price)
+	}
+	return netPnL, nil
+}
+
+// reduceShadowShare is the partial close of a shadow share (take-profit ladder), returns the
+// PnL before fees and the fees of the closed size.
+func (s *LevelService) reduceShadowShare(ctx context.Context, level *domain.Level, qty, price float64, reason string) (float64, float64) {
+	share, ok := s.shadow.Get(level.ID)
+	if !ok || share.Size <= 0 {
+		return 0, 0
+	}
+	fill := s.simulateFill(ctx, level.Symbol, share.Side == domain.SideShort, qty, price)
+	size, pnl, fees := s.shadow.Reduce(level.ID, qty, fill, s.FeeModel().Rates.Fee(math.Min(qty, share.Size)*fill, false))
+	log.Printf("SHADOW: Partial close %s %f on %s at %f. Reason: %s. PnL: %f, fees: %f (level %s)", share.Side, size, level.Symbol, fill, reason, pnl, fees, level.ID)
+	s.saveShadowTrade(ctx, &domain.ShadowTrade{
+		LevelID:     level.ID,
+		Exchange:    level.Exchange,
+		Symbol:      level.Symbol,
+		Mode:        level.EffectiveMode(),
+		Action:      domain.ShadowActionReduce,
+		Side:        share.Side,
+		Size:        size,
+		Price:       fill,
+		MarkPrice:   price,
+		EntryPrice:  share.EntryPrice,
+		RealizedPnL: pnl,
+		Fee:         fees,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	})
+	return pnl, fees
+}
+
+// checkShadowExits runs the per-level exit checks of shadow levels against their simulated
+// shares. Like the live loop, at most one exit per tick.
+func (s *LevelService) checkShadowExits(ctx context.Context, levels []*domain.Level, price float64) bool {
+	for _, level := range levels {
+		if level.EffectiveMode() != domain.LevelModeShadow {
+			continue
+		}
+		share, ok := s.shadow.Get(level.ID)
+		if !ok || share.Size <= 0 {
+			continue
+		}
+		pos := &domain.Position{
+			Exchange:   level.Exchange,
+			Symbol:     level.Symbol,
+			Side:       share.Side,
+			Size:       share.Size,
+			EntryPrice: share.EntryPrice,
+			Leverage:   level.Leverage,
+			MarginType: level.MarginType,
+		}
+		if s.checkLevelExits(ctx, level, pos, price) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *LevelService) saveShadowTrade(ctx context.Context, trade *domain.ShadowTrade) {
+	s.mu.RLock()
+	repo := s.shadowRepo
+	s.mu.RUnlock()
+	if repo == nil {
+		return
+	}
+	if err := repo.SaveShadowTrade(ctx, trade); err != nil {
+		log.Printf("SHADOW: Failed to save shadow trade for level %s: %v", trade.LevelID, err)
+	}
+}
+
+// cachedLevel returns the cached level of a symbol by ID, nil if unknown.
+func (s *LevelService) cachedLevel(symbol, levelID string) *domain.Level {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, l := range s.levelsCache[symbol] {
+		if l.ID == levelID {
+			return l
+		}
+	}
+	return nil
+}
+
+// liveLevels drops shadow and alert levels, they never own part of the exchange position.
+func liveLevels(levels []*domain.Level) []*domain.Level {
+	var live []*domain.Level
+	for _, l := range levels {
+		if l.IsLive() {
+			live = append(live, l)
+		}
+	}
+	return live
+}
+
+// LevelPerformance puts the live and shadow results of a level side by side.
+type LevelPerformance struct {
+	LevelID       string          `json:"level_id"`
+	Exchange      string          `json:"exchange"`
+	Symbol        string          `json:"symbol"`
+	LevelPrice    float64         `json:"level_price"`
+	Mode          string          `json:"mode"`
+	Live          domain.LevelPnL `json:"live"`
+	Shadow        domain.LevelPnL `json:"shadow"`
+	Open          LevelPosition   `json:"open"`           // Open share in the ledger of the current mode
+	UnrealizedPnL float64         `json:"unrealized_pnl"` // Of Open at the last price, less its entry fees
+}
+
+// TotalPnL is realized plus unrealized PnL of the current mode, net of fees.
+func (p LevelPerformance) TotalPnL() float64 {
+	if p.Mode == domain.LevelModeLive {
+		return p.Live.NetPnL() + p.UnrealizedPnL
+	}
+	return p.Shadow.NetPnL() + p.UnrealizedPnL
+}
+
+// LevelPerformance returns the realized and open PnL of every level, live and shadow,
+// ordered by symbol and level price.
+func (s *LevelService) LevelPerformance(ctx context.Context) ([]LevelPerformance, error) {
+	levels, err := s.levelRepo.ListLevels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list levels: %w", err)
+	}
+
+	s.mu.RLock()
+	repo := s.shadowRepo
+	s.mu.RUnlock()
+	pnl := make(map[string]*domain.LevelPnL) // levelID/mode -> PnL
+	if repo != nil {
+		rows, err := repo.ListLevelPnL(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum level pnl: %w", err)
+		}
+		for _, row := range rows {
+			pnl[row.LevelID+"/"+row.Mode] = row
+		}
+	}
+
+	result := make([]LevelPerformance, 0, len(levels))
+	for _, l := range levels {
+		perf := LevelPerformance{
+			LevelID:    l.ID,
+			Exchange:   l.Exchange,
+			Symbol:     l.Symbol,
+			LevelPrice: l.LevelPrice,
+			Mode:       l.EffectiveMode(),
+			Live:       domain.LevelPnL{LevelID: l.ID, Mode: domain.LevelModeLive},
+			Shadow:     domain.LevelPnL{LevelID: l.ID, Mode: domain.LevelModeShadow},
+		}
+		if row, ok := pnl[l.ID+"/"+domain.LevelModeLive]; ok {
+			perf.Live = *row
+		}
+		if row, ok := pnl[l.ID+"/"+domain.LevelModeShadow]; ok {
+			perf.Shadow = *row
+		}
+
+		ledger := s.ledger
+		if perf.Mode != domain.LevelModeLive {
+			ledger = s.shadow
+		}
+		if share, ok := ledger.Get(l.ID); ok && share.Size > 0 {
+			perf.Open = share
+			if price := s.GetLatestPrice(l.Symbol); price > 0 {
+				perf.UnrealizedPnL = (price - share.EntryPrice) * share.Size
+				if share.Side == domain.SideShort {
+					perf.UnrealizedPnL = -perf.UnrealizedPnL
+				}
+				perf.UnrealizedPnL -= share.EntryFees
+			}
+		}
+		result = append(result, perf)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Symbol != result[j].Symbol {
+			return result[i].Symbol < result[j].Symbol
+		}
+		return result[i].LevelPrice < result[j].LevelPrice
+	})
+	return result, nil
+}
+
+// ListShadowTrades returns the shadow ledger of a level (all levels if levelID is empty), newest first.
+func (s *LevelService) ListShadowTrades(ctx context.Context, levelID string, limit int) ([]*domain.ShadowTrade, error) {
+	s.mu.RLock()
+	repo := s.shadowRepo
+	s.mu.RUnlock()
+	if repo == nil {
+		return nil, nil
+	}
+	return repo.ListShadowTrades(ctx, levelID, limit)
+}
+
+// checkShadowSafety is CheckSafety for the simulated shares: a shadow share on the wrong
+// side of its level is closed.
+func (s *LevelService) checkShadowSafety(ctx context.Context, levels []*domain.Level, price float64) {
+	if price == 0 {
+		return
+	}
+	for _, level := range levels {
+		if level.EffectiveMode() != domain.LevelModeShadow {
+			continue
+		}
+		share, ok := s.shadow.Get(level.ID)
+		if !ok || share.Size <= 0 {
+			continue
+		}
+		if (share.Side == domain.SideLong && price < level.LevelPrice) || (share.Side == domain.SideShort && price > level.LevelPrice) {
+			log.Printf("SAFETY: UNSAFE shadow %s on %s (level %s). Price %f, Level %f. Closing...", share.Side, level.Symbol, level.ID, price, level.LevelPrice)
+			if _, err := s.closeShadowShare(ctx, level, "Safety Exit", price, nil); err != nil {
+				log.Printf("SAFETY: Failed to close shadow share of level %s: %v", level.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// executeEntry sends a market tier entry (levels with an execution algorithm go through
+// executeEntryAlgo). Levels with a slippage limit go through the guarded executor, which may
+// shrink or slice the order; the returned execution then says what was sent and at which
+// estimated and actual prices. Other levels send the order as is and get a nil execution.
+func (s *LevelService) executeEntry(ctx context.Context, level *domain.Level, side domain.Side, size, stopLoss float64) (*Execution, error) {
+	if level.MaxSlippagePct <= 0 {
+		return nil, s.executor.Execute(ctx, level.Symbol, side, size, level.Leverage, level.MarginType, stopLoss)
+	}
+
+	req := ExecutionRequest{
+		Symbol:         level.Symbol,
+		Side:           side,
+		Size:           size,
+		Leverage:       level.Leverage,
+		MarginType:     level.MarginType,
+		StopLoss:       stopLoss,
+		MaxSlippagePct: level.MaxSlippagePct,
+		SlippageAction: level.EffectiveSlippageAction(),
+	}
+	// Order filters for downsizing, the guard still runs without them
+	if inst, err := s.sizer.instrument(ctx, level.Symbol); err == nil {
+		req.QtyStep, req.MinQty = inst.QtyStep, inst.MinOrderQty
+	}
+
+	exec, err := s.executor.ExecuteGuarded(ctx, req)
+	if errors.Is(err, ErrSlippageRejected) {
+		return exec, err
+	}
+	if exec != nil && exec.Filled > 0 {
+		if err != nil {
+			// Later slices failed, what went out is still a position
+			log.Printf("SLIPPAGE: %s %s filled %f of %f before a slice failed: %v", level.Symbol, side, exec.Filled, size, err)
+		}
+		if exec.Action != "full" {
+			log.Printf("SLIPPAGE: %s %s %s to %f of %f in %d orders (level %s): %s", level.Symbol, side, exec.Action, exec.Filled, size, len(exec.Slices), level.ID, exec.Reason)
+		}
+		return exec, nil
+	}
+	return exec, err
+}
+
+// logFillSlippage reports the estimated against the actual fill of an execution.
+func logFillSlippage(symbol string, side domain.Side, exec *Execution, tickPrice float64) {
+	if exec == nil || exec.ExpectedPrice <= 0 {
+		return
+	}
+	if exec.FillPrice <= 0 {
+		log.Printf("SLIPPAGE: %s %s %f expected at %f (%.3f%% slippage, tick %f), fill price unknown", symbol, side, exec.Filled, exec.ExpectedPrice, exec.ExpectedSlippagePct()*100, tickPrice)
+		return
+	}
+	log.Printf("SLIPPAGE: %s %s %f expected at %f (%.3f%% slippage, tick %f), filled at %f (%.3f%% off the estimate)",
+		symbol, side, exec.Filled, exec.ExpectedPrice, exec.ExpectedSlippagePct()*100, tickPrice, exec.FillPrice, math.Abs(exec.FillPrice-exec.ExpectedPrice)/exec.ExpectedPrice*100)
+}
+
+// SlippageStats compares the expected and actual fills of the measured entries of a symbol.
+// Percentages are adverse positive: paying more on a buy or getting less on a sell.
+type SlippageStats struct {
+	Symbol              string  `json:"symbol"`
+	Entries             int     `json:"entries"`                // With a book estimate
+	Measured            int     `json:"measured"`               // With a fill price as well
+	AvgExpectedPct      float64 `json:"avg_expected_pct"`       // Estimate vs tick price
+	AvgActualPct        float64 `json:"avg_actual_pct"`         // Fill vs tick price, measured entries
+	AvgEstimateErrorPct float64 `json:"avg_estimate_error_pct"` // Fill vs estimate, measured entries
+	WorstActualPct      float64 `json:"worst_actual_pct"`
+}
+
+// adverseSlippage returns how much worse price is than ref for an order of side.
+func adverseSlippage(side domain.Side, price, ref float64) float64 {
+	if side == domain.SideShort {
+		return (ref - price) / ref
+	}
+	return (price - ref) / ref
+}
+
+// SlippageReport aggregates the slippage of the last limit trades per symbol, ordered by
+// symbol. Only entries of guarded levels carry the estimates.
+func (s *LevelService) SlippageReport(ctx context.Context, limit int) ([]SlippageStats, error) {
+	trades, err := s.tradeRepo.ListTrades(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trades: %w", err)
+	}
+
+	bySymbol := make(map[string]*SlippageStats)
+	var symbols []string
+	for _, t := range trades {
+		if t.ExpectedPrice <= 0 || t.Price <= 0 {
+			continue
+		}
+		st, ok := bySymbol[t.Symbol]
+		if !ok {
+			st = &SlippageStats{Symbol: t.Symbol}
+			bySymbol[t.Symbol] = st
+			symbols = append(symbols, t.Symbol)
+		}
+		st.Entries++
+		st.AvgExpectedPct += adverseSlippage(t.Side, t.ExpectedPrice, t.Price)
+		if t.FillPrice > 0 {
+			st.Measured++
+			actual := adverseSlippage(t.Side, t.FillPrice, t.Price)
+			st.AvgActualPct += actual
+			st.AvgEstimateErrorPct += adverseSlippage(t.Side, t.FillPrice, t.ExpectedPrice)
+			if st.Measured == 1 || actual > st.WorstActualPct {
+				st.WorstActualPct = actual
+			}
+		}
+	}
+
+	sort.Strings(symbols)
+	report := make([]SlippageStats, 0, len(symbols))
+	for _, symbol := range symbols {
+		st := bySymbol[symbol]
+		st.AvgExpectedPct /= float64(st.Entries)
+		if st.Measured > 0 {
+			st.AvgActualPct /= float64(st.Measured)
+			st.AvgEstimateErrorPct /= float64(st.Measured)
+		}
+		report = append(report, *st)
+	}
+	return report, nil
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// StrategyKindLevel is the runtime kind of the level bot.
+const StrategyKindLevel = "level"
+
+// LevelStrategy runs a LevelService inside the StrategyRuntime.
+// Levels themselves stay in the level repository, the strategy only drives the service:
+// ticks go to ProcessTick and the timer runs the safety check.
+type LevelStrategy struct {
+	svc          *LevelService
+	exchangeName string
+}
+
+// LevelStrategyStatus is the Status of the level strategy.
+type LevelStrategyStatus struct {
+	Exchange string `json:"exchange"`
+	Symbols  int    `json:"symbols"`
+	Levels   int    `json:"levels"`
+}
+
+func NewLevelStrategy(svc *LevelService, exchangeName string) *LevelStrategy {
+	return &LevelStrategy{
+		svc:          svc,
+		exchangeName: exchangeName,
+	}
+}
+
+// LevelStrategySpec is the spec the level bot is started with: every symbol, safety check every second.
+func LevelStrategySpec(exchangeName string) StrategySpec {
+	return StrategySpec{
+		ID:            StrategyKindLevel + ":" + exchangeName,
+		Kind:          StrategyKindLevel,
+		Symbols:       []string{AllSymbols},
+		TimerInterval: 1 * time.Second,
+	}
+}
+
+func (l *LevelStrategy) Init(ctx context.Context, env StrategyEnv) error {
+	l.svc.mu.Lock()
+	l.svc.onFill = env.ReportFill
+	l.svc.mu.Unlock()
+	// Symbols with live levels are leased by UpdateCache
+	if env.Leases != nil {
+		l.svc.SetSymbolLeases(env.Leases, env.ID)
+	}
+	return l.svc.UpdateCache(ctx)
+}
+
+// ParallelSymbols lets ticks of different symbols run concurrently. LevelService has always
+// handled ticks from the WS feed next to CheckSafety, and its per-symbol state is locked.
+func (l *LevelStrategy) ParallelSymbols() bool { return true }
+
+func (l *LevelStrategy) OnTick(ctx context.Context, symbol string, price float64) error {
+	return l.svc.ProcessTick(ctx, l.exchangeName, symbol, price)
+}
+
+func (l *LevelStrategy) OnTrade(ctx context.Context, trade domain.PublicTrade) error { return nil }
+
+func (l *LevelStrategy) OnFill(ctx context.Context, order *domain.Order) error { return nil }
+
+func (l *LevelStrategy) OnTimer(ctx context.Context, now time.Time) error {
+	l.svc.CheckSafety(ctx)
+	return nil
+}
+
+func (l *LevelStrategy) Status(ctx context.Context) (interface{}, error) {
+	l.svc.mu.RLock()
+	defer l.svc.mu.RUnlock()
+	status := &LevelStrategyStatus{Exchange: l.exchangeName, Symbols: len(l.svc.levelsCache)}
+	for _, levels := range l.svc.levelsCache {
+		status.Levels += len(levels)
+	}
+	return status, nil
+}
+
+func (l *LevelStrategy) Stop(ctx context.Context) error {
+	l.svc.mu.Lock()
+	l.svc.onFill = nil
+	l.svc.mu.Unlock()
+	// The runtime releases the leases themselves
+	l.svc.SetSymbolLeases(nil, "")
+	// Entries still working were cancelled with the runtime context, book what they filled.
+	// Exits send their rest at market.
+	l.svc.WaitEntries()
+	l.svc.parents.CancelExits("", CancelledByMarketExit)
+	l.svc.WaitExits()
+	return nil
+}
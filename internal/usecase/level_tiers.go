@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// ErrTiersActive is returned when tiers are changed while a level of the symbol is mid-trade.
+var ErrTiersActive = errors.New("tiers are active")
+
+// GetTiers returns the saved tiers of a symbol.
+func (s *LevelService) GetTiers(ctx context.Context, exchange, symbol string) (*domain.SymbolTiers, error) {
+	tiers, err := s.levelRepo.GetSymbolTiers(ctx, exchange, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tiers for %s: %w", symbol, err)
+	}
+	return tiers, nil
+}
+
+// UpdateTiers validates and saves the tiers of a symbol. Changing them while a level
+// has triggered tiers or holds a position would move the boundaries under a running trade.
+func (s *LevelService) UpdateTiers(ctx context.Context, tiers *domain.SymbolTiers) error {
+	if tiers.Exchange == "" || tiers.Symbol == "" {
+		return fmt.Errorf("exchange and symbol are required")
+	}
+	if err := tiers.Validate(); err != nil {
+		return err
+	}
+	if s.TiersActive(tiers.Symbol) {
+		return fmt.Errorf("cannot change tiers of %s: %w", tiers.Symbol, ErrTiersActive)
+	}
+
+	tiers.UpdatedAt = time.Now()
+	if err := s.levelRepo.SaveSymbolTiers(ctx, tiers); err != nil {
+		return fmt.Errorf("failed to save tiers: %w", err)
+	}
+	log.Printf("AUDIT: Tiers for %s set to %.4f/%.4f/%.4f", tiers.Symbol, tiers.Tier1Pct, tiers.Tier2Pct, tiers.Tier3Pct)
+
+	return s.UpdateCache(ctx)
+}
+
+// ApplyTierPreset sets a preset on many symbols. Symbols whose tiers are active are skipped,
+// the returned map holds the reason per skipped symbol.
+func (s *LevelService) ApplyTierPreset(ctx context.Context, exchange, presetName string, symbols []string) ([]string, map[string]string, error) {
+	preset, ok := domain.FindTierPreset(presetName)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown tier preset %q", presetName)
+	}
+
+	var applied []string
+	skipped := make(map[string]string)
+	for _, symbol := range symbols {
+		if err := s.UpdateTiers(ctx, preset.Tiers(exchange, symbol)); err != nil {
+			skipped[symbol] = err.Error()
+			continue
+		}
+		applied = append(applied, symbol)
+	}
+	return applied, skipped, nil
+}
+
+// TiersActive reports whether any level of the symbol has triggered tiers or holds a position.
+func (s *LevelService) TiersActive(symbol string) bool {
+	if len(s.ledger.OpenBySymbol(symbol)) > 0 {
+		return true
+	}
+
+	s.mu.RLock()
+	levels := s.levelsCache[symbol]
+	s.mu.RUnlock()
+
+	for _, l := range levels {
+		st := s.engine.GetState(l.ID)
+		if st.ActiveSide != "" || st.Tier1Triggered || st.Tier2Triggered || st.Tier3Triggered {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,354 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// ErrLevelHasPosition is returned when an edit is not allowed while the level holds a position.
+var ErrLevelHasPosition = errors.New("level has an open position")
+
+// LevelPatch holds the editable fields of a level, nil fields are left unchanged.
+// Units follow the add-level form: percentages are in percent (2 = 2%), the base close
+// cooldown is in minutes, the ladder and filters use their compact text forms.
+type LevelPatch struct {
+	LevelPrice               *float64 `json:"level_price"`
+	BaseSize                 *float64 `json:"base_size"`
+	Leverage                 *int     `json:"leverage"`
+	MarginType               *string  `json:"margin_type"`
+	CoolDownMs               *int64   `json:"cool_down_ms"`
+	StopLossAtBase           *bool    `json:"stop_loss_at_base"`
+	StopLossMode             *string  `json:"stop_loss_mode"`
+	DisableSpeedClose        *bool    `json:"disable_speed_close"`
+	MaxConsecutiveBaseCloses *int     `json:"max_consecutive_base_closes"`
+	BaseCloseCooldownMinutes *int     `json:"base_close_cooldown_minutes"`
+	TakeProfitPct            *float64 `json:"take_profit_pct"`
+	TakeProfitMode           *string  `json:"take_profit_mode"`
+	TakeProfitLadder         *string  `json:"take_profit_ladder"`
+	TrailingActivationPct    *float64 `json:"trailing_activation_pct"`
+	TrailingStopPct          *float64 `json:"trailing_stop_pct"`
+	BreakEvenTier            *int     `json:"break_even_tier"`
+	BreakEvenProfitPct       *float64 `json:"break_even_profit_pct"`
+	TrailingStopMode         *string  `json:"trailing_stop_mode"`
+	EntryFilters             *string  `json:"entry_filters"`
+	AutoModeEnabled          *bool    `json:"auto_mode_enabled"`
+	Mode                     *string  `json:"mode"`        // live, shadow or alert
+	SizingMode               *string  `json:"sizing_mode"` // fixed, risk_pct or risk_usd
+	RiskPct                  *float64 `json:"risk_pct"`
+	RiskUSD                  *float64 `json:"risk_usd"`
+	MaxSlippagePct           *float64 `json:"max_slippage_pct"` // Percent, 0 disables the guard
+	SlippageAction           *string  `json:"slippage_action"`  // reject, downsize or split
+	ExecAlgo                 *string  `json:"exec_algo"`        // market, chase, twap or iceberg
+	ExecDurationSec          *int     `json:"exec_duration_sec"`
+	ExecClipSize             *float64 `json:"exec_clip_size"`
+	ExecMaxChasePct          *float64 `json:"exec_max_chase_pct"` // Percent, 0 = unbounded
+	ExecFallback             *string  `json:"exec_fallback"`      // market or ioc
+}
+
+// Complete reports whether the patch sets the fields a full replacement (PUT) needs.
+func (p LevelPatch) Complete() bool {
+	return p.LevelPrice != nil && p.BaseSize != nil && p.Leverage != nil && p.MarginType != nil
+}
+
+// Apply validates the patch and writes it into the level.
+func (p LevelPatch) Apply(l *domain.Level) error {
+	if p.LevelPrice != nil {
+		if *p.LevelPrice <= 0 {
+			return fmt.Errorf("level_price must be greater than 0")
+		}
+		l.LevelPrice = *p.LevelPrice
+	}
+	if p.BaseSize != nil {
+		if *p.BaseSize <= 0 {
+			return fmt.Errorf("base_size must be greater than 0")
+		}
+		l.BaseSize = *p.BaseSize
+	}
+	if p.Leverage != nil {
+		if *p.Leverage <= 0 {
+			return fmt.Errorf("leverage must be greater than 0")
+		}
+		l.Leverage = *p.Leverage
+	}
+	if p.MarginType != nil {
+		if *p.MarginType != "isolated" && *p.MarginType != "cross" {
+			return fmt.Errorf("margin_type must be isolated or cross")
+		}
+		l.MarginType = *p.MarginType
+	}
+	if p.CoolDownMs != nil {
+		if *p.CoolDownMs < 0 {
+			return fmt.Errorf("cool_down_ms must not be negative")
+		}
+		l.CoolDownMs = *p.CoolDownMs
+	}
+	if p.StopLossAtBase != nil {
+		l.StopLossAtBase = *p.StopLossAtBase
+	}
+	if p.StopLossMode != nil {
+		if *p.StopLossMode != "exchange" && *p.StopLossMode != "app" {
+			return fmt.Errorf("stop_loss_mode must be exchange or app")
+		}
+		l.StopLossMode = *p.StopLossMode
+	}
+	if p.DisableSpeedClose != nil {
+		l.DisableSpeedClose = *p.DisableSpeedClose
+	}
+	if p.MaxConsecutiveBaseCloses != nil {
+		if *p.MaxConsecutiveBaseCloses < 0 {
+			return fmt.Errorf("max_consecutive_base_closes must not be negative")
+		}
+		l.MaxConsecutiveBaseCloses = *p.MaxConsecutiveBaseCloses
+	}
+	if p.BaseCloseCooldownMinutes != nil {
+		if *p.BaseCloseCooldownMinutes < 0 {
+			return fmt.Errorf("base_close_cooldown_minutes must not be negative")
+		}
+		l.BaseCloseCooldownMs = int64(*p.BaseCloseCooldownMinutes) * 60 * 1000
+	}
+	if p.TakeProfitPct != nil {
+		if *p.TakeProfitPct <= 0 {
+			return fmt.Errorf("take_profit_pct must be greater than 0")
+		}
+		l.TakeProfitPct = *p.TakeProfitPct / 100
+	}
+	if p.TakeProfitMode != nil {
+		mode, err := domain.ParseTakeProfitMode(*p.TakeProfitMode)
+		if err != nil {
+			return err
+		}
+		l.TakeProfitMode = mode
+	}
+	if p.TakeProfitLadder != nil {
+		ladder, err := domain.ParseTakeProfitLadder(*p.TakeProfitLadder)
+		if err != nil {
+			return err
+		}
+		l.TakeProfitLadder = ladder
+	}
+	if p.TrailingActivationPct != nil {
+		if *p.TrailingActivationPct < 0 {
+			return fmt.Errorf("trailing_activation_pct must not be negative")
+		}
+		l.TrailingActivationPct = *p.TrailingActivationPct / 100
+	}
+	if p.TrailingStopPct != nil {
+		if *p.TrailingStopPct < 0 {
+			return fmt.Errorf("trailing_stop_pct must not be negative")
+		}
+		l.TrailingStopPct = *p.TrailingStopPct / 100
+	}
+	if p.BreakEvenTier != nil {
+		if *p.BreakEvenTier < 0 || *p.BreakEvenTier > 3 {
+			return fmt.Errorf("break_even_tier must be between 0 and 3")
+		}
+		l.BreakEvenTier = *p.BreakEvenTier
+	}
+	if p.BreakEvenProfitPct != nil {
+		if *p.BreakEvenProfitPct < 0 {
+			return fmt.Errorf("break_even_profit_pct must not be negative")
+		}
+		l.BreakEvenProfitPct = *p.BreakEvenProfitPct / 100
+	}
+	if p.TrailingStopMode != nil {
+		if *p.TrailingStopMode != "app" && *p.TrailingStopMode != "exchange" {
+			return fmt.Errorf("trailing_stop_mode must be app or exchange")
+		}
+		l.TrailingStopMode = *p.TrailingStopMode
+	}
+	if p.EntryFilters != nil {
+		filters, err := domain.ParseEntryFilters(*p.EntryFilters)
+		if err != nil {
+			return err
+		}
+		l.EntryFilters = filters
+	}
+	if p.AutoModeEnabled != nil {
+		l.AutoModeEnabled = *p.AutoModeEnabled
+	}
+	if p.Mode != nil {
+		mode, err := domain.ParseLevelMode(*p.Mode)
+		if err != nil {
+			return err
+		}
+		l.Mode = mode
+	}
+	if p.SizingMode != nil {
+		mode, err := domain.ParseSizingMode(*p.SizingMode)
+		if err != nil {
+			return err
+		}
+		l.SizingMode = mode
+	}
+	if p.RiskPct != nil {
+		if *p.RiskPct < 0 {
+			return fmt.Errorf("risk_pct must not be negative")
+		}
+		l.RiskPct = *p.RiskPct / 100
+	}
+	if p.RiskUSD != nil {
+		if *p.RiskUSD < 0 {
+			return fmt.Errorf("risk_usd must not be negative")
+		}
+		l.RiskUSD = *p.RiskUSD
+	}
+	if p.MaxSlippagePct != nil {
+		if *p.MaxSlippagePct < 0 {
+			return fmt.Errorf("max_slippage_pct must not be negative")
+		}
+		l.MaxSlippagePct = *p.MaxSlippagePct / 100
+	}
+	if p.SlippageAction != nil {
+		action, err := domain.ParseSlippageAction(*p.SlippageAction)
+		if err != nil {
+			return err
+		}
+		l.SlippageAction = action
+	}
+	if p.ExecAlgo != nil {
+		algo, err := domain.ParseExecAlgo(*p.ExecAlgo)
+		if err != nil {
+			return err
+		}
+		l.ExecAlgo = algo
+	}
+	if p.ExecDurationSec != nil {
+		if *p.ExecDurationSec < 0 {
+			return fmt.Errorf("exec_duration_sec must not be negative")
+		}
+		l.ExecDurationSec = *p.ExecDurationSec
+	}
+	if p.ExecClipSize != nil {
+		if *p.ExecClipSize < 0 {
+			return fmt.Errorf("exec_clip_size must not be negative")
+		}
+		l.ExecClipSize = *p.ExecClipSize
+	}
+	if p.ExecMaxChasePct != nil {
+		if *p.ExecMaxChasePct < 0 {
+			return fmt.Errorf("exec_max_chase_pct must not be negative")
+		}
+		l.ExecMaxChasePct = *p.ExecMaxChasePct / 100
+	}
+	if p.ExecFallback != nil {
+		fallback, err := domain.ParseExecFallback(*p.ExecFallback)
+		if err != nil {
+			return err
+		}
+		l.ExecFallback = fallback
+	}
+	return l.ValidateSizing()
+}
+
+// UpdateLevel edits a level in place. The ID stays the same, so the runtime state
+// (streaks, cooldowns, the ledger share) survives the edit. Every effective change is
+// recorded as a revision.
+func (s *LevelService) UpdateLevel(ctx context.Context, id string, patch LevelPatch, changedBy string) (*domain.Level, []domain.LevelFieldChange, error) {
+	// 1. Load current version
+	current, err := s.levelRepo.GetLevel(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load level %s: %w", id, err)
+	}
+
+	updated := *current
+	if err := patch.Apply(&updated); err != nil {
+		return nil, nil, err
+	}
+
+	changes, err := s.saveLevelEdit(ctx, current, &updated, changedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 {
+		return current, nil, nil
+	}
+
+	if err := s.UpdateCache(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to refresh cache: %w", err)
+	}
+	return &updated, changes, nil
+}
+
+// saveLevelEdit stores a new version of a level and records the revision. It does not
+// refresh the cache, so bulk edits can refresh once at the end.
+func (s *LevelService) saveLevelEdit(ctx context.Context, current, updated *domain.Level, changedBy string) ([]domain.LevelFieldChange, error) {
+	changes := domain.DiffLevels(current, updated)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	// 1. Moving the level under an open position would re-anchor tiers and the base stop
+	// of a trade that was opened against the old price.
+	priceChanged := updated.LevelPrice != current.LevelPrice
+	if priceChanged && s.levelHasPosition(ctx, current) {
+		return nil, fmt.Errorf("cannot change level price: %w", ErrLevelHasPosition)
+	}
+
+	// 2. Switching the mode would strand the open share in the other ledger
+	if updated.EffectiveMode() != current.EffectiveMode() && s.levelHasPosition(ctx, current) {
+		return nil, fmt.Errorf("cannot change level mode: %w", ErrLevelHasPosition)
+	}
+
+	// 3. Going live trades the symbol position, another strategy must not own it
+	if updated.IsLive() && !current.IsLive() {
+		if err := s.checkSymbolLease(updated.Symbol); err != nil {
+			return nil, fmt.Errorf("cannot switch level to live: %w", err)
+		}
+	}
+
+	// 4. Save and record the revision
+	if err := s.levelRepo.UpdateLevel(ctx, updated); err != nil {
+		return nil, fmt.Errorf("failed to update level: %w", err)
+	}
+	rev := &domain.LevelRevision{
+		LevelID:   current.ID,
+		ChangedBy: changedBy,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+	if err := s.levelRepo.SaveLevelRevision(ctx, rev); err != nil {
+		log.Printf("AUDIT: Failed to save revision for level %s: %v", current.ID, err)
+	}
+	log.Printf("AUDIT: Level %s updated by %s: %v", current.ID, changedBy, changes)
+
+	// 5. Tier triggers of a flat level belong to the old price (or the other mode)
+	if priceChanged || updated.EffectiveMode() != current.EffectiveMode() {
+		s.engine.ResetState(current.ID)
+	}
+	return changes, nil
+}
+
+// levelHasPosition reports whether the level owns an open share. Without any share on the
+// symbol the exchange position cannot be attributed, so any open position counts.
+// Shadow levels only ever hold a shadow share, alert levels never hold anything.
+func (s *LevelService) levelHasPosition(ctx context.Context, level *domain.Level) bool {
+	switch level.EffectiveMode() {
+	case domain.LevelModeShadow:
+		share, ok := s.shadow.Get(level.ID)
+		return ok && share.Size > 0
+	case domain.LevelModeAlert:
+		return false
+	}
+	if share, ok := s.ledger.Get(level.ID); ok && share.Size > 0 {
+		return true
+	}
+	if len(s.ledger.OpenBySymbol(level.Symbol)) > 0 {
+		return false
+	}
+	pos, err := s.exchange.GetPosition(ctx, level.Symbol)
+	if err != nil {
+		// Unknown exposure, refuse rather than move a level under a live trade
+		return true
+	}
+	return pos != nil && pos.Size > 0
+}
+
+// ListLevelRevisions returns the edit history of a level, newest first.
+func (s *LevelService) ListLevelRevisions(ctx context.Context, id string, limit int) ([]*domain.LevelRevision, error) {
+	return s.levelRepo.ListLevelRevisions(ctx, id, limit)
+}
@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+const riskLimitCacheTTL = time.Hour
+
+// LiquidationGuardConfig controls the projected liquidation check of tier entries.
+type LiquidationGuardConfig struct {
+	Disabled  bool    `yaml:"disabled"`
+	BufferPct float64 `yaml:"buffer_pct"` // Min distance of the liquidation price beyond the level, e.g. 0.002 = 0.2%
+}
+
+// LiquidationCheck is a tier entry to project: the position it adds to and the add itself.
+type LiquidationCheck struct {
+	Symbol     string
+	Side       domain.Side
+	LevelPrice float64
+	Leverage   int
+	MarginType string           // "isolated" or "cross"
+	Position   *domain.Position // Position the add lands on, nil or zero size if flat
+	Size       float64
+	Price      float64
+	QtyStep    float64 // Resized adds are floored to it, 0 skips rounding
+	MinQty     float64
+}
+
+// LiquidationVerdict is the outcome of a check, it goes into the decision journal.
+type LiquidationVerdict struct {
+	Allowed          bool    `json:"allowed"`
+	Resized          bool    `json:"resized,omitempty"`
+	Size             float64 `json:"size"` // Allowed size, the requested one unless resized
+	RequestedSize    float64 `json:"requested_size"`
+	AvgEntry         float64 `json:"avg_entry"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+	Limit            float64 `json:"limit"` // Level moved out by the buffer, liquidation must stay beyond it
+	MMRate           float64 `json:"mm_rate"`
+	Reason           string  `json:"reason,omitempty"`
+}
+
+// LiquidationGuard projects the liquidation price of a position after a tier entry.
+// The level is the stop of every tier, so the liquidation price has to sit beyond it:
+// below the level for longs, above it for shorts. Otherwise the exchange liquidates the
+// position before StopLossAtBase gets a chance to close it.
+type LiquidationGuard struct {
+	exchange domain.Exchange
+
+	mu       sync.Mutex
+	cfg      LiquidationGuardConfig
+	limits   map[string][]domain.RiskLimitTier
+	limitsAt map[string]time.Time
+}
+
+func NewLiquidationGuard(exchange domain.Exchange) *LiquidationGuard {
+	return &LiquidationGuard{
+		exchange: exchange,
+		limits:   make(map[string][]domain.RiskLimitTier),
+		limitsAt: make(map[string]time.Time),
+	}
+}
+
+func (g *LiquidationGuard) SetConfig(cfg LiquidationGuardConfig) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cfg = cfg
+}
+
+func (g *LiquidationGuard) Config() LiquidationGuardConfig {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cfg
+}
+
+// Check projects the entry. An entry whose liquidation price would land on the wrong side of
+// the limit is shrunk to the largest size that keeps it safe, or refused if none does.
+// Without leverage there is nothing to liquidate and every entry passes.
+func (g *LiquidationGuard) Check(ctx context.Context, req LiquidationCheck) (*LiquidationVerdict, error) {
+	cfg := g.Config()
+	verdict := &LiquidationVerdict{Allowed: true, Size: req.Size, RequestedSize: req.Size}
+	if cfg.Disabled || req.Leverage <= 0 || req.Size <= 0 {
+		return verdict, nil
+	}
+
+	// 1. Inputs: risk limits and, for cross margin, the account equity backing the position
+	tiers, err := g.riskLimits(ctx, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	crossMargin := 0.0
+	if req.MarginType == "cross" {
+		eq, err := g.exchange.GetEquity(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get equity: %w", err)
+		}
+		crossMargin = eq.Equity
+	}
+
+	if req.Side == domain.SideLong {
+		verdict.Limit = req.LevelPrice * (1 - cfg.BufferPct)
+	} else {
+		verdict.Limit = req.LevelPrice * (1 + cfg.BufferPct)
+	}
+	safe := func(liq float64) bool {
+		if req.Side == domain.SideLong {
+			return liq < verdict.Limit
+		}
+		return liq > verdict.Limit
+	}
+	project := func(size float64) (avg, liq, mmr float64) {
+		return projectLiquidation(req, size, tiers, crossMargin)
+	}
+
+	// 2. Requested size
+	verdict.AvgEntry, verdict.LiquidationPrice, verdict.MMRate = project(req.Size)
+	if safe(verdict.LiquidationPrice) {
+		return verdict, nil
+	}
+
+	// 3. Largest safe size. Cross liquidation moves toward the entry as size grows, isolated
+	// liquidation follows the average entry, so the search covers both directions by only
+	// trusting sizes that were checked.
+	best := 0.0
+	lo, hi := 0.0, req.Size
+	if _, liq, _ := project(math.Max(req.MinQty, req.Size*1e-3)); safe(liq) {
+		for i := 0; i < 40; i++ {
+			mid := (lo + hi) / 2
+			if _, liq, _ := project(mid); safe(liq) {
+				best, lo = mid, mid
+			} else {
+				hi = mid
+			}
+		}
+	}
+	best = roundDownToStep(best, req.QtyStep)
+	if best > 0 && best >= req.MinQty {
+		if _, liq, _ := project(best); safe(liq) {
+			verdict.Resized = true
+			verdict.Size = best
+			verdict.AvgEntry, verdict.LiquidationPrice, verdict.MMRate = project(best)
+			verdict.Reason = fmt.Sprintf("resized %g -> %g to keep liquidation beyond %.6f", req.Size, best, verdict.Limit)
+			return verdict, nil
+		}
+	}
+
+	verdict.Allowed = false
+	verdict.Size = 0
+	verdict.Reason = fmt.Sprintf("projected liquidation %.6f is not beyond %.6f (level %.6f, buffer %.2f%%)",
+		verdict.LiquidationPrice, verdict.Limit, req.LevelPrice, cfg.BufferPct*100)
+	return verdict, nil
+}
+
+// projectLiquidation returns the average entry, liquidation price and maintenance margin rate
+// of the position after adding size at req.Price. The margin backing the position is its
+// initial margin when isolated and the account equity when cross:
+//
+//	long:  liq = avg - (margin - mm) / size
+//	short: liq = avg + (margin - mm) / size
+//
+// Fees and funding are ignored, so this is slightly optimistic, the buffer covers that.
+func projectLiquidation(req LiquidationCheck, size float64, tiers []domain.RiskLimitTier, crossMargin float64) (avg, liq, mmr float64) {
+	totalSize := size
+	cost := size * req.Price
+	if pos := req.Position; pos != nil && pos.Size > 0 && pos.Side == req.Side {
+		totalSize += pos.Size
+		cost += pos.Size * pos.EntryPrice
+	}
+	avg = cost / totalSize
+	notional := avg * totalSize
+
+	mmr, deduction := maintenanceMargin(tiers, notional)
+	mm := notional*mmr - deduction
+
+	margin := crossMargin
+	if req.MarginType != "cross" {
+		margin = notional / float64(req.Leverage)
+	}
+
+	if req.Side == domain.SideLong {
+		liq = avg - (margin-mm)/totalSize
+	} else {
+		liq = avg + (margin-mm)/totalSize
+	}
+	return avg, math.Max(liq, 0), mmr
+}
+
+// maintenanceMargin picks the risk limit tier of a notional. Without tiers (exchange did not
+// report any) there is no maintenance margin, which only makes the projection optimistic.
+func maintenanceMargin(tiers []domain.RiskLimitTier, notional float64) (rate, deduction float64) {
+	for _, t := range tiers {
+		if notional <= t.RiskLimitValue {
+			return t.MaintenanceMarginRate, t.MMDeduction
+		}
+	}
+	if len(tiers) > 0 {
+		last := tiers[len(tiers)-1]
+		return last.MaintenanceMarginRate, last.MMDeduction
+	}
+	return 0, 0
+}
+
+// riskLimits returns the cached risk limit tiers of a symbol.
+func (g *LiquidationGuard) riskLimits(ctx context.Context, symbol string) ([]domain.RiskLimitTier, error) {
+	g.mu.Lock()
+	tiers, ok := g.limits[symbol]
+	fresh := time.Since(g.limitsAt[symbol]) < riskLimitCacheTTL
+	g.mu.Unlock()
+	if ok && fresh {
+		return tiers, nil
+	}
+
+	fetched, err := g.exchange.GetRiskLimits(ctx, symbol)
+	if err != nil {
+		if ok {
+			return tiers, nil // Stale tiers beat no check
+		}
+		return nil, fmt.Errorf("failed to get risk limits: %w", err)
+	}
+
+	g.mu.Lock()
+	g.limits[symbol] = fetched
+	g.limitsAt[symbol] = time.Now()
+	g.mu.Unlock()
+	return fetched, nil
+}
+
+// SetLiquidationGuard configures the liquidation check of tier entries.
+func (s *LevelService) SetLiquidationGuard(cfg LiquidationGuardConfig) {
+	s.liqGuard.SetConfig(cfg)
+}
+
+// checkLiquidation projects a tier entry of a level onto the position it adds to: the exchange
+// position for live levels, the simulated share for shadow levels. Alert levels never fill.
+func (s *LevelService) checkLiquidation(ctx context.Context, level *domain.Level, side domain.Side, size, price float64) (*LiquidationVerdict, error) {
+	req := LiquidationCheck{
+		Symbol:     level.Symbol,
+		Side:       side,
+		LevelPrice: level.LevelPrice,
+		Leverage:   level.Leverage,
+		MarginType: level.MarginType,
+		Size:       size,
+		Price:      price,
+	}
+
+	switch level.EffectiveMode() {
+	case domain.LevelModeAlert:
+		return &LiquidationVerdict{Allowed: true, Size: size, RequestedSize: size}, nil
+	case domain.LevelModeShadow:
+		if share, ok := s.shadow.Get(level.ID); ok && share.Size > 0 {
+			req.Position = &domain.Position{Symbol: level.Symbol, Side: share.Side, Size: share.Size, EntryPrice: share.EntryPrice}
+		}
+	default:
+		pos, err := s.getPosition(ctx, level.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get position: %w", err)
+		}
+		req.Position = pos
+		// The exchange knows the leverage and margin mode the position really runs with
+		if pos != nil && pos.Size > 0 {
+			if pos.Leverage > 0 {
+				req.Leverage = pos.Leverage
+			}
+			if pos.MarginType != "" {
+				req.MarginType = pos.MarginType
+			}
+		}
+	}
+
+	// Order filters for resizing, the check still runs without them
+	if inst, err := s.sizer.instrument(ctx, level.Symbol); err == nil {
+		req.QtyStep, req.MinQty = inst.QtyStep, inst.MinOrderQty
+	}
+	return s.liqGuard.Check(ctx, req)
+}
@@ -26,29 +26,26 @@ type Trade struct {
 	Time   time.Time
 }
 
-type PricePoint struct {
-	Price float64
-	Time  time.Time
-}
-
 type MarketService struct {
 	exchange         domain.Exchange
 	repo             domain.LevelRepository
 	cache            map[string]CachedLiquidity
-	trades           map[string][]Trade // Symbol -> Trades
-	depthHistory     map[string][]DepthSnapshot
-	priceHistory     map[string][]PricePoint // Symbol -> Price Points
-	cvdAccumulator   map[string]float64      // Symbol -> Cumulative Volume Delta
 	liquidityHistory map[string][]domain.LiquiditySnapshot
 	subscribed       map[string]bool // Symbol -> Subscribed
 	mu               sync.Mutex
 	timeNow          func() time.Time // For testing
-}
 
-type DepthSnapshot struct {
-	Time     time.Time
-	TotalBid float64
-	TotalAsk float64
+	// Volume profiles, behind their own lock so market stats never wait on s.mu
+	vpCache       map[string]cachedVolumeProfile
+	vpNextRefresh map[string]time.Time // Symbol -> earliest background refresh of the default profile
+	vpMu          sync.Mutex
+
+	// Rolling trade and depth stats, each symbol behind its own lock (see market_window.go)
+	stats   map[string]*symbolStats
+	windows StatsWindows
+	statsMu sync.RWMutex
+
+	tickStats func() []domain.TickQueueStats // Tick queues of the strategy runtime, optional
 }
 
 const MaxGLI = 10.0
@@ -58,13 +55,13 @@ func NewMarketService(exchange domain.Exchange, repo domain.LevelRepository) *Ma
 		exchange:         exchange,
 		repo:             repo,
 		cache:            make(map[string]CachedLiquidity),
-		trades:           make(map[string][]Trade),
-		depthHistory:     make(map[string][]DepthSnapshot),
-		priceHistory:     make(map[string][]PricePoint),
-		cvdAccumulator:   make(map[string]float64),
 		liquidityHistory: make(map[string][]domain.LiquiditySnapshot),
 		subscribed:       make(map[string]bool),
+		vpCache:          make(map[string]cachedVolumeProfile),
+		vpNextRefresh:    make(map[string]time.Time),
 		timeNow:          time.Now,
+		stats:            make(map[string]*symbolStats),
+		windows:          DefaultStatsWindows,
 	}
 
 	// Subscribe to trades
@@ -75,6 +72,28 @@ func NewMarketService(exchange domain.Exchange, repo domain.LevelRepository) *Ma
 	return s
 }
 
+// SetTickStats sets where the tick queue stats shown with the WS status come from.
+// Called once at startup, before the stats are read.
+func (s *MarketService) SetTickStats(fn func() []domain.TickQueueStats) {
+	s.tickStats = fn
+}
+
+// wsStatus is the exchange WS status with the tick queues that feed the strategies.
+func (s *MarketService) wsStatus() domain.WSStatus {
+	status := s.exchange.GetWSStatus()
+	if s.tickStats == nil {
+		return status
+	}
+	status.TickQueues = s.tickStats()
+	for _, q := range status.TickQueues {
+		status.DroppedTicks += q.Dropped
+		if q.LastLagMS > status.MaxQueueLagMS {
+			status.MaxQueueLagMS = q.LastLagMS
+		}
+	}
+	return status
+}
+
 func (s *MarketService) startHealthCheck() {
 	ticker := time.NewTicker(30 * time.Second)
 	go func() {
@@ -102,47 +121,18 @@ func (s *MarketService) startHealthCheck() {
 }
 
 func (s *MarketService) handleTrade(symbol, side string, size, price float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	st := s.symbolStats(symbol)
+	st.mu.Lock()
+	defer st.mu.Unlock()
 
 	now := s.timeNow()
-	// Add new trade
-	s.trades[symbol] = append(s.trades[symbol], Trade{
+	st.addTrade(Trade{
 		Symbol: symbol,
 		Side:   side,
 		Size:   size,
 		Price:  price,
 		Time:   now,
-	})
-
-	// Update Cumulative Volume Delta (CVD)
-	// We no longer accumulate indefinitely. CVD is calculated on the fly for the window.
-	// Kept comment for reference if we want to revert to lifetime accumulation.
-
-	// Track price point
-	s.priceHistory[symbol] = append(s.priceHistory[symbol], PricePoint{
-		Price: price,
-		Time:  now,
-	})
-
-	// Prune old trades and prices (> 60s)
-	cutoff := now.Add(-60 * time.Second)
-	validTrades := s.trades[symbol][:0]
-	for _, t := range s.trades[symbol] {
-		if t.Time.After(cutoff) {
-			validTrades = append(validTrades, t)
-		}
-	}
-	s.trades[symbol] = validTrades
-
-	// Prune old prices
-	validPrices := s.priceHistory[symbol][:0]
-	for _, p := range s.priceHistory[symbol] {
-		if p.Time.After(cutoff) {
-			validPrices = append(validPrices, p)
-		}
-	}
-	s.priceHistory[symbol] = validPrices
+	}, now)
 }
 
 type MarketStats struct {
@@ -166,7 +156,17 @@ type MarketStats struct {
 	ConclusionScore30s float64         `json:"conclusion_score_30s"`
 	ConclusionScore10s float64         `json:"conclusion_score_10s"`
 	LastPrice          float64         `json:"last_price"`
+	PriceHigh          float64         `json:"price_high"` // Trade price extremes of the long window
+	PriceLow           float64         `json:"price_low"`
+	WindowsSec         []int64         `json:"windows_sec"` // Long, mid and short window behind the 60s/30s/10s fields
 	WSStatus           domain.WSStatus `json:"ws_status"`
+
+	// Volume profile of the default window, zero until the first profile is built
+	VolumePOC       float64   `json:"volume_poc"`
+	ValueAreaHigh   float64   `json:"value_area_high"`
+	ValueAreaLow    float64   `json:"value_area_low"`
+	HighVolumeNodes []float64 `json:"high_volume_nodes"`
+	LowVolumeNodes  []float64 `json:"low_volume_nodes"`
 }
 
 func (s *MarketService) GetMarketStats(ctx context.Context, symbol string) (*MarketStats, error) {
@@ -185,204 +185,75 @@ func (s *MarketService) GetMarketStats(ctx context.Context, symbol string) (*Mar
 		}
 	}
 
-	s.mu.Lock()
-
-	// Check if we need to hydrate/refresh trades
-	// Refresh if: 1) No trades at all, OR 2) No trades in last 60 seconds
-	needsRefresh := len(s.trades[symbol]) == 0
-	if !needsRefresh {
-		// Check if we have any trades in the last 60 seconds
-		cutoff := s.timeNow().Add(-60 * time.Second)
-		hasRecentTrades := false
-		for _, t := range s.trades[symbol] {
-			if t.Time.After(cutoff) {
-				hasRecentTrades = true
-				break
-			}
-		}
-		needsRefresh = !hasRecentTrades
-	}
+	// 1. Hydrate from REST when the long window holds no trades (fresh subscription, WS gap)
+	windows := s.statsWindows()
+	st := s.symbolStats(symbol)
+	st.mu.Lock()
+	needsRefresh := !st.hasRecentTrades(s.timeNow())
+	st.mu.Unlock()
 
 	if needsRefresh {
-		s.mu.Unlock() // Release lock for network call
-
 		recentTrades, err := s.exchange.GetRecentTrades(ctx, symbol, 1000)
-
-		s.mu.Lock() // Re-acquire lock
 		if err == nil {
-			// Double-check if we still need to refresh (another goroutine might have done it)
-			if len(s.trades[symbol]) > 0 {
-				// Check timestamp of latest trade
-				lastTradeTime := s.trades[symbol][len(s.trades[symbol])-1].Time
-				if s.timeNow().Sub(lastTradeTime) < 60*time.Second {
-					// Already refreshed, proceed to calculation
-					// We need to break out of the update block, but we are inside if err == nil.
-					// We can just set err = nil and skip the update loop?
-					// Or better, wrap the update logic in an else or just use a flag.
-					// Let's just use a goto or restructure.
-					// Restructuring is cleaner.
-				} else {
-					// Replace old trades with fresh ones
-					s.trades[symbol] = nil
-					for _, t := range recentTrades {
-						s.trades[symbol] = append(s.trades[symbol], Trade{
-							Symbol: t.Symbol,
-							Side:   t.Side,
-							Size:   t.Size,
-							Price:  t.Price,
-							Time:   time.UnixMilli(t.Time),
-						})
-					}
-				}
-			} else {
-				// Replace old trades with fresh ones
-				s.trades[symbol] = nil
+			sort.Slice(recentTrades, func(i, j int) bool { return recentTrades[i].Time < recentTrades[j].Time })
+
+			st.mu.Lock()
+			now := s.timeNow()
+			// Another caller or the WS feed may have filled the window meanwhile
+			if !st.hasRecentTrades(now) {
 				for _, t := range recentTrades {
-					s.trades[symbol] = append(s.trades[symbol], Trade{
+					st.addTrade(Trade{
 						Symbol: t.Symbol,
 						Side:   t.Side,
 						Size:   t.Size,
 						Price:  t.Price,
 						Time:   time.UnixMilli(t.Time),
-					})
-				}
-			}
-		}
-	}
-	defer s.mu.Unlock()
-
-	// 1. Calculate Speed (from trades)
-	var speedBuy, speedSell float64
-	var speedBuy30s, speedSell30s float64
-	var speedBuy10s, speedSell10s float64
-	var tradeCount int
-	if trades, ok := s.trades[symbol]; ok {
-		now := s.timeNow()
-		cutoff60 := now.Add(-60 * time.Second)
-		cutoff30 := now.Add(-30 * time.Second)
-		cutoff10 := now.Add(-10 * time.Second)
-
-		// Prune again just in case (lazy pruning)
-		validTrades := trades[:0]
-		for _, t := range trades {
-			if t.Time.After(cutoff60) {
-				validTrades = append(validTrades, t)
-				tradeCount++
-				if t.Side == "Buy" {
-					speedBuy += t.Size * t.Price
-				} else {
-					speedSell += t.Size * t.Price
-				}
-
-				if t.Time.After(cutoff30) {
-					if t.Side == "Buy" {
-						speedBuy30s += t.Size * t.Price
-					} else {
-						speedSell30s += t.Size * t.Price
-					}
-				}
-
-				if t.Time.After(cutoff10) {
-					if t.Side == "Buy" {
-						speedBuy10s += t.Size * t.Price
-					} else {
-						speedSell10s += t.Size * t.Price
-					}
+					}, now)
 				}
 			}
+			st.mu.Unlock()
 		}
-		s.trades[symbol] = validTrades
 	}
 
-	// 2. Get Depth (Moving Average)
-	// Check if we need to update depth history (lazy fetch if stale)
-	s.updateOrderBook(ctx, symbol)
-
-	var avgBid60, avgAsk60 float64
-	var avgBid30, avgAsk30 float64
-	var avgBid10, avgAsk10 float64
+	// 2. Refresh depth if the last sample is stale (network call outside the lock)
+	s.updateOrderBook(ctx, symbol, st)
 
-	if history, ok := s.depthHistory[symbol]; ok && len(history) > 0 {
-		var sumBid60, sumAsk60 float64
-		var sumBid30, sumAsk30 float64
-		var count30 int
-		var sumBid10, sumAsk10 float64
-		var count10 int
+	// 3. Fold the buckets of each window
+	st.mu.Lock()
+	agg := st.read(s.timeNow())
+	st.mu.Unlock()
+	long, mid, short := agg[0], agg[1], agg[2]
 
-		now := s.timeNow()
-		cutoff30 := now.Add(-30 * time.Second)
-		cutoff10 := now.Add(-10 * time.Second)
+	speedBuy, speedSell := long.BuyNotional, long.SellNotional
+	speedBuy30s, speedSell30s := mid.BuyNotional, mid.SellNotional
+	speedBuy10s, speedSell10s := short.BuyNotional, short.SellNotional
+	tradeCount := long.Trades
 
-		for _, snapshot := range history {
-			sumBid60 += snapshot.TotalBid
-			sumAsk60 += snapshot.TotalAsk
-
-			if snapshot.Time.After(cutoff30) {
-				sumBid30 += snapshot.TotalBid
-				sumAsk30 += snapshot.TotalAsk
-				count30++
-			}
-			if snapshot.Time.After(cutoff10) {
-				sumBid10 += snapshot.TotalBid
-				sumAsk10 += snapshot.TotalAsk
-				count10++
-			}
-		}
-		avgBid60 = sumBid60 / float64(len(history))
-		avgAsk60 = sumAsk60 / float64(len(history))
-
-		if count30 > 0 {
-			avgBid30 = sumBid30 / float64(count30)
-			avgAsk30 = sumAsk30 / float64(count30)
-		} else {
-			avgBid30 = avgBid60
-			avgAsk30 = avgAsk60
-		}
-
-		if count10 > 0 {
-			avgBid10 = sumBid10 / float64(count10)
-			avgAsk10 = sumAsk10 / float64(count10)
-		} else {
-			avgBid10 = avgBid60
-			avgAsk10 = avgAsk60
-		}
+	// Shorter windows without depth samples fall back to the long average
+	avgBid60, avgAsk60 := long.DepthBid, long.DepthAsk
+	avgBid30, avgAsk30 := mid.DepthBid, mid.DepthAsk
+	if mid.DepthSamples == 0 {
+		avgBid30, avgAsk30 = avgBid60, avgAsk60
+	}
+	avgBid10, avgAsk10 := short.DepthBid, short.DepthAsk
+	if short.DepthSamples == 0 {
+		avgBid10, avgAsk10 = avgBid60, avgAsk60
 	}
 
-	// 3. Calculate price change percentage
+	// Price change from the oldest trade of each window to the newest one
 	var priceChange60s, priceChange30s, priceChange10s float64
-	if prices, ok := s.priceHistory[symbol]; ok && len(prices) >= 2 {
-		now := s.timeNow()
-		cutoff30 := now.Add(-30 * time.Second)
-		cutoff10 := now.Add(-10 * time.Second)
-
-		// Get oldest and newest price in the 60s window
-		oldestPrice60 := prices[0].Price
-		newestPrice := prices[len(prices)-1].Price
-
-		if oldestPrice60 > 0 {
-			priceChange60s = ((newestPrice - oldestPrice60) / oldestPrice60) * 100
-		}
-
-		// Find oldest price in 30s window
-		for _, p := range prices {
-			if p.Time.After(cutoff30) {
-				if p.Price > 0 {
-					priceChange30s = ((newestPrice - p.Price) / p.Price) * 100
-				}
-				break
-			}
-		}
-
-		// Find oldest price in 10s window
-		for _, p := range prices {
-			if p.Time.After(cutoff10) {
-				if p.Price > 0 {
-					priceChange10s = ((newestPrice - p.Price) / p.Price) * 100
-				}
-				break
+	if tradeCount >= 2 {
+		change := func(w windowStats) float64 {
+			if w.Trades == 0 || w.FirstPrice <= 0 {
+				return 0
 			}
+			return ((long.LastPrice - w.FirstPrice) / w.FirstPrice) * 100
 		}
+		priceChange60s = change(long)
+		priceChange30s = change(mid)
+		priceChange10s = change(short)
 	}
+	windowSec := float64(windows.Long / time.Second)
 
 	// 4. Calculate Indicators
 	// OBI = (BidDepth - AskDepth) / (BidDepth + AskDepth)
@@ -397,7 +268,7 @@ func (s *MarketService) GetMarketStats(ctx context.Context, symbol string) (*Mar
 
 	// TSI = Trade Speed Index (Trades per Second)
 	// NumberOfTrades / TimeWindow (60s)
-	tsi := float64(tradeCount) / 60.0
+	tsi := float64(tradeCount) / windowSec
 
 	// GLI = ExecutedVolumeAtBid / ExecutedVolumeAtAsk
 	// ExecutedVolumeAtBid: volume executed at bid price (sellers hitting bids) = speedSell
@@ -413,7 +284,7 @@ func (s *MarketService) GetMarketStats(ctx context.Context, symbol string) (*Mar
 	}
 
 	// TradeVelocity = TotalVolume / TimeWindow (60s)
-	tradeVelocity := (speedBuy + speedSell) / 60.0
+	tradeVelocity := (speedBuy + speedSell) / windowSec
 
 	// 5. Calculate Conclusion Score (Market Sentiment)
 	calcConclusion := func(buy, sell, bid, ask float64) float64 {
@@ -454,13 +325,7 @@ func (s *MarketService) GetMarketStats(ctx context.Context, symbol string) (*Mar
 	conclusionScore30s := calcConclusion(speedBuy30s, speedSell30s, avgBid30, avgAsk30)
 	conclusionScore10s := calcConclusion(speedBuy10s, speedSell10s, avgBid10, avgAsk10)
 
-	// 6. Get Latest Price for UI display
-	var lastPrice float64
-	if prices, ok := s.priceHistory[symbol]; ok && len(prices) > 0 {
-		lastPrice = prices[len(prices)-1].Price
-	}
-
-	return &MarketStats{
+	stats := &MarketStats{
 		SpeedBuy:           speedBuy,
 		SpeedSell:          speedSell,
 		SpeedBuy30s:        speedBuy30s,
@@ -480,18 +345,31 @@ func (s *MarketService) GetMarketStats(ctx context.Context, symbol string) (*Mar
 		ConclusionScore:    conclusionScore,
 		ConclusionScore30s: conclusionScore30s,
 		ConclusionScore10s: conclusionScore10s,
-		LastPrice:          lastPrice,
-		WSStatus:           s.exchange.GetWSStatus(),
-	}, nil
+		LastPrice:          long.LastPrice,
+		PriceHigh:          long.High,
+		PriceLow:           long.Low,
+		WindowsSec:         windows.seconds(),
+		WSStatus:           s.wsStatus(),
+	}
+
+	// 6. Volume profile
+	if vp := s.defaultVolumeProfile(symbol); vp != nil {
+		stats.VolumePOC = vp.POC
+		stats.ValueAreaHigh = vp.ValueAreaHigh
+		stats.ValueAreaLow = vp.ValueAreaLow
+		stats.HighVolumeNodes = vp.HVN
+		stats.LowVolumeNodes = vp.LVN
+	}
+	return stats, nil
 }
 
-func (s *MarketService) updateOrderBook(ctx context.Context, symbol string) {
+func (s *MarketService) updateOrderBook(ctx context.Context, symbol string, st *symbolStats) {
 	// Check if latest snapshot is fresh (< 5s)
-	if history, ok := s.depthHistory[symbol]; ok && len(history) > 0 {
-		last := history[len(history)-1]
-		if s.timeNow().Sub(last.Time) < 5*time.Second {
-			return // Fresh enough
-		}
+	st.mu.Lock()
+	lastDepthAt := st.lastDepthAt
+	st.mu.Unlock()
+	if !lastDepthAt.IsZero() && s.timeNow().Sub(lastDepthAt) < 5*time.Second {
+		return // Fresh enough
 	}
 
 	// Fetch Linear (Futures) Order Book
@@ -543,23 +421,9 @@ func (s *MarketService) updateOrderBook(ctx context.Context, symbol string) {
 		}
 	}
 
-	now := s.timeNow()
-	// Append to history
-	s.depthHistory[symbol] = append(s.depthHistory[symbol], DepthSnapshot{
-		Time:     now,
-		TotalBid: totalBid,
-		TotalAsk: totalAsk,
-	})
-
-	// Prune old history (> 60s)
-	cutoff := now.Add(-60 * time.Second)
-	validHistory := s.depthHistory[symbol][:0]
-	for _, snapshot := range s.depthHistory[symbol] {
-		if snapshot.Time.After(cutoff) {
-			validHistory = append(validHistory, snapshot)
-		}
-	}
-	s.depthHistory[symbol] = validHistory
+	st.mu.Lock()
+	st.addDepth(totalBid, totalAsk, s.timeNow())
+	st.mu.Unlock()
 }
 
 type LiquidityCluster struct {
@@ -895,30 +759,14 @@ func (s *MarketService) processSide(linear, spot []domain.OrderBookEntry, side s
 }
 
 // GetTradeSentiment returns a score from -1.0 (Strong Sell) to 1.0 (Strong Buy)
-// based on the last 60 seconds of trade volume.
+// based on the trade volume of the long window (60 seconds by default).
 func (s *MarketService) GetTradeSentiment(ctx context.Context, symbol string) (float64, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	trades, ok := s.trades[symbol]
-	if !ok || len(trades) == 0 {
-		return 0, nil
-	}
-
-	now := s.timeNow()
-	cutoff := now.Add(-60 * time.Second)
-
-	var buyVol, sellVol float64
-	for _, t := range trades {
-		if t.Time.After(cutoff) {
-			if t.Side == "Buy" {
-				buyVol += t.Size * t.Price
-			} else {
-				sellVol += t.Size * t.Price
-			}
-		}
-	}
+	st := s.symbolStats(symbol)
+	st.mu.Lock()
+	agg := st.read(s.timeNow())
+	st.mu.Unlock()
 
+	buyVol, sellVol := agg[0].BuyNotional, agg[0].SellNotional
 	totalVol := buyVol + sellVol
 	if totalVol == 0 {
 		return 0, nil
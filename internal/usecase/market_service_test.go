@@ -11,6 +11,8 @@ import (
 // MockExchange for MarketService
 type MockExchange struct {
 	OrderBook *domain.OrderBook
+	Candles   []domain.Candle
+	Tickers   []domain.Ticker
 }
 
 func (m *MockExchange) GetOrderBook(ctx context.Context, symbol string, category string) (*domain.OrderBook, error) {
@@ -28,6 +30,13 @@ func (m *MockExchange) MarketSell(ctx context.Context, symbol string, size float
 	return nil
 }
 func (m *MockExchange) ClosePosition(ctx context.Context, symbol string) error { return nil }
+func (m *MockExchange) ReducePosition(ctx context.Context, symbol string, size float64) error {
+	return nil
+}
+
+func (m *MockExchange) SetTradingStop(ctx context.Context, symbol string, stop domain.TradingStop) error {
+	return nil
+}
 func (m *MockExchange) GetPosition(ctx context.Context, symbol string) (*domain.Position, error) {
 	return nil, nil
 }
@@ -35,10 +44,10 @@ func (m *MockExchange) GetPositions(ctx context.Context) ([]*domain.Position, er
 	return nil, nil
 }
 func (m *MockExchange) GetCandles(ctx context.Context, symbol, interval string, limit int) ([]domain.Candle, error) {
-	return nil, nil
+	return m.Candles, nil
 }
 func (m *MockExchange) GetTickers(ctx context.Context, category string) ([]domain.Ticker, error) {
-	return nil, nil
+	return m.Tickers, nil
 }
 
 func (m *MockExchange) OnTradeUpdate(callback func(symbol string, side string, size float64, price float64)) {
@@ -68,6 +77,18 @@ func (m *MockExchange) CancelOrder(ctx context.Context, symbol, orderID string)
 	return nil
 }
 
+func (m *MockExchange) CancelAllOrders(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockExchange) GetEquity(ctx context.Context) (*domain.AccountEquity, error) {
+	return &domain.AccountEquity{}, nil
+}
+
+func (m *MockExchange) GetRiskLimits(ctx context.Context, symbol string) ([]domain.RiskLimitTier, error) {
+	return nil, nil
+}
+
 func (m *MockExchange) GetWSStatus() domain.WSStatus {
 	return domain.WSStatus{Connected: true}
 }
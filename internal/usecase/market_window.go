@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StatsWindows are the rolling windows behind MarketStats. Long backs the 60s fields
+// (speed, depth, price change, CVD, TSI...), Mid the 30s fields and Short the 10s fields.
+type StatsWindows struct {
+	Long  time.Duration
+	Mid   time.Duration
+	Short time.Duration
+}
+
+var DefaultStatsWindows = StatsWindows{Long: 60 * time.Second, Mid: 30 * time.Second, Short: 10 * time.Second}
+
+// maxStatsWindow caps the ring size, one bucket per second.
+const maxStatsWindow = time.Hour
+
+func (w StatsWindows) Validate() error {
+	if w.Short < time.Second || w.Mid < w.Short || w.Long < w.Mid {
+		return fmt.Errorf("windows must satisfy 1s <= short <= mid <= long, got %v/%v/%v", w.Short, w.Mid, w.Long)
+	}
+	if w.Long > maxStatsWindow {
+		return fmt.Errorf("long window %v exceeds %v", w.Long, maxStatsWindow)
+	}
+	return nil
+}
+
+// seconds returns the windows in whole seconds, longest first.
+func (w StatsWindows) seconds() []int64 {
+	return []int64{int64(w.Long / time.Second), int64(w.Mid / time.Second), int64(w.Short / time.Second)}
+}
+
+// statsBucket aggregates one second of trades and depth samples of a symbol.
+type statsBucket struct {
+	sec int64 // Unix second held by the bucket, a different value means the slot is stale
+
+	buyNotional  float64
+	sellNotional float64
+	trades       int
+	firstPrice   float64
+	firstAt      time.Time
+	lastPrice    float64
+	lastAt       time.Time
+	high         float64
+	low          float64
+
+	depthBid     float64 // Sums, averaged on read
+	depthAsk     float64
+	depthSamples int
+}
+
+func (b *statsBucket) addTrade(side string, size, price float64, at time.Time) {
+	if side == "Buy" {
+		b.buyNotional += size * price
+	} else {
+		b.sellNotional += size * price
+	}
+	if b.trades == 0 || at.Before(b.firstAt) {
+		b.firstPrice, b.firstAt = price, at
+	}
+	if b.trades == 0 || !at.Before(b.lastAt) {
+		b.lastPrice, b.lastAt = price, at
+	}
+	if b.trades == 0 || price > b.high {
+		b.high = price
+	}
+	if b.trades == 0 || price < b.low {
+		b.low = price
+	}
+	b.trades++
+}
+
+// windowStats is the fold of the buckets of one window.
+type windowStats struct {
+	BuyNotional  float64
+	SellNotional float64
+	Trades       int
+	FirstPrice   float64 // Oldest trade price in the window
+	LastPrice    float64
+	High         float64
+	Low          float64
+	DepthBid     float64 // Average of the depth samples
+	DepthAsk     float64
+	DepthSamples int
+}
+
+// windowSums is the running fold of one window, kept in step with the buckets: trades and
+// depth samples add to it as they come in and their bucket subtracts when it leaves the
+// window. The extremes and the first price are the seconds of the buckets holding them.
+type windowSums struct {
+	span int64 // Seconds
+
+	buyNotional  float64
+	sellNotional float64
+	trades       int
+	depthBid     float64
+	depthAsk     float64
+	depthSamples int
+
+	firstSec int64   // Oldest second with trades, valid while trades > 0
+	highs    []int64 // Seconds that may still hold the high, oldest first, highs descending
+	lows     []int64 // Same for the low
+}
+
+// symbolStats keeps the per-second buckets of one symbol in a ring and the running sums of
+// every window, so adding a trade and reading the stats cost amortized O(1) whatever the
+// trade rate and the window length. Each symbol has its own lock, a busy symbol never waits on another.
+type symbolStats struct {
+	mu      sync.Mutex
+	buckets []statsBucket
+	sums    []windowSums // Longest first, the longest is the size of the ring
+	head    int64        // Second the sums are advanced to
+
+	// Raw trades of the long window for the live volume profile, oldest first from tradeHead
+	trades      []Trade
+	tradeHead   int
+	lastTradeAt time.Time
+	lastPrice   float64 // Of the newest trade
+	lastDepthAt time.Time
+}
+
+func newSymbolStats(windows []int64) *symbolStats {
+	st := &symbolStats{buckets: make([]statsBucket, windows[0])}
+	for _, w := range windows {
+		st.sums = append(st.sums, windowSums{span: w})
+	}
+	return st
+}
+
+// slot returns the ring slot of a second. Must hold st.mu.
+func (st *symbolStats) slot(sec int64) *statsBucket {
+	size := int64(len(st.buckets))
+	return &st.buckets[((sec%size)+size)%size]
+}
+
+// bucket returns the slot for a second, resetting it if it held an older second.
+// Returns nil if the slot already moved on, i.e. the second is older than the ring.
+// Must hold st.mu.
+func (st *symbolStats) bucket(sec int64) *statsBucket {
+	b := st.slot(sec)
+	if b.sec == sec {
+		return b
+	}
+	if b.sec > sec {
+		return nil
+	}
+	*b = statsBucket{sec: sec}
+	return b
+}
+
+// held returns the bucket of a second if the ring still holds it. Must hold st.mu.
+func (st *symbolStats) held(sec int64) *statsBucket {
+	if b := st.slot(sec); b.sec == sec {
+		return b
+	}
+	return nil
+}
+
+// advance moves the windows up to a second, taking out the buckets that leave them. A gap
+// longer than the ring leaves nothing behind, the sums start over. Must hold st.mu.
+func (st *symbolStats) advance(sec int64) {
+	if sec <= st.head {
+		return
+	}
+	if sec-st.head >= int64(len(st.buckets)) {
+		st.head = sec
+		for i := range st.sums {
+			st.sums[i] = windowSums{span: st.sums[i].span}
+		}
+		return
+	}
+	for st.head < sec {
+		st.head++
+		for i := range st.sums {
+			st.evict(&st.sums[i], st.head-st.sums[i].span)
+		}
+	}
+}
+
+// evict takes a bucket out of a window. Must hold st.mu.
+func (st *symbolStats) evict(ws *windowSums, sec int64) {
+	b := st.held(sec)
+	if b == nil {
+		return
+	}
+	if b.depthSamples > 0 {
+		ws.depthSamples -= b.depthSamples
+		ws.depthBid -= b.depthBid
+		ws.depthAsk -= b.depthAsk
+		if ws.depthSamples == 0 {
+			ws.depthBid, ws.depthAsk = 0, 0 // No float residue
+		}
+	}
+	if b.trades == 0 {
+		return
+	}
+	ws.trades -= b.trades
+	ws.buyNotional -= b.buyNotional
+	ws.sellNotional -= b.sellNotional
+	if len(ws.highs) > 0 && ws.highs[0] == sec {
+		ws.highs = ws.highs[1:]
+	}
+	if len(ws.lows) > 0 && ws.lows[0] == sec {
+		ws.lows = ws.lows[1:]
+	}
+	if ws.trades == 0 {
+		*ws = windowSums{span: ws.span, depthBid: ws.depthBid, depthAsk: ws.depthAsk, depthSamples: ws.depthSamples}
+		return
+	}
+	// The next second with trades, each second is passed over once while the window moves
+	for ws.firstSec = sec + 1; ws.firstSec <= st.head; ws.firstSec++ {
+		if b := st.held(ws.firstSec); b != nil && b.trades > 0 {
+			break
+		}
+	}
+}
+
+// inWindow tells whether a second up to the head counts in a window.
+func (st *symbolStats) inWindow(ws *windowSums, sec int64) bool {
+	return sec > st.head-ws.span
+}
+
+// addTrade records a trade. Must hold st.mu.
+func (st *symbolStats) addTrade(t Trade, now time.Time) {
+	long := time.Duration(len(st.buckets)) * time.Second
+	if !t.Time.After(now.Add(-long)) {
+		return
+	}
+	// A trade stamped ahead of our clock moves the windows along, its slot must be free
+	sec := t.Time.Unix()
+	st.advance(max(now.Unix(), sec))
+	b := st.bucket(sec)
+	if b == nil {
+		return
+	}
+	b.addTrade(t.Side, t.Size, t.Price, t.Time)
+	if !t.Time.Before(st.lastTradeAt) {
+		st.lastTradeAt, st.lastPrice = t.Time, t.Price
+	}
+
+	for i := range st.sums {
+		ws := &st.sums[i]
+		if !st.inWindow(ws, sec) {
+			continue
+		}
+		if t.Side == "Buy" {
+			ws.buyNotional += t.Size * t.Price
+		} else {
+			ws.sellNotional += t.Size * t.Price
+		}
+		if ws.trades == 0 || sec < ws.firstSec {
+			ws.firstSec = sec
+		}
+		ws.trades++
+		ws.highs = st.pushExtreme(ws, ws.highs, sec, func(b *statsBucket) float64 { return b.high })
+		ws.lows = st.pushExtreme(ws, ws.lows, sec, func(b *statsBucket) float64 { return -b.low })
+	}
+
+	st.trades = append(st.trades, t)
+	st.pruneTrades(now)
+}
+
+// pushExtreme adds a second whose bucket changed to the candidates of an extreme, where
+// a larger value is better. Trades arrive in time order, so this works at the back; a late
+// trade for an older second rebuilds the candidates from the window. Must hold st.mu.
+func (st *symbolStats) pushExtreme(ws *windowSums, d []int64, sec int64, value func(*statsBucket) float64) []int64 {
+	if n := len(d); n > 0 && d[n-1] > sec {
+		d = d[:0]
+		for at := st.head - ws.span + 1; at <= st.head; at++ {
+			if b := st.held(at); b != nil && b.trades > 0 {
+				d = pushBack(d, at, st, value)
+			}
+		}
+		return d
+	}
+	return pushBack(d, sec, st, value)
+}
+
+func pushBack(d []int64, sec int64, st *symbolStats, value func(*statsBucket) float64) []int64 {
+	v := value(st.slot(sec))
+	for len(d) > 0 && (d[len(d)-1] == sec || value(st.slot(d[len(d)-1])) <= v) {
+		d = d[:len(d)-1]
+	}
+	return append(d, sec)
+}
+
+// pruneTrades drops raw trades that left the long window. Trades are appended in time
+// order, so this only moves the head; the slice is compacted once half of it is dropped,
+// which keeps the copying O(1) per trade. Must hold st.mu.
+func (st *symbolStats) pruneTrades(now time.Time) {
+	cutoff := now.Add(-time.Duration(len(st.buckets)) * time.Second)
+	for st.tradeHead < len(st.trades) && !st.trades[st.tradeHead].Time.After(cutoff) {
+		st.trades[st.tradeHead] = Trade{}
+		st.tradeHead++
+	}
+	if st.tradeHead > 0 && st.tradeHead >= len(st.trades)/2 {
+		n := copy(st.trades, st.trades[st.tradeHead:])
+		st.trades = st.trades[:n]
+		st.tradeHead = 0
+	}
+}
+
+// recentTrades returns a copy of the raw trades of the long window. Must hold st.mu.
+func (st *symbolStats) recentTrades(now time.Time) []Trade {
+	st.pruneTrades(now)
+	return append([]Trade(nil), st.trades[st.tradeHead:]...)
+}
+
+// addDepth records an order book depth sample. Must hold st.mu.
+func (st *symbolStats) addDepth(totalBid, totalAsk float64, now time.Time) {
+	st.advance(now.Unix())
+	sec := now.Unix()
+	b := st.bucket(sec)
+	if b == nil {
+		return
+	}
+	b.depthBid += totalBid
+	b.depthAsk += totalAsk
+	b.depthSamples++
+	st.lastDepthAt = now
+	for i := range st.sums {
+		ws := &st.sums[i]
+		if st.inWindow(ws, sec) {
+			ws.depthBid += totalBid
+			ws.depthAsk += totalAsk
+			ws.depthSamples++
+		}
+	}
+}
+
+// hasRecentTrades tells whether the long window holds any trade. Must hold st.mu.
+func (st *symbolStats) hasRecentTrades(now time.Time) bool {
+	long := time.Duration(len(st.buckets)) * time.Second
+	return st.lastTradeAt.After(now.Add(-long))
+}
+
+// read returns the running fold of every window, longest first. A bucket belongs to a
+// window of w seconds if it is one of the last w seconds up to now. Must hold st.mu.
+func (st *symbolStats) read(now time.Time) []windowStats {
+	st.advance(now.Unix())
+	out := make([]windowStats, len(st.sums))
+	for i := range st.sums {
+		ws, o := &st.sums[i], &out[i]
+		o.BuyNotional, o.SellNotional, o.Trades = ws.buyNotional, ws.sellNotional, ws.trades
+		if ws.trades > 0 {
+			o.FirstPrice = st.slot(ws.firstSec).firstPrice
+			o.LastPrice = st.lastPrice
+			o.High = st.slot(ws.highs[0]).high
+			o.Low = st.slot(ws.lows[0]).low
+		}
+		if n := ws.depthSamples; n > 0 {
+			o.DepthBid = ws.depthBid / float64(n)
+			o.DepthAsk = ws.depthAsk / float64(n)
+			o.DepthSamples = n
+		}
+	}
+	return out
+}
+
+// symbolStats returns the buckets of a symbol, creating them on first use.
+func (s *MarketService) symbolStats(symbol string) *symbolStats {
+	s.statsMu.RLock()
+	st, ok := s.stats[symbol]
+	s.statsMu.RUnlock()
+	if ok {
+		return st
+	}
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if st, ok := s.stats[symbol]; ok {
+		return st
+	}
+	st = newSymbolStats(s.windows.seconds())
+	s.stats[symbol] = st
+	return st
+}
+
+// statsWindows returns the configured windows.
+func (s *MarketService) statsWindows() StatsWindows {
+	s.statsMu.RLock()
+	defer s.statsMu.RUnlock()
+	return s.windows
+}
+
+// SetStatsWindows changes the rolling windows of MarketStats. The collected buckets are
+// sized for the old windows, so they are dropped and the stats refill from live data.
+func (s *MarketService) SetStatsWindows(w StatsWindows) error {
+	if err := w.Validate(); err != nil {
+		return fmt.Errorf("failed to set stats windows: %w", err)
+	}
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.windows = w
+	s.stats = make(map[string]*symbolStats)
+	return nil
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestMarketService_RollingWindows(t *testing.T) {
+	service := NewMarketService(&MockExchange{}, nil)
+	if err := service.SetStatsWindows(StatsWindows{Long: 20 * time.Second, Mid: 10 * time.Second, Short: 5 * time.Second}); err != nil {
+		t.Fatalf("SetStatsWindows failed: %v", err)
+	}
+
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	currentTime := start
+	service.timeNow = func() time.Time { return currentTime }
+	at := func(sec int) { currentTime = start.Add(time.Duration(sec) * time.Second) }
+
+	symbol := "BTCUSDT"
+	at(0)
+	service.handleTrade(symbol, "Buy", 1, 100)
+	at(8)
+	service.handleTrade(symbol, "Sell", 2, 105)
+	at(17)
+	service.handleTrade(symbol, "Buy", 1, 98)
+
+	// 1. Each window only sees its own seconds
+	stats, err := service.GetMarketStats(context.Background(), symbol)
+	if err != nil {
+		t.Fatalf("GetMarketStats failed: %v", err)
+	}
+	if stats.SpeedBuy != 198 || stats.SpeedSell != 210 {
+		t.Errorf("Expected long window 198/210, got %.2f/%.2f", stats.SpeedBuy, stats.SpeedSell)
+	}
+	if stats.SpeedBuy30s != 98 || stats.SpeedSell30s != 210 {
+		t.Errorf("Expected mid window 98/210, got %.2f/%.2f", stats.SpeedBuy30s, stats.SpeedSell30s)
+	}
+	if stats.SpeedBuy10s != 98 || stats.SpeedSell10s != 0 {
+		t.Errorf("Expected short window 98/0, got %.2f/%.2f", stats.SpeedBuy10s, stats.SpeedSell10s)
+	}
+	if stats.PriceHigh != 105 || stats.PriceLow != 98 || stats.LastPrice != 98 {
+		t.Errorf("Expected extremes 105/98 and last 98, got %+v", stats)
+	}
+	if want := (98.0 - 100) / 100 * 100; math.Abs(stats.PriceChange60s-want) > 1e-9 {
+		t.Errorf("Expected long price change %f, got %f", want, stats.PriceChange60s)
+	}
+	if stats.TSI != 3.0/20 {
+		t.Errorf("Expected TSI over the configured window, got %f", stats.TSI)
+	}
+
+	// 2. Old seconds fall out without any pruning pass
+	at(25)
+	stats, _ = service.GetMarketStats(context.Background(), symbol)
+	if stats.SpeedBuy != 98 || stats.SpeedSell != 210 || stats.PriceHigh != 105 {
+		t.Errorf("Expected the first trade to leave the long window, got %+v", stats)
+	}
+	sentiment, _ := service.GetTradeSentiment(context.Background(), symbol)
+	if want := (98.0 - 210) / 308; math.Abs(sentiment-want) > 1e-9 {
+		t.Errorf("Expected sentiment %f, got %f", want, sentiment)
+	}
+	if vp := service.GetLiveVolumeProfile(symbol, 10); vp.TotalVolume != 3 {
+		t.Errorf("Expected live profile of the 2 remaining trades, got %+v", vp)
+	}
+
+	// 3. Windows are validated
+	if err := service.SetStatsWindows(StatsWindows{Long: 5 * time.Second, Mid: 10 * time.Second, Short: time.Second}); err == nil {
+		t.Errorf("Expected mid > long to be rejected")
+	}
+}
+
+func TestSymbolStats_RunningSumsMatchFold(t *testing.T) {
+	windows := []int64{20, 10, 5}
+	st := newSymbolStats(windows)
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type sample struct {
+		at       time.Time
+		trade    *Trade
+		bid, ask float64
+	}
+	var kept []sample
+	now := start
+	for step := 0; step < 3000; step++ {
+		// Mostly steady, sometimes a gap, at times longer than the ring
+		switch r := rng.Intn(100); {
+		case r < 60:
+			now = now.Add(time.Duration(rng.Intn(400)) * time.Millisecond)
+		case r < 98:
+			now = now.Add(time.Duration(1+rng.Intn(6)) * time.Second)
+		default:
+			now = now.Add(30 * time.Second)
+		}
+		if rng.Intn(5) == 0 {
+			bid, ask := float64(rng.Intn(100)), float64(rng.Intn(100))
+			st.addDepth(bid, ask, now)
+			kept = append(kept, sample{at: now, bid: bid, ask: ask})
+		} else {
+			// Some trades arrive a few seconds late
+			at := now.Add(-time.Duration(rng.Intn(4000)) * time.Millisecond * time.Duration(rng.Intn(2)))
+			side := "Buy"
+			if rng.Intn(2) == 0 {
+				side = "Sell"
+			}
+			tr := Trade{Side: side, Size: float64(1 + rng.Intn(5)), Price: float64(90 + rng.Intn(20)), Time: at}
+			if at.After(now.Add(-20 * time.Second)) {
+				kept = append(kept, sample{at: at, trade: &tr})
+			}
+			st.addTrade(tr, now)
+		}
+
+		got := st.read(now)
+		for i, w := range windows {
+			var want windowStats
+			var firstAt, lastAt time.Time
+			for _, s := range kept {
+				if s.at.Unix() <= now.Unix()-w || s.at.Unix() > now.Unix() {
+					continue
+				}
+				if s.trade == nil {
+					want.DepthBid += s.bid
+					want.DepthAsk += s.ask
+					want.DepthSamples++
+					continue
+				}
+				tr := s.trade
+				if tr.Side == "Buy" {
+					want.BuyNotional += tr.Size * tr.Price
+				} else {
+					want.SellNotional += tr.Size * tr.Price
+				}
+				if want.Trades == 0 || tr.Price > want.High {
+					want.High = tr.Price
+				}
+				if want.Trades == 0 || tr.Price < want.Low {
+					want.Low = tr.Price
+				}
+				if want.Trades == 0 || tr.Time.Before(firstAt) {
+					want.FirstPrice, firstAt = tr.Price, tr.Time
+				}
+				if want.Trades == 0 || !tr.Time.Before(lastAt) {
+					want.LastPrice, lastAt = tr.Price, tr.Time
+				}
+				want.Trades++
+			}
+			if n := want.DepthSamples; n > 0 {
+				want.DepthBid /= float64(n)
+				want.DepthAsk /= float64(n)
+			}
+			g := got[i]
+			if g.Trades != want.Trades || g.DepthSamples != want.DepthSamples || g.High != want.High || g.Low != want.Low ||
+				g.FirstPrice != want.FirstPrice || g.LastPrice != want.LastPrice ||
+				math.Abs(g.BuyNotional-want.BuyNotional) > 1e-6 || math.Abs(g.SellNotional-want.SellNotional) > 1e-6 ||
+				math.Abs(g.DepthBid-want.DepthBid) > 1e-6 || math.Abs(g.DepthAsk-want.DepthAsk) > 1e-6 {
+				t.Fatalf("Step %d, %ds window: expected %+v, got %+v", step, w, want, g)
+			}
+		}
+	}
+}
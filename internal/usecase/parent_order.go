@@ -0,0 +1,806 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// ErrParentOrderNotFound is returned when cancelling a parent order that is not working.
+var ErrParentOrderNotFound = errors.New("parent order not found")
+
+// Parent order statuses.
+const (
+	ParentWorking   = "working"
+	ParentFilled    = "filled"
+	ParentCancelled = "cancelled"
+	ParentFailed    = "failed"
+)
+
+// Who cancelled a parent order.
+const (
+	CancelledByUser       = "user"
+	CancelledByExit       = "exit"
+	CancelledByMarketExit = "market exit"
+	CancelledByKillSwitch = "kill switch"
+)
+
+// Parent order purposes.
+const (
+	PurposeEntry = "entry"
+	PurposeExit  = "exit"
+)
+
+const (
+	defaultChaseSec     = 30
+	defaultTWAPSec      = 60
+	defaultIcebergSec   = 120
+	defaultAlgoSlices   = 5  // Clips or slices of an order when the level sets no clip size
+	maxAlgoSlices       = 50 // Caps the slices of a tiny clip size
+	parentPollInterval  = time.Second
+	parentHistoryLimit  = 50 // Finished parent orders kept for the UI
+	parentStopWait      = 10 * time.Second
+	childCancelTimeout  = 10 * time.Second
+	parentFilledEpsilon = 1e-9
+	childTimeInForce    = "PostOnly"
+	fallbackTimeInForce = "ImmediateOrCancel"
+)
+
+// ParentRequest is an order to be worked by an execution algorithm. Side is the side of the
+// child orders, the exit of a long sells.
+type ParentRequest struct {
+	Symbol         string
+	LevelID        string
+	Purpose        string // PurposeEntry or PurposeExit
+	Side           domain.Side
+	Size           float64
+	ReduceOnly     bool
+	Leverage       int
+	MarginType     string
+	StopLoss       float64 // Attached to the market children of entries
+	Algo           string  // domain.ExecMarket, ExecChase, ExecTWAP or ExecIceberg
+	DurationSec    int     // 0 = algorithm default
+	ClipSize       float64 // 0 = a fifth of the order
+	QtyStep        float64
+	MinQty         float64
+	TickSize       float64
+	MaxSlippagePct float64 // Market children of entries go through the slippage guard
+	SlippageAction string
+	MaxChasePct    float64 // Chase/iceberg children stay within this of the first touch, 0 = unbounded
+	Fallback       string  // domain.ExecFallbackMarket or ExecFallbackIOC for the rest at the deadline
+}
+
+// ParentOrder is the progress of a parent order. Its children are the exchange orders sent
+// for it: market slices, or post-only limits resting at the touch.
+type ParentOrder struct {
+	ID            string      `json:"id"`
+	Symbol        string      `json:"symbol"`
+	LevelID       string      `json:"level_id"`
+	Purpose       string      `json:"purpose"`
+	Side          domain.Side `json:"side"`
+	Algo          string      `json:"algo"`
+	Size          float64     `json:"size"`
+	Filled        float64     `json:"filled"`
+	PricedSize    float64     `json:"priced_size"`           // Filled quantity with a known price
+	AvgPrice      float64     `json:"avg_price"`             // Over PricedSize, 0 if nothing is priced
+	ExpectedPrice float64     `json:"expected_price"`        // Book estimate or limit price of the children, 0 if none
+	MakerFilled   float64     `json:"maker_filled"`          // Filled by resting post-only children
+	TakerFilled   float64     `json:"taker_filled"`          // Filled by market and IOC children
+	FeesSaved     float64     `json:"fees_saved"`            // Taker minus maker fee of the maker fills, in quote
+	Fees          float64     `json:"fees"`                  // Paid on the priced fills, actual when the exchange reports them
+	ChaseLimit    float64     `json:"chase_limit,omitempty"` // Worst price a child rests at, 0 = unbounded
+	Fallback      string      `json:"fallback,omitempty"`    // Of chase and iceberg at the deadline
+	Children      int         `json:"children"`
+	WorkingID     string      `json:"working_order_id,omitempty"` // Resting child
+	WorkingPrice  float64     `json:"working_price,omitempty"`
+	Status        string      `json:"status"`
+	CancelledBy   string      `json:"cancelled_by,omitempty"`
+	Reason        string      `json:"reason,omitempty"` // Why it stopped short of the size
+	Deadline      time.Time   `json:"deadline"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// Remaining returns the quantity still to fill.
+func (p ParentOrder) Remaining() float64 {
+	return math.Max(p.Size-p.Filled, 0)
+}
+
+// ProgressPct returns the filled share of the order in percent.
+func (p ParentOrder) ProgressPct() float64 {
+	if p.Size <= 0 {
+		return 0
+	}
+	return math.Min(p.Filled/p.Size, 1) * 100
+}
+
+// MakerRatio returns the share of the filled quantity that was filled as maker.
+func (p ParentOrder) MakerRatio() float64 {
+	if p.Filled <= 0 {
+		return 0
+	}
+	return p.MakerFilled / p.Filled
+}
+
+// Execution describes the parent order the way a guarded market order is described, the fill
+// price is only set when every fill is priced.
+func (p ParentOrder) Execution() *Execution {
+	exec := &Execution{
+		Requested:     p.Size,
+		Filled:        p.Filled,
+		Action:        p.Algo,
+		ExpectedPrice: p.ExpectedPrice,
+		Reason:        p.Reason,
+		ParentID:      p.ID,
+		Fee:           p.Fees,
+	}
+	if p.Filled > 0 && p.PricedSize >= p.Filled-parentFilledEpsilon {
+		exec.FillPrice = p.AvgPrice
+	}
+	return exec
+}
+
+// parentRun is a parent order being worked.
+type parentRun struct {
+	order    ParentOrder
+	cost     float64 // Of the priced fills
+	expCost  float64
+	expSize  float64
+	cancel   context.CancelFunc
+	done     chan struct{}
+	finished bool
+}
+
+// ExecutionManager works parent orders with their execution algorithm and keeps their
+// progress for the UI. Run blocks the caller until the order is done: tier entries call it
+// from a goroutine of their own, take profit exits and the funding bot wait on it. An order
+// can be cancelled from anywhere else.
+type ExecutionManager struct {
+	exchange domain.Exchange
+	executor *TradeExecutor
+
+	mu    sync.Mutex
+	runs  map[string]*parentRun
+	ids   []string // Creation order
+	seq   int64
+	poll  time.Duration
+	fees  FeeRates
+	stats map[string]*ExecutionStats // Finished orders by symbol, since start
+}
+
+func NewExecutionManager(exchange domain.Exchange, executor *TradeExecutor) *ExecutionManager {
+	return &ExecutionManager{
+		exchange: exchange,
+		executor: executor,
+		runs:     make(map[string]*parentRun),
+		poll:     parentPollInterval,
+		fees:     DefaultFeeRates,
+		stats:    make(map[string]*ExecutionStats),
+	}
+}
+
+// SetPollInterval changes how often resting children are checked and re-priced.
+func (m *ExecutionManager) SetPollInterval(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.poll = d
+}
+
+// Run works a parent order until it is filled, cancelled or fails, and returns its final
+// state. The error says why it stopped short, the order may still have filled partly.
+func (m *ExecutionManager) Run(ctx context.Context, req ParentRequest) (ParentOrder, error) {
+	// 1. Register
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	run := m.start(req, cancel)
+	log.Printf("EXEC: Parent %s started: %s %s %f %s (%s, level %s)", run.order.ID, req.Purpose, req.Side, req.Size, req.Symbol, req.Algo, req.LevelID)
+
+	// 2. Work it
+	var err error
+	switch req.Algo {
+	case domain.ExecTWAP:
+		err = m.runTWAP(runCtx, run, req)
+	case domain.ExecChase, domain.ExecIceberg:
+		err = m.runPassive(runCtx, run, req)
+	default:
+		err = m.sendMarket(runCtx, run, req, req.Size)
+	}
+
+	// 3. Finish
+	final := m.finish(run, err)
+	if final.Status == ParentFilled {
+		log.Printf("EXEC: Parent %s filled %f %s in %d orders, avg %f", final.ID, final.Filled, final.Symbol, final.Children, final.AvgPrice)
+		return final, nil
+	}
+	log.Printf("EXEC: Parent %s %s with %f of %f filled: %s", final.ID, final.Status, final.Filled, final.Size, final.Reason)
+	if err == nil || final.Status == ParentCancelled {
+		err = errors.New(final.Reason) // Not the bare context error of a cancel
+	}
+	return final, err
+}
+
+func (m *ExecutionManager) start(req ParentRequest, cancel context.CancelFunc) *parentRun {
+	now := time.Now()
+	duration := req.DurationSec
+	if duration <= 0 {
+		switch req.Algo {
+		case domain.ExecChase:
+			duration = defaultChaseSec
+		case domain.ExecTWAP:
+			duration = defaultTWAPSec
+		case domain.ExecIceberg:
+			duration = defaultIcebergSec
+		}
+	}
+
+	fallback := ""
+	if req.Algo == domain.ExecChase || req.Algo == domain.ExecIceberg {
+		fallback = req.Fallback
+		if fallback == "" {
+			fallback = domain.ExecFallbackMarket
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	run := &parentRun{
+		order: ParentOrder{
+			ID:        fmt.Sprintf("po-%d-%d", now.Unix(), m.seq),
+			Symbol:    req.Symbol,
+			LevelID:   req.LevelID,
+			Purpose:   req.Purpose,
+			Side:      req.Side,
+			Algo:      req.Algo,
+			Size:      req.Size,
+			Fallback:  fallback,
+			Status:    ParentWorking,
+			Deadline:  now.Add(time.Duration(duration) * time.Second),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.runs[run.order.ID] = run
+	m.ids = append(m.ids, run.order.ID)
+	return run
+}
+
+func (m *ExecutionManager) finish(run *parentRun, err error) ParentOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := &run.order
+	p.WorkingID, p.WorkingPrice = "", 0
+	p.UpdatedAt = time.Now()
+	switch {
+	case p.Remaining() <= parentFilledEpsilon:
+		p.Status = ParentFilled
+	case p.CancelledBy != "":
+		p.Status = ParentCancelled
+		if p.Reason == "" {
+			p.Reason = "cancelled by " + p.CancelledBy
+		}
+	default:
+		p.Status = ParentFailed
+		if err != nil {
+			p.Reason = err.Error()
+		} else if p.Reason == "" {
+			p.Reason = "stopped short of the size"
+		}
+	}
+	run.finished = true
+	close(run.done)
+	m.record(*p)
+	m.trim()
+	return *p
+}
+
+// trim drops the oldest finished orders over the history limit. Callers hold m.mu.
+func (m *ExecutionManager) trim() {
+	finished := 0
+	for _, id := range m.ids {
+		if m.runs[id].finished {
+			finished++
+		}
+	}
+	kept := m.ids[:0]
+	for _, id := range m.ids {
+		if finished > parentHistoryLimit && m.runs[id].finished {
+			delete(m.runs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	m.ids = kept
+}
+
+// fill books executed quantity on the parent, price and expected are 0 when unknown. Maker
+// fills are the ones of resting post-only children, they save the taker fee difference.
+// fee is the one the exchange reported, a negative fee is estimated from the fee rates.
+func (m *ExecutionManager) fill(run *parentRun, qty, price, expected float64, maker bool, fee float64) {
+	if qty <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := &run.order
+	p.Filled += qty
+	if maker {
+		p.MakerFilled += qty
+		if notional := qty * math.Max(price, expected); notional > 0 {
+			p.FeesSaved += notional * (m.fees.Taker - m.fees.Maker)
+		}
+	} else {
+		p.TakerFilled += qty
+	}
+	if price > 0 {
+		run.cost += qty * price
+		p.PricedSize += qty
+		p.AvgPrice = run.cost / p.PricedSize
+		if fee < 0 {
+			fee = m.fees.Fee(qty*price, maker)
+		}
+		p.Fees += fee
+	}
+	if expected > 0 {
+		run.expCost += qty * expected
+		run.expSize += qty
+		p.ExpectedPrice = run.expCost / run.expSize
+	}
+	p.UpdatedAt = time.Now()
+}
+
+func (m *ExecutionManager) update(run *parentRun, fn func(p *ParentOrder)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(&run.order)
+	run.order.UpdatedAt = time.Now()
+}
+
+func (m *ExecutionManager) snapshot(run *parentRun) ParentOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return run.order
+}
+
+func (m *ExecutionManager) pollInterval() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.poll
+}
+
+// sendMarket sends size at market as one child. Entries go through the slippage guard,
+// exits are reduce-only.
+func (m *ExecutionManager) sendMarket(ctx context.Context, run *parentRun, req ParentRequest, size float64) error {
+	if size <= parentFilledEpsilon {
+		return nil
+	}
+	if req.ReduceOnly {
+		m.update(run, func(p *ParentOrder) { p.Children++ })
+		if err := m.exchange.ReducePosition(ctx, req.Symbol, size); err != nil {
+			return fmt.Errorf("failed to reduce %s by %f: %w", req.Symbol, size, err)
+		}
+		m.fill(run, size, 0, 0, false, -1) // The reduce reports no price
+		return nil
+	}
+
+	exec, err := m.executor.ExecuteGuarded(ctx, ExecutionRequest{
+		Symbol:         req.Symbol,
+		Side:           req.Side,
+		Size:           size,
+		Leverage:       req.Leverage,
+		MarginType:     req.MarginType,
+		StopLoss:       req.StopLoss,
+		MaxSlippagePct: req.MaxSlippagePct,
+		SlippageAction: req.SlippageAction,
+		QtyStep:        req.QtyStep,
+		MinQty:         req.MinQty,
+	})
+	if exec != nil {
+		m.update(run, func(p *ParentOrder) { p.Children += len(exec.Slices) })
+		if exec.Filled > 0 {
+			m.fill(run, exec.Filled, exec.FillPrice, exec.ExpectedPrice, false, -1)
+		}
+		if errors.Is(err, ErrSlippageRejected) {
+			return fmt.Errorf("slippage guard: %s: %w", exec.Reason, err)
+		}
+	}
+	return err
+}
+
+// clipSize returns the size of one slice or visible clip of the request.
+func clipSize(req ParentRequest) float64 {
+	clip := req.ClipSize
+	if clip <= 0 {
+		clip = req.Size / defaultAlgoSlices
+	}
+	if minClip := req.Size / maxAlgoSlices; clip < minClip {
+		clip = minClip
+	}
+	if req.QtyStep > 0 {
+		clip = math.Max(roundDownToStep(clip, req.QtyStep), req.QtyStep)
+	}
+	return math.Max(clip, req.MinQty)
+}
+
+// runTWAP sends equal market slices spread over the duration. A slice the slippage guard
+// rejects is carried into the next one.
+func (m *ExecutionManager) runTWAP(ctx context.Context, run *parentRun, req ParentRequest) error {
+	p := m.snapshot(run)
+	clip := clipSize(req)
+	slices := int(math.Ceil(req.Size/clip - parentFilledEpsilon))
+	if slices < 1 {
+		slices = 1
+	}
+	interval := time.Duration(0)
+	if slices > 1 {
+		interval = p.Deadline.Sub(p.CreatedAt) / time.Duration(slices-1)
+	}
+
+	var lastRejection error
+	for i := 0; i < slices; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+		remaining := m.snapshot(run).Remaining()
+		if remaining <= parentFilledEpsilon {
+			return nil
+		}
+		size := remaining
+		if left := slices - i; left > 1 {
+			size = math.Min(remaining, roundDownToStep(remaining/float64(left), req.QtyStep))
+			if size < req.MinQty || size <= 0 {
+				continue // Too small to split further, the next slice takes it
+			}
+		}
+
+		err := m.sendMarket(ctx, run, req, size)
+		if errors.Is(err, ErrSlippageRejected) {
+			log.Printf("EXEC: Parent %s slice %d/%d skipped: %v", p.ID, i+1, slices, err)
+			lastRejection = err
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if m.snapshot(run).Remaining() > parentFilledEpsilon && lastRejection != nil {
+		return lastRejection
+	}
+	return nil
+}
+
+// passiveTouch returns the price a post-only child of the side rests at: the best bid for a
+// buy, the best ask for a sell.
+func passiveTouch(ob *domain.OrderBook, side domain.Side) float64 {
+	book := ob.Bids
+	if side == domain.SideShort {
+		book = ob.Asks
+	}
+	if len(book) == 0 {
+		return 0
+	}
+	return book[0].Price
+}
+
+// chaseLimit returns the worst price a child of the side may rest at: a buy follows a rising
+// bid up to maxPct above the first touch, a sell a falling ask down to maxPct below it. The
+// limit is rounded away from the market to the tick, 0 means unbounded.
+func chaseLimit(side domain.Side, touch, maxPct, tick float64) float64 {
+	if maxPct <= 0 || touch <= 0 {
+		return 0
+	}
+	if side == domain.SideShort {
+		return roundUpToStep(touch*(1-maxPct), tick)
+	}
+	return roundDownToStep(touch*(1+maxPct), tick)
+}
+
+// restPrice returns where a child rests for the touch, held at the chase limit once the touch
+// runs past it.
+func restPrice(side domain.Side, touch, limit float64) float64 {
+	if limit <= 0 || touch <= 0 {
+		return touch
+	}
+	if side == domain.SideShort {
+		return math.Max(touch, limit)
+	}
+	return math.Min(touch, limit)
+}
+
+// roundUpToStep rounds a price up to the tick size.
+func roundUpToStep(price, step float64) float64 {
+	if step <= 0 {
+		return price
+	}
+	down := roundDownToStep(price, step)
+	if down < price-step*1e-9 {
+		return roundDownToStep(down+step, step)
+	}
+	return down
+}
+
+// runPassive works chase and iceberg orders: a post-only limit rests at the touch and is
+// re-priced when the touch moves, up to the chase limit. Chase shows the whole remainder,
+// iceberg one clip at a time. At the deadline the remainder goes to the fallback.
+func (m *ExecutionManager) runPassive(ctx context.Context, run *parentRun, req ParentRequest) error {
+	clip := 0.0
+	if req.Algo == domain.ExecIceberg {
+		clip = clipSize(req)
+	}
+	deadline := m.snapshot(run).Deadline
+	limit := 0.0
+	var child *domain.Order
+
+	for {
+		remaining := m.snapshot(run).Remaining()
+		if remaining <= parentFilledEpsilon {
+			return nil
+		}
+
+		// 1. Past the deadline, hand the rest to the fallback
+		if !time.Now().Before(deadline) {
+			if child != nil {
+				m.cancelChild(run, req, child, true)
+				child = nil
+			}
+			return m.sendFallback(ctx, run, req, limit)
+		}
+
+		// 2. Rest a child at the touch, or move it there
+		touch := 0.0
+		if ob, err := m.exchange.GetOrderBook(ctx, req.Symbol, "linear"); err == nil && ob != nil {
+			touch = passiveTouch(ob, req.Side)
+		}
+		if limit == 0 && touch > 0 {
+			if limit = chaseLimit(req.Side, touch, req.MaxChasePct, req.TickSize); limit > 0 {
+				m.update(run, func(p *ParentOrder) { p.ChaseLimit = limit })
+			}
+		}
+		price := restPrice(req.Side, touch, limit)
+		if child != nil && price > 0 && price != child.Price {
+			m.cancelChild(run, req, child, true)
+			child = nil
+			remaining = m.snapshot(run).Remaining()
+		}
+		if child == nil && price > 0 && remaining > parentFilledEpsilon {
+			size := remaining
+			if clip > 0 {
+				size = math.Min(clip, remaining)
+			}
+			placed, err := m.placeChild(ctx, run, req, size, price, childTimeInForce)
+			if err != nil {
+				return err
+			}
+			child = placed
+			m.update(run, func(p *ParentOrder) { p.WorkingID, p.WorkingPrice = placed.OrderID, price })
+		}
+
+		// 3. Wait, then book what the child filled
+		select {
+		case <-ctx.Done():
+			if child != nil {
+				m.cancelChild(run, req, child, true)
+			}
+			return ctx.Err()
+		case <-time.After(m.pollInterval()):
+		}
+		if child != nil {
+			if m.syncChild(ctx, run, req, child, true) {
+				child = nil
+			}
+		}
+	}
+}
+
+func (m *ExecutionManager) placeChild(ctx context.Context, run *parentRun, req ParentRequest, size, price float64, tif string) (*domain.Order, error) {
+	placed, err := m.exchange.PlaceOrder(ctx, &domain.Order{
+		Symbol:      req.Symbol,
+		LevelID:     req.LevelID,
+		Side:        req.Side,
+		Type:        "Limit",
+		Size:        size,
+		Price:       price,
+		TimeInForce: tif,
+		ReduceOnly:  req.ReduceOnly,
+		Leverage:    req.Leverage,
+		MarginType:  req.MarginType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to place %s child of %s at %f: %w", req.Side, req.Symbol, price, err)
+	}
+	m.update(run, func(p *ParentOrder) { p.Children++ })
+	return placed, nil
+}
+
+// sendFallback takes what a passive order left at its deadline. Market sends it all; IOC
+// crosses the spread only up to the chase limit (or the far touch when unbounded) and drops
+// what is not offered there.
+func (m *ExecutionManager) sendFallback(ctx context.Context, run *parentRun, req ParentRequest, limit float64) error {
+	p := m.snapshot(run)
+	remaining := p.Remaining()
+	if remaining <= parentFilledEpsilon {
+		return nil
+	}
+	if req.Fallback != domain.ExecFallbackIOC {
+		log.Printf("EXEC: Parent %s deadline reached, sending %f %s at market", p.ID, remaining, req.Symbol)
+		return m.sendMarket(ctx, run, req, remaining)
+	}
+
+	price := limit
+	if price <= 0 {
+		opposite := domain.SideShort
+		if req.Side == domain.SideShort {
+			opposite = domain.SideLong
+		}
+		if ob, err := m.exchange.GetOrderBook(ctx, req.Symbol, "linear"); err == nil && ob != nil {
+			price = passiveTouch(ob, opposite)
+		}
+	}
+	if price <= 0 {
+		return fmt.Errorf("no price for the IOC fallback of %s", req.Symbol)
+	}
+	log.Printf("EXEC: Parent %s deadline reached, sending %f %s IOC at %f", p.ID, remaining, req.Symbol, price)
+	child, err := m.placeChild(ctx, run, req, remaining, price, fallbackTimeInForce)
+	if err != nil {
+		return err
+	}
+	if !m.syncChild(ctx, run, req, child, false) {
+		m.cancelChild(run, req, child, false)
+	}
+	if left := m.snapshot(run).Remaining(); left > parentFilledEpsilon {
+		return fmt.Errorf("IOC fallback at %f left %f of %f unfilled", price, left, p.Size)
+	}
+	return nil
+}
+
+// syncChild books new fills of a child and reports whether it is done (filled, cancelled or
+// rejected, a post-only that would have crossed is cancelled by the exchange). Fills of
+// resting post-only children are maker fills.
+func (m *ExecutionManager) syncChild(ctx context.Context, run *parentRun, req ParentRequest, child *domain.Order, maker bool) bool {
+	o, err := m.exchange.GetOrder(ctx, req.Symbol, child.OrderID)
+	if err != nil {
+		log.Printf("EXEC: Failed to get child %s of %s: %v", child.OrderID, req.Symbol, err)
+		return false
+	}
+	if o.FilledSize > child.FilledSize {
+		// Price the new part from the change of the child's average
+		newQty := o.FilledSize - child.FilledSize
+		price := child.Price
+		if o.AvgFillPrice > 0 {
+			price = (o.AvgFillPrice*o.FilledSize - child.AvgFillPrice*child.FilledSize) / newQty
+		}
+		fee := -1.0
+		if o.CumFee != 0 {
+			fee = o.CumFee - child.CumFee
+		}
+		m.fill(run, newQty, price, child.Price, maker, fee)
+		child.FilledSize, child.AvgFillPrice, child.CumFee = o.FilledSize, o.AvgFillPrice, o.CumFee
+	}
+
+	switch o.Status {
+	case "Filled", "Cancelled", "Rejected", "Deactivated", "PartiallyFilledCanceled":
+		m.update(run, func(p *ParentOrder) { p.WorkingID, p.WorkingPrice = "", 0 })
+		return true
+	}
+	return false
+}
+
+// cancelChild cancels a child and books its last fills. It runs on its own context, the
+// parent's may already be cancelled and the child must not be left on the book.
+func (m *ExecutionManager) cancelChild(run *parentRun, req ParentRequest, child *domain.Order, maker bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), childCancelTimeout)
+	defer cancel()
+	if err := m.exchange.CancelOrder(ctx, req.Symbol, child.OrderID); err != nil {
+		log.Printf("EXEC: Failed to cancel child %s of %s: %v", child.OrderID, req.Symbol, err)
+	}
+	m.syncChild(ctx, run, req, child, maker)
+	m.update(run, func(p *ParentOrder) { p.WorkingID, p.WorkingPrice = "", 0 })
+}
+
+// List returns the working and recently finished parent orders, newest first.
+func (m *ExecutionManager) List() []ParentOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]ParentOrder, 0, len(m.ids))
+	for _, id := range m.ids {
+		out = append(out, m.runs[id].order)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Get returns a parent order by ID.
+func (m *ExecutionManager) Get(id string) (ParentOrder, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return ParentOrder{}, false
+	}
+	return run.order, true
+}
+
+// Cancel stops a working parent order. Its resting child is cancelled, what filled stays.
+func (m *ExecutionManager) Cancel(id, by string) error {
+	m.mu.Lock()
+	run, ok := m.runs[id]
+	if !ok || run.finished {
+		m.mu.Unlock()
+		return ErrParentOrderNotFound
+	}
+	m.mu.Unlock()
+	m.stop([]*parentRun{run}, by)
+	return nil
+}
+
+// CancelEntries stops the working entry orders of a symbol and waits for them to wind down,
+// an exit must not race an entry that is still adding to the position. Returns how many
+// were cancelled.
+func (m *ExecutionManager) CancelEntries(symbol, by string) int {
+	return m.stop(m.working(func(p ParentOrder) bool { return p.Symbol == symbol && p.Purpose == PurposeEntry }), by)
+}
+
+// CancelExits stops the working exit orders of a symbol, or of every symbol when symbol is
+// empty, and waits for them to wind down. Their exits send the rest at market.
+func (m *ExecutionManager) CancelExits(symbol, by string) int {
+	return m.stop(m.working(func(p ParentOrder) bool {
+		return (symbol == "" || p.Symbol == symbol) && p.Purpose == PurposeExit
+	}), by)
+}
+
+// CancelAll stops every working parent order and waits for them to wind down.
+func (m *ExecutionManager) CancelAll(by string) int {
+	return m.stop(m.working(func(ParentOrder) bool { return true }), by)
+}
+
+func (m *ExecutionManager) working(match func(ParentOrder) bool) []*parentRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var runs []*parentRun
+	for _, id := range m.ids {
+		if run := m.runs[id]; !run.finished && match(run.order) {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+func (m *ExecutionManager) stop(runs []*parentRun, by string) int {
+	for _, run := range runs {
+		m.update(run, func(p *ParentOrder) {
+			if p.CancelledBy == "" {
+				p.CancelledBy = by
+			}
+		})
+		run.cancel()
+	}
+	timeout := time.After(parentStopWait)
+	for _, run := range runs {
+		select {
+		case <-run.done:
+		case <-timeout:
+			m.mu.Lock()
+			id := run.order.ID
+			m.mu.Unlock()
+			log.Printf("EXEC: Parent %s did not stop within %v", id, parentStopWait)
+		}
+	}
+	if len(runs) > 0 {
+		log.Printf("EXEC: Cancelled %d parent orders (by %s)", len(runs), by)
+	}
+	return len(runs)
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// LevelPosition is the virtual share of the exchange position owned by one level.
+// The exchange only knows one position per symbol; the ledger splits it back into
+// the parts each level opened so exits and PnL can be attributed to the right level.
+type LevelPosition struct {
+	LevelID     string
+	Symbol      string
+	Side        domain.Side
+	Size        float64
+	EntryPrice  float64 // Average entry of this level's fills
+	RealizedPnL float64 // Cumulative realized PnL of the level before fees, kept after the share is closed
+	EntryFees   float64 // Entry fees of the open size
+	Fees        float64 // Cumulative entry and exit fees of the closed size
+	UpdatedAt   time.Time
+}
+
+// NetPnL is the cumulative realized PnL after fees.
+func (p LevelPosition) NetPnL() float64 {
+	return p.RealizedPnL - p.Fees
+}
+
+// PositionLedger tracks per-level sub-positions built from the fills we send.
+// It is in-memory only: after a restart the exchange position is unattributed and
+// LevelService falls back to the level closest to the entry price.
+type PositionLedger struct {
+	mu        sync.RWMutex
+	positions map[string]*LevelPosition // levelID -> share
+}
+
+func NewPositionLedger() *PositionLedger {
+	return &PositionLedger{
+		positions: make(map[string]*LevelPosition),
+	}
+}
+
+// RecordFill adds a fill and the fee paid on it to the level's share, averaging the entry
+// price. A fill on the opposite side of a non-empty share starts a fresh share.
+func (l *PositionLedger) RecordFill(levelID, symbol string, side domain.Side, size, price, fee float64) {
+	if size <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p, ok := l.positions[levelID]
+	if !ok {
+		p = &LevelPosition{LevelID: levelID, Symbol: symbol}
+		l.positions[levelID] = p
+	}
+	if p.Size > 0 && p.Side != side {
+		p.Size = 0
+		p.EntryPrice = 0
+		p.EntryFees = 0
+	}
+
+	p.EntryPrice = (p.EntryPrice*p.Size + price*size) / (p.Size + size)
+	p.Size += size
+	p.EntryFees += fee
+	p.Side = side
+	p.Symbol = symbol
+	p.UpdatedAt = time.Now()
+}
+
+// Reduce removes up to size from the level's share at price and returns the closed size,
+// the PnL realized on it before fees and the fees of the closed size: its part of the entry
+// fees plus exitFee.
+func (l *PositionLedger) Reduce(levelID string, size, price, exitFee float64) (float64, float64, float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p, ok := l.positions[levelID]
+	if !ok || p.Size <= 0 {
+		return 0, 0, 0
+	}
+	if size > p.Size {
+		size = p.Size
+	}
+
+	var pnl float64
+	if p.Side == domain.SideLong {
+		pnl = (price - p.EntryPrice) * size
+	} else {
+		pnl = (p.EntryPrice - price) * size
+	}
+
+	entryFee := p.EntryFees * size / p.Size
+	fees := entryFee + exitFee
+
+	p.Size -= size
+	p.EntryFees -= entryFee
+	p.RealizedPnL += pnl
+	p.Fees += fees
+	p.UpdatedAt = time.Now()
+	const dustEpsilon = 1e-9
+	if p.Size <= dustEpsilon {
+		p.Size = 0
+		p.EntryPrice = 0
+		p.EntryFees = 0
+	}
+	return size, pnl, fees
+}
+
+// SyncEntry replaces the estimated entry of a share with the exchange's average fill price.
+func (l *PositionLedger) SyncEntry(levelID string, entryPrice float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p, ok := l.positions[levelID]; ok && p.Size > 0 && entryPrice > 0 {
+		p.EntryPrice = entryPrice
+	}
+}
+
+// Get returns a copy of the level's share.
+func (l *PositionLedger) Get(levelID string) (LevelPosition, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	p, ok := l.positions[levelID]
+	if !ok {
+		return LevelPosition{}, false
+	}
+	return *p, true
+}
+
+// OpenBySymbol returns copies of all open shares for a symbol, ordered by level ID.
+func (l *PositionLedger) OpenBySymbol(symbol string) []LevelPosition {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var open []LevelPosition
+	for _, p := range l.positions {
+		if p.Symbol == symbol && p.Size > 0 {
+			open = append(open, *p)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].LevelID < open[j].LevelID
+	})
+	return open
+}
+
+// Opposing returns the open shares of a symbol on the other side of side, ordered by level ID.
+// A one-way exchange position nets both sides, so the ledger must never hold them at once.
+func (l *PositionLedger) Opposing(symbol string, side domain.Side) []LevelPosition {
+	var opposing []LevelPosition
+	for _, p := range l.OpenBySymbol(symbol) {
+		if p.Side != side {
+			opposing = append(opposing, p)
+		}
+	}
+	return opposing
+}
+
+// Discard drops the open size of a share without realizing PnL
+// (e.g. the exchange position was closed outside the bot).
+func (l *PositionLedger) Discard(levelID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p, ok := l.positions[levelID]; ok {
+		p.Size = 0
+		p.EntryPrice = 0
+		p.EntryFees = 0
+		p.UpdatedAt = time.Now()
+	}
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+const (
+	instrumentCacheTTL = time.Hour
+	sizingEquityTTL    = 10 * time.Second // Tiers of one move trigger back to back, one fetch serves them all
+)
+
+// LevelSizing records how a risk sized order was computed, it goes into the decision journal.
+type LevelSizing struct {
+	Mode        string  `json:"mode"`
+	Equity      float64 `json:"equity,omitempty"` // risk_pct only
+	RiskBudget  float64 `json:"risk_budget"`      // USD lost if the full ladder fills and price returns to the level
+	LadderRisk  float64 `json:"ladder_risk"`      // Loss of the full ladder per size unit at the level
+	UnitSize    float64 `json:"unit_size"`        // Stands in for BaseSize
+	Units       float64 `json:"units"`            // Tier weight times the wins multiplier
+	RawSize     float64 `json:"raw_size"`
+	Size        float64 `json:"size"` // After the instrument filters
+	QtyStep     float64 `json:"qty_step"`
+	MinOrderQty float64 `json:"min_order_qty"`
+	Capped      bool    `json:"capped,omitempty"` // Cut to the max market order quantity
+}
+
+// PositionSizer turns the risk budget of a level into order quantities.
+//
+// The budget covers the whole tier ladder: with every tier filled (weights 1/1/2) and price
+// back at the level, the loss equals the budget. Each tier order is its share of that ladder,
+// so the closer the tiers sit to the level the larger the size. The wins multiplier applies
+// on top, as it does for fixed sizes.
+type PositionSizer struct {
+	exchange domain.Exchange
+
+	mu            sync.Mutex
+	instruments   map[string]domain.Instrument
+	instrumentsAt time.Time
+	equity        float64
+	equityAt      time.Time
+}
+
+func NewPositionSizer(exchange domain.Exchange) *PositionSizer {
+	return &PositionSizer{exchange: exchange}
+}
+
+// SizeOrder computes the quantity of a tier order. units is the size the engine returned for a
+// BaseSize of 1, boundaries are the tier prices and price is the expected fill price.
+func (p *PositionSizer) SizeOrder(ctx context.Context, level *domain.Level, boundaries []float64, units, price float64) (*LevelSizing, error) {
+	sizing := &LevelSizing{Mode: level.EffectiveSizingMode(), Units: units}
+
+	// 1. Risk budget
+	switch sizing.Mode {
+	case domain.SizingRiskPct:
+		equity, err := p.currentEquity(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get equity: %w", err)
+		}
+		if equity <= 0 {
+			return nil, fmt.Errorf("account equity is %.2f", equity)
+		}
+		sizing.Equity = equity
+		sizing.RiskBudget = equity * level.RiskPct
+	case domain.SizingRiskUSD:
+		sizing.RiskBudget = level.RiskUSD
+	default:
+		return nil, fmt.Errorf("level %s uses %s sizing", level.ID, sizing.Mode)
+	}
+	if sizing.RiskBudget <= 0 {
+		return nil, fmt.Errorf("risk budget is %.2f", sizing.RiskBudget)
+	}
+
+	// 2. Loss per unit with the level as the stop
+	if len(boundaries) < len(tierSizeWeights) {
+		return nil, fmt.Errorf("expected %d tier prices, got %d", len(tierSizeWeights), len(boundaries))
+	}
+	for i, w := range tierSizeWeights {
+		sizing.LadderRisk += w * math.Abs(boundaries[i]-level.LevelPrice)
+	}
+	if sizing.LadderRisk <= 0 {
+		return nil, fmt.Errorf("tiers sit on the level, the stop distance is zero")
+	}
+	sizing.UnitSize = sizing.RiskBudget / sizing.LadderRisk
+	sizing.RawSize = sizing.UnitSize * units
+
+	// 3. Instrument filters
+	inst, err := p.instrument(ctx, level.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	sizing.QtyStep = inst.QtyStep
+	sizing.MinOrderQty = inst.MinOrderQty
+	sizing.Size = roundDownToStep(sizing.RawSize, inst.QtyStep)
+	if inst.MaxOrderQty > 0 && sizing.Size > inst.MaxOrderQty {
+		log.Printf("SIZING: %s size %f capped to max order qty %f (level %s)", level.Symbol, sizing.Size, inst.MaxOrderQty, level.ID)
+		sizing.Size = roundDownToStep(inst.MaxOrderQty, inst.QtyStep)
+		sizing.Capped = true
+	}
+	if sizing.Size <= 0 || sizing.Size < inst.MinOrderQty {
+		return sizing, fmt.Errorf("size %g is below the min order qty %g of %s", sizing.RawSize, inst.MinOrderQty, level.Symbol)
+	}
+	if inst.MinNotional > 0 && sizing.Size*price < inst.MinNotional {
+		return sizing, fmt.Errorf("notional %.2f is below the min notional %.2f of %s", sizing.Size*price, inst.MinNotional, level.Symbol)
+	}
+	return sizing, nil
+}
+
+// currentEquity returns the account equity, cached for a few seconds.
+func (p *PositionSizer) currentEquity(ctx context.Context) (float64, error) {
+	p.mu.Lock()
+	if !p.equityAt.IsZero() && time.Since(p.equityAt) < sizingEquityTTL {
+		equity := p.equity
+		p.mu.Unlock()
+		return equity, nil
+	}
+	p.mu.Unlock()
+
+	eq, err := p.exchange.GetEquity(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	p.equity, p.equityAt = eq.Equity, time.Now()
+	p.mu.Unlock()
+	return eq.Equity, nil
+}
+
+// instrument returns the order filters of a symbol. The list is cached for an hour and
+// reloaded early when a symbol is missing, e.g. a listing newer than the cache.
+func (p *PositionSizer) instrument(ctx context.Context, symbol string) (domain.Instrument, error) {
+	p.mu.Lock()
+	inst, ok := p.instruments[symbol]
+	fresh := time.Since(p.instrumentsAt) < instrumentCacheTTL
+	p.mu.Unlock()
+	if ok && fresh {
+		return inst, nil
+	}
+
+	list, err := p.exchange.GetInstruments(ctx, "linear")
+	if err != nil {
+		if ok {
+			return inst, nil // Stale filters beat no entry
+		}
+		return domain.Instrument{}, fmt.Errorf("failed to get instruments: %w", err)
+	}
+
+	bySymbol := make(map[string]domain.Instrument, len(list))
+	for _, i := range list {
+		bySymbol[i.Symbol] = i
+	}
+	p.mu.Lock()
+	p.instruments, p.instrumentsAt = bySymbol, time.Now()
+	p.mu.Unlock()
+
+	inst, ok = bySymbol[symbol]
+	if !ok {
+		return domain.Instrument{}, fmt.Errorf("instrument %s not found", symbol)
+	}
+	return inst, nil
+}
+
+// roundDownToStep floors a quantity to the step, risk sizing never rounds up.
+func roundDownToStep(qty, step float64) float64 {
+	if step <= 0 {
+		return qty
+	}
+	n := math.Floor(qty/step + 1e-9)
+	// Trim the float noise of n*step, e.g. 3*0.1 = 0.30000000000000004
+	decimals := math.Max(0, math.Ceil(-math.Log10(step)))
+	scale := math.Pow(10, decimals)
+	return math.Round(n*step*scale) / scale
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// RiskManagedExchange puts the risk manager in front of every order path of an exchange.
+// Services get this instead of the raw adapter, so a new bot cannot forget the check.
+// ClosePosition and ReducePosition only ever reduce and pass straight through.
+type RiskManagedExchange struct {
+	domain.Exchange
+	risk *RiskManager
+}
+
+func NewRiskManagedExchange(exchange domain.Exchange, risk *RiskManager) *RiskManagedExchange {
+	return &RiskManagedExchange{Exchange: exchange, risk: risk}
+}
+
+func (e *RiskManagedExchange) MarketBuy(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error {
+	if err := e.risk.CheckOrder(ctx, RiskOrder{Symbol: symbol, Side: domain.SideLong, Size: size}); err != nil {
+		return fmt.Errorf("failed to buy %s: %w", symbol, err)
+	}
+	return e.Exchange.MarketBuy(ctx, symbol, size, leverage, marginType, stopLoss)
+}
+
+func (e *RiskManagedExchange) MarketSell(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error {
+	if err := e.risk.CheckOrder(ctx, RiskOrder{Symbol: symbol, Side: domain.SideShort, Size: size}); err != nil {
+		return fmt.Errorf("failed to sell %s: %w", symbol, err)
+	}
+	return e.Exchange.MarketSell(ctx, symbol, size, leverage, marginType, stopLoss)
+}
+
+func (e *RiskManagedExchange) PlaceOrder(ctx context.Context, order *domain.Order) (*domain.Order, error) {
+	check := RiskOrder{Symbol: order.Symbol, Side: order.Side, Size: order.Size, Price: order.Price, ReduceOnly: order.ReduceOnly}
+	if order.Type == "Market" {
+		check.Price = 0
+	}
+	if err := e.risk.CheckOrder(ctx, check); err != nil {
+		return nil, fmt.Errorf("failed to place %s order on %s: %w", order.Side, order.Symbol, err)
+	}
+	return e.Exchange.PlaceOrder(ctx, order)
+}
@@ -0,0 +1,402 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// ErrRiskRejected is wrapped by every order the risk manager refuses.
+var ErrRiskRejected = errors.New("rejected by risk manager")
+
+// RiskRejection lists every limit an order would break.
+type RiskRejection struct {
+	Reasons []string
+}
+
+func (r *RiskRejection) Error() string {
+	return fmt.Sprintf("%s: %s", ErrRiskRejected, strings.Join(r.Reasons, "; "))
+}
+
+func (r *RiskRejection) Unwrap() error { return ErrRiskRejected }
+
+// RiskOrder is the order the risk manager is asked about.
+// Price may be zero for market orders, the current price is used then.
+type RiskOrder struct {
+	Symbol     string
+	Side       domain.Side // Side of the order: long buys, short sells
+	Size       float64
+	Price      float64
+	ReduceOnly bool
+}
+
+// RiskExposure is the open notional of one symbol, signed: short is negative.
+type RiskExposure struct {
+	Symbol   string  `json:"symbol"`
+	Notional float64 `json:"notional"`
+}
+
+// RiskStatus is what the risk API reports.
+type RiskStatus struct {
+	domain.RiskState
+	Equity        float64        `json:"equity"`
+	WalletBalance float64        `json:"wallet_balance"`
+	DailyLoss     float64        `json:"daily_loss"`
+	DrawdownPct   float64        `json:"drawdown_pct"`
+	GrossNotional float64        `json:"gross_notional"`
+	NetNotional   float64        `json:"net_notional"`
+	Positions     int            `json:"positions"`
+	Exposures     []RiskExposure `json:"exposures"`
+	EquityError   string         `json:"equity_error,omitempty"`
+}
+
+// RiskManager is consulted by every order path before an order reaches the exchange.
+// Exposure limits reject single orders, loss limits latch a halt that blocks all new
+// exposure until an operator resets it. Orders that only reduce a position always pass,
+// a halted account must still be able to get flat.
+type RiskManager struct {
+	exchange domain.Exchange // The raw exchange, positions and equity only
+	repo     domain.RiskRepository
+
+	mu      sync.Mutex
+	state   domain.RiskState
+	timeNow func() time.Time
+}
+
+// NewRiskManager restores the persisted state (halt, peaks) if there is one. Limits from
+// the repository win over the given defaults, they are what the operator set last.
+func NewRiskManager(exchange domain.Exchange, repo domain.RiskRepository, limits domain.RiskLimits) *RiskManager {
+	m := &RiskManager{
+		exchange: exchange,
+		repo:     repo,
+		state:    domain.RiskState{Limits: limits},
+		timeNow:  time.Now,
+	}
+	if repo != nil {
+		saved, err := repo.GetRiskState(context.Background())
+		if err != nil {
+			log.Printf("RISK: Failed to load risk state, starting fresh: %v", err)
+		} else if saved != nil {
+			m.state = *saved
+			if m.state.Halted {
+				log.Printf("RISK: Trading halted since %v: %s", m.state.HaltedAt, m.state.HaltReason)
+			}
+		}
+	}
+	return m
+}
+
+// CheckOrder returns nil if the order may go to the exchange, a *RiskRejection otherwise.
+func (m *RiskManager) CheckOrder(ctx context.Context, order RiskOrder) error {
+	// 1. Reduce-only orders always pass, halted or not, even with the positions unknown
+	if order.ReduceOnly {
+		return nil
+	}
+
+	// 2. Current exposure, orders that shrink the position pass as well
+	positions, err := m.exchange.GetPositions(ctx)
+	if err != nil {
+		return &RiskRejection{Reasons: []string{fmt.Sprintf("positions unavailable: %v", err)}}
+	}
+	exposures := positionExposures(positions)
+
+	current := exposures[order.Symbol]
+	signedSize := order.Size
+	if order.Side == domain.SideShort {
+		signedSize = -signedSize
+	}
+	currentSize := signedPositionSize(positions, order.Symbol)
+	projectedSize := currentSize + signedSize
+	if currentSize != 0 && math.Abs(projectedSize) <= math.Abs(currentSize) && projectedSize*currentSize >= 0 {
+		return nil
+	}
+
+	// 3. Loss limits, these latch
+	m.mu.Lock()
+	limits := m.state.Limits
+	m.mu.Unlock()
+	if limits.MaxDailyLoss > 0 || limits.MaxDrawdownPct > 0 {
+		if _, err := m.refreshEquity(ctx); err != nil {
+			return &RiskRejection{Reasons: []string{fmt.Sprintf("equity unavailable: %v", err)}}
+		}
+	}
+	m.mu.Lock()
+	halted, haltReason := m.state.Halted, m.state.HaltReason
+	m.mu.Unlock()
+	if halted {
+		return &RiskRejection{Reasons: []string{"trading halted: " + haltReason}}
+	}
+
+	// 4. Exposure after the order
+	price := order.Price
+	if price <= 0 {
+		price, err = m.exchange.GetCurrentPrice(ctx, order.Symbol)
+		if err != nil || price <= 0 {
+			return &RiskRejection{Reasons: []string{fmt.Sprintf("price of %s unavailable: %v", order.Symbol, err)}}
+		}
+	}
+	// The open part keeps its mark price, the new part is priced at the order price
+	projected := current + signedSize*price
+
+	var gross, net float64
+	count := 0
+	for symbol, notional := range exposures {
+		if symbol == order.Symbol {
+			continue
+		}
+		gross += math.Abs(notional)
+		net += notional
+		count++
+	}
+	gross += math.Abs(projected)
+	net += projected
+	if projected != 0 {
+		count++
+	}
+
+	var reasons []string
+	if limits.MaxSymbolNotional > 0 && math.Abs(projected) > limits.MaxSymbolNotional {
+		reasons = append(reasons, fmt.Sprintf("%s notional %.2f > max %.2f", order.Symbol, math.Abs(projected), limits.MaxSymbolNotional))
+	}
+	if limits.MaxGrossNotional > 0 && gross > limits.MaxGrossNotional {
+		reasons = append(reasons, fmt.Sprintf("gross notional %.2f > max %.2f", gross, limits.MaxGrossNotional))
+	}
+	if limits.MaxNetNotional > 0 && math.Abs(net) > limits.MaxNetNotional {
+		reasons = append(reasons, fmt.Sprintf("net notional %.2f > max %.2f", math.Abs(net), limits.MaxNetNotional))
+	}
+	if limits.MaxPositions > 0 && current == 0 && count > limits.MaxPositions {
+		reasons = append(reasons, fmt.Sprintf("positions %d > max %d", count, limits.MaxPositions))
+	}
+	if len(reasons) > 0 {
+		log.Printf("RISK: Rejected %s %f %s: %s", order.Side, order.Size, order.Symbol, strings.Join(reasons, "; "))
+		return &RiskRejection{Reasons: reasons}
+	}
+	return nil
+}
+
+// refreshEquity updates the peak and the day start balance and latches a halt on a breach.
+func (m *RiskManager) refreshEquity(ctx context.Context) (*domain.AccountEquity, error) {
+	eq, err := m.exchange.GetEquity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	now := m.timeNow().UTC()
+	changed := false
+
+	// 1. Day rollover at UTC midnight
+	if day := now.Format("2006-01-02"); m.state.Day != day {
+		m.state.Day = day
+		m.state.DayStartBalance = eq.WalletBalance
+		changed = true
+	}
+	// 2. Peak equity
+	if eq.Equity > m.state.PeakEquity {
+		m.state.PeakEquity = eq.Equity
+		changed = true
+	}
+
+	// 3. Loss limits
+	if !m.state.Halted {
+		limits := m.state.Limits
+		if loss := m.state.DayStartBalance - eq.WalletBalance; limits.MaxDailyLoss > 0 && loss >= limits.MaxDailyLoss {
+			m.halt(fmt.Sprintf("daily loss %.2f >= max %.2f", loss, limits.MaxDailyLoss), now)
+			changed = true
+		} else if dd := drawdownPct(m.state.PeakEquity, eq.Equity); limits.MaxDrawdownPct > 0 && dd >= limits.MaxDrawdownPct {
+			m.halt(fmt.Sprintf("drawdown %.2f%% >= max %.2f%%", dd*100, limits.MaxDrawdownPct*100), now)
+			changed = true
+		}
+	}
+
+	var snapshot domain.RiskState
+	if changed {
+		m.state.UpdatedAt = now
+		snapshot = m.state
+	}
+	m.mu.Unlock()
+
+	if changed {
+		m.save(ctx, &snapshot)
+	}
+	return eq, nil
+}
+
+// halt latches the halt. Must hold m.mu.
+func (m *RiskManager) halt(reason string, now time.Time) {
+	m.state.Halted = true
+	m.state.HaltReason = reason
+	m.state.HaltedAt = now
+	log.Printf("RISK: Trading HALTED: %s. New exposure is blocked until an operator resets it.", reason)
+}
+
+func (m *RiskManager) save(ctx context.Context, state *domain.RiskState) {
+	if m.repo == nil {
+		return
+	}
+	if err := m.repo.SaveRiskState(ctx, state); err != nil {
+		log.Printf("RISK: Failed to save risk state: %v", err)
+	}
+}
+
+// Status reports limits, exposure and the loss figures. Reading the status also runs the
+// loss checks, so a breach latches even when no order is being placed.
+func (m *RiskManager) Status(ctx context.Context) (*RiskStatus, error) {
+	positions, err := m.exchange.GetPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	status := &RiskStatus{}
+	eq, err := m.refreshEquity(ctx)
+	if err != nil {
+		status.EquityError = err.Error()
+	}
+
+	m.mu.Lock()
+	status.RiskState = m.state
+	m.mu.Unlock()
+
+	if eq != nil {
+		status.Equity = eq.Equity
+		status.WalletBalance = eq.WalletBalance
+		status.DailyLoss = math.Max(0, status.DayStartBalance-eq.WalletBalance)
+		status.DrawdownPct = drawdownPct(status.PeakEquity, eq.Equity)
+	}
+	for symbol, notional := range positionExposures(positions) {
+		status.Exposures = append(status.Exposures, RiskExposure{Symbol: symbol, Notional: notional})
+		status.GrossNotional += math.Abs(notional)
+		status.NetNotional += notional
+		status.Positions++
+	}
+	return status, nil
+}
+
+// Limits returns the active limits.
+func (m *RiskManager) Limits() domain.RiskLimits {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Limits
+}
+
+// SetLimits replaces the limits. A running halt stays latched.
+func (m *RiskManager) SetLimits(ctx context.Context, limits domain.RiskLimits) error {
+	if limits.MaxGrossNotional < 0 || limits.MaxNetNotional < 0 || limits.MaxPositions < 0 ||
+		limits.MaxSymbolNotional < 0 || limits.MaxDailyLoss < 0 || limits.MaxDrawdownPct < 0 || limits.MaxDrawdownPct >= 1 {
+		return fmt.Errorf("invalid risk limits: values must be >= 0 and max_drawdown_pct < 1")
+	}
+	m.mu.Lock()
+	m.state.Limits = limits
+	m.state.UpdatedAt = m.timeNow()
+	snapshot := m.state
+	m.mu.Unlock()
+
+	log.Printf("RISK: Limits updated: %+v", limits)
+	m.save(ctx, &snapshot)
+	return nil
+}
+
+// Reset clears a halt. The loss baselines restart from the current account so the same
+// breach does not latch again right away.
+func (m *RiskManager) Reset(ctx context.Context, by string) error {
+	eq, err := m.exchange.GetEquity(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get equity: %w", err)
+	}
+
+	m.mu.Lock()
+	log.Printf("RISK: Halt reset by %s (was: %q)", by, m.state.HaltReason)
+	now := m.timeNow().UTC()
+	m.state.Halted = false
+	m.state.HaltReason = ""
+	m.state.HaltedAt = time.Time{}
+	m.state.PeakEquity = eq.Equity
+	m.state.Day = now.Format("2006-01-02")
+	m.state.DayStartBalance = eq.WalletBalance
+	m.state.UpdatedAt = now
+	snapshot := m.state
+	m.mu.Unlock()
+
+	m.save(ctx, &snapshot)
+	return nil
+}
+
+// Halt latches a halt by hand, e.g. from the kill switch. It blocks new exposure and is
+// cleared by Reset, the same as a loss limit halt.
+func (m *RiskManager) Halt(ctx context.Context, reason string) {
+	m.mu.Lock()
+	now := m.timeNow().UTC()
+	m.halt(reason, now)
+	m.state.UpdatedAt = now
+	snapshot := m.state
+	m.mu.Unlock()
+
+	m.save(ctx, &snapshot)
+}
+
+// HaltReason returns why trading is halted, empty if it is not.
+func (m *RiskManager) HaltReason() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.state.Halted {
+		return ""
+	}
+	return m.state.HaltReason
+}
+
+// IsHalted tells whether a loss limit or a manual halt latched.
+func (m *RiskManager) IsHalted() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Halted
+}
+
+// positionExposures maps symbol -> signed notional at the mark price (entry if unknown).
+func positionExposures(positions []*domain.Position) map[string]float64 {
+	out := make(map[string]float64)
+	for _, p := range positions {
+		if p == nil || p.Size == 0 {
+			continue
+		}
+		price := p.CurrentPrice
+		if price <= 0 {
+			price = p.EntryPrice
+		}
+		notional := p.Size * price
+		if p.Side == domain.SideShort {
+			notional = -notional
+		}
+		out[p.Symbol] += notional
+	}
+	return out
+}
+
+// signedPositionSize is the position size of a symbol, short is negative.
+func signedPositionSize(positions []*domain.Position, symbol string) float64 {
+	var size float64
+	for _, p := range positions {
+		if p == nil || p.Symbol != symbol {
+			continue
+		}
+		if p.Side == domain.SideShort {
+			size -= p.Size
+		} else {
+			size += p.Size
+		}
+	}
+	return size
+}
+
+func drawdownPct(peak, equity float64) float64 {
+	if peak <= 0 || equity >= peak {
+		return 0
+	}
+	return (peak - equity) / peak
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -10,6 +11,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// StrategyKindSpeed is the runtime kind of speed bots.
+const StrategyKindSpeed = "speed"
+
 type SpeedBotConfig struct {
 	Symbol       string        `json:"symbol"`
 	PositionSize float64       `json:"position_size"`
@@ -18,12 +22,13 @@ type SpeedBotConfig struct {
 	Cooldown     time.Duration `json:"cooldown"`
 }
 
+// SpeedBotService is the speed bot facade used by the web handlers.
+// The bots themselves run as strategies in the StrategyRuntime.
 type SpeedBotService struct {
+	runtime       *StrategyRuntime
 	exchange      domain.Exchange
 	marketService *MarketService
-	bots          map[string]*SpeedBot
 	logger        *zap.Logger
-	mu            sync.Mutex
 }
 
 type SpeedBot struct {
@@ -31,10 +36,9 @@ type SpeedBot struct {
 	exchange      domain.Exchange
 	marketService *MarketService
 	logger        *zap.Logger
+	reportFill    func(order *domain.Order)
 	lastCloseTime time.Time
 	running       bool
-	stopChan      chan struct{}
-	cancel        context.CancelFunc
 	mu            sync.Mutex
 }
 
@@ -46,108 +50,152 @@ type BotStatus struct {
 	LastSignal time.Time        `json:"last_signal"`
 }
 
-func NewSpeedBotService(exchange domain.Exchange, marketService *MarketService, logger *zap.Logger) *SpeedBotService {
-	return &SpeedBotService{
+func NewSpeedBotService(runtime *StrategyRuntime, exchange domain.Exchange, marketService *MarketService, logger *zap.Logger) *SpeedBotService {
+	s := &SpeedBotService{
+		runtime:       runtime,
 		exchange:      exchange,
 		marketService: marketService,
-		bots:          make(map[string]*SpeedBot),
 		logger:        logger,
 	}
+	runtime.RegisterKind(StrategyKindSpeed, s.newBot)
+	return s
 }
 
-func (s *SpeedBotService) StartBot(ctx context.Context, config SpeedBotConfig) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func speedBotID(symbol string) string {
+	return StrategyKindSpeed + ":" + symbol
+}
 
-	// Check if bot already running for this symbol
-	if bot, exists := s.bots[config.Symbol]; exists && bot.running {
-		return fmt.Errorf("bot already running for %s", config.Symbol)
+// newBot is the StrategyFactory of speed bots.
+func (s *SpeedBotService) newBot(spec StrategySpec) (Strategy, error) {
+	var config SpeedBotConfig
+	if err := json.Unmarshal(spec.Config, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode speed bot config: %w", err)
 	}
-
-	// Create new bot
-	bot := &SpeedBot{
+	return &SpeedBot{
 		config:        config,
 		exchange:      s.exchange,
 		marketService: s.marketService,
 		logger:        s.logger,
-		running:       true,
-		stopChan:      make(chan struct{}),
-	}
+	}, nil
+}
 
-	s.bots[config.Symbol] = bot
+func (s *SpeedBotService) StartBot(ctx context.Context, config SpeedBotConfig) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode speed bot config: %w", err)
+	}
 
-	// Start bot loop with background context (not request context!)
-	// The request context expires after the HTTP response is sent
-	// Use a cancellable context derived from background
-	botCtx, cancel := context.WithCancel(context.Background())
-	bot.cancel = cancel
-	go bot.run(botCtx)
+	if err := s.runtime.Start(ctx, StrategySpec{
+		ID:            speedBotID(config.Symbol),
+		Kind:          StrategyKindSpeed,
+		Symbols:       []string{config.Symbol},
+		TimerInterval: 1 * time.Second,
+		Config:        raw,
+	}); err != nil {
+		return err
+	}
 
 	s.logger.Info("Speed bot started", zap.String("symbol", config.Symbol))
 	return nil
 }
 
 func (s *SpeedBotService) StopBot(symbol string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	bot, exists := s.bots[symbol]
-	if !exists || !bot.running {
+	if err := s.runtime.Stop(context.Background(), speedBotID(symbol)); err != nil {
 		return fmt.Errorf("no running bot found for %s", symbol)
 	}
 
-	bot.stop()
-	delete(s.bots, symbol)
-
 	s.logger.Info("Speed bot stopped", zap.String("symbol", symbol))
 	return nil
 }
 
 func (s *SpeedBotService) GetBotStatus(ctx context.Context, symbol string) (*BotStatus, error) {
-	s.mu.Lock()
-	bot, exists := s.bots[symbol]
-	s.mu.Unlock()
-
-	if !exists {
+	strategy, exists := s.runtime.Get(speedBotID(symbol))
+	bot, ok := strategy.(*SpeedBot)
+	if !exists || !ok {
 		return &BotStatus{Running: false}, nil
 	}
 
 	return bot.getStatus(ctx)
 }
 
-func (b *SpeedBot) run(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	b.logger.Info("Bot evaluation loop started", zap.String("symbol", b.config.Symbol))
+// --- Strategy implementation ---
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := b.evaluate(ctx); err != nil {
-				b.logger.Error("Bot evaluation error", zap.Error(err), zap.String("symbol", b.config.Symbol))
-			}
-		case <-b.stopChan:
-			b.logger.Info("Bot evaluation loop stopped", zap.String("symbol", b.config.Symbol))
-			return
-		case <-ctx.Done():
-			b.logger.Info("Bot evaluation loop cancelled", zap.String("symbol", b.config.Symbol))
-			return
+func (b *SpeedBot) Init(ctx context.Context, env StrategyEnv) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if env.Logger != nil {
+		b.logger = env.Logger
+	}
+	// The bot opens and closes the whole symbol position, it must be the only one trading it
+	if env.Leases != nil {
+		if err := env.Leases.Acquire(b.config.Symbol, env.ID, ""); err != nil {
+			return err
 		}
 	}
+	b.reportFill = env.ReportFill
+	b.running = true
+	b.logger.Info("Bot evaluation loop started", zap.String("symbol", b.config.Symbol))
+	return nil
 }
 
-func (b *SpeedBot) stop() {
+func (b *SpeedBot) OnTick(ctx context.Context, symbol string, price float64) error { return nil }
+
+func (b *SpeedBot) OnTrade(ctx context.Context, trade domain.PublicTrade) error { return nil }
+
+func (b *SpeedBot) OnFill(ctx context.Context, order *domain.Order) error { return nil }
+
+// OnTimer runs the signal evaluation once per second.
+func (b *SpeedBot) OnTimer(ctx context.Context, now time.Time) error {
+	return b.evaluate(ctx)
+}
+
+func (b *SpeedBot) Status(ctx context.Context) (interface{}, error) {
+	return b.getStatus(ctx)
+}
+
+func (b *SpeedBot) Stop(ctx context.Context) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-
 	if b.running {
 		b.running = false
-		if b.cancel != nil {
-			b.cancel()
-		}
-		close(b.stopChan)
+		b.logger.Info("Bot evaluation loop stopped", zap.String("symbol", b.config.Symbol))
 	}
+	return nil
+}
+
+type speedBotState struct {
+	LastCloseTime time.Time `json:"last_close_time"`
+}
+
+// SnapshotState keeps the cooldown across restarts.
+func (b *SpeedBot) SnapshotState() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return json.Marshal(speedBotState{LastCloseTime: b.lastCloseTime})
+}
+
+func (b *SpeedBot) RestoreState(data []byte) error {
+	var st speedBotState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.lastCloseTime = st.LastCloseTime
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *SpeedBot) fill(side domain.Side, size, price float64) {
+	if b.reportFill == nil {
+		return
+	}
+	b.reportFill(&domain.Order{
+		Symbol:    b.config.Symbol,
+		Side:      side,
+		Size:      size,
+		Price:     price,
+		CreatedAt: time.Now(),
+	})
 }
 
 func (b *SpeedBot) evaluate(ctx context.Context) error {
@@ -185,7 +233,7 @@ func (b *SpeedBot) evaluate(ctx context.Context) error {
 	divergence := speedRatio - depthRatio
 
 	// If we have a position, check for close signal
-	if position.Size > 0 {
+	if position != nil && position.Size > 0 {
 		if b.shouldClose(stats, position.Side) {
 			b.logger.Info("Closing position",
 				zap.String("symbol", b.config.Symbol),
@@ -196,14 +244,20 @@ func (b *SpeedBot) evaluate(ctx context.Context) error {
 				return fmt.Errorf("failed to close position: %w", err)
 			}
 
+			b.mu.Lock()
 			b.lastCloseTime = time.Now()
+			b.mu.Unlock()
+			b.fill(position.Side, 0, stats.LastPrice) // Close marker
 			return nil
 		}
 		return nil // Position open, no close signal
 	}
 
 	// Check cooldown
-	if time.Since(b.lastCloseTime) < b.config.Cooldown {
+	b.mu.Lock()
+	lastCloseTime := b.lastCloseTime
+	b.mu.Unlock()
+	if time.Since(lastCloseTime) < b.config.Cooldown {
 		return nil // Still in cooldown
 	}
 
@@ -231,8 +285,12 @@ func (b *SpeedBot) evaluate(ctx context.Context) error {
 			zap.String("symbol", b.config.Symbol),
 			zap.Float64("size", b.config.PositionSize))
 
-		return b.exchange.MarketBuy(ctx, b.config.Symbol, b.config.PositionSize,
-			b.config.Leverage, b.config.MarginType, 0)
+		if err := b.exchange.MarketBuy(ctx, b.config.Symbol, b.config.PositionSize,
+			b.config.Leverage, b.config.MarginType, 0); err != nil {
+			return err
+		}
+		b.fill(domain.SideLong, b.config.PositionSize, stats.LastPrice)
+		return nil
 	}
 
 	if shortSignal {
@@ -240,8 +298,12 @@ func (b *SpeedBot) evaluate(ctx context.Context) error {
 			zap.String("symbol", b.config.Symbol),
 			zap.Float64("size", b.config.PositionSize))
 
-		return b.exchange.MarketSell(ctx, b.config.Symbol, b.config.PositionSize,
-			b.config.Leverage, b.config.MarginType, 0)
+		if err := b.exchange.MarketSell(ctx, b.config.Symbol, b.config.PositionSize,
+			b.config.Leverage, b.config.MarginType, 0); err != nil {
+			return err
+		}
+		b.fill(domain.SideShort, b.config.PositionSize, stats.LastPrice)
+		return nil
 	}
 
 	return nil
@@ -274,7 +336,7 @@ func (b *SpeedBot) getStatus(ctx context.Context) (*BotStatus, error) {
 
 	// Get current position
 	position, err := b.exchange.GetPosition(ctx, b.config.Symbol)
-	if err == nil && position.Size > 0 {
+	if err == nil && position != nil && position.Size > 0 {
 		status.Position = position
 		status.Signal = fmt.Sprintf("Position: %s %.4f @ $%.2f",
 			position.Side, position.Size, position.EntryPrice)
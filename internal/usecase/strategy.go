@@ -0,0 +1,579 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"go.uber.org/zap"
+)
+
+// Strategy is a bot instance driven by the StrategyRuntime.
+// Callbacks of one instance never run concurrently (see SymbolParallelStrategy), so a strategy only needs locking for
+// state it shares with Status (called from HTTP handlers). Ticks wait in a one-slot queue
+// per symbol, a tick not handled yet is replaced by the next price of its symbol.
+type Strategy interface {
+	Init(ctx context.Context, env StrategyEnv) error
+	OnTick(ctx context.Context, symbol string, price float64) error
+	OnTrade(ctx context.Context, trade domain.PublicTrade) error
+	OnTimer(ctx context.Context, now time.Time) error
+	OnFill(ctx context.Context, order *domain.Order) error
+	Status(ctx context.Context) (interface{}, error)
+	Stop(ctx context.Context) error
+}
+
+// StatefulStrategy is implemented by strategies that want their state to survive restarts.
+// The runtime snapshots after every timer callback and on stop.
+type StatefulStrategy interface {
+	Strategy
+	SnapshotState() ([]byte, error)
+	RestoreState(data []byte) error
+}
+
+// SymbolParallelStrategy is implemented by strategies whose symbols are independent of each
+// other. Their OnTick runs on one worker per symbol, concurrently across symbols, so a slow
+// symbol never holds up the others. Ticks of one symbol stay in order, and the other
+// callbacks run on the instance goroutine, concurrently with the ticks.
+type SymbolParallelStrategy interface {
+	Strategy
+	ParallelSymbols() bool
+}
+
+// StrategyEnv is what the runtime hands to a strategy on Init.
+type StrategyEnv struct {
+	ID       string
+	Exchange domain.Exchange
+	Market   *MarketService
+	Logger   *zap.Logger
+	// Leases arbitrates symbols between strategies, acquire a symbol before trading it.
+	// Leases still held when the strategy stops are released by the runtime.
+	Leases *SymbolLeaseRegistry
+	// ReportFill fans an executed order out to OnFill of every strategy on the symbol.
+	ReportFill func(order *domain.Order)
+}
+
+// AllSymbols subscribes a strategy to every symbol.
+const AllSymbols = "*"
+
+// StrategySpec identifies a strategy instance and how the runtime drives it.
+type StrategySpec struct {
+	ID            string          `json:"id"`
+	Kind          string          `json:"kind"`
+	Symbols       []string        `json:"symbols"`        // Symbols the instance receives ticks/trades/fills for
+	TimerInterval time.Duration   `json:"timer_interval"` // 0 disables OnTimer
+	Config        json.RawMessage `json:"config"`         // Kind specific, decoded by the factory
+}
+
+// StrategyFactory builds a strategy of one kind from its spec.
+type StrategyFactory func(spec StrategySpec) (Strategy, error)
+
+// StrategyInfo is the runtime view of an instance, for the API.
+type StrategyInfo struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Symbols   []string  `json:"symbols"`
+	StartedAt time.Time `json:"started_at"`
+	Panics    int64     `json:"panics"`
+	Dropped   int64     `json:"dropped"`       // Trades and fills dropped because the instance fell behind
+	Coalesced uint64    `json:"dropped_ticks"` // Ticks replaced by a newer price of their symbol
+}
+
+type strategyEventKind int
+
+const (
+	eventTrade strategyEventKind = iota
+	eventFill
+)
+
+type strategyEvent struct {
+	kind   strategyEventKind
+	symbol string
+	trade  domain.PublicTrade
+	order  *domain.Order
+}
+
+type strategyInstance struct {
+	spec      StrategySpec
+	strategy  Strategy
+	events    chan strategyEvent // Trades and fills, ticks go to the tick queues
+	ticks     *TickDispatcher
+	calls     sync.Mutex // Serializes the callbacks, unless ticks run per symbol
+	cancel    context.CancelFunc
+	done      chan struct{}
+	startedAt time.Time
+	panics    atomic.Int64
+	dropped   atomic.Int64
+	stopping  atomic.Bool
+	lastState string
+}
+
+func (i *strategyInstance) wants(symbol string) bool {
+	for _, s := range i.spec.Symbols {
+		if s == AllSymbols || s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// StrategyRuntime owns the lifecycle of all strategies: scheduling, market data fan-out,
+// persistence of config and state, and panic isolation (a panicking strategy is logged
+// and stopped after maxPanics, the others keep running).
+type StrategyRuntime struct {
+	exchange domain.Exchange
+	market   *MarketService
+	repo     domain.StrategyRepository // Optional, nil disables persistence
+	logger   *zap.Logger
+	leases   *SymbolLeaseRegistry
+
+	mu        sync.RWMutex
+	factories map[string]StrategyFactory
+	instances map[string]*strategyInstance
+}
+
+const (
+	strategyEventBuffer = 256
+	strategyMaxPanics   = 5
+)
+
+func NewStrategyRuntime(exchange domain.Exchange, market *MarketService, repo domain.StrategyRepository, logger *zap.Logger) *StrategyRuntime {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &StrategyRuntime{
+		exchange:  exchange,
+		market:    market,
+		repo:      repo,
+		logger:    logger,
+		leases:    NewSymbolLeaseRegistry(),
+		factories: make(map[string]StrategyFactory),
+		instances: make(map[string]*strategyInstance),
+	}
+}
+
+// RegisterKind makes a strategy kind startable (and restorable after a restart).
+func (r *StrategyRuntime) RegisterKind(kind string, factory StrategyFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[kind] = factory
+}
+
+// Leases returns the symbol ownership registry shared by all strategies.
+func (r *StrategyRuntime) Leases() *SymbolLeaseRegistry {
+	return r.leases
+}
+
+// Start creates, initialises and schedules a new strategy instance and persists its config.
+func (r *StrategyRuntime) Start(ctx context.Context, spec StrategySpec) error {
+	return r.start(ctx, spec, "")
+}
+
+func (r *StrategyRuntime) start(ctx context.Context, spec StrategySpec, state string) error {
+	r.mu.Lock()
+	if _, exists := r.instances[spec.ID]; exists {
+		r.mu.Unlock()
+		return fmt.Errorf("strategy %s already running", spec.ID)
+	}
+	factory, ok := r.factories[spec.Kind]
+	if !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("unknown strategy kind %q", spec.Kind)
+	}
+
+	strategy, err := factory(spec)
+	if err != nil {
+		r.mu.Unlock()
+		return fmt.Errorf("failed to create strategy %s: %w", spec.ID, err)
+	}
+
+	inst := &strategyInstance{
+		spec:      spec,
+		strategy:  strategy,
+		events:    make(chan strategyEvent, strategyEventBuffer),
+		done:      make(chan struct{}),
+		startedAt: time.Now(),
+		lastState: state,
+	}
+	// Reserve the ID before releasing the lock so a concurrent Start fails fast
+	r.instances[spec.ID] = inst
+	r.mu.Unlock()
+
+	if stateful, ok := strategy.(StatefulStrategy); ok && state != "" {
+		if err := stateful.RestoreState([]byte(state)); err != nil {
+			r.logger.Warn("Failed to restore strategy state, starting fresh", zap.String("id", spec.ID), zap.Error(err))
+		}
+	}
+
+	env := StrategyEnv{
+		ID:         spec.ID,
+		Exchange:   r.exchange,
+		Market:     r.market,
+		Logger:     r.logger.With(zap.String("strategy", spec.ID)),
+		Leases:     r.leases,
+		ReportFill: r.DispatchFill,
+	}
+	if err := r.safeCall(inst, "Init", func() error { return strategy.Init(ctx, env) }); err != nil {
+		r.leases.ReleaseAll(spec.ID)
+		r.mu.Lock()
+		delete(r.instances, spec.ID)
+		r.mu.Unlock()
+		return fmt.Errorf("failed to init strategy %s: %w", spec.ID, err)
+	}
+
+	r.persist(ctx, inst, true)
+
+	// Background context: the caller's context is usually an HTTP request
+	loopCtx, cancel := context.WithCancel(context.Background())
+	inst.cancel = cancel
+	var lock sync.Locker = &inst.calls
+	if parallel, ok := strategy.(SymbolParallelStrategy); ok && parallel.ParallelSymbols() {
+		lock = nil
+	}
+	inst.ticks = NewTickDispatcher(func(symbol string, price float64) {
+		if err := r.safeCall(inst, "OnTick", func() error { return strategy.OnTick(loopCtx, symbol, price) }); err != nil {
+			r.logger.Error("Strategy callback failed", zap.String("id", spec.ID), zap.Error(err))
+		}
+		r.checkPanics(inst)
+	}, lock)
+	go r.loop(loopCtx, inst)
+
+	r.logger.Info("Strategy started", zap.String("id", spec.ID), zap.String("kind", spec.Kind), zap.Strings("symbols", spec.Symbols))
+	return nil
+}
+
+// Stop stops an instance and marks it as not running, so it is not restored on the next start.
+func (r *StrategyRuntime) Stop(ctx context.Context, id string) error {
+	inst, err := r.detach(id)
+	if err != nil {
+		return err
+	}
+	r.shutdownInstance(ctx, inst)
+	r.persist(ctx, inst, false)
+	r.logger.Info("Strategy stopped", zap.String("id", id))
+	return nil
+}
+
+// Shutdown stops all instances for a process exit. They stay marked as running and are
+// brought back by Restore.
+func (r *StrategyRuntime) Shutdown(ctx context.Context) {
+	r.mu.Lock()
+	instances := r.instances
+	r.instances = make(map[string]*strategyInstance)
+	r.mu.Unlock()
+
+	for _, inst := range instances {
+		r.shutdownInstance(ctx, inst)
+		r.persist(ctx, inst, true)
+	}
+}
+
+// Restore restarts every persisted strategy that was running, with its last state snapshot.
+func (r *StrategyRuntime) Restore(ctx context.Context) error {
+	if r.repo == nil {
+		return nil
+	}
+	recs, err := r.repo.ListStrategies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list strategies: %w", err)
+	}
+
+	for _, rec := range recs {
+		if !rec.Running {
+			continue
+		}
+		var spec StrategySpec
+		if err := json.Unmarshal([]byte(rec.Config), &spec); err != nil {
+			r.logger.Error("Failed to decode strategy spec", zap.String("id", rec.ID), zap.Error(err))
+			continue
+		}
+		if err := r.start(ctx, spec, rec.State); err != nil {
+			r.logger.Error("Failed to restore strategy", zap.String("id", rec.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Get returns the running instance with this ID.
+func (r *StrategyRuntime) Get(id string) (Strategy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	inst, ok := r.instances[id]
+	if !ok {
+		return nil, false
+	}
+	return inst.strategy, true
+}
+
+// Status returns the strategy specific status of a running instance.
+func (r *StrategyRuntime) Status(ctx context.Context, id string) (interface{}, error) {
+	r.mu.RLock()
+	inst, ok := r.instances[id]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("strategy %s not running", id)
+	}
+
+	var status interface{}
+	err := r.safeCall(inst, "Status", func() error {
+		var err error
+		status, err = inst.strategy.Status(ctx)
+		return err
+	})
+	return status, err
+}
+
+// List returns all running instances ordered by ID.
+func (r *StrategyRuntime) List() []StrategyInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]StrategyInfo, 0, len(r.instances))
+	for _, inst := range r.instances {
+		info := StrategyInfo{
+			ID:        inst.spec.ID,
+			Kind:      inst.spec.Kind,
+			Symbols:   inst.spec.Symbols,
+			StartedAt: inst.startedAt,
+			Panics:    inst.panics.Load(),
+			Dropped:   inst.dropped.Load(),
+		}
+		if inst.ticks != nil {
+			for _, st := range inst.ticks.Stats() {
+				info.Coalesced += st.Dropped
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// DispatchTick hands a price update to the tick queues of the instances subscribed to the
+// symbol. It never blocks, so it can run in the WS read loop.
+func (r *StrategyRuntime) DispatchTick(symbol string, price float64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, inst := range r.instances {
+		if inst.ticks != nil && inst.wants(symbol) {
+			inst.ticks.Push(symbol, price)
+		}
+	}
+}
+
+// TickStats returns the tick queues of every instance, by strategy and symbol.
+func (r *StrategyRuntime) TickStats() []domain.TickQueueStats {
+	r.mu.RLock()
+	var stats []domain.TickQueueStats
+	for _, inst := range r.instances {
+		if inst.ticks == nil {
+			continue
+		}
+		for _, st := range inst.ticks.Stats() {
+			st.Strategy = inst.spec.ID
+			stats = append(stats, st)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Strategy != stats[j].Strategy {
+			return stats[i].Strategy < stats[j].Strategy
+		}
+		return stats[i].Symbol < stats[j].Symbol
+	})
+	return stats
+}
+
+// DispatchTrade fans a public trade out to the instances subscribed to the symbol.
+func (r *StrategyRuntime) DispatchTrade(symbol, side string, size, price float64) {
+	r.dispatch(symbol, strategyEvent{kind: eventTrade, symbol: symbol, trade: domain.PublicTrade{
+		Symbol: symbol, Side: side, Size: size, Price: price, Time: time.Now().UnixMilli(),
+	}})
+}
+
+// DispatchFill fans an executed order out to the instances subscribed to its symbol.
+func (r *StrategyRuntime) DispatchFill(order *domain.Order) {
+	if order == nil {
+		return
+	}
+	r.dispatch(order.Symbol, strategyEvent{kind: eventFill, symbol: order.Symbol, order: order})
+}
+
+// dispatch never blocks the market data feed: a full queue drops the event for that instance.
+func (r *StrategyRuntime) dispatch(symbol string, ev strategyEvent) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, inst := range r.instances {
+		if !inst.wants(symbol) {
+			continue
+		}
+		select {
+		case inst.events <- ev:
+		default:
+			if inst.dropped.Add(1)%100 == 1 {
+				r.logger.Warn("Strategy falling behind, dropping events", zap.String("id", inst.spec.ID), zap.Int64("dropped", inst.dropped.Load()))
+			}
+		}
+	}
+}
+
+func (r *StrategyRuntime) loop(ctx context.Context, inst *strategyInstance) {
+	defer close(inst.done)
+
+	var timerC <-chan time.Time
+	if inst.spec.TimerInterval > 0 {
+		ticker := time.NewTicker(inst.spec.TimerInterval)
+		defer ticker.Stop()
+		timerC = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-inst.events:
+			inst.calls.Lock()
+			switch ev.kind {
+			case eventTrade:
+				err = r.safeCall(inst, "OnTrade", func() error { return inst.strategy.OnTrade(ctx, ev.trade) })
+			case eventFill:
+				err = r.safeCall(inst, "OnFill", func() error { return inst.strategy.OnFill(ctx, ev.order) })
+			}
+			inst.calls.Unlock()
+		case now := <-timerC:
+			inst.calls.Lock()
+			err = r.safeCall(inst, "OnTimer", func() error { return inst.strategy.OnTimer(ctx, now) })
+			r.persistStateIfChanged(ctx, inst)
+			inst.calls.Unlock()
+		}
+
+		if err != nil {
+			r.logger.Error("Strategy callback failed", zap.String("id", inst.spec.ID), zap.Error(err))
+		}
+
+		if r.checkPanics(inst) {
+			return
+		}
+	}
+}
+
+// checkPanics stops an instance that panicked too often, once, and reports whether it did.
+func (r *StrategyRuntime) checkPanics(inst *strategyInstance) bool {
+	if inst.panics.Load() < strategyMaxPanics {
+		return false
+	}
+	if inst.stopping.CompareAndSwap(false, true) {
+		r.logger.Error("Strategy panicked too often, stopping it", zap.String("id", inst.spec.ID))
+		go func() {
+			if err := r.Stop(context.Background(), inst.spec.ID); err != nil {
+				r.logger.Error("Failed to stop panicking strategy", zap.String("id", inst.spec.ID), zap.Error(err))
+			}
+		}()
+	}
+	return true
+}
+
+// safeCall runs a strategy callback and turns a panic into an error.
+func (r *StrategyRuntime) safeCall(inst *strategyInstance, name string, fn func() error) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			inst.panics.Add(1)
+			r.logger.Error("Strategy panic recovered",
+				zap.String("id", inst.spec.ID),
+				zap.String("callback", name),
+				zap.Any("panic", rec),
+				zap.ByteString("stack", debug.Stack()))
+			err = fmt.Errorf("panic in %s: %v", name, rec)
+		}
+	}()
+	return fn()
+}
+
+func (r *StrategyRuntime) detach(id string) (*strategyInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inst, ok := r.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("strategy %s not running", id)
+	}
+	delete(r.instances, id)
+	return inst, nil
+}
+
+func (r *StrategyRuntime) shutdownInstance(ctx context.Context, inst *strategyInstance) {
+	if inst.cancel != nil {
+		inst.cancel()
+		<-inst.done
+		inst.ticks.Close() // Waits for the ticks being handled
+	}
+	if err := r.safeCall(inst, "Stop", func() error { return inst.strategy.Stop(ctx) }); err != nil {
+		r.logger.Error("Strategy stop failed", zap.String("id", inst.spec.ID), zap.Error(err))
+	}
+	r.leases.ReleaseAll(inst.spec.ID)
+}
+
+func (r *StrategyRuntime) snapshot(inst *strategyInstance) string {
+	stateful, ok := inst.strategy.(StatefulStrategy)
+	if !ok {
+		return ""
+	}
+	var data []byte
+	if err := r.safeCall(inst, "SnapshotState", func() error {
+		var err error
+		data, err = stateful.SnapshotState()
+		return err
+	}); err != nil {
+		r.logger.Error("Failed to snapshot strategy state", zap.String("id", inst.spec.ID), zap.Error(err))
+		return inst.lastState
+	}
+	return string(data)
+}
+
+func (r *StrategyRuntime) persistStateIfChanged(ctx context.Context, inst *strategyInstance) {
+	if r.repo == nil {
+		return
+	}
+	if _, ok := inst.strategy.(StatefulStrategy); !ok {
+		return
+	}
+	if state := r.snapshot(inst); state != inst.lastState {
+		inst.lastState = state
+		r.save(ctx, inst, true)
+	}
+}
+
+// persist snapshots the state and saves the record.
+func (r *StrategyRuntime) persist(ctx context.Context, inst *strategyInstance, running bool) {
+	if r.repo == nil {
+		return
+	}
+	inst.lastState = r.snapshot(inst)
+	r.save(ctx, inst, running)
+}
+
+func (r *StrategyRuntime) save(ctx context.Context, inst *strategyInstance, running bool) {
+	spec, err := json.Marshal(inst.spec)
+	if err != nil {
+		r.logger.Error("Failed to encode strategy spec", zap.String("id", inst.spec.ID), zap.Error(err))
+		return
+	}
+
+	rec := &domain.StrategyRecord{
+		ID:        inst.spec.ID,
+		Kind:      inst.spec.Kind,
+		Symbols:   inst.spec.Symbols,
+		Config:    string(spec),
+		State:     inst.lastState,
+		Running:   running,
+		UpdatedAt: time.Now(),
+	}
+	if err := r.repo.SaveStrategy(ctx, rec); err != nil {
+		r.logger.Error("Failed to persist strategy", zap.String("id", inst.spec.ID), zap.Error(err))
+	}
+}
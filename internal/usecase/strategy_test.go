@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+type memStrategyRepo struct {
+	mu   sync.Mutex
+	recs map[string]domain.StrategyRecord
+}
+
+func newMemStrategyRepo() *memStrategyRepo {
+	return &memStrategyRepo{recs: make(map[string]domain.StrategyRecord)}
+}
+
+func (m *memStrategyRepo) SaveStrategy(ctx context.Context, rec *domain.StrategyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recs[rec.ID] = *rec
+	return nil
+}
+
+func (m *memStrategyRepo) GetStrategy(ctx context.Context, id string) (*domain.StrategyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.recs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+func (m *memStrategyRepo) ListStrategies(ctx context.Context) ([]*domain.StrategyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var recs []*domain.StrategyRecord
+	for _, rec := range m.recs {
+		rec := rec
+		recs = append(recs, &rec)
+	}
+	return recs, nil
+}
+
+func (m *memStrategyRepo) DeleteStrategy(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.recs, id)
+	return nil
+}
+
+// countingStrategy counts ticks and panics on every tick when told to.
+type countingStrategy struct {
+	mu      sync.Mutex
+	ticks   map[string]int
+	panicky bool
+	Counter int `json:"counter"`
+}
+
+func (s *countingStrategy) Init(ctx context.Context, env StrategyEnv) error { return nil }
+
+func (s *countingStrategy) OnTick(ctx context.Context, symbol string, price float64) error {
+	if s.panicky {
+		panic("boom")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ticks[symbol]++
+	s.Counter++
+	return nil
+}
+
+func (s *countingStrategy) OnTrade(ctx context.Context, trade domain.PublicTrade) error { return nil }
+func (s *countingStrategy) OnTimer(ctx context.Context, now time.Time) error            { return nil }
+func (s *countingStrategy) OnFill(ctx context.Context, order *domain.Order) error       { return nil }
+func (s *countingStrategy) Stop(ctx context.Context) error                              { return nil }
+
+func (s *countingStrategy) Status(ctx context.Context) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Counter, nil
+}
+
+func (s *countingStrategy) tickCount(symbol string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ticks[symbol]
+}
+
+func (s *countingStrategy) SnapshotState() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Marshal(s)
+}
+
+func (s *countingStrategy) RestoreState(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Unmarshal(data, s)
+}
+
+// parallelStrategy runs its ticks per symbol and holds BTC ticks until released.
+type parallelStrategy struct {
+	countingStrategy
+	release chan struct{}
+}
+
+func (s *parallelStrategy) ParallelSymbols() bool { return true }
+
+func (s *parallelStrategy) OnTick(ctx context.Context, symbol string, price float64) error {
+	if symbol == "BTCUSDT" {
+		<-s.release
+	}
+	return s.countingStrategy.OnTick(ctx, symbol, price)
+}
+
+func newCountingRuntime(repo domain.StrategyRepository) (*StrategyRuntime, map[string]*countingStrategy) {
+	created := make(map[string]*countingStrategy)
+	r := NewStrategyRuntime(nil, nil, repo, nil)
+	r.RegisterKind("counting", func(spec StrategySpec) (Strategy, error) {
+		s := &countingStrategy{ticks: make(map[string]int)}
+		created[spec.ID] = s
+		return s, nil
+	})
+	r.RegisterKind("panicky", func(spec StrategySpec) (Strategy, error) {
+		s := &countingStrategy{ticks: make(map[string]int), panicky: true}
+		created[spec.ID] = s
+		return s, nil
+	})
+	return r, created
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+func TestStrategyRuntime_FanOutBySymbol(t *testing.T) {
+	r, created := newCountingRuntime(nil)
+	ctx := context.Background()
+	defer r.Shutdown(ctx)
+
+	if err := r.Start(ctx, StrategySpec{ID: "btc", Kind: "counting", Symbols: []string{"BTCUSDT"}}); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	if err := r.Start(ctx, StrategySpec{ID: "all", Kind: "counting", Symbols: []string{AllSymbols}}); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	if err := r.Start(ctx, StrategySpec{ID: "btc", Kind: "counting", Symbols: []string{"BTCUSDT"}}); err == nil {
+		t.Errorf("Expected duplicate ID to be rejected")
+	}
+
+	r.DispatchTick("BTCUSDT", 100)
+	r.DispatchTick("ETHUSDT", 10)
+
+	waitFor(t, "ticks", func() bool {
+		return created["all"].tickCount("BTCUSDT") == 1 && created["all"].tickCount("ETHUSDT") == 1 && created["btc"].tickCount("BTCUSDT") == 1
+	})
+	if n := created["btc"].tickCount("ETHUSDT"); n != 0 {
+		t.Errorf("BTC strategy should not see ETH ticks, got %d", n)
+	}
+}
+
+func TestStrategyRuntime_ParallelSymbolsCoalesceTicks(t *testing.T) {
+	r := NewStrategyRuntime(nil, nil, nil, nil)
+	s := &parallelStrategy{countingStrategy: countingStrategy{ticks: make(map[string]int)}, release: make(chan struct{})}
+	r.RegisterKind("parallel", func(spec StrategySpec) (Strategy, error) { return s, nil })
+	ctx := context.Background()
+	defer r.Shutdown(ctx)
+
+	if err := r.Start(ctx, StrategySpec{ID: "par", Kind: "parallel", Symbols: []string{AllSymbols}}); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+
+	// 1. BTC is stuck in OnTick, ETH keeps flowing
+	r.DispatchTick("BTCUSDT", 1)
+	waitFor(t, "BTC tick to be taken", func() bool {
+		st := tickStatsOf(r, "par", "BTCUSDT")
+		return st.Received == 1 && !st.Pending
+	})
+	r.DispatchTick("ETHUSDT", 10)
+	waitFor(t, "ETH tick", func() bool { return s.tickCount("ETHUSDT") == 1 })
+
+	// 2. BTC ticks waiting behind the stuck one are coalesced to the latest
+	for p := 2.0; p <= 4; p++ {
+		r.DispatchTick("BTCUSDT", p)
+	}
+	close(s.release)
+	waitFor(t, "BTC ticks", func() bool { return tickStatsOf(r, "par", "BTCUSDT").Processed == 2 })
+
+	if n := s.tickCount("BTCUSDT"); n != 2 {
+		t.Errorf("Expected the stuck tick and the latest one, got %d", n)
+	}
+	if st := tickStatsOf(r, "par", "BTCUSDT"); st.Dropped != 2 || st.MaxLagMS <= 0 {
+		t.Errorf("Unexpected queue stats %+v", st)
+	}
+	if infos := r.List(); len(infos) != 1 || infos[0].Coalesced != 2 {
+		t.Errorf("Expected 2 coalesced ticks in the strategy info, got %+v", infos)
+	}
+}
+
+func tickStatsOf(r *StrategyRuntime, id, symbol string) domain.TickQueueStats {
+	for _, st := range r.TickStats() {
+		if st.Strategy == id && st.Symbol == symbol {
+			return st
+		}
+	}
+	return domain.TickQueueStats{}
+}
+
+func panicsOf(r *StrategyRuntime, id string) int64 {
+	for _, info := range r.List() {
+		if info.ID == id {
+			return info.Panics
+		}
+	}
+	return 0
+}
+
+func TestStrategyRuntime_PanicIsolation(t *testing.T) {
+	r, created := newCountingRuntime(nil)
+	ctx := context.Background()
+	defer r.Shutdown(ctx)
+
+	r.Start(ctx, StrategySpec{ID: "bad", Kind: "panicky", Symbols: []string{AllSymbols}})
+	r.Start(ctx, StrategySpec{ID: "good", Kind: "counting", Symbols: []string{AllSymbols}})
+
+	// One tick at a time, the queues keep only the latest tick of a symbol
+	for i := 1; i <= strategyMaxPanics+2; i++ {
+		r.DispatchTick("BTCUSDT", 100)
+		waitFor(t, "tick to be processed", func() bool {
+			_, running := r.Get("bad")
+			return created["good"].tickCount("BTCUSDT") == i && (!running || panicsOf(r, "bad") == int64(i))
+		})
+	}
+
+	waitFor(t, "panicking strategy to be stopped", func() bool {
+		_, running := r.Get("bad")
+		return !running
+	})
+	waitFor(t, "healthy strategy to process all ticks", func() bool {
+		return created["good"].tickCount("BTCUSDT") == strategyMaxPanics+2
+	})
+	if _, running := r.Get("good"); !running {
+		t.Errorf("Healthy strategy should keep running")
+	}
+}
+
+func TestStrategyRuntime_PersistAndRestore(t *testing.T) {
+	repo := newMemStrategyRepo()
+	ctx := context.Background()
+
+	r, created := newCountingRuntime(repo)
+	r.Start(ctx, StrategySpec{ID: "kept", Kind: "counting", Symbols: []string{"BTCUSDT"}})
+	r.Start(ctx, StrategySpec{ID: "stopped", Kind: "counting", Symbols: []string{"BTCUSDT"}})
+	r.DispatchTick("BTCUSDT", 100)
+	waitFor(t, "tick", func() bool { return created["kept"].tickCount("BTCUSDT") == 1 })
+	r.DispatchTick("BTCUSDT", 101)
+	waitFor(t, "tick", func() bool { return created["kept"].tickCount("BTCUSDT") == 2 })
+
+	if err := r.Stop(ctx, "stopped"); err != nil {
+		t.Fatalf("Failed to stop: %v", err)
+	}
+	r.Shutdown(ctx) // Process exit
+
+	// New process
+	r2, created2 := newCountingRuntime(repo)
+	if err := r2.Restore(ctx); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	defer r2.Shutdown(ctx)
+
+	if _, running := r2.Get("stopped"); running {
+		t.Errorf("Explicitly stopped strategy should not be restored")
+	}
+	status, err := r2.Status(ctx, "kept")
+	if err != nil {
+		t.Fatalf("Expected kept strategy to be restored: %v", err)
+	}
+	if status != 2 || created2["kept"] == nil {
+		t.Errorf("Expected restored counter 2, got %v", status)
+	}
+}
@@ -28,6 +28,27 @@ type LevelState struct {
 	DisabledUntil         time.Time // Timestamp until which the level is disabled
 	RangeHigh             float64   // Highest price observed during active period
 	RangeLow              float64   // Lowest price observed during active period
+	TakeProfitStepsDone   int       // Number of take-profit ladder steps already executed for the open position
+	LadderBaseSize        float64   // Position size when the ladder started, step sizes are fractions of it
+
+	// Protective stops for the open position
+	TrailingActive    bool    // Trailing stop armed (activation distance reached)
+	TrailingPeak      float64 // Best price since the trailing stop armed
+	TrailingStopPrice float64 // Current trailing stop price
+	BreakEvenActive   bool    // Stop moved to entry
+	BreakEvenPrice    float64 // Entry price the break-even stop sits at
+	ExchangeStopSet   bool    // Trading-stop already sent to the exchange (exchange mode)
+
+	LastEntryDecisions []EntryDecision // Filter verdicts of the last attempted entry (kept across resets)
+}
+
+func (s *LevelState) clearProtectiveStops() {
+	s.TrailingActive = false
+	s.TrailingPeak = 0
+	s.TrailingStopPrice = 0
+	s.BreakEvenActive = false
+	s.BreakEvenPrice = 0
+	s.ExchangeStopSet = false
 }
 
 type SublevelEngine struct {
@@ -72,6 +93,9 @@ func (e *SublevelEngine) ResetState(levelID string) {
 		s.Tier2Triggered = false
 		s.Tier3Triggered = false
 		s.ActiveSide = ""
+		s.TakeProfitStepsDone = 0
+		s.LadderBaseSize = 0
+		s.clearProtectiveStops()
 		// ConsecutiveWins is preserved
 		// ConsecutiveBaseCloses is preserved
 		// DisabledUntil is preserved
@@ -81,6 +105,9 @@ func (e *SublevelEngine) ResetState(levelID string) {
 	}
 }
 
+// tierSizeWeights are the tier order sizes in units of BaseSize, before the wins multiplier.
+var tierSizeWeights = [3]float64{1, 1, 2}
+
 // Evaluate checks if price movement triggers a tier action.
 // boundaries: [Tier1, Tier2, Tier3] prices.
 func (e *SublevelEngine) Evaluate(level *domain.Level, boundaries []float64, prevPrice, currPrice float64, side domain.Side) (Action, float64) {
@@ -205,23 +232,26 @@ func (e *SublevelEngine) Evaluate(level *domain.Level, boundaries []float64, pre
 			log.Printf("AUDIT: Tier 1 Triggered (Short). Level %s. Price %f -> %f. Boundary: %f. Wins: %d. Mult: %f", level.ID, prevPrice, currPrice, tier1Price, state.ConsecutiveWins, multiplier)
 			state.Tier1Triggered = true
 			state.ActiveSide = domain.SideShort
+			state.TakeProfitStepsDone = 0 // Fresh position, fresh ladder
+			state.LadderBaseSize = 0
+			state.clearProtectiveStops()
 			triggered = true
 			action = ActionOpen
-			size = level.BaseSize * multiplier
+			size = level.BaseSize * tierSizeWeights[0] * multiplier
 		} else if !state.Tier2Triggered && crossesUp(prevPrice, currPrice, tier2Price) {
 			// Tier 2
 			log.Printf("AUDIT: Tier 2 Triggered (Short). Level %s. Price %f -> %f. Boundary: %f", level.ID, prevPrice, currPrice, tier2Price)
 			state.Tier2Triggered = true
 			triggered = true
 			action = ActionAddToPosition
-			size = level.BaseSize // Additions are usually base size? Or scaled? Spec implies initial entry scaling. Keeping additions flat for now to manage risk.
+			size = level.BaseSize * tierSizeWeights[1] // Additions are usually base size? Or scaled? Spec implies initial entry scaling. Keeping additions flat for now to manage risk.
 		} else if !state.Tier3Triggered && crossesUp(prevPrice, currPrice, tier3Price) {
 			// Tier 3
 			log.Printf("AUDIT: Tier 3 Triggered (Short). Level %s. Price %f -> %f. Boundary: %f", level.ID, prevPrice, currPrice, tier3Price)
 			state.Tier3Triggered = true
 			triggered = true
 			action = ActionAddToPosition
-			size = 2 * level.BaseSize
+			size = level.BaseSize * tierSizeWeights[2]
 		}
 	} else {
 		// Long (Support): Tiers are ABOVE Level.
@@ -237,21 +267,24 @@ func (e *SublevelEngine) Evaluate(level *domain.Level, boundaries []float64, pre
 			log.Printf("AUDIT: Tier 1 Triggered (Long). Level %s. Price %f -> %f. Boundary: %f. Wins: %d. Mult: %f", level.ID, prevPrice, currPrice, tier1Price, state.ConsecutiveWins, multiplier)
 			state.Tier1Triggered = true
 			state.ActiveSide = domain.SideLong
+			state.TakeProfitStepsDone = 0 // Fresh position, fresh ladder
+			state.LadderBaseSize = 0
+			state.clearProtectiveStops()
 			triggered = true
 			action = ActionOpen
-			size = level.BaseSize * multiplier
+			size = level.BaseSize * tierSizeWeights[0] * multiplier
 		} else if !state.Tier2Triggered && crossesDown(prevPrice, currPrice, tier2Price) {
 			log.Printf("AUDIT: Tier 2 Triggered (Long). Level %s. Price %f -> %f. Boundary: %f", level.ID, prevPrice, currPrice, tier2Price)
 			state.Tier2Triggered = true
 			triggered = true
 			action = ActionAddToPosition
-			size = level.BaseSize
+			size = level.BaseSize * tierSizeWeights[1]
 		} else if !state.Tier3Triggered && crossesDown(prevPrice, currPrice, tier3Price) {
 			log.Printf("AUDIT: Tier 3 Triggered (Long). Level %s. Price %f -> %f. Boundary: %f", level.ID, prevPrice, currPrice, tier3Price)
 			state.Tier3Triggered = true
 			triggered = true
 			action = ActionAddToPosition
-			size = 2 * level.BaseSize
+			size = level.BaseSize * tierSizeWeights[2]
 		}
 	}
 
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// ErrSymbolLeased is returned when a symbol (or side) is already owned by another strategy.
+var ErrSymbolLeased = errors.New("symbol is owned by another strategy")
+
+// SymbolLease is the ownership of a symbol by one strategy. An empty Side owns both sides.
+type SymbolLease struct {
+	Symbol     string      `json:"symbol"`
+	Side       domain.Side `json:"side,omitempty"`
+	Owner      string      `json:"owner"` // Strategy ID, e.g. "speed:BTCUSDT"
+	AcquiredAt time.Time   `json:"acquired_at"`
+}
+
+// SymbolLeaseRegistry arbitrates symbols between strategies. Every bot trades the same
+// one-way position per symbol, so a bot has to own a symbol before it opens or closes
+// anything on it, otherwise one bot flattens what another opened.
+//
+// Side leases only let two owners share a symbol in hedge mode, where each side is its own
+// position. In one-way mode (the default) any two leases of a symbol conflict.
+type SymbolLeaseRegistry struct {
+	mu        sync.Mutex
+	leases    map[string][]SymbolLease // symbol -> leases
+	hedgeMode bool
+}
+
+func NewSymbolLeaseRegistry() *SymbolLeaseRegistry {
+	return &SymbolLeaseRegistry{leases: make(map[string][]SymbolLease)}
+}
+
+// SetHedgeMode lets owners of opposite sides share a symbol.
+func (r *SymbolLeaseRegistry) SetHedgeMode(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hedgeMode = enabled
+}
+
+// conflicts reports whether two leases of the same symbol by different owners collide.
+func (r *SymbolLeaseRegistry) conflicts(a, b SymbolLease) bool {
+	if !r.hedgeMode || a.Side == "" || b.Side == "" {
+		return true
+	}
+	return a.Side == b.Side
+}
+
+// Acquire takes a symbol (or one side of it) for owner. Acquiring again is a no-op, an owner
+// can hold both sides. Returns an ErrSymbolLeased error naming the current owner on conflict.
+func (r *SymbolLeaseRegistry) Acquire(symbol, owner string, side domain.Side) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	want := SymbolLease{Symbol: symbol, Side: side, Owner: owner, AcquiredAt: time.Now()}
+	for _, l := range r.leases[symbol] {
+		if l.Owner == owner {
+			if l.Side == side {
+				return nil
+			}
+			continue
+		}
+		if r.conflicts(l, want) {
+			return fmt.Errorf("cannot lease %s%s to %s, owned by %s: %w", symbol, sideSuffix(side), owner, l.Owner, ErrSymbolLeased)
+		}
+	}
+
+	r.leases[symbol] = append(r.leases[symbol], want)
+	log.Printf("LEASE: %s%s acquired by %s", symbol, sideSuffix(side), owner)
+	return nil
+}
+
+// Release gives up every lease owner holds on a symbol.
+func (r *SymbolLeaseRegistry) Release(symbol, owner string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.release(symbol, owner)
+}
+
+// ReleaseAll gives up every lease of owner, e.g. when its strategy stops.
+func (r *SymbolLeaseRegistry) ReleaseAll(owner string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for symbol := range r.leases {
+		r.release(symbol, owner)
+	}
+}
+
+// release must hold r.mu.
+func (r *SymbolLeaseRegistry) release(symbol, owner string) {
+	kept := r.leases[symbol][:0]
+	released := false
+	for _, l := range r.leases[symbol] {
+		if l.Owner == owner {
+			released = true
+			continue
+		}
+		kept = append(kept, l)
+	}
+	if len(kept) == 0 {
+		delete(r.leases, symbol)
+	} else {
+		r.leases[symbol] = kept
+	}
+	if released {
+		log.Printf("LEASE: %s released by %s", symbol, owner)
+	}
+}
+
+// Holds reports whether owner holds any lease on the symbol.
+func (r *SymbolLeaseRegistry) Holds(symbol, owner string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.leases[symbol] {
+		if l.Owner == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// Owners returns the strategies holding the symbol, empty if nobody does.
+func (r *SymbolLeaseRegistry) Owners(symbol string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var owners []string
+	for _, l := range r.leases[symbol] {
+		owners = append(owners, l.Owner)
+	}
+	return owners
+}
+
+// List returns all leases ordered by symbol.
+func (r *SymbolLeaseRegistry) List() []SymbolLease {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []SymbolLease
+	for _, leases := range r.leases {
+		all = append(all, leases...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Symbol != all[j].Symbol {
+			return all[i].Symbol < all[j].Symbol
+		}
+		return all[i].Owner < all[j].Owner
+	})
+	return all
+}
+
+func sideSuffix(side domain.Side) string {
+	if side == "" {
+		return ""
+	}
+	return " " + string(side)
+}
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+func TestSymbolLeaseRegistry_OneWayConflicts(t *testing.T) {
+	r := NewSymbolLeaseRegistry()
+	if err := r.Acquire("BTCUSDT", "level:bybit", domain.SideLong); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	// Acquiring again and the other side are fine for the same owner
+	if err := r.Acquire("BTCUSDT", "level:bybit", domain.SideLong); err != nil {
+		t.Errorf("Re-acquire failed: %v", err)
+	}
+	if err := r.Acquire("BTCUSDT", "level:bybit", domain.SideShort); err != nil {
+		t.Errorf("Acquire of the other side failed: %v", err)
+	}
+
+	// One-way mode: the opposite side is still the same position
+	if err := r.Acquire("BTCUSDT", "speed:BTCUSDT", domain.SideShort); !errors.Is(err, ErrSymbolLeased) {
+		t.Errorf("Expected conflict, got %v", err)
+	}
+	if err := r.Acquire("ETHUSDT", "speed:ETHUSDT", ""); err != nil {
+		t.Errorf("Other symbol should be free: %v", err)
+	}
+
+	r.ReleaseAll("level:bybit")
+	if r.Holds("BTCUSDT", "level:bybit") {
+		t.Errorf("Expected leases released")
+	}
+	if err := r.Acquire("BTCUSDT", "speed:BTCUSDT", ""); err != nil {
+		t.Errorf("Released symbol should be free: %v", err)
+	}
+	if got := r.List(); len(got) != 2 || got[0].Symbol != "BTCUSDT" || got[1].Symbol != "ETHUSDT" {
+		t.Errorf("Unexpected leases %+v", got)
+	}
+}
+
+func TestSymbolLeaseRegistry_HedgeModeSides(t *testing.T) {
+	r := NewSymbolLeaseRegistry()
+	r.SetHedgeMode(true)
+	if err := r.Acquire("BTCUSDT", "level:bybit", domain.SideLong); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if err := r.Acquire("BTCUSDT", "funding:BTCUSDT", domain.SideShort); err != nil {
+		t.Errorf("Opposite side should be free in hedge mode: %v", err)
+	}
+	if err := r.Acquire("BTCUSDT", "speed:BTCUSDT", domain.SideLong); !errors.Is(err, ErrSymbolLeased) {
+		t.Errorf("Expected conflict on the same side, got %v", err)
+	}
+	// A whole symbol lease collides with any side
+	if err := r.Acquire("BTCUSDT", "speed:BTCUSDT", ""); !errors.Is(err, ErrSymbolLeased) {
+		t.Errorf("Expected conflict on the whole symbol, got %v", err)
+	}
+}
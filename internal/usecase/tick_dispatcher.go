@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// TickHandler processes one price update of a symbol.
+type TickHandler func(symbol string, price float64)
+
+// TickDispatcher is the tick queue of one strategy instance. Every symbol gets a one-slot
+// queue and its own worker. Only the latest price matters, so a price that was not picked up
+// yet is replaced (and counted as dropped) instead of queueing up behind a slow handler.
+// Without a lock a slow REST call in one symbol's processing never delays the other symbols.
+// With a lock the workers take turns, a worker picks its price up only once it holds the
+// lock, so the wait for the lock counts as queue lag and coalesces like any other.
+type TickDispatcher struct {
+	handler TickHandler
+	lock    sync.Locker // Optional, held around every handler call
+
+	mu      sync.Mutex
+	queues  map[string]*tickQueue
+	closed  bool
+	workers sync.WaitGroup
+}
+
+type tickQueue struct {
+	symbol string
+	wake   chan struct{}
+	done   chan struct{}
+
+	mu       sync.Mutex
+	price    float64
+	queuedAt time.Time
+	pending  bool
+	stats    domain.TickQueueStats
+	totalLag time.Duration
+}
+
+// NewTickDispatcher returns a dispatcher running handler on per-symbol workers, lock may be nil.
+func NewTickDispatcher(handler TickHandler, lock sync.Locker) *TickDispatcher {
+	return &TickDispatcher{
+		handler: handler,
+		lock:    lock,
+		queues:  make(map[string]*tickQueue),
+	}
+}
+
+// Push hands a price to the symbol's worker and never blocks.
+func (d *TickDispatcher) Push(symbol string, price float64) {
+	q := d.queue(symbol)
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	if q.pending {
+		q.stats.Dropped++
+	}
+	q.price = price
+	q.queuedAt = time.Now()
+	q.pending = true
+	q.stats.Received++
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default: // Worker is already signalled
+	}
+}
+
+// queue returns the queue of a symbol, starting its worker on first use.
+func (d *TickDispatcher) queue(symbol string) *tickQueue {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+	q, ok := d.queues[symbol]
+	if !ok {
+		q = &tickQueue{
+			symbol: symbol,
+			wake:   make(chan struct{}, 1),
+			done:   make(chan struct{}),
+			stats:  domain.TickQueueStats{Symbol: symbol},
+		}
+		d.queues[symbol] = q
+		d.workers.Add(1)
+		go d.run(q)
+	}
+	return q
+}
+
+func (d *TickDispatcher) run(q *tickQueue) {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-q.wake:
+		case <-q.done:
+			return
+		}
+
+		if d.lock != nil {
+			d.lock.Lock()
+		}
+		q.mu.Lock()
+		if !q.pending {
+			q.mu.Unlock()
+			if d.lock != nil {
+				d.lock.Unlock()
+			}
+			continue
+		}
+		price, queuedAt := q.price, q.queuedAt
+		q.pending = false
+		q.mu.Unlock()
+
+		lag := time.Since(queuedAt)
+		d.handle(q.symbol, price)
+		if d.lock != nil {
+			d.lock.Unlock()
+		}
+
+		q.mu.Lock()
+		q.stats.Processed++
+		q.totalLag += lag
+		q.stats.LastLagMS = durationMS(lag)
+		if q.stats.LastLagMS > q.stats.MaxLagMS {
+			q.stats.MaxLagMS = q.stats.LastLagMS
+		}
+		q.stats.AvgLagMS = durationMS(q.totalLag) / float64(q.stats.Processed)
+		q.stats.LastTick = queuedAt.Unix()
+		q.mu.Unlock()
+	}
+}
+
+// handle runs the handler, a panic must not take the symbol's worker down with it.
+func (d *TickDispatcher) handle(symbol string, price float64) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("TICKS: Handler panic on %s: %v", symbol, r)
+		}
+	}()
+	d.handler(symbol, price)
+}
+
+// Stats returns the queue metrics per symbol, sorted by symbol.
+func (d *TickDispatcher) Stats() []domain.TickQueueStats {
+	d.mu.Lock()
+	queues := make([]*tickQueue, 0, len(d.queues))
+	for _, q := range d.queues {
+		queues = append(queues, q)
+	}
+	d.mu.Unlock()
+
+	stats := make([]domain.TickQueueStats, 0, len(queues))
+	for _, q := range queues {
+		q.mu.Lock()
+		st := q.stats
+		st.Pending = q.pending
+		q.mu.Unlock()
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Symbol < stats[j].Symbol })
+	return stats
+}
+
+// Close stops all workers and waits for the prices being handled, pending prices are discarded.
+func (d *TickDispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	for _, q := range d.queues {
+		close(q.done)
+	}
+	d.mu.Unlock()
+	d.workers.Wait()
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
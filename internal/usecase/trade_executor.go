@@ -2,21 +2,43 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"time"
 
 	"github.com/vitos/crypto_trade_level/internal/domain"
 )
 
+// ErrSlippageRejected is returned when a guarded market order cannot go out within its
+// slippage limit.
+var ErrSlippageRejected = errors.New("expected slippage over the limit")
+
+const (
+	maxOrderSlices  = 5
+	orderSliceDelay = 500 * time.Millisecond // Lets the book refill between split slices
+	slippageEpsilon = 1e-12
+	fillSizeEpsilon = 1e-9
+)
+
 type TradeExecutor struct {
-	exchange domain.Exchange
+	exchange   domain.Exchange
+	sliceDelay time.Duration
 }
 
 func NewTradeExecutor(exchange domain.Exchange) *TradeExecutor {
 	return &TradeExecutor{
-		exchange: exchange,
+		exchange:   exchange,
+		sliceDelay: orderSliceDelay,
 	}
 }
 
+// SetSliceDelay changes the pause between the slices of a split order.
+func (e *TradeExecutor) SetSliceDelay(d time.Duration) {
+	e.sliceDelay = d
+}
+
 func (e *TradeExecutor) Execute(ctx context.Context, symbol string, side domain.Side, size float64, leverage int, marginType string, stopLoss float64) error {
 	if side == domain.SideLong {
 		return e.exchange.MarketBuy(ctx, symbol, size, leverage, marginType, stopLoss)
@@ -25,3 +47,262 @@ func (e *TradeExecutor) Execute(ctx context.Context, symbol string, side domain.
 	}
 	return fmt.Errorf("invalid side: %s", side)
 }
+
+// BookWalk is the expected outcome of taking a quantity from one side of the order book.
+type BookWalk struct {
+	Size        float64 `json:"size"`
+	Fillable    float64 `json:"fillable"` // Less than Size when the book runs out
+	BestPrice   float64 `json:"best_price"`
+	AvgPrice    float64 `json:"avg_price"`
+	SlippagePct float64 `json:"slippage_pct"` // AvgPrice vs BestPrice
+}
+
+// bookSide returns the levels a market order of the side takes liquidity from.
+func bookSide(ob *domain.OrderBook, side domain.Side) []domain.OrderBookEntry {
+	if side == domain.SideShort {
+		return ob.Bids
+	}
+	return ob.Asks
+}
+
+// WalkOrderBook estimates the average fill of a market order for size. A buy walks the asks,
+// a sell the bids.
+func WalkOrderBook(ob *domain.OrderBook, side domain.Side, size float64) BookWalk {
+	walk := BookWalk{Size: size}
+	book := bookSide(ob, side)
+	if len(book) == 0 || size <= 0 {
+		return walk
+	}
+	walk.BestPrice = book[0].Price
+
+	remaining, cost := size, 0.0
+	for _, lvl := range book {
+		fill := math.Min(remaining, lvl.Size)
+		cost += fill * lvl.Price
+		remaining -= fill
+		if remaining <= 0 {
+			break
+		}
+	}
+	walk.Fillable = size - math.Max(remaining, 0)
+	if walk.Fillable > 0 {
+		walk.AvgPrice = cost / walk.Fillable
+		walk.SlippagePct = math.Abs(walk.AvgPrice-walk.BestPrice) / walk.BestPrice
+	}
+	return walk
+}
+
+// MaxSizeWithinSlippage returns the largest quantity whose average fill stays within maxPct
+// of the best price, capped at size.
+func MaxSizeWithinSlippage(ob *domain.OrderBook, side domain.Side, size, maxPct float64) float64 {
+	book := bookSide(ob, side)
+	if len(book) == 0 || size <= 0 {
+		return 0
+	}
+	best := book[0].Price
+	limit := best * (1 + maxPct) // Worst average a buy may get
+	if side == domain.SideShort {
+		limit = best * (1 - maxPct)
+	}
+
+	qty, cost := 0.0, 0.0
+	for _, lvl := range book {
+		take := math.Min(lvl.Size, size-qty)
+		avg := (cost + take*lvl.Price) / (qty + take)
+		if (side != domain.SideShort && avg <= limit+slippageEpsilon) || (side == domain.SideShort && avg >= limit-slippageEpsilon) {
+			qty += take
+			cost += take * lvl.Price
+			if qty >= size {
+				return size
+			}
+			continue
+		}
+		// Only part of this level fits: (cost + x*price) / (qty + x) = limit
+		if x := (limit*qty - cost) / (lvl.Price - limit); x > 0 {
+			qty += math.Min(x, take)
+		}
+		break
+	}
+	return qty
+}
+
+// ExecutionRequest is a market order with a slippage guard.
+type ExecutionRequest struct {
+	Symbol         string
+	Side           domain.Side
+	Size           float64
+	Leverage       int
+	MarginType     string
+	StopLoss       float64
+	MaxSlippagePct float64 // 0 sends the order unguarded
+	SlippageAction string  // domain.SlippageReject, SlippageDownsize or SlippageSplit
+	QtyStep        float64 // Downsized quantities are floored to it, 0 skips rounding
+	MinQty         float64 // Smaller slices are not sent
+}
+
+// ExecutionSlice is one market order sent for an execution.
+type ExecutionSlice struct {
+	Size                float64 `json:"size"`
+	BestPrice           float64 `json:"best_price"`
+	ExpectedPrice       float64 `json:"expected_price"`
+	ExpectedSlippagePct float64 `json:"expected_slippage_pct"`
+	FillPrice           float64 `json:"fill_price,omitempty"` // From the position, 0 if it could not be derived
+	Error               string  `json:"error,omitempty"`
+}
+
+// Execution is what a guarded market order did.
+type Execution struct {
+	Requested     float64          `json:"requested"`
+	Filled        float64          `json:"filled"` // Accepted by the exchange
+	Action        string           `json:"action"` // "full", "downsized", "split" or "rejected"
+	Slices        []ExecutionSlice `json:"slices"`
+	ExpectedPrice float64          `json:"expected_price"` // Size weighted over the slices
+	FillPrice     float64          `json:"fill_price"`     // Size weighted, 0 if unknown
+	Reason        string           `json:"reason,omitempty"`
+	ParentID      string           `json:"parent_order_id,omitempty"` // Set when an execution algorithm worked the order
+	Fee           float64          `json:"fee,omitempty"`             // Of the priced fills of a parent order
+}
+
+// ExpectedSlippagePct is the size weighted slippage the book walks promised.
+func (x *Execution) ExpectedSlippagePct() float64 {
+	best, size := 0.0, 0.0
+	for _, sl := range x.Slices {
+		if sl.Error == "" {
+			best += sl.BestPrice * sl.Size
+			size += sl.Size
+		}
+	}
+	if size == 0 || best == 0 {
+		return 0
+	}
+	best /= size
+	return math.Abs(x.ExpectedPrice-best) / best
+}
+
+// ExecuteGuarded sends a market order after walking the order book for it. Above the
+// slippage limit the order is rejected, downsized to what the book takes within the limit,
+// or split into slices sent on a refreshed book. Without a book the order goes out unguarded.
+//
+// The execution is returned whenever something was sent, together with an error if a slice
+// failed. A rejection returns an ErrSlippageRejected error and the execution.
+func (e *TradeExecutor) ExecuteGuarded(ctx context.Context, req ExecutionRequest) (*Execution, error) {
+	exec := &Execution{Requested: req.Size, Action: "full"}
+	remaining := req.Size
+
+	for i := 0; i < maxOrderSlices && remaining > fillSizeEpsilon; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return exec, ctx.Err()
+			case <-time.After(e.sliceDelay):
+			}
+		}
+
+		// 1. Walk the current book
+		ob, err := e.exchange.GetOrderBook(ctx, req.Symbol, "linear")
+		if err != nil || ob == nil || len(bookSide(ob, req.Side)) == 0 {
+			if i > 0 {
+				exec.Reason = "no order book for the next slice"
+				break
+			}
+			log.Printf("SLIPPAGE: No order book for %s, sending %f unguarded: %v", req.Symbol, remaining, err)
+			return exec, e.sendSlice(ctx, req, exec, ExecutionSlice{Size: remaining})
+		}
+		size := remaining
+		walk := WalkOrderBook(ob, req.Side, size)
+
+		// 2. Cut it down to the limit
+		if req.MaxSlippagePct > 0 && (walk.Fillable < size || walk.SlippagePct > req.MaxSlippagePct) {
+			reason := fmt.Sprintf("expected slippage %.3f%% > %.3f%% for %f", walk.SlippagePct*100, req.MaxSlippagePct*100, size)
+			if walk.Fillable < size {
+				reason = fmt.Sprintf("book too thin: %f of %f fillable", walk.Fillable, size)
+			}
+			if req.SlippageAction == domain.SlippageReject || req.SlippageAction == "" {
+				exec.Action, exec.Reason = "rejected", reason
+				return exec, fmt.Errorf("%s: %w", reason, ErrSlippageRejected)
+			}
+			size = roundDownToStep(MaxSizeWithinSlippage(ob, req.Side, size, req.MaxSlippagePct), req.QtyStep)
+			if size <= 0 || size < req.MinQty {
+				if i > 0 {
+					exec.Reason = "slice under the minimum on the refreshed book: " + reason
+					break
+				}
+				exec.Action, exec.Reason = "rejected", reason+", nothing fits the limit"
+				return exec, fmt.Errorf("%s: %w", exec.Reason, ErrSlippageRejected)
+			}
+			walk = WalkOrderBook(ob, req.Side, size)
+			if exec.Reason == "" {
+				exec.Reason = reason
+			}
+			exec.Action = "downsized"
+			if req.SlippageAction == domain.SlippageSplit {
+				exec.Action = "split"
+			}
+		}
+
+		// 3. Send
+		slice := ExecutionSlice{Size: size, BestPrice: walk.BestPrice, ExpectedPrice: walk.AvgPrice, ExpectedSlippagePct: walk.SlippagePct}
+		if err := e.sendSlice(ctx, req, exec, slice); err != nil {
+			return exec, err
+		}
+		remaining -= size
+		if exec.Action != "split" {
+			break
+		}
+	}
+
+	if exec.Action == "split" && remaining > fillSizeEpsilon {
+		log.Printf("SLIPPAGE: Split %s %s stopped with %f of %f unfilled: %s", req.Side, req.Symbol, remaining, req.Size, exec.Reason)
+	}
+	return exec, nil
+}
+
+// sendSlice sends one market order and books it on the execution, with the fill price
+// derived from the position before and after.
+func (e *TradeExecutor) sendSlice(ctx context.Context, req ExecutionRequest, exec *Execution, slice ExecutionSlice) error {
+	before, beforeErr := e.exchange.GetPosition(ctx, req.Symbol)
+	if err := e.Execute(ctx, req.Symbol, req.Side, slice.Size, req.Leverage, req.MarginType, req.StopLoss); err != nil {
+		slice.Error = err.Error()
+		exec.Slices = append(exec.Slices, slice)
+		return err
+	}
+	if beforeErr == nil {
+		if after, err := e.exchange.GetPosition(ctx, req.Symbol); err == nil {
+			slice.FillPrice = fillPrice(before, after, req.Side)
+		}
+	}
+	exec.Slices = append(exec.Slices, slice)
+
+	// Size weighted averages over the sent slices
+	sent := exec.Filled + slice.Size
+	if slice.ExpectedPrice > 0 {
+		exec.ExpectedPrice = (exec.ExpectedPrice*exec.Filled + slice.ExpectedPrice*slice.Size) / sent
+	}
+	if slice.FillPrice > 0 && (exec.FillPrice > 0 || exec.Filled == 0) {
+		exec.FillPrice = (exec.FillPrice*exec.Filled + slice.FillPrice*slice.Size) / sent
+	} else {
+		exec.FillPrice = 0 // One unknown slice makes the average unknown
+	}
+	exec.Filled = sent
+	return nil
+}
+
+// fillPrice derives the average price of an order that added to the position of side,
+// 0 when the positions do not allow it (flipped side, no size change).
+func fillPrice(before, after *domain.Position, side domain.Side) float64 {
+	if after == nil || after.Size == 0 || after.Side != side {
+		return 0
+	}
+	prevSize, prevEntry := 0.0, 0.0
+	if before != nil && before.Size > 0 {
+		if before.Side != side {
+			return 0
+		}
+		prevSize, prevEntry = before.Size, before.EntryPrice
+	}
+	added := after.Size - prevSize
+	if added <= fillSizeEpsilon {
+		return 0
+	}
+	return (after.EntryPrice*after.Size - prevEntry*prevSize) / added
+}
@@ -2,6 +2,7 @@ package usecase_test
 
 import (
 	"context"
+	"math"
 	"testing"
 
 	"github.com/vitos/crypto_trade_level/internal/domain"
@@ -31,3 +32,35 @@ func TestTradeExecutor_Execute(t *testing.T) {
 		t.Error("Expected MarketSell to be called")
 	}
 }
+
+func TestWalkOrderBook(t *testing.T) {
+	ob := &domain.OrderBook{
+		Bids: []domain.OrderBookEntry{{Price: 100, Size: 1}, {Price: 99, Size: 1}},
+		Asks: []domain.OrderBookEntry{{Price: 101, Size: 1}, {Price: 102, Size: 1}},
+	}
+
+	// A buy of 1.5 takes all of 101 and half of 102
+	walk := usecase.WalkOrderBook(ob, domain.SideLong, 1.5)
+	if math.Abs(walk.AvgPrice-(101+0.5*102)/1.5) > 1e-9 || walk.Fillable != 1.5 || walk.BestPrice != 101 {
+		t.Errorf("Unexpected buy walk: %+v", walk)
+	}
+
+	// A sell over the whole book only fills what is there
+	walk = usecase.WalkOrderBook(ob, domain.SideShort, 3)
+	if walk.Fillable != 2 || walk.AvgPrice != 99.5 || math.Abs(walk.SlippagePct-0.005) > 1e-9 {
+		t.Errorf("Unexpected sell walk: %+v", walk)
+	}
+
+	// 0.5% on a sell averages at 99.5, which is the whole book
+	if got := usecase.MaxSizeWithinSlippage(ob, domain.SideShort, 3, 0.005); math.Abs(got-2) > 1e-9 {
+		t.Errorf("Expected 2 within 0.5%%, got %f", got)
+	}
+	// 0.25% averages at 99.75: 1 @ 100 + x @ 99 with x = 1/3
+	if got := usecase.MaxSizeWithinSlippage(ob, domain.SideShort, 3, 0.0025); math.Abs(got-4.0/3) > 1e-9 {
+		t.Errorf("Expected 1.333 within 0.25%%, got %f", got)
+	}
+	// Capped at the requested size
+	if got := usecase.MaxSizeWithinSlippage(ob, domain.SideLong, 0.5, 0.001); got != 0.5 {
+		t.Errorf("Expected the requested 0.5, got %f", got)
+	}
+}
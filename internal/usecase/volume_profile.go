@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// VolumeProfileWindow is the candle range a volume profile is built over.
+type VolumeProfileWindow struct {
+	Name     string `json:"name"`
+	Interval string `json:"interval"` // Candle interval
+	Candles  int    `json:"candles"`
+}
+
+// VolumeProfileWindows are the preset windows, "live" profiles the in-memory trades instead.
+var VolumeProfileWindows = []VolumeProfileWindow{
+	{Name: "4h", Interval: "5", Candles: 48},
+	{Name: "1d", Interval: "15", Candles: 96},
+	{Name: "1w", Interval: "60", Candles: 168},
+	{Name: "1M", Interval: "240", Candles: 180},
+}
+
+const (
+	DefaultVolumeProfileWindow = "1d"
+	LiveVolumeProfileWindow    = "live"
+	DefaultVolumeProfileBins   = 50
+
+	valueAreaShare      = 0.70 // Share of the volume inside the value area
+	volumeProfileTTL    = time.Minute
+	maxVolumeProfileBin = 2000
+)
+
+// FindVolumeProfileWindow looks a preset window up by name.
+func FindVolumeProfileWindow(name string) (VolumeProfileWindow, bool) {
+	for _, w := range VolumeProfileWindows {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return VolumeProfileWindow{}, false
+}
+
+// VolumeBin is the traded volume of one price bin, Price is the bin center.
+type VolumeBin struct {
+	Price  float64 `json:"price"`
+	Volume float64 `json:"volume"`
+}
+
+// VolumeProfile is the traded volume at price over a window.
+type VolumeProfile struct {
+	Symbol        string      `json:"symbol"`
+	Window        string      `json:"window"`
+	Source        string      `json:"source"` // "candles" or "trades"
+	From          int64       `json:"from"`   // Unix ms
+	To            int64       `json:"to"`
+	BinSize       float64     `json:"bin_size"`
+	TotalVolume   float64     `json:"total_volume"`
+	POC           float64     `json:"poc"` // Point of control, the busiest price
+	ValueAreaHigh float64     `json:"value_area_high"`
+	ValueAreaLow  float64     `json:"value_area_low"`
+	HVN           []float64   `json:"hvn"` // High volume nodes
+	LVN           []float64   `json:"lvn"` // Low volume nodes
+	Bins          []VolumeBin `json:"bins,omitempty"`
+
+	low       float64
+	pocVolume float64
+}
+
+// BuildVolumeProfile spreads every candle's volume evenly over its high-low range.
+// Candles are expected oldest first.
+func BuildVolumeProfile(candles []domain.Candle, binSize float64) *VolumeProfile {
+	p := &VolumeProfile{Source: "candles"}
+	if len(candles) == 0 {
+		return p
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, c := range candles {
+		low = math.Min(low, c.Low)
+		high = math.Max(high, c.High)
+	}
+	p.From, p.To = candles[0].Time, candles[len(candles)-1].Time
+	if !p.allocate(low, high, binSize) {
+		return p
+	}
+	for _, c := range candles {
+		from, to := p.bin(c.Low), p.bin(c.High)
+		per := c.Volume / float64(to-from+1)
+		for b := from; b <= to; b++ {
+			p.Bins[b].Volume += per
+		}
+	}
+	p.finish()
+	return p
+}
+
+// buildTradeProfile adds every trade's size at its price.
+func buildTradeProfile(trades []Trade, binSize float64) *VolumeProfile {
+	p := &VolumeProfile{Source: "trades"}
+	if len(trades) == 0 {
+		return p
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, t := range trades {
+		low = math.Min(low, t.Price)
+		high = math.Max(high, t.Price)
+	}
+	p.From, p.To = trades[0].Time.UnixMilli(), trades[len(trades)-1].Time.UnixMilli()
+	if !p.allocate(low, high, binSize) {
+		return p
+	}
+	for _, t := range trades {
+		p.Bins[p.bin(t.Price)].Volume += t.Size
+	}
+	p.finish()
+	return p
+}
+
+func (p *VolumeProfile) allocate(low, high, binSize float64) bool {
+	if binSize <= 0 || high < low || math.IsInf(low, 0) {
+		return false
+	}
+	if (high-low)/binSize > maxVolumeProfileBin {
+		binSize = (high - low) / maxVolumeProfileBin
+	}
+	p.low = low
+	p.BinSize = binSize
+	p.Bins = make([]VolumeBin, int((high-low)/binSize)+1)
+	for i := range p.Bins {
+		p.Bins[i].Price = low + (float64(i)+0.5)*binSize
+	}
+	return true
+}
+
+func (p *VolumeProfile) bin(price float64) int {
+	b := int((price - p.low) / p.BinSize)
+	if b < 0 {
+		return 0
+	}
+	if b >= len(p.Bins) {
+		return len(p.Bins) - 1
+	}
+	return b
+}
+
+// finish derives POC, value area and the volume nodes from the bins.
+func (p *VolumeProfile) finish() {
+	poc := 0
+	for i, b := range p.Bins {
+		p.TotalVolume += b.Volume
+		if b.Volume > p.Bins[poc].Volume {
+			poc = i
+		}
+	}
+	if p.TotalVolume == 0 {
+		return
+	}
+	p.POC = p.Bins[poc].Price
+	p.pocVolume = p.Bins[poc].Volume
+
+	// 1. Value area: grow from the POC towards the busier neighbour until it holds 70%
+	lo, hi := poc, poc
+	covered := p.Bins[poc].Volume
+	for covered < valueAreaShare*p.TotalVolume && (lo > 0 || hi < len(p.Bins)-1) {
+		below, above := -1.0, -1.0
+		if lo > 0 {
+			below = p.Bins[lo-1].Volume
+		}
+		if hi < len(p.Bins)-1 {
+			above = p.Bins[hi+1].Volume
+		}
+		if above >= below {
+			hi++
+			covered += above
+		} else {
+			lo--
+			covered += below
+		}
+	}
+	p.ValueAreaLow = p.Bins[lo].Price - p.BinSize/2
+	p.ValueAreaHigh = p.Bins[hi].Price + p.BinSize/2
+
+	// 2. Nodes: local peaks well above and local troughs well below the mean
+	mean := p.TotalVolume / float64(len(p.Bins))
+	for i, b := range p.Bins {
+		prev, next := math.Inf(-1), math.Inf(-1)
+		if i > 0 {
+			prev = p.Bins[i-1].Volume
+		}
+		if i < len(p.Bins)-1 {
+			next = p.Bins[i+1].Volume
+		}
+		if b.Volume >= 1.5*mean && b.Volume >= prev && b.Volume > next {
+			p.HVN = append(p.HVN, b.Price)
+		}
+		if i > 0 && i < len(p.Bins)-1 && b.Volume < 0.5*mean && b.Volume <= prev && b.Volume <= next && (b.Volume < prev || b.Volume < next) {
+			p.LVN = append(p.LVN, b.Price)
+		}
+	}
+}
+
+// Share returns the volume at price relative to the point of control (0..1).
+func (p *VolumeProfile) Share(price float64) float64 {
+	if p.pocVolume == 0 || len(p.Bins) == 0 {
+		return 0
+	}
+	return p.Bins[p.bin(price)].Volume / p.pocVolume
+}
+
+// Summary returns the profile without its bins.
+func (p *VolumeProfile) Summary() *VolumeProfile {
+	s := *p
+	s.Bins = nil
+	return &s
+}
+
+// profileBinSize splits a price range into bins, a flat range gets one narrow bin.
+func profileBinSize(low, high float64, bins int) float64 {
+	if high > low {
+		return (high - low) / float64(bins)
+	}
+	return high * 0.0001
+}
+
+type cachedVolumeProfile struct {
+	profile *VolumeProfile
+	expiry  time.Time
+}
+
+// GetVolumeProfile builds (or returns the cached) candle volume profile of a window.
+func (s *MarketService) GetVolumeProfile(ctx context.Context, symbol string, window VolumeProfileWindow, bins int) (*VolumeProfile, error) {
+	if window.Candles <= 0 || window.Interval == "" {
+		return nil, fmt.Errorf("invalid volume profile window %+v", window)
+	}
+	if bins <= 0 {
+		bins = DefaultVolumeProfileBins
+	}
+	key := fmt.Sprintf("%s/%s/%d/%d", symbol, window.Interval, window.Candles, bins)
+
+	s.vpMu.Lock()
+	cached, ok := s.vpCache[key]
+	s.vpMu.Unlock()
+	if ok && s.timeNow().Before(cached.expiry) {
+		return cached.profile, nil
+	}
+
+	candles, err := s.exchange.GetCandles(ctx, symbol, window.Interval, window.Candles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch candles: %w", err)
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles for %s", symbol)
+	}
+	candles = append([]domain.Candle(nil), candles...)
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Time < candles[j].Time })
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, c := range candles {
+		low = math.Min(low, c.Low)
+		high = math.Max(high, c.High)
+	}
+	p := BuildVolumeProfile(candles, profileBinSize(low, high, bins))
+	p.Symbol = symbol
+	p.Window = window.Name
+
+	s.vpMu.Lock()
+	s.vpCache[key] = cachedVolumeProfile{profile: p, expiry: s.timeNow().Add(volumeProfileTTL)}
+	s.vpMu.Unlock()
+	return p, nil
+}
+
+// GetLiveVolumeProfile profiles the trades of the last 60s.
+func (s *MarketService) GetLiveVolumeProfile(symbol string, bins int) *VolumeProfile {
+	if bins <= 0 {
+		bins = DefaultVolumeProfileBins
+	}
+	st := s.symbolStats(symbol)
+	st.mu.Lock()
+	trades := st.recentTrades(s.timeNow())
+	st.mu.Unlock()
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, t := range trades {
+		low = math.Min(low, t.Price)
+		high = math.Max(high, t.Price)
+	}
+	p := buildTradeProfile(trades, profileBinSize(low, high, bins))
+	p.Symbol = symbol
+	p.Window = LiveVolumeProfileWindow
+	return p
+}
+
+// defaultVolumeProfile returns the cached default window profile and refreshes it in the
+// background when stale, so market stats never wait for candles.
+func (s *MarketService) defaultVolumeProfile(symbol string) *VolumeProfile {
+	window, _ := FindVolumeProfileWindow(DefaultVolumeProfileWindow)
+	key := fmt.Sprintf("%s/%s/%d/%d", symbol, window.Interval, window.Candles, DefaultVolumeProfileBins)
+	s.vpMu.Lock()
+	defer s.vpMu.Unlock()
+	cached, ok := s.vpCache[key]
+	now := s.timeNow()
+	if (!ok || !now.Before(cached.expiry)) && !now.Before(s.vpNextRefresh[symbol]) {
+		s.vpNextRefresh[symbol] = now.Add(volumeProfileTTL) // Also throttles retries after failures
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if _, err := s.GetVolumeProfile(ctx, symbol, window, DefaultVolumeProfileBins); err != nil {
+				log.Printf("MarketService: Volume profile of %s failed: %v", symbol, err)
+			}
+		}()
+	}
+	return cached.profile
+}
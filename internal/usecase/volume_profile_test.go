@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// profileCandles puts most volume at 100-101, a second node at 104-105 and a thin
+// range candle across 100-110.
+func profileCandles() []domain.Candle {
+	var candles []domain.Candle
+	add := func(low, high, volume float64) {
+		candles = append(candles, domain.Candle{Time: int64(len(candles)) * 60000, Open: low, Close: high, Low: low, High: high, Volume: volume})
+	}
+	for i := 0; i < 10; i++ {
+		add(100, 101, 100)
+	}
+	for i := 0; i < 10; i++ {
+		add(104, 105, 50)
+	}
+	add(100, 110, 20)
+	return candles
+}
+
+func TestBuildVolumeProfile(t *testing.T) {
+	vp := BuildVolumeProfile(profileCandles(), 0.5)
+
+	if vp.POC < 100 || vp.POC > 101 {
+		t.Errorf("Expected POC in 100-101, got %f", vp.POC)
+	}
+	if vp.ValueAreaLow > vp.POC || vp.ValueAreaHigh < vp.POC || vp.ValueAreaLow < 99.9 || vp.ValueAreaHigh > 105.6 {
+		t.Errorf("Expected value area around the POC within 100-105.5, got %f-%f", vp.ValueAreaLow, vp.ValueAreaHigh)
+	}
+	if vp.TotalVolume < 1519.99 || vp.TotalVolume > 1520.01 {
+		t.Errorf("Expected total volume 1520, got %f", vp.TotalVolume)
+	}
+
+	hasNode := func(nodes []float64, low, high float64) bool {
+		for _, n := range nodes {
+			if n >= low && n <= high {
+				return true
+			}
+		}
+		return false
+	}
+	// A candle's high falls into the bin above it, so nodes may sit half a bin higher
+	if !hasNode(vp.HVN, 100, 101.5) || !hasNode(vp.HVN, 104, 105.5) {
+		t.Errorf("Expected high volume nodes at 100-101 and 104-105, got %v", vp.HVN)
+	}
+	if !hasNode(vp.LVN, 101.5, 104) {
+		t.Errorf("Expected a low volume node between the two nodes, got %v", vp.LVN)
+	}
+	if share := vp.Share(104.5); share <= 0 || share >= 1 {
+		t.Errorf("Expected second node below the POC volume, got share %f", share)
+	}
+}
+
+func TestMarketService_GetVolumeProfile_Cached(t *testing.T) {
+	mockEx := &MockExchange{Candles: profileCandles()}
+	service := NewMarketService(mockEx, nil)
+	currentTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.timeNow = func() time.Time { return currentTime }
+
+	ctx := context.Background()
+	window, _ := FindVolumeProfileWindow("1d")
+	vp, err := service.GetVolumeProfile(ctx, "BTCUSDT", window, 20)
+	if err != nil {
+		t.Fatalf("GetVolumeProfile failed: %v", err)
+	}
+	if vp.Window != "1d" || vp.POC < 100 || vp.POC > 101 {
+		t.Fatalf("Unexpected profile %+v", vp.Summary())
+	}
+
+	// Within the TTL the cached profile is returned
+	mockEx.Candles = []domain.Candle{{Time: 1, Low: 200, High: 201, Volume: 1}}
+	if again, _ := service.GetVolumeProfile(ctx, "BTCUSDT", window, 20); again != vp {
+		t.Errorf("Expected cached profile")
+	}
+
+	currentTime = currentTime.Add(2 * time.Minute)
+	if fresh, _ := service.GetVolumeProfile(ctx, "BTCUSDT", window, 20); fresh.POC < 200 {
+		t.Errorf("Expected rebuilt profile after the TTL, got POC %f", fresh.POC)
+	}
+}
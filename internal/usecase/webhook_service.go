@@ -0,0 +1,354 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// Webhook actions.
+const (
+	WebhookCreateLevel   = "create_level"
+	WebhookDeleteLevel   = "delete_level"
+	WebhookClosePosition = "close_position"
+	WebhookPauseSymbol   = "pause_symbol"
+	WebhookResumeSymbol  = "resume_symbol"
+	WebhookStartBot      = "start_bot"
+	WebhookStopBot       = "stop_bot"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body, optionally
+// prefixed with "sha256=".
+const WebhookSignatureHeader = "X-Signature"
+
+var (
+	ErrWebhookDisabled  = errors.New("webhook is disabled, no secret configured")
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+	ErrWebhookPayload   = errors.New("invalid webhook payload")
+)
+
+// WebhookPayload is the JSON body sent by charting tool alerts.
+type WebhookPayload struct {
+	AlertID  string `json:"alert_id"` // Unique per alert, repeated deliveries are ignored
+	Action   string `json:"action"`
+	Exchange string `json:"exchange"`
+	Symbol   string `json:"symbol"`
+
+	Level        *LevelRecord `json:"level,omitempty"`         // create_level, exchange and symbol default to the payload's
+	Key          string       `json:"key,omitempty"`           // delete_level: external key or level ID
+	PauseMinutes int          `json:"pause_minutes,omitempty"` // pause_symbol: 0 pauses until resumed
+	Bot          *WebhookBot  `json:"bot,omitempty"`           // start_bot / stop_bot
+}
+
+// WebhookBot selects a bot and, for start_bot, its config.
+type WebhookBot struct {
+	Kind           string  `json:"kind"` // "speed" or "funding"
+	PositionSize   float64 `json:"position_size"`
+	Leverage       int     `json:"leverage"`
+	MarginType     string  `json:"margin_type"`
+	CooldownMs     int64   `json:"cooldown_ms"`      // Speed bot
+	MinFundingRate float64 `json:"min_funding_rate"` // Funding bot, e.g. 0.0001 for 0.01%
+}
+
+// WebhookResult is the outcome of a webhook request. Duplicates report the stored outcome
+// of the first delivery.
+type WebhookResult struct {
+	AlertID   string `json:"alert_id"`
+	Action    string `json:"action"`
+	Status    string `json:"status"`
+	Result    string `json:"result"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+// WebhookService authenticates webhook alerts and dispatches them to the level and bot services.
+type WebhookService struct {
+	secret  []byte
+	repo    domain.WebhookRepository
+	levels  *LevelService
+	speed   *SpeedBotService   // Optional
+	funding *FundingBotService // Optional
+}
+
+func NewWebhookService(secret string, repo domain.WebhookRepository, levels *LevelService, speed *SpeedBotService, funding *FundingBotService) *WebhookService {
+	return &WebhookService{
+		secret:  []byte(secret),
+		repo:    repo,
+		levels:  levels,
+		speed:   speed,
+		funding: funding,
+	}
+}
+
+// Sign returns the signature of a body, for senders and tests.
+func (w *WebhookService) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, w.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a body in constant time.
+func (w *WebhookService) Verify(body []byte, signature string) error {
+	if len(w.secret) == 0 {
+		return ErrWebhookDisabled
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil || len(got) == 0 {
+		return ErrWebhookSignature
+	}
+	want, _ := hex.DecodeString(w.Sign(body))
+	if !hmac.Equal(got, want) {
+		return ErrWebhookSignature
+	}
+	return nil
+}
+
+// Handle verifies, deduplicates and executes a webhook request. A failed alert can be
+// delivered again under the same ID, a successful or in-flight one cannot.
+func (w *WebhookService) Handle(ctx context.Context, body []byte, signature string) (*WebhookResult, error) {
+	// 1. Authenticate
+	if err := w.Verify(body, signature); err != nil {
+		return nil, err
+	}
+
+	// 2. Decode
+	var p WebhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookPayload, err)
+	}
+	if p.AlertID == "" || p.Action == "" {
+		return nil, fmt.Errorf("%w: alert_id and action are required", ErrWebhookPayload)
+	}
+
+	// 3. Deduplicate by alert ID
+	symbol := p.Symbol
+	if symbol == "" && p.Level != nil {
+		symbol = p.Level.Symbol
+	}
+	claimed, err := w.repo.ClaimWebhookAlert(ctx, &domain.WebhookAlert{
+		AlertID:    p.AlertID,
+		Action:     p.Action,
+		Symbol:     symbol,
+		Status:     domain.WebhookAlertPending,
+		ReceivedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record webhook alert: %w", err)
+	}
+	if !claimed {
+		prev, err := w.repo.GetWebhookAlert(ctx, p.AlertID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load webhook alert: %w", err)
+		}
+		log.Printf("AUDIT: Webhook alert %s (%s) is a duplicate, ignored", p.AlertID, p.Action)
+		return &WebhookResult{AlertID: prev.AlertID, Action: prev.Action, Status: prev.Status, Result: prev.Result, Duplicate: true}, nil
+	}
+
+	// 4. Execute and record the outcome
+	res := &WebhookResult{AlertID: p.AlertID, Action: p.Action, Status: domain.WebhookAlertDone}
+	outcome, dispatchErr := w.dispatch(ctx, &p)
+	res.Result = outcome
+	if dispatchErr != nil {
+		res.Status = domain.WebhookAlertFailed
+		res.Result = dispatchErr.Error()
+	}
+	if err := w.repo.FinishWebhookAlert(ctx, p.AlertID, res.Status, res.Result); err != nil {
+		log.Printf("AUDIT: Failed to record outcome of webhook alert %s: %v", p.AlertID, err)
+	}
+	log.Printf("AUDIT: Webhook alert %s (%s %s): %s %s", p.AlertID, p.Action, symbol, res.Status, res.Result)
+	return res, dispatchErr
+}
+
+// dispatch runs the alert's action and describes what it did.
+func (w *WebhookService) dispatch(ctx context.Context, p *WebhookPayload) (string, error) {
+	switch p.Action {
+	case WebhookCreateLevel:
+		return w.createLevel(ctx, p)
+	case WebhookDeleteLevel:
+		return w.deleteLevel(ctx, p)
+	case WebhookClosePosition:
+		if p.Symbol == "" {
+			return "", fmt.Errorf("%w: symbol is required", ErrWebhookPayload)
+		}
+		if err := w.levels.ClosePosition(ctx, p.Symbol); err != nil {
+			return "", fmt.Errorf("failed to close position: %w", err)
+		}
+		return "closed position on " + p.Symbol, nil
+	case WebhookPauseSymbol:
+		if p.Symbol == "" || p.PauseMinutes < 0 {
+			return "", fmt.Errorf("%w: symbol and a non-negative pause_minutes are required", ErrWebhookPayload)
+		}
+		w.levels.PauseSymbol(p.Symbol, time.Duration(p.PauseMinutes)*time.Minute)
+		return "paused entries on " + p.Symbol, nil
+	case WebhookResumeSymbol:
+		if p.Symbol == "" {
+			return "", fmt.Errorf("%w: symbol is required", ErrWebhookPayload)
+		}
+		w.levels.ResumeSymbol(p.Symbol)
+		return "resumed entries on " + p.Symbol, nil
+	case WebhookStartBot, WebhookStopBot:
+		return w.controlBot(ctx, p)
+	default:
+		return "", fmt.Errorf("%w: unknown action %q", ErrWebhookPayload, p.Action)
+	}
+}
+
+// createLevel validates the level like an import row. Tiers in the payload are only
+// written when they differ from the symbol's current tiers.
+func (w *WebhookService) createLevel(ctx context.Context, p *WebhookPayload) (string, error) {
+	if p.Level == nil {
+		return "", fmt.Errorf("%w: level is required", ErrWebhookPayload)
+	}
+	rec := *p.Level
+	if rec.Exchange == "" {
+		rec.Exchange = p.Exchange
+	}
+	if rec.Symbol == "" {
+		rec.Symbol = p.Symbol
+	}
+
+	// 1. Validate
+	now := time.Now()
+	level := &domain.Level{
+		ID:        fmt.Sprintf("%d", now.UnixNano()),
+		Source:    "webhook",
+		CreatedAt: now,
+	}
+	if err := rec.ApplyTo(level); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrWebhookPayload, err)
+	}
+	if level.ExternalKey != "" {
+		if existing := w.findLevel(ctx, level.ExternalKey); existing != nil {
+			return "", fmt.Errorf("%w: level with key %s already exists (%s)", ErrWebhookPayload, level.ExternalKey, existing.ID)
+		}
+	}
+
+	// 2. Tiers
+	if tiers := rec.Tiers(); tiers != nil {
+		current, err := w.levels.GetTiers(ctx, tiers.Exchange, tiers.Symbol)
+		if err != nil || current == nil || !sameTiers(current, tiers) {
+			if err := w.levels.UpdateTiers(ctx, tiers); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// 3. Save
+	if err := w.levels.CreateLevel(ctx, level); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("created level %s on %s at %g", level.ID, level.Symbol, level.LevelPrice), nil
+}
+
+func (w *WebhookService) deleteLevel(ctx context.Context, p *WebhookPayload) (string, error) {
+	if p.Key == "" {
+		return "", fmt.Errorf("%w: key is required", ErrWebhookPayload)
+	}
+	level := w.findLevel(ctx, p.Key)
+	if level == nil {
+		return "", fmt.Errorf("%w: no level with key %s", ErrWebhookPayload, p.Key)
+	}
+	if err := w.levels.DeleteLevel(ctx, level); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("deleted level %s on %s", level.ID, level.Symbol), nil
+}
+
+// findLevel looks a level up by external key, then by ID.
+func (w *WebhookService) findLevel(ctx context.Context, key string) *domain.Level {
+	levels, err := w.levels.levelRepo.ListLevels(ctx)
+	if err != nil {
+		return nil
+	}
+	var byID *domain.Level
+	for _, l := range levels {
+		if l.ExternalKey == key {
+			return l
+		}
+		if l.ID == key {
+			byID = l
+		}
+	}
+	return byID
+}
+
+func (w *WebhookService) controlBot(ctx context.Context, p *WebhookPayload) (string, error) {
+	if p.Bot == nil || p.Symbol == "" {
+		return "", fmt.Errorf("%w: bot and symbol are required", ErrWebhookPayload)
+	}
+	bot := p.Bot
+	start := p.Action == WebhookStartBot
+	if start {
+		if bot.PositionSize <= 0 || bot.Leverage <= 0 {
+			return "", fmt.Errorf("%w: position_size and leverage must be greater than 0", ErrWebhookPayload)
+		}
+		if bot.MarginType != "isolated" && bot.MarginType != "cross" {
+			return "", fmt.Errorf("%w: margin_type must be isolated or cross", ErrWebhookPayload)
+		}
+	}
+
+	switch bot.Kind {
+	case "speed":
+		if w.speed == nil {
+			return "", fmt.Errorf("speed bot is not available")
+		}
+		if !start {
+			if err := w.speed.StopBot(p.Symbol); err != nil {
+				return "", fmt.Errorf("failed to stop speed bot: %w", err)
+			}
+			return "stopped speed bot on " + p.Symbol, nil
+		}
+		err := w.speed.StartBot(ctx, SpeedBotConfig{
+			Symbol:       p.Symbol,
+			PositionSize: bot.PositionSize,
+			Leverage:     bot.Leverage,
+			MarginType:   bot.MarginType,
+			Cooldown:     time.Duration(bot.CooldownMs) * time.Millisecond,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to start speed bot: %w", err)
+		}
+		return "started speed bot on " + p.Symbol, nil
+	case "funding":
+		if w.funding == nil {
+			return "", fmt.Errorf("funding bot is not available")
+		}
+		if !start {
+			if err := w.funding.StopBot(p.Symbol); err != nil {
+				return "", fmt.Errorf("failed to stop funding bot: %w", err)
+			}
+			return "stopped funding bot on " + p.Symbol, nil
+		}
+		minRate := bot.MinFundingRate
+		if minRate == 0 {
+			minRate = 0.0001 // Same default as the funding bot API
+		}
+		err := w.funding.StartBot(ctx, FundingBotConfig{
+			Symbol:             p.Symbol,
+			PositionSize:       bot.PositionSize,
+			Leverage:           bot.Leverage,
+			MarginType:         bot.MarginType,
+			CountdownThreshold: 60 * time.Second,
+			MinFundingRate:     minRate,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to start funding bot: %w", err)
+		}
+		return "started funding bot on " + p.Symbol, nil
+	default:
+		return "", fmt.Errorf("%w: bot kind must be speed or funding", ErrWebhookPayload)
+	}
+}
+
+// ListAlerts returns the most recent webhook alerts.
+func (w *WebhookService) ListAlerts(ctx context.Context, limit int) ([]*domain.WebhookAlert, error) {
+	return w.repo.ListWebhookAlerts(ctx, limit)
+}
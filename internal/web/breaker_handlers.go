@@ -0,0 +1,71 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+// Circuit Breaker Handlers
+
+func (s *Server) circuitBreakers() *usecase.CircuitBreakers {
+	if s.service == nil {
+		return nil
+	}
+	return s.service.CircuitBreakers()
+}
+
+func (s *Server) breakersEnabled(w http.ResponseWriter) (*usecase.CircuitBreakers, bool) {
+	breakers := s.circuitBreakers()
+	if breakers == nil {
+		http.Error(w, "Circuit breakers are not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return breakers, true
+}
+
+func (s *Server) handleListBreakers(w http.ResponseWriter, r *http.Request) {
+	breakers, ok := s.breakersEnabled(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breakers.Status())
+}
+
+// handleResetBreakers serves POST /api/breakers/reset with {"kind": "...", "symbol": "..."},
+// an empty body resets every breaker.
+func (s *Server) handleResetBreakers(w http.ResponseWriter, r *http.Request) {
+	breakers, ok := s.breakersEnabled(w)
+	if !ok {
+		return
+	}
+	var req struct {
+		Kind   string `json:"kind"`
+		Symbol string `json:"symbol"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	breakers.Reset(req.Kind, req.Symbol)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breakers.Status())
+}
+
+// handleResetBreakerForm serves POST /breakers/reset from the risk panel.
+func (s *Server) handleResetBreakerForm(w http.ResponseWriter, r *http.Request) {
+	breakers, ok := s.breakersEnabled(w)
+	if !ok || !s.riskEnabled(w) {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	breakers.Reset(r.FormValue("kind"), r.FormValue("symbol"))
+	s.renderRiskPanel(w, r, "")
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"go.uber.org/zap"
+)
+
+// Decision Journal Handlers
+
+// parseDecisionFilter reads level_id, symbol, kind, outcome, from, to (RFC3339) and limit.
+func parseDecisionFilter(q url.Values) (domain.DecisionFilter, error) {
+	filter := domain.DecisionFilter{
+		LevelID: q.Get("level_id"),
+		Symbol:  q.Get("symbol"),
+		Kind:    q.Get("kind"),
+		Outcome: q.Get("outcome"),
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+		filter.From = t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+		filter.To = t
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return filter, fmt.Errorf("invalid limit: %s", v)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// handleListDecisions serves GET /api/decisions?level_id=&symbol=&kind=&outcome=&from=&to=&limit=.
+func (s *Server) handleListDecisions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDecisionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	decisions, err := s.service.ListDecisions(r.Context(), filter)
+	if err != nil {
+		s.logger.Error("Failed to list decisions", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if decisions == nil {
+		decisions = []*domain.Decision{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decisions)
+}
+
+// handleDecisionTimeline renders the journal of one level, newest first.
+func (s *Server) handleDecisionTimeline(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	filter, err := parseDecisionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.LevelID = id
+	if filter.Limit == 0 {
+		filter.Limit = 50
+	}
+	decisions, err := s.service.ListDecisions(r.Context(), filter)
+	if err != nil {
+		s.logger.Error("Failed to list decisions", zap.String("id", id), zap.Error(err))
+		http.Error(w, "Failed to list decisions", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"LevelID":   id,
+		"Kind":      filter.Kind,
+		"Decisions": decisions,
+	}
+	if err := templates.ExecuteTemplate(w, "decision_timeline", data); err != nil {
+		s.logger.Error("Template error", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Level Discovery Handlers
+
+type levelProposalsView struct {
+	Symbol    string
+	Proposals []*usecase.LevelProposal
+	Error     string
+}
+
+func (s *Server) renderProposals(w http.ResponseWriter, symbol, errMsg string) {
+	view := levelProposalsView{Symbol: symbol, Proposals: s.discoveryService.Proposals(symbol), Error: errMsg}
+	if err := templates.ExecuteTemplate(w, "level_proposals", view); err != nil {
+		s.logger.Error("Template error", zap.Error(err))
+	}
+}
+
+func (s *Server) discoveryEnabled(w http.ResponseWriter) bool {
+	if s.discoveryService == nil {
+		http.Error(w, "Level discovery is not enabled", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// handleDiscoveryScan serves POST /discovery/scan (form: symbol, exchange).
+func (s *Server) handleDiscoveryScan(w http.ResponseWriter, r *http.Request) {
+	if !s.discoveryEnabled(w) {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(r.FormValue("symbol")))
+	exchange := r.FormValue("exchange")
+	if exchange == "" {
+		exchange = "bybit"
+	}
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	errMsg := ""
+	if _, err := s.discoveryService.Discover(r.Context(), exchange, symbol); err != nil {
+		s.logger.Error("Level discovery failed", zap.String("symbol", symbol), zap.Error(err))
+		errMsg = err.Error()
+	}
+	s.renderProposals(w, symbol, errMsg)
+}
+
+func (s *Server) handleListProposals(w http.ResponseWriter, r *http.Request) {
+	if !s.discoveryEnabled(w) {
+		return
+	}
+	s.renderProposals(w, r.URL.Query().Get("symbol"), "")
+}
+
+// handleAcceptProposal serves POST /discovery/proposals/{id}/accept. Form fields override the
+// level settings like the level edit form.
+func (s *Server) handleAcceptProposal(w http.ResponseWriter, r *http.Request) {
+	if !s.discoveryEnabled(w) {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	patch, err := parseLevelPatchForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	errMsg := ""
+	if _, err := s.discoveryService.AcceptProposal(r.Context(), r.PathValue("id"), patch); err != nil {
+		if errors.Is(err, usecase.ErrProposalNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		errMsg = err.Error()
+	}
+	s.renderProposals(w, r.FormValue("symbol"), errMsg)
+}
+
+func (s *Server) handleRejectProposal(w http.ResponseWriter, r *http.Request) {
+	if !s.discoveryEnabled(w) {
+		return
+	}
+	if err := s.discoveryService.RejectProposal(r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.renderProposals(w, r.URL.Query().Get("symbol"), "")
+}
+
+func (s *Server) handleListProposalsAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.discoveryEnabled(w) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.discoveryService.Proposals(r.URL.Query().Get("symbol")))
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+// Parent Order Handlers
+
+func (s *Server) executionManager(w http.ResponseWriter) (*usecase.ExecutionManager, bool) {
+	if s.service == nil {
+		http.Error(w, "Level service is not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return s.service.ExecutionManager(), true
+}
+
+// parentOrdersView is the data of the parent orders panel.
+type parentOrdersView struct {
+	Orders []usecase.ParentOrder
+	Report usecase.ExecutionReport
+}
+
+func (s *Server) renderParentOrders(w http.ResponseWriter, manager *usecase.ExecutionManager) {
+	view := parentOrdersView{Orders: manager.List(), Report: manager.Report()}
+	if err := templates.ExecuteTemplate(w, "parent_orders", view); err != nil {
+		s.logger.Error("Template error", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// handleParentOrders serves GET /parent-orders, the progress panel of the dashboard.
+func (s *Server) handleParentOrders(w http.ResponseWriter, r *http.Request) {
+	manager, ok := s.executionManager(w)
+	if !ok {
+		return
+	}
+	s.renderParentOrders(w, manager)
+}
+
+// handleCancelParentOrderForm serves POST /parent-orders/{id}/cancel from the panel.
+func (s *Server) handleCancelParentOrderForm(w http.ResponseWriter, r *http.Request) {
+	manager, ok := s.executionManager(w)
+	if !ok {
+		return
+	}
+	// An order that finished in the meantime is not an error, the panel shows how it ended
+	_ = manager.Cancel(r.PathValue("id"), usecase.CancelledByUser)
+	s.renderParentOrders(w, manager)
+}
+
+func (s *Server) handleListParentOrders(w http.ResponseWriter, r *http.Request) {
+	manager, ok := s.executionManager(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(manager.List())
+}
+
+// handleCancelParentOrder serves POST /api/parent-orders/{id}/cancel and returns the order
+// once it stopped.
+func (s *Server) handleCancelParentOrder(w http.ResponseWriter, r *http.Request) {
+	manager, ok := s.executionManager(w)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	if err := manager.Cancel(id, usecase.CancelledByUser); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	order, _ := manager.Get(id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// handleExecutionReport serves GET /api/execution/report with the maker against taker fills
+// and the fees saved of the parent orders finished since start, per symbol and in total.
+func (s *Server) handleExecutionReport(w http.ResponseWriter, r *http.Request) {
+	manager, ok := s.executionManager(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(manager.Report())
+}
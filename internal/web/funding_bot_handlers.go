@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/vitos/crypto_trade_level/internal/domain"
 	"github.com/vitos/crypto_trade_level/internal/usecase"
 	"go.uber.org/zap"
 )
@@ -26,6 +27,9 @@ func (s *Server) handleStartFundingBot(w http.ResponseWriter, r *http.Request) {
 		WallThresholdMultiplier float64 `json:"wall_threshold_multiplier"`
 		StopLossPercentage      float64 `json:"stop_loss_percentage"`
 		TakeProfitPercentage    float64 `json:"take_profit_percentage"`
+		MakerFirst              bool    `json:"maker_first"`
+		MaxChasePct             float64 `json:"max_chase_pct"`
+		MakerFallback           string  `json:"maker_fallback"`
 	}
 
 	var req FundingBotConfigRequest
@@ -51,6 +55,14 @@ func (s *Server) handleStartFundingBot(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "MarginType is required", http.StatusBadRequest)
 		return
 	}
+	if _, err := domain.ParseExecFallback(req.MakerFallback); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.MaxChasePct < 0 {
+		http.Error(w, "MaxChasePct must not be negative", http.StatusBadRequest)
+		return
+	}
 
 	// Set defaults
 	if req.CountdownThreshold == 0 {
@@ -71,6 +83,9 @@ func (s *Server) handleStartFundingBot(w http.ResponseWriter, r *http.Request) {
 		WallThresholdMultiplier: req.WallThresholdMultiplier,
 		StopLossPercentage:      req.StopLossPercentage,
 		TakeProfitPercentage:    req.TakeProfitPercentage,
+		MakerFirst:              req.MakerFirst,
+		MaxChasePct:             req.MaxChasePct,
+		MakerFallback:           req.MakerFallback,
 	}
 
 	if err := s.fundingBotService.StartBot(r.Context(), config); err != nil {
@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -32,6 +33,8 @@ func InitTemplates(dir string) error {
 			}
 			return a / b
 		},
+		"ladder": domain.FormatTakeProfitLadder,
+		"join":   strings.Join,
 	}
 	templates, err = template.New("").Funcs(funcMap).ParseGlob(filepath.Join(dir, "*.html"))
 	return err
@@ -44,6 +47,18 @@ type LevelView struct {
 	LongTiers             []float64
 	ShortTiers            []float64
 	ConsecutiveBaseCloses int
+	TrailingStopPrice     float64                 // Live trailing stop (0 until armed)
+	BreakEvenPrice        float64                 // Live break-even stop (0 until armed)
+	Share                 usecase.LevelPosition   // Level's share of the exchange position, simulated for shadow levels
+	EntryDecisions        []usecase.EntryDecision // Filter verdicts of the last attempted entry
+}
+
+// levelShare returns the position share shown for a level, shadow levels show the simulated one.
+func (s *Server) levelShare(l *domain.Level) usecase.LevelPosition {
+	if l.IsLive() {
+		return s.service.GetLevelPosition(l.ID)
+	}
+	return s.service.GetShadowPosition(l.ID)
 }
 
 func (s *Server) handleLanding(w http.ResponseWriter, r *http.Request) {
@@ -71,11 +86,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		tiers, err := s.levelRepo.GetSymbolTiers(r.Context(), l.Exchange, l.Symbol)
 		if err != nil || tiers == nil {
 			// Defaults if not found
-			tiers = &domain.SymbolTiers{
-				Tier1Pct: 0.005,
-				Tier2Pct: 0.003,
-				Tier3Pct: 0.0015,
-			}
+			tiers = domain.DefaultSymbolTiers(l.Exchange, l.Symbol)
 		}
 
 		side := evaluator.DetermineSide(l.LevelPrice, price)
@@ -92,6 +103,10 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 			LongTiers:             longTiers,
 			ShortTiers:            shortTiers,
 			ConsecutiveBaseCloses: state.ConsecutiveBaseCloses,
+			TrailingStopPrice:     state.TrailingStopPrice,
+			BreakEvenPrice:        state.BreakEvenPrice,
+			Share:                 s.levelShare(l),
+			EntryDecisions:        state.LastEntryDecisions,
 		})
 	}
 
@@ -120,11 +135,7 @@ func (s *Server) handleLevelsTable(w http.ResponseWriter, r *http.Request) {
 		tiers, err := s.levelRepo.GetSymbolTiers(r.Context(), l.Exchange, l.Symbol)
 		if err != nil || tiers == nil {
 			// Defaults if not found
-			tiers = &domain.SymbolTiers{
-				Tier1Pct: 0.001,
-				Tier2Pct: 0.002,
-				Tier3Pct: 0.003,
-			}
+			tiers = domain.DefaultSymbolTiers(l.Exchange, l.Symbol)
 		}
 
 		side := evaluator.DetermineSide(l.LevelPrice, price)
@@ -141,6 +152,10 @@ func (s *Server) handleLevelsTable(w http.ResponseWriter, r *http.Request) {
 			LongTiers:             longTiers,
 			ShortTiers:            shortTiers,
 			ConsecutiveBaseCloses: state.ConsecutiveBaseCloses,
+			TrailingStopPrice:     state.TrailingStopPrice,
+			BreakEvenPrice:        state.BreakEvenPrice,
+			Share:                 s.levelShare(l),
+			EntryDecisions:        state.LastEntryDecisions,
 		})
 	}
 
@@ -161,15 +176,6 @@ func (s *Server) handleAddLevel(w http.ResponseWriter, r *http.Request) {
 	leverage, _ := strconv.Atoi(r.FormValue("leverage"))
 	coolDownMs, _ := strconv.ParseInt(r.FormValue("cool_down_ms"), 10, 64)
 
-	tier1, _ := strconv.ParseFloat(r.FormValue("tier1"), 64)
-	tier2, _ := strconv.ParseFloat(r.FormValue("tier2"), 64)
-	tier3, _ := strconv.ParseFloat(r.FormValue("tier3"), 64)
-
-	// Convert percentage to decimal (e.g. 1.0 -> 0.01)
-	tier1 = tier1 / 100
-	tier2 = tier2 / 100
-	tier3 = tier3 / 100
-
 	takeProfitPct, _ := strconv.ParseFloat(r.FormValue("take_profit_pct"), 64)
 	if takeProfitPct == 0 {
 		takeProfitPct = 2.0 // Default
@@ -181,6 +187,12 @@ func (s *Server) handleAddLevel(w http.ResponseWriter, r *http.Request) {
 		takeProfitMode = "fixed"
 	}
 
+	takeProfitLadder, err := domain.ParseTakeProfitLadder(r.FormValue("take_profit_ladder"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	exchange := r.FormValue("exchange")
 	symbol := r.FormValue("symbol")
 	marginType := r.FormValue("margin_type")
@@ -191,11 +203,72 @@ func (s *Server) handleAddLevel(w http.ResponseWriter, r *http.Request) {
 	}
 	disableSpeedClose := r.FormValue("disable_speed_close") == "on"
 
+	trailingActivationPct, _ := strconv.ParseFloat(r.FormValue("trailing_activation_pct"), 64)
+	trailingStopPct, _ := strconv.ParseFloat(r.FormValue("trailing_stop_pct"), 64)
+	breakEvenTier, _ := strconv.Atoi(r.FormValue("break_even_tier"))
+	breakEvenProfitPct, _ := strconv.ParseFloat(r.FormValue("break_even_profit_pct"), 64)
+	trailingStopMode := r.FormValue("trailing_stop_mode")
+	if trailingStopMode == "" {
+		trailingStopMode = "app"
+	}
+
+	entryFilters, err := domain.ParseEntryFilters(r.FormValue("entry_filters"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mode, err := domain.ParseLevelMode(r.FormValue("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sizingMode, err := domain.ParseSizingMode(r.FormValue("sizing_mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	riskPct, _ := strconv.ParseFloat(r.FormValue("risk_pct"), 64)
+	riskUSD, _ := strconv.ParseFloat(r.FormValue("risk_usd"), 64)
+
+	slippageAction, err := domain.ParseSlippageAction(r.FormValue("slippage_action"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxSlippagePct, _ := strconv.ParseFloat(r.FormValue("max_slippage_pct"), 64)
+
+	execAlgo, err := domain.ParseExecAlgo(r.FormValue("exec_algo"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	execDurationSec, _ := strconv.Atoi(r.FormValue("exec_duration_sec"))
+	execClipSize, _ := strconv.ParseFloat(r.FormValue("exec_clip_size"), 64)
+	execMaxChasePct, _ := strconv.ParseFloat(r.FormValue("exec_max_chase_pct"), 64)
+	execFallback, err := domain.ParseExecFallback(r.FormValue("exec_fallback"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	maxConsecutiveBaseCloses, _ := strconv.Atoi(r.FormValue("max_consecutive_base_closes"))
 	baseCloseCooldownMinutes, _ := strconv.Atoi(r.FormValue("base_close_cooldown_minutes"))
 	baseCloseCooldownMs := int64(baseCloseCooldownMinutes) * 60 * 1000
 	autoModeEnabled := r.FormValue("auto_mode_enabled") == "on"
 
+	// Tiers from the form are only used for symbols that have none yet, existing tiers
+	// are managed through the tier API and are not overwritten by adding a level.
+	var newTiers *domain.SymbolTiers
+	if _, err := s.levelRepo.GetSymbolTiers(r.Context(), exchange, symbol); err != nil {
+		newTiers, err = parseTiersForm(r, exchange, symbol)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	level := &domain.Level{
 		ID:                       fmt.Sprintf("%d", time.Now().UnixNano()),
 		Exchange:                 exchange,
@@ -212,12 +285,39 @@ func (s *Server) handleAddLevel(w http.ResponseWriter, r *http.Request) {
 		BaseCloseCooldownMs:      baseCloseCooldownMs,
 		TakeProfitPct:            takeProfitPct,
 		TakeProfitMode:           takeProfitMode,
+		TakeProfitLadder:         takeProfitLadder,
+		TrailingActivationPct:    trailingActivationPct / 100,
+		TrailingStopPct:          trailingStopPct / 100,
+		BreakEvenTier:            breakEvenTier,
+		BreakEvenProfitPct:       breakEvenProfitPct / 100,
+		TrailingStopMode:         trailingStopMode,
+		EntryFilters:             entryFilters,
+		Mode:                     mode,
+		SizingMode:               sizingMode,
+		RiskPct:                  riskPct / 100,
+		RiskUSD:                  riskUSD,
+		MaxSlippagePct:           maxSlippagePct / 100,
+		SlippageAction:           slippageAction,
+		ExecAlgo:                 execAlgo,
+		ExecDurationSec:          execDurationSec,
+		ExecClipSize:             execClipSize,
+		ExecMaxChasePct:          execMaxChasePct / 100,
+		ExecFallback:             execFallback,
 		IsAuto:                   false,
 		AutoModeEnabled:          autoModeEnabled, // Enabled if checkbox checked
 		Source:                   "manual-web",
 		CreatedAt:                time.Now(),
 	}
 
+	if err := level.ValidateSizing(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !level.UsesRiskSizing() && level.BaseSize <= 0 {
+		http.Error(w, "base_size must be greater than 0 for fixed sizing", http.StatusBadRequest)
+		return
+	}
+
 	if err := s.service.CreateLevel(r.Context(), level); err != nil {
 		s.logger.Error("Failed to create level", zap.Error(err))
 		http.Error(w, "Failed to save level", http.StatusInternalServerError)
@@ -225,17 +325,11 @@ func (s *Server) handleAddLevel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create Tiers
-	tiers := &domain.SymbolTiers{
-		Exchange:  exchange,
-		Symbol:    symbol,
-		Tier1Pct:  tier1,
-		Tier2Pct:  tier2,
-		Tier3Pct:  tier3,
-		UpdatedAt: time.Now(),
-	}
-	if err := s.levelRepo.SaveSymbolTiers(r.Context(), tiers); err != nil {
-		s.logger.Error("Failed to save tiers", zap.Error(err))
-		// Continue, but log error
+	if newTiers != nil {
+		if err := s.service.UpdateTiers(r.Context(), newTiers); err != nil {
+			s.logger.Error("Failed to save tiers", zap.Error(err))
+			// Continue, but log error
+		}
 	}
 
 	// Return updated table
@@ -252,6 +346,260 @@ func (s *Server) handleDeleteLevel(w http.ResponseWriter, r *http.Request) {
 	s.handleLevelsTable(w, r)
 }
 
+// handleUpdateLevel serves PUT (full edit, core fields required) and PATCH (any subset)
+// of /levels/{id}. JSON bodies get a JSON answer, form posts from the UI get the table.
+func (s *Server) handleUpdateLevel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := s.levelRepo.GetLevel(r.Context(), id); err != nil {
+		http.Error(w, "Level not found", http.StatusNotFound)
+		return
+	}
+
+	isJSON := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	var patch usecase.LevelPatch
+	if isJSON {
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if patch, err = parseLevelPatchForm(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if r.Method == http.MethodPut && !patch.Complete() {
+		http.Error(w, "PUT requires level_price, base_size, leverage and margin_type", http.StatusBadRequest)
+		return
+	}
+
+	changedBy := r.Header.Get("X-Changed-By")
+	if changedBy == "" {
+		changedBy = "web:" + r.RemoteAddr
+	}
+
+	level, changes, err := s.service.UpdateLevel(r.Context(), id, patch, changedBy)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrLevelHasPosition) {
+			status = http.StatusConflict
+		}
+		s.logger.Error("Failed to update level", zap.String("id", id), zap.Error(err))
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"level":   level,
+			"changes": changes,
+		})
+		return
+	}
+	s.handleLevelsTable(w, r)
+}
+
+// parseLevelPatchForm builds a patch from the form fields that are present.
+func parseLevelPatchForm(r *http.Request) (usecase.LevelPatch, error) {
+	var p usecase.LevelPatch
+	var err error
+	has := func(name string) bool { _, ok := r.Form[name]; return ok }
+	// Blank numeric inputs mean "unchanged", a blank text input clears the field
+	hasValue := func(name string) bool { return strings.TrimSpace(r.FormValue(name)) != "" }
+	parseFloat := func(name string, dst **float64) {
+		if err != nil || !hasValue(name) {
+			return
+		}
+		var v float64
+		if v, err = strconv.ParseFloat(r.FormValue(name), 64); err != nil {
+			err = fmt.Errorf("invalid %s: %w", name, err)
+			return
+		}
+		*dst = &v
+	}
+	parseInt := func(name string, dst **int) {
+		if err != nil || !hasValue(name) {
+			return
+		}
+		var v int
+		if v, err = strconv.Atoi(r.FormValue(name)); err != nil {
+			err = fmt.Errorf("invalid %s: %w", name, err)
+			return
+		}
+		*dst = &v
+	}
+	parseInt64 := func(name string, dst **int64) {
+		if err != nil || !hasValue(name) {
+			return
+		}
+		var v int64
+		if v, err = strconv.ParseInt(r.FormValue(name), 10, 64); err != nil {
+			err = fmt.Errorf("invalid %s: %w", name, err)
+			return
+		}
+		*dst = &v
+	}
+	parseBool := func(name string, dst **bool) {
+		if !hasValue(name) {
+			return
+		}
+		v := r.FormValue(name) == "on" || r.FormValue(name) == "true" // "off"/"false" to clear
+		*dst = &v
+	}
+	parseString := func(name string, dst **string) {
+		if !has(name) {
+			return
+		}
+		v := r.FormValue(name)
+		*dst = &v
+	}
+
+	parseFloat("level_price", &p.LevelPrice)
+	parseFloat("base_size", &p.BaseSize)
+	parseInt("leverage", &p.Leverage)
+	parseString("margin_type", &p.MarginType)
+	parseInt64("cool_down_ms", &p.CoolDownMs)
+	parseBool("stop_loss_at_base", &p.StopLossAtBase)
+	parseString("stop_loss_mode", &p.StopLossMode)
+	parseBool("disable_speed_close", &p.DisableSpeedClose)
+	parseInt("max_consecutive_base_closes", &p.MaxConsecutiveBaseCloses)
+	parseInt("base_close_cooldown_minutes", &p.BaseCloseCooldownMinutes)
+	parseFloat("take_profit_pct", &p.TakeProfitPct)
+	parseString("take_profit_mode", &p.TakeProfitMode)
+	parseString("take_profit_ladder", &p.TakeProfitLadder)
+	parseFloat("trailing_activation_pct", &p.TrailingActivationPct)
+	parseFloat("trailing_stop_pct", &p.TrailingStopPct)
+	parseInt("break_even_tier", &p.BreakEvenTier)
+	parseFloat("break_even_profit_pct", &p.BreakEvenProfitPct)
+	parseString("trailing_stop_mode", &p.TrailingStopMode)
+	parseString("entry_filters", &p.EntryFilters)
+	parseBool("auto_mode_enabled", &p.AutoModeEnabled)
+	if hasValue("mode") { // The edit form's blank option keeps the mode
+		parseString("mode", &p.Mode)
+	}
+	if hasValue("sizing_mode") {
+		parseString("sizing_mode", &p.SizingMode)
+	}
+	parseFloat("risk_pct", &p.RiskPct)
+	parseFloat("risk_usd", &p.RiskUSD)
+	parseFloat("max_slippage_pct", &p.MaxSlippagePct)
+	if hasValue("slippage_action") {
+		parseString("slippage_action", &p.SlippageAction)
+	}
+	if hasValue("exec_algo") {
+		parseString("exec_algo", &p.ExecAlgo)
+	}
+	parseInt("exec_duration_sec", &p.ExecDurationSec)
+	parseFloat("exec_clip_size", &p.ExecClipSize)
+	parseFloat("exec_max_chase_pct", &p.ExecMaxChasePct)
+	if hasValue("exec_fallback") {
+		parseString("exec_fallback", &p.ExecFallback)
+	}
+	return p, err
+}
+
+func (s *Server) handleLevelRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	revisions, err := s.service.ListLevelRevisions(r.Context(), id, 50)
+	if err != nil {
+		s.logger.Error("Failed to list level revisions", zap.String("id", id), zap.Error(err))
+		http.Error(w, "Failed to list revisions", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"LevelID":   id,
+		"Revisions": revisions,
+	}
+	if err := templates.ExecuteTemplate(w, "level_revisions", data); err != nil {
+		s.logger.Error("Template error", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleLevelRevisionsAPI(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	revisions, err := s.service.ListLevelRevisions(r.Context(), id, 200)
+	if err != nil {
+		s.logger.Error("Failed to list level revisions", zap.String("id", id), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+var formatContentTypes = map[string]string{
+	usecase.FormatJSON: "application/json",
+	usecase.FormatYAML: "application/yaml",
+	usecase.FormatCSV:  "text/csv",
+}
+
+// handleExportLevels serves GET /api/levels/export?format=json|yaml|csv.
+func (s *Server) handleExportLevels(w http.ResponseWriter, r *http.Request) {
+	format, err := usecase.NormalizeFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recs, err := s.service.ExportLevels(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to export levels", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=levels.%s", format))
+	if err := usecase.EncodeLevelRecords(w, format, recs); err != nil {
+		s.logger.Error("Failed to encode levels", zap.Error(err))
+	}
+}
+
+// handleImportLevels serves POST /api/levels/import?format=json|yaml|csv&dry_run=true&prune=true
+// with the file as the request body.
+func (s *Server) handleImportLevels(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format, err := usecase.NormalizeFormat(q.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(q.Get("dry_run"))
+	prune, _ := strconv.ParseBool(q.Get("prune"))
+
+	changedBy := r.Header.Get("X-Changed-By")
+	if changedBy == "" {
+		changedBy = "import:" + r.RemoteAddr
+	}
+
+	res, err := s.service.ImportLevelsFrom(r.Context(), r.Body, format, usecase.ImportOptions{
+		DryRun:    dryRun,
+		Prune:     prune,
+		ChangedBy: changedBy,
+	})
+	if err != nil {
+		s.logger.Error("Failed to import levels", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(res.Errors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
 func (s *Server) handleAutoCreateLevel(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if err := s.service.AutoCreateNextLevel(r.Context(), id); err != nil {
@@ -262,9 +610,130 @@ func (s *Server) handleAutoCreateLevel(w http.ResponseWriter, r *http.Request) {
 	s.handleLevelsTable(w, r)
 }
 
+// parseTiersForm reads tier1..tier3 in percent (0.5 -> 0.005), or the tiers of a preset
+// when the form has "preset". Blank tiers fall back to the defaults.
+func parseTiersForm(r *http.Request, exchange, symbol string) (*domain.SymbolTiers, error) {
+	if name := r.FormValue("preset"); name != "" {
+		preset, ok := domain.FindTierPreset(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown tier preset %q", name)
+		}
+		return preset.Tiers(exchange, symbol), nil
+	}
+
+	tiers := domain.DefaultSymbolTiers(exchange, symbol)
+	for _, f := range []struct {
+		name string
+		dst  *float64
+	}{{"tier1", &tiers.Tier1Pct}, {"tier2", &tiers.Tier2Pct}, {"tier3", &tiers.Tier3Pct}} {
+		raw := strings.TrimSpace(r.FormValue(f.name))
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", f.name, err)
+		}
+		// Convert percentage to decimal (e.g. 1.0 -> 0.01)
+		*f.dst = v / 100
+	}
+	if err := tiers.Validate(); err != nil {
+		return nil, err
+	}
+	return tiers, nil
+}
+
+// handleUpdateTiers is the form variant of PUT /tiers/{exchange}/{symbol} (tiers in percent or a preset).
 func (s *Server) handleUpdateTiers(w http.ResponseWriter, r *http.Request) {
-	// Implementation for updating tiers
-	// ...
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	tiers, err := parseTiersForm(r, r.FormValue("exchange"), r.FormValue("symbol"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.service.UpdateTiers(r.Context(), tiers); err != nil {
+		s.writeTiersError(w, err)
+		return
+	}
+	s.handleLevelsTable(w, r)
+}
+
+func (s *Server) handleGetTiers(w http.ResponseWriter, r *http.Request) {
+	tiers, err := s.service.GetTiers(r.Context(), r.PathValue("exchange"), r.PathValue("symbol"))
+	if err != nil {
+		http.Error(w, "Tiers not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tiers":  tiers,
+		"active": s.service.TiersActive(tiers.Symbol),
+	})
+}
+
+// handlePutTiers takes a JSON body with fractions, e.g. {"tier1_pct": 0.005, "tier2_pct": 0.003, "tier3_pct": 0.0015}.
+func (s *Server) handlePutTiers(w http.ResponseWriter, r *http.Request) {
+	var tiers domain.SymbolTiers
+	if err := json.NewDecoder(r.Body).Decode(&tiers); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tiers.Exchange = r.PathValue("exchange")
+	tiers.Symbol = r.PathValue("symbol")
+
+	if err := s.service.UpdateTiers(r.Context(), &tiers); err != nil {
+		s.writeTiersError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tiers)
+}
+
+func (s *Server) handleListTierPresets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain.TierPresets)
+}
+
+// handleApplyTierPreset applies a preset to many symbols: {"exchange": "bybit", "symbols": ["BTCUSDT", "ETHUSDT"]}.
+func (s *Server) handleApplyTierPreset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Exchange string   `json:"exchange"`
+		Symbols  []string `json:"symbols"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Exchange == "" || len(req.Symbols) == 0 {
+		http.Error(w, "exchange and symbols are required", http.StatusBadRequest)
+		return
+	}
+
+	applied, skipped, err := s.service.ApplyTierPreset(r.Context(), req.Exchange, r.PathValue("name"), req.Symbols)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"applied": applied,
+		"skipped": skipped,
+	})
+}
+
+func (s *Server) writeTiersError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, usecase.ErrTiersActive) {
+		status = http.StatusConflict
+	}
+	s.logger.Error("Failed to update tiers", zap.Error(err))
+	http.Error(w, err.Error(), status)
 }
 
 func (s *Server) handlePositionsTable(w http.ResponseWriter, r *http.Request) {
@@ -275,11 +744,26 @@ func (s *Server) handlePositionsTable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := templates.ExecuteTemplate(w, "positions_table", positions); err != nil {
+	views := make([]positionView, 0, len(positions))
+	for _, p := range positions {
+		view := positionView{Position: p}
+		if s.runtime != nil {
+			view.Owners = s.runtime.Leases().Owners(p.Symbol)
+		}
+		views = append(views, view)
+	}
+
+	if err := templates.ExecuteTemplate(w, "positions_table", views); err != nil {
 		s.logger.Error("Template error", zap.Error(err))
 	}
 }
 
+// positionView is an exchange position with the strategies that own its symbol.
+type positionView struct {
+	*domain.Position
+	Owners []string
+}
+
 func (s *Server) handleIncrementCloses(w http.ResponseWriter, r *http.Request) {
 	levelID := r.PathValue("id")
 	if levelID == "" {
@@ -410,6 +894,52 @@ func (s *Server) handleMarketStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
+// handleVolumeProfile serves GET /api/volume-profile?symbol=&window=4h|1d|1w|1M|live&bins=.
+// A custom window is given as interval and candles instead of a window name.
+func (s *Server) handleVolumeProfile(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	symbol := q.Get("symbol")
+	if symbol == "" {
+		symbol = "BTCUSDT"
+	}
+	bins, _ := strconv.Atoi(q.Get("bins"))
+
+	var profile *usecase.VolumeProfile
+	if q.Get("window") == usecase.LiveVolumeProfileWindow {
+		profile = s.marketService.GetLiveVolumeProfile(symbol, bins)
+	} else {
+		var window usecase.VolumeProfileWindow
+		if q.Get("interval") != "" {
+			candles, _ := strconv.Atoi(q.Get("candles"))
+			window = usecase.VolumeProfileWindow{Name: "custom", Interval: q.Get("interval"), Candles: candles}
+		} else {
+			name := q.Get("window")
+			if name == "" {
+				name = usecase.DefaultVolumeProfileWindow
+			}
+			var ok bool
+			if window, ok = usecase.FindVolumeProfileWindow(name); !ok {
+				http.Error(w, fmt.Sprintf("unknown window %q", name), http.StatusBadRequest)
+				return
+			}
+		}
+
+		var err error
+		profile, err = s.marketService.GetVolumeProfile(r.Context(), symbol, window, bins)
+		if err != nil {
+			s.logger.Error("Failed to get volume profile", zap.String("symbol", symbol), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if q.Get("bins_detail") == "false" {
+		profile = profile.Summary()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
 type CoinData struct {
 	Symbol            string
 	BaseCoin          string
@@ -843,3 +1373,40 @@ func (s *Server) handleSpeedBotStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
 }
+
+// Strategy Runtime API Handlers
+
+func (s *Server) handleListStrategies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.runtime.List())
+}
+
+// handleListLeases shows which strategy owns which symbol.
+func (s *Server) handleListLeases(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.runtime.Leases().List())
+}
+
+func (s *Server) handleStrategyStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	status, err := s.runtime.Status(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) handleStopStrategy(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.runtime.Stop(r.Context(), id); err != nil {
+		s.logger.Error("Failed to stop strategy", zap.String("id", id), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "stopped"})
+}
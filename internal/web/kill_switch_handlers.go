@@ -0,0 +1,109 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Kill Switch Handlers
+
+func (s *Server) killSwitchEnabled(w http.ResponseWriter) bool {
+	if s.killSwitch == nil {
+		http.Error(w, "Kill switch is not enabled", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// killSwitchContext keeps the request values but not its cancellation: the kill switch
+// runs to the end even when the client disconnects.
+func killSwitchContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(r.Context()), usecase.KillSwitchTimeout)
+}
+
+// killSwitchStatus is what GET /api/kill-switch reports.
+type killSwitchStatus struct {
+	Halted     bool                      `json:"halted"`
+	HaltReason string                    `json:"halt_reason,omitempty"`
+	Running    bool                      `json:"running"`
+	LastReport *usecase.KillSwitchReport `json:"last_report,omitempty"`
+}
+
+func (s *Server) handleKillSwitchStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.killSwitchEnabled(w) {
+		return
+	}
+	status := killSwitchStatus{Running: s.killSwitch.Running(), LastReport: s.killSwitch.LastReport()}
+	if s.riskManager != nil {
+		status.HaltReason = s.riskManager.HaltReason()
+		status.Halted = s.riskManager.IsHalted()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleTriggerKillSwitch serves POST /api/kill-switch with {"confirm": true, "reason": "...", "by": "..."}.
+// The report is returned either way, the status is 500 if the account is not confirmed flat.
+func (s *Server) handleTriggerKillSwitch(w http.ResponseWriter, r *http.Request) {
+	if !s.killSwitchEnabled(w) {
+		return
+	}
+	var req struct {
+		Confirm bool   `json:"confirm"`
+		Reason  string `json:"reason"`
+		By      string `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !req.Confirm {
+		http.Error(w, `The kill switch flattens every position, send "confirm": true`, http.StatusBadRequest)
+		return
+	}
+	if req.By == "" {
+		req.By = "api"
+	}
+
+	ctx, cancel := killSwitchContext(r)
+	defer cancel()
+	report, err := s.killSwitch.Trigger(ctx, req.By, req.Reason)
+	if errors.Is(err, usecase.ErrKillSwitchRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		s.logger.Error("Kill switch did not complete", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleKillSwitchForm serves POST /kill-switch from the risk panel.
+func (s *Server) handleKillSwitchForm(w http.ResponseWriter, r *http.Request) {
+	if !s.killSwitchEnabled(w) || !s.riskEnabled(w) {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if r.FormValue("confirm") != "yes" {
+		s.renderRiskPanel(w, r, "Kill switch not confirmed")
+		return
+	}
+	ctx, cancel := killSwitchContext(r)
+	defer cancel()
+	errMsg := ""
+	if _, err := s.killSwitch.Trigger(ctx, "ui", r.FormValue("reason")); err != nil {
+		s.logger.Error("Kill switch did not complete", zap.Error(err))
+		errMsg = "Kill switch: " + err.Error()
+	}
+	s.renderRiskPanel(w, r, errMsg)
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Risk Manager Handlers
+
+type riskPanelView struct {
+	Status     *usecase.RiskStatus
+	Error      string
+	KillSwitch bool                      // Show the kill switch
+	KillReport *usecase.KillSwitchReport // Last kill switch run, nil if none
+	Breakers   []usecase.BreakerStatus   // Nil without circuit breakers
+}
+
+func (s *Server) riskEnabled(w http.ResponseWriter) bool {
+	if s.riskManager == nil {
+		http.Error(w, "Risk manager is not enabled", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+func (s *Server) renderRiskPanel(w http.ResponseWriter, r *http.Request, errMsg string) {
+	status, err := s.riskManager.Status(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to load risk status", zap.Error(err))
+		if errMsg == "" {
+			errMsg = err.Error()
+		}
+	}
+	view := riskPanelView{Status: status, Error: errMsg}
+	if s.killSwitch != nil {
+		view.KillSwitch = true
+		view.KillReport = s.killSwitch.LastReport()
+	}
+	if breakers := s.circuitBreakers(); breakers != nil {
+		view.Breakers = breakers.Status()
+	}
+	if err := templates.ExecuteTemplate(w, "risk_panel", view); err != nil {
+		s.logger.Error("Template error", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleRiskPanel(w http.ResponseWriter, r *http.Request) {
+	if !s.riskEnabled(w) {
+		return
+	}
+	s.renderRiskPanel(w, r, "")
+}
+
+// handleRiskLimitsForm serves POST /risk/limits from the panel form.
+func (s *Server) handleRiskLimitsForm(w http.ResponseWriter, r *http.Request) {
+	if !s.riskEnabled(w) {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	limits := s.riskManager.Limits()
+	floatField := func(name string, dst *float64) {
+		if v := r.FormValue(name); v != "" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				*dst = f
+			}
+		}
+	}
+	floatField("max_gross_notional", &limits.MaxGrossNotional)
+	floatField("max_net_notional", &limits.MaxNetNotional)
+	floatField("max_symbol_notional", &limits.MaxSymbolNotional)
+	floatField("max_daily_loss", &limits.MaxDailyLoss)
+	if v := r.FormValue("max_drawdown_pct"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			limits.MaxDrawdownPct = f / 100 // Entered in percent
+		}
+	}
+	if v := r.FormValue("max_positions"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limits.MaxPositions = n
+		}
+	}
+
+	errMsg := ""
+	if err := s.riskManager.SetLimits(r.Context(), limits); err != nil {
+		errMsg = err.Error()
+	}
+	s.renderRiskPanel(w, r, errMsg)
+}
+
+// handleRiskResetForm serves POST /risk/reset from the panel.
+func (s *Server) handleRiskResetForm(w http.ResponseWriter, r *http.Request) {
+	if !s.riskEnabled(w) {
+		return
+	}
+	errMsg := ""
+	if err := s.riskManager.Reset(r.Context(), "ui"); err != nil {
+		errMsg = err.Error()
+	}
+	s.renderRiskPanel(w, r, errMsg)
+}
+
+func (s *Server) handleRiskStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.riskEnabled(w) {
+		return
+	}
+	status, err := s.riskManager.Status(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to load risk status", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleSetRiskLimits serves PUT /api/risk/limits with a full domain.RiskLimits body.
+func (s *Server) handleSetRiskLimits(w http.ResponseWriter, r *http.Request) {
+	if !s.riskEnabled(w) {
+		return
+	}
+	var limits domain.RiskLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := s.riskManager.SetLimits(r.Context(), limits); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.riskManager.Limits())
+}
+
+// handleResetRisk serves POST /api/risk/reset, optional body {"by": "name"}.
+func (s *Server) handleResetRisk(w http.ResponseWriter, r *http.Request) {
+	if !s.riskEnabled(w) {
+		return
+	}
+	var req struct {
+		By string `json:"by"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req) // Body is optional
+	if req.By == "" {
+		req.By = "api"
+	}
+	if err := s.riskManager.Reset(r.Context(), req.By); err != nil {
+		s.logger.Error("Failed to reset risk halt", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.handleRiskStatus(w, r)
+}
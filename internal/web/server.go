@@ -19,6 +19,11 @@ type Server struct {
 	marketService     *usecase.MarketService
 	speedBotService   *usecase.SpeedBotService
 	fundingBotService *usecase.FundingBotService
+	runtime           *usecase.StrategyRuntime
+	webhookService    *usecase.WebhookService // nil disables the webhook endpoint
+	discoveryService  *usecase.LevelDiscoveryService
+	riskManager       *usecase.RiskManager
+	killSwitch        *usecase.KillSwitch
 	logger            *zap.Logger
 }
 
@@ -30,6 +35,11 @@ func NewServer(
 	marketService *usecase.MarketService,
 	speedBotService *usecase.SpeedBotService,
 	fundingBotService *usecase.FundingBotService,
+	runtime *usecase.StrategyRuntime,
+	webhookService *usecase.WebhookService,
+	discoveryService *usecase.LevelDiscoveryService,
+	riskManager *usecase.RiskManager,
+	killSwitch *usecase.KillSwitch,
 	logger *zap.Logger,
 ) *Server {
 	s := &Server{
@@ -40,6 +50,11 @@ func NewServer(
 		marketService:     marketService,
 		speedBotService:   speedBotService,
 		fundingBotService: fundingBotService,
+		runtime:           runtime,
+		webhookService:    webhookService,
+		discoveryService:  discoveryService,
+		riskManager:       riskManager,
+		killSwitch:        killSwitch,
 		logger:            logger,
 	}
 	s.routes()
@@ -60,12 +75,60 @@ func (s *Server) routes() {
 	// Levels
 	s.router.HandleFunc("GET /levels", s.handleLevelsTable)
 	s.router.HandleFunc("POST /levels", s.handleAddLevel)
+	s.router.HandleFunc("PUT /levels/{id}", s.handleUpdateLevel)
+	s.router.HandleFunc("PATCH /levels/{id}", s.handleUpdateLevel)
 	s.router.HandleFunc("DELETE /levels/{id}", s.handleDeleteLevel)
+	s.router.HandleFunc("GET /levels/{id}/revisions", s.handleLevelRevisions)
+	s.router.HandleFunc("GET /api/levels/{id}/revisions", s.handleLevelRevisionsAPI)
+	s.router.HandleFunc("GET /api/levels/export", s.handleExportLevels)
+	s.router.HandleFunc("POST /api/levels/import", s.handleImportLevels)
 	s.router.HandleFunc("POST /levels/{id}/increment-closes", s.handleIncrementCloses)
 	s.router.HandleFunc("POST /levels/{id}/auto", s.handleAutoCreateLevel)
+	s.router.HandleFunc("GET /levels/performance", s.handleLevelPerformance)
+	s.router.HandleFunc("GET /api/levels/performance", s.handleLevelPerformanceAPI)
+	s.router.HandleFunc("GET /api/shadow-trades", s.handleListShadowTrades)
+	s.router.HandleFunc("GET /api/slippage", s.handleSlippageReport)
+	s.router.HandleFunc("GET /levels/{id}/decisions", s.handleDecisionTimeline)
+	s.router.HandleFunc("GET /api/decisions", s.handleListDecisions)
+
+	// Risk Manager
+	s.router.HandleFunc("GET /api/risk", s.handleRiskStatus)
+	s.router.HandleFunc("PUT /api/risk/limits", s.handleSetRiskLimits)
+	s.router.HandleFunc("POST /api/risk/reset", s.handleResetRisk)
+	s.router.HandleFunc("GET /risk", s.handleRiskPanel)
+	s.router.HandleFunc("POST /risk/limits", s.handleRiskLimitsForm)
+	s.router.HandleFunc("POST /risk/reset", s.handleRiskResetForm)
+
+	// Kill Switch
+	s.router.HandleFunc("GET /api/kill-switch", s.handleKillSwitchStatus)
+	s.router.HandleFunc("POST /api/kill-switch", s.handleTriggerKillSwitch)
+	s.router.HandleFunc("POST /kill-switch", s.handleKillSwitchForm)
+
+	// Circuit Breakers
+	s.router.HandleFunc("GET /api/breakers", s.handleListBreakers)
+	s.router.HandleFunc("POST /api/breakers/reset", s.handleResetBreakers)
+	s.router.HandleFunc("POST /breakers/reset", s.handleResetBreakerForm)
+
+	// Parent Orders
+	s.router.HandleFunc("GET /parent-orders", s.handleParentOrders)
+	s.router.HandleFunc("POST /parent-orders/{id}/cancel", s.handleCancelParentOrderForm)
+	s.router.HandleFunc("GET /api/parent-orders", s.handleListParentOrders)
+	s.router.HandleFunc("POST /api/parent-orders/{id}/cancel", s.handleCancelParentOrder)
+	s.router.HandleFunc("GET /api/execution/report", s.handleExecutionReport)
+
+	// Level Discovery
+	s.router.HandleFunc("POST /discovery/scan", s.handleDiscoveryScan)
+	s.router.HandleFunc("GET /discovery/proposals", s.handleListProposals)
+	s.router.HandleFunc("POST /discovery/proposals/{id}/accept", s.handleAcceptProposal)
+	s.router.HandleFunc("POST /discovery/proposals/{id}/reject", s.handleRejectProposal)
+	s.router.HandleFunc("GET /api/discovery/proposals", s.handleListProposalsAPI)
 
 	// Tiers
 	s.router.HandleFunc("POST /tiers", s.handleUpdateTiers)
+	s.router.HandleFunc("GET /tiers/{exchange}/{symbol}", s.handleGetTiers)
+	s.router.HandleFunc("PUT /tiers/{exchange}/{symbol}", s.handlePutTiers)
+	s.router.HandleFunc("GET /api/tier-presets", s.handleListTierPresets)
+	s.router.HandleFunc("POST /api/tier-presets/{name}/apply", s.handleApplyTierPreset)
 
 	// Positions
 	s.router.HandleFunc("GET /positions", s.handlePositionsTable)
@@ -87,6 +150,7 @@ func (s *Server) routes() {
 
 	// Market Stats
 	s.router.HandleFunc("GET /api/market-stats", s.handleMarketStats)
+	s.router.HandleFunc("GET /api/volume-profile", s.handleVolumeProfile)
 
 	// Level Bot
 	s.router.HandleFunc("GET /level-bot", s.handleLevelBot)
@@ -114,6 +178,18 @@ func (s *Server) routes() {
 	s.router.HandleFunc("GET /api/fundingbot/auto/status", s.handleGetAutoScannerStatus)
 	s.router.HandleFunc("GET /api/fundingbot/session-logs", s.handleListSessionLogs)
 	s.router.HandleFunc("GET /api/fundingbot/session-logs/{id}", s.handleGetSessionLog)
+
+	// Strategy Runtime API
+	s.router.HandleFunc("GET /api/strategies", s.handleListStrategies)
+	s.router.HandleFunc("GET /api/strategies/{id}", s.handleStrategyStatus)
+	s.router.HandleFunc("POST /api/strategies/{id}/stop", s.handleStopStrategy)
+	s.router.HandleFunc("GET /api/leases", s.handleListLeases)
+
+	// Webhook API
+	s.router.HandleFunc("POST /api/webhook", s.handleWebhook)
+	s.router.HandleFunc("GET /api/webhook/alerts", s.handleListWebhookAlerts)
+	s.router.HandleFunc("GET /api/pauses", s.handleListPauses)
+	s.router.HandleFunc("DELETE /api/pauses/{symbol}", s.handleResumeSymbol)
 }
 
 func (s *Server) Start() error {
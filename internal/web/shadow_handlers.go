@@ -0,0 +1,52 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// Shadow Mode Handlers
+
+// handleLevelPerformance renders live and shadow PnL per level side by side.
+func (s *Server) handleLevelPerformance(w http.ResponseWriter, r *http.Request) {
+	perf, err := s.service.LevelPerformance(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to load level performance", zap.Error(err))
+		http.Error(w, "Failed to load level performance", http.StatusInternalServerError)
+		return
+	}
+	if err := templates.ExecuteTemplate(w, "level_performance", perf); err != nil {
+		s.logger.Error("Template error", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleLevelPerformanceAPI(w http.ResponseWriter, r *http.Request) {
+	perf, err := s.service.LevelPerformance(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to load level performance", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(perf)
+}
+
+// handleListShadowTrades serves GET /api/shadow-trades?level_id=&limit=.
+func (s *Server) handleListShadowTrades(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	trades, err := s.service.ListShadowTrades(r.Context(), r.URL.Query().Get("level_id"), limit)
+	if err != nil {
+		s.logger.Error("Failed to list shadow trades", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trades)
+}
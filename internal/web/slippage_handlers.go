@@ -0,0 +1,28 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// Slippage Handlers
+
+// handleSlippageReport serves GET /api/slippage?limit= with the expected against the actual
+// fills of guarded entries per symbol, over the last limit trades (default 500).
+func (s *Server) handleSlippageReport(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 500
+	}
+	report, err := s.service.SlippageReport(r.Context(), limit)
+	if err != nil {
+		s.logger.Error("Failed to build slippage report", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Webhook API Handlers
+
+const maxWebhookBody = 64 << 10
+
+// handleWebhook serves POST /api/webhook for charting tool alerts. The body must be signed
+// with the shared secret, see usecase.WebhookSignatureHeader.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.webhookService == nil {
+		http.Error(w, usecase.ErrWebhookDisabled.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	res, err := s.webhookService.Handle(r.Context(), body, r.Header.Get(usecase.WebhookSignatureHeader))
+	if res == nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, usecase.ErrWebhookDisabled):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, usecase.ErrWebhookSignature):
+			status = http.StatusUnauthorized
+		case !errors.Is(err, usecase.ErrWebhookPayload):
+			status = http.StatusInternalServerError
+		}
+		s.logger.Warn("Webhook rejected", zap.String("remote", r.RemoteAddr), zap.Error(err))
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	status := http.StatusOK
+	switch {
+	case err == nil:
+	case errors.Is(err, usecase.ErrWebhookPayload):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, usecase.ErrLevelHasPosition), errors.Is(err, usecase.ErrTiersActive):
+		status = http.StatusConflict
+	default:
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (s *Server) handleListWebhookAlerts(w http.ResponseWriter, r *http.Request) {
+	if s.webhookService == nil {
+		http.Error(w, usecase.ErrWebhookDisabled.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	alerts, err := s.webhookService.ListAlerts(r.Context(), limit)
+	if err != nil {
+		s.logger.Error("Failed to list webhook alerts", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+func (s *Server) handleListPauses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.service.PausedSymbols())
+}
+
+func (s *Server) handleResumeSymbol(w http.ResponseWriter, r *http.Request) {
+	s.service.ResumeSymbol(r.PathValue("symbol"))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "resumed"})
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+// setupBreakerLevel trades the scenario level through circuit breakers on a manual clock.
+func setupBreakerLevel(t *testing.T, cfg usecase.CircuitBreakerConfig) (*TestScenarioHelper, *usecase.CircuitBreakers, *time.Time) {
+	h := NewTestScenarioHelper(t)
+	now := time.Now()
+	breakers := usecase.NewCircuitBreakers(cfg)
+	breakers.SetClock(func() time.Time { return now })
+	tradeThrough(h, usecase.NewBreakerExchange(h.mockEx, breakers))
+	h.svc.SetCircuitBreakers(breakers)
+	h.SetupLevel(10000, true)
+	return h, breakers, &now
+}
+
+func tierOutcomes(h *TestScenarioHelper) []*domain.Decision {
+	decisions, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if err != nil {
+		h.t.Fatalf("Failed to list decisions: %v", err)
+	}
+	return decisions
+}
+
+func TestCircuitBreaker_OrderFailuresPauseEntriesUntilTrialSucceeds(t *testing.T) {
+	h, breakers, now := setupBreakerLevel(t, usecase.CircuitBreakerConfig{SymbolMaxFailures: 2, CooldownSec: 60})
+	h.mockEx.OrderErr = errors.New("503 service unavailable")
+
+	// 1. T1 and T2 shorts fail and open the symbol breaker
+	h.Tick(9900)
+	h.Tick(9960)
+	h.Tick(9975)
+	if err := breakers.AllowEntry(h.symbol); !errors.Is(err, usecase.ErrCircuitOpen) {
+		t.Fatalf("Expected the breaker to be open, got %v", err)
+	}
+
+	// 2. T3 is skipped instead of hitting the exchange again
+	h.Tick(9990)
+	decisions := tierOutcomes(h)
+	if len(decisions) != 3 {
+		t.Fatalf("Expected 3 tier decisions, got %d", len(decisions))
+	}
+	last := decisions[0]
+	if last.Outcome != domain.OutcomeSkipped || !strings.Contains(last.Reason, "circuit breaker open") {
+		t.Errorf("Expected the entry skipped by the breaker, got %s (%s)", last.Outcome, last.Reason)
+	}
+	for _, d := range decisions[1:] {
+		if d.Outcome != domain.OutcomeFailed {
+			t.Errorf("Expected the first attempts failed, got %s (%s)", d.Outcome, d.Reason)
+		}
+	}
+
+	// 3. Before the cooldown the exchange may be fine again, entries still wait
+	h.mockEx.OrderErr = nil
+	*now = now.Add(30 * time.Second)
+	if err := breakers.AllowEntry(h.symbol); err == nil {
+		t.Fatalf("Expected the breaker to stay open during the cooldown")
+	}
+
+	// 4. After it the next order is the trial, its success closes the order breakers. Here the
+	// close of a position the exchange did open despite the errors.
+	*now = now.Add(31 * time.Second)
+	h.mockEx.SetPosition(h.symbol, domain.SideShort, 0.1, 9960)
+	if err := h.svc.ClosePosition(h.ctx, h.symbol); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	for _, st := range breakers.Status() {
+		if st.Kind != usecase.BreakerKindPrice && st.State != usecase.BreakerClosed {
+			t.Errorf("Expected the order breakers closed after the trial, got %+v", st)
+		}
+	}
+	h.Tick(9900)
+	h.Tick(9960)
+	if !h.mockEx.SellCalled {
+		t.Errorf("Expected entries to resume")
+	}
+}
+
+func TestCircuitBreaker_UnknownPositionAndStalePrice(t *testing.T) {
+	h, breakers, now := setupBreakerLevel(t, usecase.CircuitBreakerConfig{MaxPriceAgeSec: 10})
+
+	// 1. A failed position fetch is not a flat position, entries wait for a good fetch
+	h.mockEx.PositionErr = errors.New("timeout")
+	h.Tick(9900)
+	h.Tick(9960)
+	if h.mockEx.SellCalled {
+		t.Fatalf("Expected no entry while the position is unknown")
+	}
+	if d := tierOutcomes(h); len(d) != 1 || !strings.Contains(d[0].Reason, "position unknown") {
+		t.Fatalf("Expected the entry skipped as position unknown, got %+v", d)
+	}
+	h.mockEx.PositionErr = nil
+
+	// 2. A short the last tick saw as safe is not judged on that stale tick, nor at all while
+	// the price cannot be refreshed
+	h.mockEx.SetPosition(h.symbol, domain.SideShort, 0.1, 9960)
+	h.Tick(9970)
+	*now = now.Add(30 * time.Second)
+	h.mockEx.Price = 10100 // The feed died while the market went through the level
+	h.mockEx.PriceErr = errors.New("rest down")
+	h.svc.CheckSafety(h.ctx)
+	if h.mockEx.Position == nil {
+		t.Fatalf("Expected no safety close on a stale price")
+	}
+	stale := false
+	for _, st := range breakers.Status() {
+		if st.Kind == usecase.BreakerKindPrice && st.Symbol == h.symbol && st.State == usecase.BreakerOpen {
+			stale = true
+		}
+	}
+	if !stale {
+		t.Errorf("Expected the stale price in the breaker status, got %+v", breakers.Status())
+	}
+
+	// 3. Once REST answers the check runs on the refreshed price
+	h.mockEx.PriceErr = nil
+	h.svc.CheckSafety(h.ctx)
+	if h.mockEx.Position != nil {
+		t.Errorf("Expected the safety close on the refreshed price, got %+v", h.mockEx.Position)
+	}
+}
@@ -2,8 +2,12 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
 )
 
 type MockExchange struct {
@@ -12,6 +16,24 @@ type MockExchange struct {
 	SellCalled bool
 	Position   *domain.Position
 	OrderBook  *domain.OrderBook
+	BookSteps  []*domain.OrderBook // Served one per GetOrderBook call before OrderBook
+	Candles    []domain.Candle
+
+	LastTradingStop domain.TradingStop
+	Equity          *domain.AccountEquity
+	Instruments     []domain.Instrument
+	RiskLimits      []domain.RiskLimitTier
+	OpenOrders      int   // Cancelled by CancelAllOrders
+	CloseFailures   int   // ClosePosition fails this many times before it goes through
+	ReduceErr       error // Returned by ReducePosition
+	OrderErr        error // Returned by MarketBuy and MarketSell
+	PositionErr     error // Returned by GetPosition
+	PositionsErr    error // Returned by GetPositions
+	PriceErr        error // Returned by GetCurrentPrice
+
+	LimitOrders []*domain.Order // Limit orders placed, in order
+	FillLimits  bool            // GetOrder fills a resting limit order in full at its price
+	FillIOC     bool            // GetOrder fills an IOC limit order in full at its price, else it is cancelled
 }
 
 func (m *MockExchange) SetPosition(symbol string, side domain.Side, size, entryPrice float64) {
@@ -24,10 +46,16 @@ func (m *MockExchange) SetPosition(symbol string, side domain.Side, size, entryP
 }
 
 func (m *MockExchange) GetCurrentPrice(ctx context.Context, symbol string) (float64, error) {
+	if m.PriceErr != nil {
+		return 0, m.PriceErr
+	}
 	return m.Price, nil
 }
 
 func (m *MockExchange) MarketBuy(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error {
+	if m.OrderErr != nil {
+		return m.OrderErr
+	}
 	m.BuyCalled = true
 	if m.Position == nil {
 		m.Position = &domain.Position{
@@ -44,6 +72,9 @@ func (m *MockExchange) MarketBuy(ctx context.Context, symbol string, size float6
 }
 
 func (m *MockExchange) MarketSell(ctx context.Context, symbol string, size float64, leverage int, marginType string, stopLoss float64) error {
+	if m.OrderErr != nil {
+		return m.OrderErr
+	}
 	m.SellCalled = true
 	if m.Position == nil {
 		m.Position = &domain.Position{
@@ -60,11 +91,37 @@ func (m *MockExchange) MarketSell(ctx context.Context, symbol string, size float
 }
 
 func (m *MockExchange) ClosePosition(ctx context.Context, symbol string) error {
+	if m.CloseFailures > 0 {
+		m.CloseFailures--
+		return errors.New("close rejected")
+	}
 	m.Position = nil
 	return nil
 }
 
+func (m *MockExchange) ReducePosition(ctx context.Context, symbol string, size float64) error {
+	if m.ReduceErr != nil {
+		return m.ReduceErr
+	}
+	if m.Position == nil {
+		return nil
+	}
+	m.Position.Size -= size
+	if m.Position.Size <= 1e-9 {
+		m.Position = nil
+	}
+	return nil
+}
+
+func (m *MockExchange) SetTradingStop(ctx context.Context, symbol string, stop domain.TradingStop) error {
+	m.LastTradingStop = stop
+	return nil
+}
+
 func (m *MockExchange) GetPosition(ctx context.Context, symbol string) (*domain.Position, error) {
+	if m.PositionErr != nil {
+		return nil, m.PositionErr
+	}
 	if m.Position != nil && m.Position.Symbol == symbol {
 		return m.Position, nil
 	}
@@ -72,6 +129,9 @@ func (m *MockExchange) GetPosition(ctx context.Context, symbol string) (*domain.
 }
 
 func (m *MockExchange) GetPositions(ctx context.Context) ([]*domain.Position, error) {
+	if m.PositionsErr != nil {
+		return nil, m.PositionsErr
+	}
 	if m.Position != nil && m.Position.Size > 0 {
 		return []*domain.Position{m.Position}, nil
 	}
@@ -79,10 +139,15 @@ func (m *MockExchange) GetPositions(ctx context.Context) ([]*domain.Position, er
 }
 
 func (m *MockExchange) GetCandles(ctx context.Context, symbol, interval string, limit int) ([]domain.Candle, error) {
-	return nil, nil
+	return m.Candles, nil
 }
 
 func (m *MockExchange) GetOrderBook(ctx context.Context, symbol string, category string) (*domain.OrderBook, error) {
+	if len(m.BookSteps) > 0 {
+		ob := m.BookSteps[0]
+		m.BookSteps = m.BookSteps[1:]
+		return ob, nil
+	}
 	if m.OrderBook != nil {
 		return m.OrderBook, nil
 	}
@@ -101,7 +166,7 @@ func (m *MockExchange) GetRecentTrades(ctx context.Context, symbol string, limit
 }
 
 func (m *MockExchange) GetInstruments(ctx context.Context, category string) ([]domain.Instrument, error) {
-	return nil, nil
+	return m.Instruments, nil
 }
 
 func (m *MockExchange) Subscribe(symbols []string) error {
@@ -109,17 +174,148 @@ func (m *MockExchange) Subscribe(symbols []string) error {
 }
 
 func (m *MockExchange) PlaceOrder(ctx context.Context, order *domain.Order) (*domain.Order, error) {
-	return order, nil
+	if order.Type != "Limit" {
+		return order, nil
+	}
+	resting := *order
+	resting.OrderID = fmt.Sprintf("mock-%d", len(m.LimitOrders)+1)
+	resting.Status = "New"
+	m.LimitOrders = append(m.LimitOrders, &resting)
+	placed := resting
+	return &placed, nil
+}
+
+func (m *MockExchange) limitOrder(orderID string) *domain.Order {
+	for _, o := range m.LimitOrders {
+		if o.OrderID == orderID {
+			return o
+		}
+	}
+	return nil
 }
 
 func (m *MockExchange) GetOrder(ctx context.Context, symbol, orderID string) (*domain.Order, error) {
-	return &domain.Order{OrderID: orderID, Symbol: symbol, Status: "Filled"}, nil
+	o := m.limitOrder(orderID)
+	if o == nil {
+		return &domain.Order{OrderID: orderID, Symbol: symbol, Status: "Filled"}, nil
+	}
+	if o.TimeInForce == "ImmediateOrCancel" && o.Status == "New" {
+		if !m.FillIOC {
+			o.Status = "Cancelled"
+		} else {
+			o.Status, o.FilledSize, o.AvgFillPrice = "Filled", o.Size, o.Price
+			m.applyLimitFill(o)
+		}
+	}
+	if m.FillLimits && o.Status == "New" {
+		o.Status, o.FilledSize, o.AvgFillPrice = "Filled", o.Size, o.Price
+		m.applyLimitFill(o)
+	}
+	found := *o
+	return &found, nil
+}
+
+// applyLimitFill moves the position by a filled limit order.
+func (m *MockExchange) applyLimitFill(o *domain.Order) {
+	if o.ReduceOnly {
+		if m.Position != nil {
+			m.Position.Size -= o.Size
+			if m.Position.Size <= 1e-9 {
+				m.Position = nil
+			}
+		}
+		return
+	}
+	if m.Position == nil {
+		m.Position = &domain.Position{Symbol: o.Symbol, Side: o.Side, Size: o.Size, EntryPrice: o.Price}
+		return
+	}
+	m.Position.EntryPrice = (m.Position.EntryPrice*m.Position.Size + o.Price*o.Size) / (m.Position.Size + o.Size)
+	m.Position.Size += o.Size
 }
 
 func (m *MockExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	if o := m.limitOrder(orderID); o != nil && o.Status == "New" {
+		o.Status = "Cancelled"
+	}
 	return nil
 }
 
+func (m *MockExchange) CancelAllOrders(ctx context.Context) (int, error) {
+	n := m.OpenOrders
+	m.OpenOrders = 0
+	return n, nil
+}
+
+func (m *MockExchange) GetEquity(ctx context.Context) (*domain.AccountEquity, error) {
+	if m.Equity != nil {
+		return m.Equity, nil
+	}
+	return &domain.AccountEquity{}, nil
+}
+
+func (m *MockExchange) GetRiskLimits(ctx context.Context, symbol string) ([]domain.RiskLimitTier, error) {
+	return m.RiskLimits, nil
+}
+
 func (m *MockExchange) GetWSStatus() domain.WSStatus {
 	return domain.WSStatus{Connected: true}
 }
+
+// saveLevels saves levels with the default tiers of the symbol and loads them into the cache.
+func saveLevels(h *TestScenarioHelper, levels ...*domain.Level) {
+	for _, l := range levels {
+		if err := h.store.SaveLevel(h.ctx, l); err != nil {
+			h.t.Fatalf("Failed to save level: %v", err)
+		}
+	}
+	if err := h.store.SaveSymbolTiers(h.ctx, &domain.SymbolTiers{
+		Exchange: h.exchange, Symbol: h.symbol, Tier1Pct: 0.005, Tier2Pct: 0.003, Tier3Pct: 0.0015, UpdatedAt: time.Now(),
+	}); err != nil {
+		h.t.Fatalf("Failed to save tiers: %v", err)
+	}
+	if err := h.svc.UpdateCache(h.ctx); err != nil {
+		h.t.Fatalf("Failed to update cache: %v", err)
+	}
+}
+
+// setupProtectiveLevel saves a long-side level far below the position so tiers never trigger.
+func setupProtectiveLevel(h *TestScenarioHelper, configure func(l *domain.Level)) *domain.Level {
+	level := &domain.Level{
+		ID:                "protective-level",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        90,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		TrailingStopMode:  "app",
+		CreatedAt:         time.Now(),
+	}
+	configure(level)
+	saveLevels(h, level)
+	h.mockEx.SetPosition(h.symbol, domain.SideLong, 1.0, 100)
+	return level
+}
+
+// configureLevel changes the scenario level (see SetupLevel) and reloads the cache.
+func configureLevel(h *TestScenarioHelper, configure func(l *domain.Level)) {
+	level, err := h.store.GetLevel(h.ctx, h.levelID)
+	if err != nil {
+		h.t.Fatalf("Failed to load level: %v", err)
+	}
+	configure(level)
+	if err := h.store.UpdateLevel(h.ctx, level); err != nil {
+		h.t.Fatalf("Failed to update level: %v", err)
+	}
+	if err := h.svc.UpdateCache(h.ctx); err != nil {
+		h.t.Fatalf("Failed to update cache: %v", err)
+	}
+}
+
+// tradeThrough rebuilds the service over a wrapper of the mock exchange (breakers, risk
+// manager), the journal goes to the store. Call it before SetupLevel.
+func tradeThrough(h *TestScenarioHelper, exchange domain.Exchange) {
+	h.svc = usecase.NewLevelService(h.store, h.store, exchange, usecase.NewMarketService(h.mockEx, h.store))
+	h.svc.SetDecisionRepository(h.store)
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+func TestDecisionJournal_RecordsEntryAndExit(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetDecisionRepository(h.store)
+	h.SetupLevel(10000, true)
+
+	// 1. T1 short entry is journaled with its inputs
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(1)
+
+	entries, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one tier decision, got %d %v", len(entries), err)
+	}
+	entry := entries[0]
+	if entry.Outcome != domain.OutcomeExecuted || entry.Side != domain.SideShort || entry.Price != 9960 || entry.LevelPrice != 10000 {
+		t.Errorf("Unexpected tier decision %+v", entry)
+	}
+	if _, ok := entry.Inputs["state"]; !ok {
+		t.Errorf("Expected state snapshot in inputs, got %v", entry.Inputs)
+	}
+	if _, ok := entry.Inputs["tiers"]; !ok {
+		t.Errorf("Expected tiers in inputs, got %v", entry.Inputs)
+	}
+
+	// 2. Stop loss at base is journaled as an exit with its reason and PnL
+	h.Tick(10000)
+	exits, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionExit})
+	if err != nil || len(exits) != 1 {
+		t.Fatalf("Expected one exit decision, got %d %v", len(exits), err)
+	}
+	if exits[0].Outcome != domain.OutcomeClosed || exits[0].Reason != "Stop Loss (Base)" {
+		t.Errorf("Unexpected exit decision %+v", exits[0])
+	}
+	if _, ok := exits[0].Inputs["realized_pnl"]; !ok {
+		t.Errorf("Expected realized PnL in exit inputs, got %v", exits[0].Inputs)
+	}
+
+	// 3. Filters narrow the journal
+	all, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{Symbol: h.symbol})
+	if len(all) != 2 || all[0].Kind != domain.DecisionExit {
+		t.Errorf("Expected exit then entry, newest first, got %d decisions", len(all))
+	}
+	if none, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{Symbol: "ETHUSDT"}); len(none) != 0 {
+		t.Errorf("Expected symbol filter to exclude other symbols, got %d", len(none))
+	}
+	if future, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{From: time.Now().Add(time.Hour)}); len(future) != 0 {
+		t.Errorf("Expected time filter to exclude older decisions, got %d", len(future))
+	}
+	if limited, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{Limit: 1}); len(limited) != 1 {
+		t.Errorf("Expected limit to cap the result, got %d", len(limited))
+	}
+}
+
+func TestDecisionJournal_RecordsFilterRejection(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetDecisionRepository(h.store)
+	h.SetupLevel(10000, false)
+
+	level, err := h.store.GetLevel(h.ctx, h.levelID)
+	if err != nil {
+		t.Fatalf("Failed to load level: %v", err)
+	}
+	level.EntryFilters = []string{domain.EntryFilterSlippage}
+	if err := h.store.UpdateLevel(h.ctx, level); err != nil {
+		t.Fatalf("Failed to update level: %v", err)
+	}
+	h.svc.UpdateCache(h.ctx)
+
+	// The bid side cannot absorb the short
+	h.mockEx.OrderBook = &domain.OrderBook{
+		Bids: []domain.OrderBookEntry{{Price: 9959, Size: 0.01}},
+		Asks: []domain.OrderBookEntry{{Price: 9961, Size: 10}},
+	}
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(0)
+
+	rejections, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Outcome: domain.OutcomeRejected})
+	if err != nil || len(rejections) != 1 {
+		t.Fatalf("Expected one rejection, got %d %v", len(rejections), err)
+	}
+	if rejections[0].Kind != domain.DecisionFilterRejection || rejections[0].Reason == "" {
+		t.Errorf("Unexpected rejection %+v", rejections[0])
+	}
+	if _, ok := rejections[0].Inputs["filters"]; !ok {
+		t.Errorf("Expected filter decisions in inputs, got %v", rejections[0].Inputs)
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+// rangeCandles oscillates between low and high, ten candles per leg.
+func rangeCandles(low, high float64, legs int) []domain.Candle {
+	var candles []domain.Candle
+	prev := low
+	for leg := 0; leg < legs; leg++ {
+		from, to := low, high
+		if leg%2 == 1 {
+			from, to = high, low
+		}
+		for i := 1; i <= 10; i++ {
+			price := from + (to-from)*float64(i)/10
+			candles = append(candles, domain.Candle{
+				Time:   int64(len(candles)) * 60000,
+				Open:   prev,
+				Close:  price,
+				High:   math.Max(prev, price) + 0.05,
+				Low:    math.Min(prev, price) - 0.05,
+				Volume: 10,
+			})
+			prev = price
+		}
+	}
+	return candles
+}
+
+func TestLevelDiscovery_ProposeAndAccept(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.mockEx.Candles = rangeCandles(100, 110, 8)
+	h.Tick(105)
+
+	// An existing level at the range high is not proposed again, its settings are copied
+	manual := &domain.Level{ID: "manual", Exchange: h.exchange, Symbol: h.symbol, LevelPrice: 110, BaseSize: 0.2, Leverage: 7, MarginType: "cross", ExternalKey: "tv-110", CreatedAt: time.Now()}
+	if err := h.svc.CreateLevel(h.ctx, manual); err != nil {
+		t.Fatalf("Failed to create level: %v", err)
+	}
+
+	cfg := usecase.DefaultDiscoveryConfig()
+	cfg.MinConfidence = 0.8
+	discovery := usecase.NewLevelDiscoveryService(h.svc, usecase.NewMarketService(h.mockEx, h.store), cfg)
+
+	proposals, err := discovery.Discover(h.ctx, h.exchange, h.symbol)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(proposals) != 1 || math.Abs(proposals[0].Price-100) > 0.5 || proposals[0].Side != "support" {
+		t.Fatalf("Expected one support proposal near 100, got %+v", proposals)
+	}
+
+	level, err := discovery.AcceptProposal(h.ctx, proposals[0].ID, usecase.LevelPatch{})
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	if level.IsAuto || level.Source != "discovery" || level.BaseSize != 0.2 || level.Leverage != 7 || level.ExternalKey != "" {
+		t.Errorf("Expected a reviewed level with copied settings, got %+v", level)
+	}
+	if _, err := discovery.AcceptProposal(h.ctx, proposals[0].ID, usecase.LevelPatch{}); err != usecase.ErrProposalNotFound {
+		t.Errorf("Expected accepted proposal to be closed, got %v", err)
+	}
+	if got := discovery.Proposals(h.symbol); len(got) != 1 || got[0].Status != usecase.ProposalAccepted || got[0].LevelID != level.ID {
+		t.Errorf("Expected accepted proposal, got %+v", got)
+	}
+
+	// Rediscovery skips both zones now that they have levels
+	if proposals, _ := discovery.Discover(h.ctx, h.exchange, h.symbol); len(proposals) != 0 {
+		t.Errorf("Expected no new proposals, got %+v", proposals)
+	}
+}
+
+func TestLevelDiscovery_AutoCreateNeedsSettings(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.mockEx.Candles = rangeCandles(100, 110, 8)
+	h.Tick(105)
+
+	cfg := usecase.DefaultDiscoveryConfig()
+	cfg.AutoCreate = true
+	discovery := usecase.NewLevelDiscoveryService(h.svc, usecase.NewMarketService(h.mockEx, h.store), cfg)
+
+	// No level to copy size and leverage from: proposals stay pending
+	proposals, err := discovery.Discover(h.ctx, h.exchange, h.symbol)
+	if err != nil || len(proposals) == 0 {
+		t.Fatalf("Expected proposals, got %+v %v", proposals, err)
+	}
+	if levels, _ := h.store.ListLevels(h.ctx); len(levels) != 0 {
+		t.Fatalf("Expected no auto levels without settings, got %d", len(levels))
+	}
+
+	size, leverage := 0.1, 5
+	level, err := discovery.AcceptProposal(h.ctx, proposals[0].ID, usecase.LevelPatch{BaseSize: &size, Leverage: &leverage})
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+
+	// With a level to copy from, confident proposals are created as auto levels
+	if _, err := discovery.Discover(h.ctx, h.exchange, h.symbol); err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	levels, _ := h.store.ListLevels(h.ctx)
+	auto := 0
+	for _, l := range levels {
+		if l.IsAuto && l.Source == "discovery" && l.BaseSize == level.BaseSize {
+			auto++
+		}
+	}
+	if auto == 0 {
+		t.Errorf("Expected auto-created discovery levels, got %+v", levels)
+	}
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+func TestEntryFilters_PersistedInOrder(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	filters, err := domain.ParseEntryFilters("Trend, sentiment, trend")
+	if err != nil {
+		t.Fatalf("Failed to parse filters: %v", err)
+	}
+	level := &domain.Level{
+		ID:           "filters-level",
+		Exchange:     h.exchange,
+		Symbol:       h.symbol,
+		LevelPrice:   100,
+		BaseSize:     1,
+		EntryFilters: filters,
+		CreatedAt:    time.Now(),
+	}
+	if err := h.store.SaveLevel(h.ctx, level); err != nil {
+		t.Fatalf("Failed to save level: %v", err)
+	}
+
+	saved, err := h.store.GetLevel(h.ctx, level.ID)
+	if err != nil {
+		t.Fatalf("Failed to load level: %v", err)
+	}
+	if want := []string{"trend", "sentiment"}; !reflect.DeepEqual(saved.EntryFilterChain(), want) {
+		t.Errorf("Expected chain %v, got %v", want, saved.EntryFilterChain())
+	}
+
+	// Levels without a list keep the original sentiment gate, "none" disables it
+	if chain := (&domain.Level{}).EntryFilterChain(); !reflect.DeepEqual(chain, domain.DefaultEntryFilters) {
+		t.Errorf("Expected default chain, got %v", chain)
+	}
+	if chain := (&domain.Level{EntryFilters: []string{domain.EntryFilterNone}}).EntryFilterChain(); len(chain) != 0 {
+		t.Errorf("Expected no filters for none, got %v", chain)
+	}
+}
+
+func TestParseEntryFilters_Invalid(t *testing.T) {
+	if _, err := domain.ParseEntryFilters("sentiment, moon"); err == nil {
+		t.Errorf("Expected error for unknown filter")
+	}
+	if _, err := domain.ParseEntryFilters("none, trend"); err == nil {
+		t.Errorf("Expected error when combining none with other filters")
+	}
+}
@@ -0,0 +1,370 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+// setupAlgoLevel gives the scenario level an execution algorithm over a book quoted
+// 9958 / 9962. Resting children are checked every 5ms.
+func setupAlgoLevel(h *TestScenarioHelper, algo string, durationSec int, clip float64) *usecase.ExecutionManager {
+	h.svc.SetDecisionRepository(h.store)
+	h.mockEx.OrderBook = &domain.OrderBook{
+		Symbol: h.symbol,
+		Bids:   []domain.OrderBookEntry{{Price: 9958, Size: 5}},
+		Asks:   []domain.OrderBookEntry{{Price: 9962, Size: 5}},
+	}
+	h.SetupLevel(10000, true)
+	configureLevel(h, func(l *domain.Level) {
+		l.ExecAlgo = algo
+		l.ExecDurationSec = durationSec
+		l.ExecClipSize = clip
+	})
+	manager := h.svc.ExecutionManager()
+	manager.SetPollInterval(5 * time.Millisecond)
+	return manager
+}
+
+func TestExecutionAlgo_TWAPEntrySendsSlices(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecTWAP, 1, 0.02)
+
+	h.Tick(9900)
+	start := time.Now()
+	h.Tick(9960)
+
+	if h.mockEx.Position == nil || math.Abs(h.mockEx.Position.Size-0.1) > 1e-9 {
+		t.Fatalf("Expected the full 0.1 short, got %+v", h.mockEx.Position)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Expected the slices spread over 1s, took %v", elapsed)
+	}
+	h.AssertTradeCount(1)
+	h.AssertLastTrade(domain.SideShort, 0.1)
+
+	orders := manager.List()
+	if len(orders) != 1 || orders[0].Status != usecase.ParentFilled || orders[0].Children != 5 || orders[0].Algo != domain.ExecTWAP {
+		t.Fatalf("Expected one filled TWAP parent of 5 slices, got %+v", orders)
+	}
+	entries, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	exec, ok := entries[0].Inputs["execution"].(map[string]interface{})
+	if !ok || exec["parent_order_id"] != orders[0].ID {
+		t.Errorf("Expected the journal to point at parent %s, got %v", orders[0].ID, entries[0].Inputs["execution"])
+	}
+}
+
+func TestExecutionAlgo_IcebergEntryRestsClips(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecIceberg, 60, 0.04)
+	h.mockEx.FillLimits = true
+
+	h.Tick(9900)
+	h.Tick(9960)
+
+	// A short rests at the best ask, one clip at a time
+	if len(h.mockEx.LimitOrders) != 3 {
+		t.Fatalf("Expected 3 clips, got %d", len(h.mockEx.LimitOrders))
+	}
+	for i, want := range []float64{0.04, 0.04, 0.02} {
+		o := h.mockEx.LimitOrders[i]
+		if math.Abs(o.Size-want) > 1e-9 || o.Price != 9962 || o.TimeInForce != "PostOnly" || o.Side != domain.SideShort || o.ReduceOnly {
+			t.Errorf("Unexpected clip %d: %+v", i, o)
+		}
+	}
+	if h.mockEx.SellCalled {
+		t.Errorf("Expected no market order")
+	}
+
+	trades, _ := h.store.ListTrades(h.ctx, 1)
+	if len(trades) != 1 || math.Abs(trades[0].Size-0.1) > 1e-9 || trades[0].FillPrice != 9962 || trades[0].ExpectedPrice != 9962 {
+		t.Fatalf("Expected the entry booked at the resting price, got %+v", trades)
+	}
+	if orders := manager.List(); orders[0].Status != usecase.ParentFilled || orders[0].AvgPrice != 9962 {
+		t.Errorf("Expected a filled parent at 9962, got %+v", orders[0])
+	}
+}
+
+func TestExecutionAlgo_ChaseSendsRestAtDeadline(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 1, 0)
+
+	h.Tick(9900)
+	h.Tick(9960)
+
+	// Nothing filled on the book, the resting child is pulled and the rest goes at market
+	if len(h.mockEx.LimitOrders) != 1 || h.mockEx.LimitOrders[0].Status != "Cancelled" {
+		t.Fatalf("Expected the resting child cancelled, got %+v", h.mockEx.LimitOrders)
+	}
+	if !h.mockEx.SellCalled || h.mockEx.Position == nil || math.Abs(h.mockEx.Position.Size-0.1) > 1e-9 {
+		t.Fatalf("Expected 0.1 sold at market, got %+v", h.mockEx.Position)
+	}
+	if orders := manager.List(); orders[0].Status != usecase.ParentFilled || orders[0].Children != 2 {
+		t.Errorf("Expected a filled parent of 2 orders, got %+v", orders[0])
+	}
+	h.AssertLastTrade(domain.SideShort, 0.1)
+}
+
+func TestExecutionAlgo_CancelStopsEntry(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 60, 0)
+
+	h.Tick(9900)
+	if err := h.svc.ProcessTick(h.ctx, h.exchange, h.symbol, 9960); err != nil {
+		t.Fatalf("ProcessTick failed: %v", err)
+	}
+	waitFor(t, func() bool {
+		orders := manager.List()
+		return len(orders) == 1 && orders[0].WorkingID != ""
+	})
+	if err := manager.Cancel(manager.List()[0].ID, usecase.CancelledByUser); err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	h.svc.WaitEntries()
+
+	order := manager.List()[0]
+	if order.Status != usecase.ParentCancelled || order.CancelledBy != usecase.CancelledByUser || order.Filled != 0 {
+		t.Fatalf("Expected the parent cancelled with nothing filled, got %+v", order)
+	}
+	if h.mockEx.LimitOrders[0].Status != "Cancelled" || h.mockEx.SellCalled {
+		t.Errorf("Expected the child pulled and no market order")
+	}
+	h.AssertTradeCount(0)
+	entries, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if len(entries) != 1 || entries[0].Outcome != domain.OutcomeFailed {
+		t.Errorf("Expected the entry journaled as failed, got %+v", entries)
+	}
+	if err := manager.Cancel(order.ID, usecase.CancelledByUser); err == nil {
+		t.Errorf("Expected a finished parent not to cancel again")
+	}
+}
+
+func TestExecutionAlgo_EntryDoesNotBlockTicksOrExits(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 60, 0)
+
+	// 1. The tick that triggers the entry returns while the parent order rests
+	h.Tick(9900)
+	start := time.Now()
+	h.mockEx.Price = 9960
+	if err := h.svc.ProcessTick(h.ctx, h.exchange, h.symbol, 9960); err != nil {
+		t.Fatalf("ProcessTick failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected the tick not to wait on the parent order, took %v", elapsed)
+	}
+	waitFor(t, func() bool {
+		orders := manager.List()
+		return len(orders) == 1 && orders[0].WorkingID != ""
+	})
+
+	// 2. Later ticks and an exit still go through, the exit cancels the entry
+	if err := h.svc.ProcessTick(h.ctx, h.exchange, h.symbol, 9955); err != nil {
+		t.Fatalf("ProcessTick failed: %v", err)
+	}
+	if err := h.svc.ClosePosition(h.ctx, h.symbol); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	h.svc.WaitEntries()
+
+	if order := manager.List()[0]; order.Status != usecase.ParentCancelled || order.CancelledBy != usecase.CancelledByExit {
+		t.Fatalf("Expected the entry cancelled by the exit, got %+v", order)
+	}
+	if h.mockEx.Position != nil {
+		t.Errorf("Expected no position, got %+v", h.mockEx.Position)
+	}
+	entries, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if len(entries) != 1 || entries[0].Outcome != domain.OutcomeFailed {
+		t.Errorf("Expected the entry journaled as failed, got %+v", entries)
+	}
+}
+
+func TestExecutionAlgo_TakeProfitExitIceberg(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecIceberg, 60, 0.05)
+	h.mockEx.FillLimits = true
+
+	configureLevel(h, func(l *domain.Level) { l.TakeProfitPct = 0.01 })
+	h.mockEx.SetPosition(h.symbol, domain.SideShort, 0.1, 9960)
+
+	h.Tick(9850) // TP at 9960 * 0.99 = 9860.4
+
+	// Bought back at the best bid in two reduce-only clips
+	if h.mockEx.Position != nil {
+		t.Fatalf("Expected the short closed, got %+v", h.mockEx.Position)
+	}
+	if len(h.mockEx.LimitOrders) != 2 {
+		t.Fatalf("Expected 2 exit clips, got %d", len(h.mockEx.LimitOrders))
+	}
+	for _, o := range h.mockEx.LimitOrders {
+		if !o.ReduceOnly || o.Side != domain.SideLong || o.Price != 9958 {
+			t.Errorf("Unexpected exit clip %+v", o)
+		}
+	}
+	orders := manager.List()
+	if len(orders) != 1 || orders[0].Purpose != usecase.PurposeExit || orders[0].Status != usecase.ParentFilled {
+		t.Errorf("Expected one filled exit parent, got %+v", orders)
+	}
+}
+
+// setMakerOptions gives the algo level a chase limit and a deadline fallback, prices tick 0.5.
+func setMakerOptions(h *TestScenarioHelper, maxChasePct float64, fallback string) {
+	h.mockEx.Instruments = []domain.Instrument{{Symbol: h.symbol, TickSize: 0.5}}
+	configureLevel(h, func(l *domain.Level) {
+		l.ExecMaxChasePct = maxChasePct
+		l.ExecFallback = fallback
+	})
+}
+
+func TestExecutionAlgo_ChaseHoldsAtMaxChase(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 1, 0)
+	setMakerOptions(h, 0.001, domain.ExecFallbackMarket)
+
+	// The ask falls from 9962 to 9940, past the 0.1% chase limit of 9952.038 (9952.5 on the tick)
+	fallen := &domain.OrderBook{
+		Symbol: h.symbol,
+		Bids:   []domain.OrderBookEntry{{Price: 9938, Size: 5}},
+		Asks:   []domain.OrderBookEntry{{Price: 9940, Size: 5}},
+	}
+	h.mockEx.BookSteps = []*domain.OrderBook{h.mockEx.OrderBook}
+	h.mockEx.OrderBook = fallen
+
+	h.Tick(9900)
+	h.Tick(9960)
+
+	if len(h.mockEx.LimitOrders) != 2 {
+		t.Fatalf("Expected the child at the touch and one re-price, got %+v", h.mockEx.LimitOrders)
+	}
+	if first, held := h.mockEx.LimitOrders[0], h.mockEx.LimitOrders[1]; first.Price != 9962 || held.Price != 9952.5 || held.TimeInForce != "PostOnly" {
+		t.Fatalf("Expected the short re-priced to the chase limit only, got %v then %v", first.Price, held.Price)
+	}
+	if !h.mockEx.SellCalled {
+		t.Errorf("Expected the rest sold at market at the deadline")
+	}
+	order := manager.List()[0]
+	if order.ChaseLimit != 9952.5 || order.Fallback != domain.ExecFallbackMarket || order.TakerFilled != 0.1 || order.MakerRatio() != 0 {
+		t.Errorf("Expected a taker fill held at 9952.5, got %+v", order)
+	}
+}
+
+func TestExecutionAlgo_IOCFallbackAtChaseLimit(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 1, 0)
+	setMakerOptions(h, 0.001, domain.ExecFallbackIOC)
+	h.mockEx.FillIOC = true
+
+	h.Tick(9900)
+	h.Tick(9960)
+
+	// Nothing filled resting, the rest crosses as IOC at the limit and never at market
+	if len(h.mockEx.LimitOrders) != 2 {
+		t.Fatalf("Expected the resting child and the IOC, got %+v", h.mockEx.LimitOrders)
+	}
+	ioc := h.mockEx.LimitOrders[1]
+	if ioc.TimeInForce != "ImmediateOrCancel" || ioc.Price != 9952.5 || math.Abs(ioc.Size-0.1) > 1e-9 {
+		t.Fatalf("Expected an IOC of 0.1 at 9952.5, got %+v", ioc)
+	}
+	if h.mockEx.SellCalled {
+		t.Errorf("Expected no market order")
+	}
+	h.AssertLastTrade(domain.SideShort, 0.1)
+	if order := manager.List()[0]; order.Status != usecase.ParentFilled || order.TakerFilled != 0.1 || order.AvgPrice != 9952.5 {
+		t.Errorf("Expected a parent filled by the IOC, got %+v", order)
+	}
+}
+
+func TestExecutionAlgo_IOCFallbackDropsUnfilledRest(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 1, 0)
+	setMakerOptions(h, 0.001, domain.ExecFallbackIOC)
+
+	h.Tick(9900)
+	h.Tick(9960)
+
+	if h.mockEx.SellCalled || h.mockEx.Position != nil {
+		t.Fatalf("Expected no fill at all, got %+v", h.mockEx.Position)
+	}
+	order := manager.List()[0]
+	if order.Status != usecase.ParentFailed || order.Filled != 0 {
+		t.Errorf("Expected the parent failed with nothing filled, got %+v", order)
+	}
+	h.AssertTradeCount(0)
+}
+
+func TestExecutionAlgo_ReportsMakerFillsAndFeesSaved(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 60, 0)
+	h.mockEx.FillLimits = true
+
+	h.Tick(9900)
+	h.Tick(9960)
+
+	order := manager.List()[0]
+	saved := 0.1 * 9962 * (usecase.DefaultFeeRates.Taker - usecase.DefaultFeeRates.Maker)
+	if order.MakerFilled != 0.1 || order.MakerRatio() != 1 || math.Abs(order.FeesSaved-saved) > 1e-9 {
+		t.Fatalf("Expected 0.1 filled as maker saving %f, got %+v", saved, order)
+	}
+
+	report := manager.Report()
+	if report.Total.Orders != 1 || report.Total.MakerRatio != 1 || math.Abs(report.Total.FeesSaved-saved) > 1e-9 {
+		t.Errorf("Expected the report to count the maker fill, got %+v", report.Total)
+	}
+	if len(report.Symbols) != 1 || report.Symbols[0].Symbol != h.symbol {
+		t.Errorf("Expected one symbol in the report, got %+v", report.Symbols)
+	}
+}
+
+func TestExecutionAlgo_TakeProfitExitDoesNotBlockTicksOrStops(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	manager := setupAlgoLevel(h, domain.ExecChase, 60, 0)
+	configureLevel(h, func(l *domain.Level) {
+		l.TakeProfitPct = 0.01
+		l.StopLossAtBase = false
+	})
+	h.mockEx.SetPosition(h.symbol, domain.SideShort, 0.1, 9960)
+
+	// 1. The take profit tick returns while the exit rests at the bid
+	h.Tick(9900)
+	start := time.Now()
+	if err := h.svc.ProcessTick(h.ctx, h.exchange, h.symbol, 9850); err != nil {
+		t.Fatalf("ProcessTick failed: %v", err)
+	}
+	waitFor(t, func() bool {
+		orders := manager.List()
+		return len(orders) == 1 && orders[0].WorkingID != ""
+	})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected the tick not to wait on the exit, took %v", elapsed)
+	}
+
+	// 2. Later ticks go through without a second exit
+	if err := h.svc.ProcessTick(h.ctx, h.exchange, h.symbol, 9855); err != nil {
+		t.Fatalf("ProcessTick failed: %v", err)
+	}
+	if err := h.svc.ProcessTick(h.ctx, h.exchange, h.symbol, 10020); err != nil {
+		t.Fatalf("ProcessTick failed: %v", err)
+	}
+
+	// 3. A safety exit sends the rest at market and books the share once
+	h.svc.CheckSafety(h.ctx)
+	h.svc.WaitExits()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Expected the exit not to wait out the chase, took %v", elapsed)
+	}
+
+	orders := manager.List()
+	if len(orders) != 1 || orders[0].Purpose != usecase.PurposeExit || orders[0].CancelledBy != usecase.CancelledByMarketExit {
+		t.Fatalf("Expected the one exit parent cancelled by the market exit, got %+v", orders)
+	}
+	if h.mockEx.Position != nil {
+		t.Errorf("Expected no position, got %+v", h.mockEx.Position)
+	}
+	history, _ := h.store.ListPositionHistory(h.ctx, 10)
+	if len(history) != 1 || history[0].Reason != "Take Profit" {
+		t.Errorf("Expected one take profit history row, got %+v", history)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func TestFees_TakeProfitWaitsForFeesAndReportsNetPnL(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetFeeModel(usecase.FeeModel{Rates: usecase.FeeRates{Maker: 0.0005, Taker: 0.001}, MinNetProfitPct: 0.001})
+	h.svc.SetShadowRepository(h.store) // Sums the performance
+	h.svc.SetDecisionRepository(h.store)
+
+	level := &domain.Level{
+		ID:                "fee-level",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        10000,
+		BaseSize:          0.1,
+		DisableSpeedClose: true,
+		TakeProfitPct:     0.001, // 0.1%, less than the round-trip fees
+		TakeProfitMode:    "fixed",
+		CreatedAt:         time.Now(),
+	}
+	saveLevels(h, level)
+
+	// 1. T1 short at 9960 pays the taker fee
+	h.Tick(9900)
+	h.Tick(9960)
+	trades, err := h.store.ListTrades(h.ctx, 10)
+	if err != nil || len(trades) != 1 {
+		t.Fatalf("Expected the entry trade, got %d %v", len(trades), err)
+	}
+	if math.Abs(trades[0].Fee-0.996) > 1e-9 {
+		t.Errorf("Expected entry fee 0.996, got %f", trades[0].Fee)
+	}
+
+	// 2. The fixed 0.1% target would close at a net loss, the floor is ~0.3% away
+	h.Tick(9945)
+	if h.mockEx.Position == nil {
+		t.Fatalf("Expected the take profit to wait for the fee floor")
+	}
+
+	// 3. Past the floor the exit books gross PnL and both fees
+	h.Tick(9930)
+	if h.mockEx.Position != nil {
+		t.Fatalf("Expected the take profit past the fee floor, position %+v", h.mockEx.Position)
+	}
+	history, err := h.store.ListPositionHistory(h.ctx, 10)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected one history row, got %d %v", len(history), err)
+	}
+	row := history[0]
+	if math.Abs(row.RealizedPnL-3) > 1e-9 || math.Abs(row.Fees-1.989) > 1e-9 || math.Abs(row.NetPnL()-1.011) > 1e-9 {
+		t.Errorf("Expected gross 3, fees 1.989, net 1.011, got %f, %f, %f", row.RealizedPnL, row.Fees, row.NetPnL())
+	}
+
+	trades, _ = h.store.ListTrades(h.ctx, 10)
+	if len(trades) != 2 || math.Abs(trades[0].Fee-1.989) > 1e-9 || math.Abs(trades[0].NetPnL()-1.011) > 1e-9 {
+		t.Errorf("Expected the close trade with fees 1.989 and net 1.011, got %+v", trades[0])
+	}
+
+	exits, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: level.ID, Kind: domain.DecisionExit})
+	if err != nil || len(exits) != 1 {
+		t.Fatalf("Expected one exit decision, got %d %v", len(exits), err)
+	}
+	if net, ok := exits[0].Inputs["net_pnl"].(float64); !ok || math.Abs(net-1.011) > 1e-9 {
+		t.Errorf("Expected net PnL 1.011 in the exit inputs, got %v", exits[0].Inputs)
+	}
+
+	perf, err := h.svc.LevelPerformance(h.ctx)
+	if err != nil || len(perf) != 1 {
+		t.Fatalf("Expected the level's performance, got %d %v", len(perf), err)
+	}
+	if perf[0].Live.Wins != 1 || math.Abs(perf[0].Live.Fees-1.989) > 1e-9 || math.Abs(perf[0].TotalPnL()-1.011) > 1e-9 {
+		t.Errorf("Expected one net win and total 1.011, got %+v (total %f)", perf[0].Live, perf[0].TotalPnL())
+	}
+}
+
+func TestFees_NetLossIsNotAWin(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetFeeModel(usecase.FeeModel{Rates: usecase.FeeRates{Maker: 0.0005, Taker: 0.001}, MinNetProfitPct: 0.001})
+	h.svc.SetShadowRepository(h.store) // Sums the performance
+	h.SetupLevel(10000, false)
+
+	// A manual close 1 below the entry is gross positive but pays ~2 in fees
+	h.Tick(9900)
+	h.Tick(9960)
+	h.Tick(9959)
+	if err := h.svc.ClosePosition(h.ctx, h.symbol); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	perf, err := h.svc.LevelPerformance(h.ctx)
+	if err != nil || len(perf) != 1 {
+		t.Fatalf("Expected the level's performance, got %d %v", len(perf), err)
+	}
+	if perf[0].Live.Exits != 1 || perf[0].Live.Wins != 0 || perf[0].Live.RealizedPnL <= 0 || perf[0].Live.NetPnL() >= 0 {
+		t.Errorf("Expected a gross win counted as a net loss, got %+v", perf[0].Live)
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+	"go.uber.org/zap"
+)
+
+func TestKillSwitch_FlattensHaltsAndStopsBots(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetDecisionRepository(h.store)
+	h.SetupLevel(10000, true)
+
+	risk := usecase.NewRiskManager(h.mockEx, h.store, domain.RiskLimits{})
+	h.svc.SetRiskManager(risk)
+	runtime := usecase.NewStrategyRuntime(h.mockEx, usecase.NewMarketService(h.mockEx, h.store), h.store, zap.NewNop())
+	defer runtime.Shutdown(context.Background())
+	speed := usecase.NewSpeedBotService(runtime, h.mockEx, usecase.NewMarketService(h.mockEx, h.store), zap.NewNop())
+	if err := speed.StartBot(h.ctx, usecase.SpeedBotConfig{Symbol: "ETHUSDT", PositionSize: 1, Cooldown: time.Minute}); err != nil {
+		t.Fatalf("Failed to start speed bot: %v", err)
+	}
+
+	// An open position whose first close is rejected, and two resting orders
+	h.mockEx.SetPosition(h.symbol, domain.SideShort, 0.2, 9960)
+	h.mockEx.CloseFailures = 1
+	h.mockEx.OpenOrders = 2
+
+	ks := usecase.NewKillSwitch(h.mockEx, risk, runtime, h.svc, nil)
+	ks.SetRetryPolicy(3, 0)
+	report, err := ks.Trigger(h.ctx, "test", "incident")
+	if err != nil {
+		t.Fatalf("Kill switch failed: %v", err)
+	}
+
+	// 1. Flat after a retry, orders cancelled, bot stopped and not restored on restart
+	if !report.Flat || len(report.Positions) != 1 || report.Positions[0].Attempts != 2 {
+		t.Errorf("Expected one position closed on the second attempt, got %+v", report)
+	}
+	if h.mockEx.Position != nil {
+		t.Errorf("Expected no position, got %+v", h.mockEx.Position)
+	}
+	if report.CancelledOrders != 2 {
+		t.Errorf("Expected 2 cancelled orders, got %d", report.CancelledOrders)
+	}
+	if len(report.StoppedStrategies) != 1 || report.StoppedStrategies[0] != "speed:ETHUSDT" {
+		t.Errorf("Expected speed bot stopped, got %v", report.StoppedStrategies)
+	}
+	rec, err := h.store.GetStrategy(h.ctx, "speed:ETHUSDT")
+	if err != nil || rec == nil || rec.Running {
+		t.Errorf("Expected stopped speed bot to be persisted as not running, got %+v %v", rec, err)
+	}
+
+	// 2. The halt is persisted
+	if !usecase.NewRiskManager(h.mockEx, h.store, domain.RiskLimits{}).IsHalted() {
+		t.Errorf("Expected the halt to survive a restart")
+	}
+
+	// 3. Live entries are skipped while halted
+	h.Tick(9900)
+	h.Tick(9960)
+	if h.mockEx.BuyCalled || h.mockEx.SellCalled {
+		t.Errorf("Expected no entry order while halted")
+	}
+	entries, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one tier decision, got %d %v", len(entries), err)
+	}
+	if entries[0].Outcome != domain.OutcomeSkipped || !strings.Contains(entries[0].Reason, "kill switch by test: incident") {
+		t.Errorf("Expected entry skipped by the halt, got %s (%s)", entries[0].Outcome, entries[0].Reason)
+	}
+}
+
+func TestKillSwitch_ReportsPositionThatWouldNotClose(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	risk := usecase.NewRiskManager(h.mockEx, h.store, domain.RiskLimits{})
+	h.mockEx.SetPosition(h.symbol, domain.SideLong, 0.5, 10000)
+	h.mockEx.CloseFailures = 10
+
+	ks := usecase.NewKillSwitch(h.mockEx, risk, nil, h.svc, nil)
+	ks.SetRetryPolicy(2, 0)
+	report, err := ks.Trigger(h.ctx, "test", "")
+	if err == nil {
+		t.Fatalf("Expected an error while a position is still open")
+	}
+	if report.Flat || len(report.Positions) != 1 || report.Positions[0].Closed || report.Positions[0].Attempts != 2 {
+		t.Errorf("Expected the open position in the report after 2 attempts, got %+v", report)
+	}
+	if !risk.IsHalted() {
+		t.Errorf("Expected trading halted even though the account is not flat")
+	}
+	if ks.LastReport() != report {
+		t.Errorf("Expected the report to be kept for the UI")
+	}
+}
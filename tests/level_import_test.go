@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func countActions(res *usecase.ImportResult) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range res.Changes {
+		counts[c.Action]++
+	}
+	return counts
+}
+
+func TestLevelExport_RoundTrip(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	level := &domain.Level{
+		ID:               "export-1",
+		Exchange:         h.exchange,
+		Symbol:           h.symbol,
+		LevelPrice:       100,
+		BaseSize:         0.5,
+		Leverage:         10,
+		MarginType:       "isolated",
+		StopLossMode:     "exchange",
+		TakeProfitPct:    0.015,
+		TakeProfitMode:   "fixed",
+		TakeProfitLadder: []domain.TakeProfitStep{{ProfitPct: 0.01, ClosePct: 0.5}},
+		TrailingStopMode: "app",
+		EntryFilters:     []string{"trend", "sentiment"},
+		CreatedAt:        time.Now(),
+	}
+	// The other take profit modes, one without a take profit percentage
+	liquidity := *level
+	liquidity.ID, liquidity.LevelPrice = "export-liquidity", 110
+	liquidity.TakeProfitMode, liquidity.TakeProfitPct = domain.TakeProfitLiquidity, 0
+	sentiment := *level
+	sentiment.ID, sentiment.LevelPrice = "export-sentiment", 120
+	sentiment.TakeProfitMode, sentiment.TakeProfitPct = domain.TakeProfitSentiment, 0.03
+	for _, l := range []*domain.Level{level, &liquidity, &sentiment} {
+		if err := h.svc.CreateLevel(h.ctx, l); err != nil {
+			t.Fatalf("Failed to create level: %v", err)
+		}
+	}
+	if err := h.svc.UpdateTiers(h.ctx, domain.DefaultSymbolTiers(h.exchange, h.symbol)); err != nil {
+		t.Fatalf("Failed to set tiers: %v", err)
+	}
+
+	recs, err := h.svc.ExportLevels(h.ctx)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	for _, format := range []string{usecase.FormatJSON, usecase.FormatYAML, usecase.FormatCSV} {
+		var buf bytes.Buffer
+		if err := usecase.EncodeLevelRecords(&buf, format, recs); err != nil {
+			t.Fatalf("%s: failed to encode: %v", format, err)
+		}
+		res, err := h.svc.ImportLevelsFrom(h.ctx, &buf, format, usecase.ImportOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("%s: failed to import: %v", format, err)
+		}
+		if counts := countActions(res); counts[usecase.ImportUnchanged] != 3 || len(res.Changes) != 3 || len(res.Tiers) != 0 || len(res.Errors) != 0 {
+			t.Errorf("%s: expected the export to re-import unchanged, got %+v", format, res)
+		}
+	}
+}
+
+func TestLevelImport_DryRunErrorsAndPrune(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	old := &domain.Level{ID: "old-level", Exchange: h.exchange, Symbol: "ETHUSDT", LevelPrice: 2000, BaseSize: 1, Leverage: 5, MarginType: "cross", CreatedAt: time.Now()}
+	if err := h.svc.CreateLevel(h.ctx, old); err != nil {
+		t.Fatalf("Failed to create level: %v", err)
+	}
+
+	csvWithError := `key,exchange,symbol,level_price,base_size,leverage,margin_type,take_profit_pct,tier1_pct,tier2_pct,tier3_pct
+btc-100,bybit,BTCUSDT,100,1,10,isolated,2,0.5,0.3,0.15
+btc-110,bybit,BTCUSDT,110,abc,10,isolated,2,0.5,0.3,0.15
+btc-120,bybit,BTCUSDT,120,1,10,bogus,2,0.5,0.3,0.15
+`
+	res, err := h.svc.ImportLevelsFrom(h.ctx, strings.NewReader(csvWithError), usecase.FormatCSV, usecase.ImportOptions{Prune: true, ChangedBy: "test"})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if res.Applied || len(res.Errors) != 2 || res.Errors[0].Row != 2 || res.Errors[1].Row != 3 {
+		t.Fatalf("Expected errors on rows 2 and 3 and nothing applied, got %+v", res)
+	}
+	if levels, _ := h.store.ListLevels(h.ctx); len(levels) != 1 {
+		t.Fatalf("Expected nothing written, got %d levels", len(levels))
+	}
+
+	csvOK := `key,exchange,symbol,level_price,base_size,leverage,margin_type,take_profit_pct,tier1_pct,tier2_pct,tier3_pct
+btc-100,bybit,BTCUSDT,100,1,10,isolated,2,0.5,0.3,0.15
+btc-110,bybit,BTCUSDT,110,2,10,isolated,2,0.5,0.3,0.15
+`
+	res, err = h.svc.ImportLevelsFrom(h.ctx, strings.NewReader(csvOK), usecase.FormatCSV, usecase.ImportOptions{DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if counts := countActions(res); res.Applied || counts[usecase.ImportCreate] != 2 || counts[usecase.ImportDelete] != 1 || len(res.Tiers) != 1 {
+		t.Fatalf("Expected dry run with 2 creates, 1 delete and a tier change, got %+v", res)
+	}
+
+	res, err = h.svc.ImportLevelsFrom(h.ctx, strings.NewReader(csvOK), usecase.FormatCSV, usecase.ImportOptions{Prune: true, ChangedBy: "test"})
+	if err != nil || !res.Applied {
+		t.Fatalf("Expected import to apply, got %+v %v", res, err)
+	}
+	levels, _ := h.store.ListLevels(h.ctx)
+	if len(levels) != 2 {
+		t.Fatalf("Expected 2 imported levels after prune, got %d", len(levels))
+	}
+	tiers, err := h.store.GetSymbolTiers(h.ctx, "bybit", "BTCUSDT")
+	if err != nil || tiers.Tier1Pct != 0.005 {
+		t.Errorf("Expected imported tiers, got %+v %v", tiers, err)
+	}
+
+	// Upsert by key: same key, new size -> update with a revision
+	yamlUpdate := `
+- key: btc-100
+  exchange: bybit
+  symbol: BTCUSDT
+  level_price: 100
+  base_size: 3
+  leverage: 10
+  margin_type: isolated
+  take_profit_pct: 2
+`
+	res, err = h.svc.ImportLevelsFrom(h.ctx, strings.NewReader(yamlUpdate), usecase.FormatYAML, usecase.ImportOptions{ChangedBy: "test"})
+	if err != nil || !res.Applied {
+		t.Fatalf("Expected yaml import to apply, got %+v %v", res, err)
+	}
+	if counts := countActions(res); counts[usecase.ImportUpdate] != 1 || len(res.Changes[0].Changes) != 1 {
+		t.Fatalf("Expected one update of base_size, got %+v", res.Changes)
+	}
+	revisions, _ := h.svc.ListLevelRevisions(h.ctx, res.Changes[0].LevelID, 10)
+	if len(revisions) != 1 || revisions[0].ChangedBy != "test" {
+		t.Errorf("Expected a revision for the import update, got %+v", revisions)
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func TestUpdateLevel_RecordsRevision(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	level := &domain.Level{
+		ID:             "edit-level",
+		Exchange:       h.exchange,
+		Symbol:         h.symbol,
+		LevelPrice:     100,
+		BaseSize:       1,
+		Leverage:       10,
+		MarginType:     "isolated",
+		CoolDownMs:     1000,
+		TakeProfitPct:  0.02,
+		TakeProfitMode: "fixed",
+		CreatedAt:      time.Now(),
+	}
+	if err := h.svc.CreateLevel(h.ctx, level); err != nil {
+		t.Fatalf("Failed to create level: %v", err)
+	}
+
+	tp, cooldown := 1.5, int64(5000)
+	updated, changes, err := h.svc.UpdateLevel(h.ctx, level.ID, usecase.LevelPatch{
+		TakeProfitPct: &tp,
+		CoolDownMs:    &cooldown,
+	}, "alice")
+	if err != nil {
+		t.Fatalf("Failed to update level: %v", err)
+	}
+	if updated.TakeProfitPct != 0.015 || updated.CoolDownMs != 5000 || updated.ID != level.ID {
+		t.Errorf("Unexpected updated level %+v", updated)
+	}
+	if len(changes) != 2 {
+		t.Errorf("Expected 2 changed fields, got %+v", changes)
+	}
+
+	saved, err := h.store.GetLevel(h.ctx, level.ID)
+	if err != nil {
+		t.Fatalf("Failed to load level: %v", err)
+	}
+	if saved.TakeProfitPct != 0.015 || saved.CoolDownMs != 5000 || saved.LevelPrice != 100 {
+		t.Errorf("Expected edit to be persisted, got %+v", saved)
+	}
+
+	revisions, err := h.svc.ListLevelRevisions(h.ctx, level.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to list revisions: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].ChangedBy != "alice" {
+		t.Fatalf("Expected 1 revision by alice, got %+v", revisions)
+	}
+	want := map[string]domain.LevelFieldChange{
+		"cool_down_ms":    {Field: "cool_down_ms", Old: "1000", New: "5000"},
+		"take_profit_pct": {Field: "take_profit_pct", Old: "2%", New: "1.5%"},
+	}
+	for _, c := range revisions[0].Changes {
+		if want[c.Field] != c {
+			t.Errorf("Unexpected change %+v", c)
+		}
+	}
+
+	// No-op edit does not create a revision
+	if _, changes, err := h.svc.UpdateLevel(h.ctx, level.ID, usecase.LevelPatch{TakeProfitPct: &tp}, "alice"); err != nil || len(changes) != 0 {
+		t.Errorf("Expected no-op edit, got %v %v", changes, err)
+	}
+	if revisions, _ := h.svc.ListLevelRevisions(h.ctx, level.ID, 10); len(revisions) != 1 {
+		t.Errorf("Expected still 1 revision, got %d", len(revisions))
+	}
+}
+
+func TestUpdateLevel_PriceChangeRefusedWithPosition(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	level := &domain.Level{
+		ID:                "edit-price",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        100,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		TakeProfitPct:     0.05,
+		CreatedAt:         time.Now(),
+	}
+	saveLevels(h, level)
+
+	h.Tick(101)
+	h.Tick(100.45) // T1 -> level owns a LONG share
+	if share := h.svc.GetLevelPosition(level.ID); share.Size == 0 {
+		t.Fatalf("Expected an open share")
+	}
+
+	price := 95.0
+	_, _, err := h.svc.UpdateLevel(h.ctx, level.ID, usecase.LevelPatch{LevelPrice: &price}, "bob")
+	if !errors.Is(err, usecase.ErrLevelHasPosition) {
+		t.Fatalf("Expected ErrLevelHasPosition, got %v", err)
+	}
+
+	// Other fields stay editable while the position is open
+	tp := 3.0
+	if _, _, err := h.svc.UpdateLevel(h.ctx, level.ID, usecase.LevelPatch{TakeProfitPct: &tp}, "bob"); err != nil {
+		t.Errorf("Expected TP edit to be allowed with an open position: %v", err)
+	}
+	if st := h.svc.GetLevelState(level.ID); !st.Tier1Triggered {
+		t.Errorf("Expected tier state to survive the edit, got %+v", st)
+	}
+	if share := h.svc.GetLevelPosition(level.ID); share.Size == 0 {
+		t.Errorf("Expected the share to survive the edit")
+	}
+
+	// Invalid values are rejected
+	bad := -1.0
+	if _, _, err := h.svc.UpdateLevel(h.ctx, level.ID, usecase.LevelPatch{BaseSize: &bad}, "bob"); err == nil {
+		t.Errorf("Expected negative base size to be rejected")
+	}
+}
+
+func TestUpdateLevel_TakeProfitModes(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.SetupLevel(100, false)
+
+	for _, mode := range []string{domain.TakeProfitSentiment, domain.TakeProfitLiquidity, domain.TakeProfitFixed} {
+		updated, _, err := h.svc.UpdateLevel(h.ctx, h.levelID, usecase.LevelPatch{TakeProfitMode: &mode}, "bob")
+		if err != nil || updated.TakeProfitMode != mode {
+			t.Errorf("Expected take_profit_mode %s to be accepted, got %v", mode, err)
+		}
+	}
+
+	unknown := "moon"
+	if _, _, err := h.svc.UpdateLevel(h.ctx, h.levelID, usecase.LevelPatch{TakeProfitMode: &unknown}, "bob"); err == nil {
+		t.Errorf("Expected an unknown take_profit_mode to be rejected")
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+// setupLeveragedLevel gives the scenario short level at 10000 (T1 at 9950) a leverage and
+// margin mode, with a flat 0.5% maintenance margin and a 0.2% liquidation buffer.
+func setupLeveragedLevel(h *TestScenarioHelper, leverage int, marginType string) {
+	h.mockEx.RiskLimits = []domain.RiskLimitTier{{RiskLimitValue: 2000000, MaintenanceMarginRate: 0.005, MaxLeverage: 100}}
+	h.mockEx.Instruments = []domain.Instrument{{Symbol: h.symbol, QtyStep: 0.001, MinOrderQty: 0.001}}
+	h.svc.SetLiquidationGuard(usecase.LiquidationGuardConfig{BufferPct: 0.002})
+	h.svc.SetDecisionRepository(h.store)
+	h.SetupLevel(10000, true)
+	configureLevel(h, func(l *domain.Level) {
+		l.Leverage = leverage
+		l.MarginType = marginType
+	})
+}
+
+func TestLiquidationGuard_RefusesIsolatedEntryLiquidatedBeforeLevel(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	// 100x isolated short at 9960 liquidates at 9960 * (1 + 1% - 0.5%) = 10009.8, under 10020
+	setupLeveragedLevel(h, 100, "isolated")
+
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(0)
+
+	entries, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one tier decision, got %d %v", len(entries), err)
+	}
+	if entries[0].Outcome != domain.OutcomeRejected {
+		t.Errorf("Expected rejected entry, got %s (%s)", entries[0].Outcome, entries[0].Reason)
+	}
+	if _, ok := entries[0].Inputs["liquidation"]; !ok {
+		t.Errorf("Expected liquidation projection in inputs, got %v", entries[0].Inputs)
+	}
+}
+
+func TestLiquidationGuard_AllowsSafeLeverageAndAdds(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	// 50x liquidates around 10109, well above the level
+	setupLeveragedLevel(h, 50, "isolated")
+
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(1)
+	h.AssertLastTrade(domain.SideShort, 0.1)
+
+	// T2 add keeps the projected liquidation beyond the level
+	h.Tick(9975)
+	h.AssertTradeCount(2)
+}
+
+func TestLiquidationGuard_ResizesCrossEntry(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	// 5 USD of cross margin carries at most ~0.045 BTC short with liquidation beyond 10020
+	h.mockEx.Equity = &domain.AccountEquity{Equity: 5, WalletBalance: 5}
+	setupLeveragedLevel(h, 10, "cross")
+
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(1)
+	h.AssertLastTrade(domain.SideShort, 0.045)
+}
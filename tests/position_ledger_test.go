@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+func TestPositionLedger_ExitClosesOnlyOwnShare(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	// Two long levels close to each other so one dip triggers T1 on both
+	levelA := &domain.Level{
+		ID:                "ledger-a",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        100,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		TakeProfitPct:     0.01,
+		CreatedAt:         time.Now(),
+	}
+	levelB := &domain.Level{
+		ID:                "ledger-b",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        100.1,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		CreatedAt:         time.Now(),
+	}
+	saveLevels(h, levelA, levelB)
+
+	h.Tick(101)
+	h.Tick(100.45) // T1 of both levels (100.5 and 100.6005)
+
+	if h.mockEx.Position == nil || math.Abs(h.mockEx.Position.Size-2) > 1e-9 {
+		t.Fatalf("Expected combined position of 2, got %+v", h.mockEx.Position)
+	}
+	for _, id := range []string{levelA.ID, levelB.ID} {
+		if share := h.svc.GetLevelPosition(id); share.Size != 1 || share.Side != domain.SideLong {
+			t.Fatalf("Expected level %s to own 1 LONG, got %+v", id, share)
+		}
+	}
+
+	h.Tick(101.5) // +1.05% on A's share -> A takes profit, B keeps running
+
+	if h.mockEx.Position == nil || math.Abs(h.mockEx.Position.Size-1) > 1e-9 {
+		t.Fatalf("Expected only A's share to be closed, got %+v", h.mockEx.Position)
+	}
+	if share := h.svc.GetLevelPosition(levelA.ID); share.Size != 0 || share.RealizedPnL <= 0 {
+		t.Errorf("Expected A flat with realized profit, got %+v", share)
+	}
+	if share := h.svc.GetLevelPosition(levelB.ID); share.Size != 1 {
+		t.Errorf("Expected B to keep its share, got %+v", share)
+	}
+	if st := h.svc.GetLevelState(levelB.ID); !st.Tier1Triggered {
+		t.Errorf("Expected B tier state to survive A's exit, got %+v", st)
+	}
+	if st := h.svc.GetLevelState(levelA.ID); st.Tier1Triggered {
+		t.Errorf("Expected A tier state to be reset, got %+v", st)
+	}
+
+	history, err := h.store.ListPositionHistory(h.ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 history row, got %d", len(history))
+	}
+	if history[0].LevelID != levelA.ID || !history[0].Partial || history[0].Size != 1 {
+		t.Errorf("Expected partial 1.0 row owned by %s, got %+v", levelA.ID, history[0])
+	}
+}
+
+func TestPositionLedger_OppositeEntryIsRefused(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetDecisionRepository(h.store)
+
+	// A support and a resistance level sharing T1 at 99.9975, a one-way position nets both
+	support := &domain.Level{
+		ID:                "ledger-support",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        99.5,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		CreatedAt:         time.Now(),
+	}
+	resistance := &domain.Level{
+		ID:                "ledger-resistance",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        100.5,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		CreatedAt:         time.Now(),
+	}
+	saveLevels(h, support, resistance)
+
+	// 1. Falling through T1 opens the support's long
+	h.Tick(100.2)
+	h.Tick(99.9)
+	if share := h.svc.GetLevelPosition(support.ID); share.Size != 1 || share.Side != domain.SideLong {
+		t.Fatalf("Expected the support to own 1 LONG, got %+v", share)
+	}
+
+	// 2. Rising back through it would short against the support's share
+	h.Tick(100.05)
+	if h.mockEx.SellCalled {
+		t.Fatalf("Expected no short while the support holds a long")
+	}
+	if share := h.svc.GetLevelPosition(resistance.ID); share.Size != 0 {
+		t.Errorf("Expected the resistance to own nothing, got %+v", share)
+	}
+	if h.mockEx.Position == nil || h.mockEx.Position.Size != 1 || h.svc.GetLevelPosition(support.ID).Size != 1 {
+		t.Errorf("Expected the support's long untouched, got %+v", h.mockEx.Position)
+	}
+	entries, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: resistance.ID, Kind: domain.DecisionTierTrigger})
+	if len(entries) != 1 || entries[0].Outcome != domain.OutcomeSkipped || !strings.Contains(entries[0].Reason, support.ID) {
+		t.Errorf("Expected the short skipped for the support's share, got %+v", entries)
+	}
+}
+
+func TestPositionLedger_FailedPartialExitIsRetried(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	levelA := &domain.Level{
+		ID:                "ledger-a",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        100,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		TakeProfitPct:     0.01,
+		CreatedAt:         time.Now(),
+	}
+	levelB := &domain.Level{
+		ID:                "ledger-b",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        100.1,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		CreatedAt:         time.Now(),
+	}
+	saveLevels(h, levelA, levelB)
+	h.Tick(101)
+	h.Tick(100.45)
+
+	// 1. A's take profit fails to reduce, nothing is booked and A keeps its share
+	h.mockEx.ReduceErr = errors.New("reduce rejected")
+	h.Tick(101.5)
+	if h.mockEx.Position == nil || math.Abs(h.mockEx.Position.Size-2) > 1e-9 {
+		t.Fatalf("Expected the combined position untouched, got %+v", h.mockEx.Position)
+	}
+	if share := h.svc.GetLevelPosition(levelA.ID); share.Size != 1 || share.RealizedPnL != 0 {
+		t.Errorf("Expected A to keep its share, got %+v", share)
+	}
+	if st := h.svc.GetLevelState(levelA.ID); !st.Tier1Triggered {
+		t.Errorf("Expected A's tier state kept, got %+v", st)
+	}
+	if history, _ := h.store.ListPositionHistory(h.ctx, 10); len(history) != 0 {
+		t.Errorf("Expected no history row, got %+v", history)
+	}
+
+	// 2. The next tick retries the exit
+	h.mockEx.ReduceErr = nil
+	h.Tick(101.5)
+	if h.mockEx.Position == nil || math.Abs(h.mockEx.Position.Size-1) > 1e-9 {
+		t.Fatalf("Expected A's share closed on the retry, got %+v", h.mockEx.Position)
+	}
+	if share := h.svc.GetLevelPosition(levelA.ID); share.Size != 0 || share.RealizedPnL <= 0 {
+		t.Errorf("Expected A flat with realized profit, got %+v", share)
+	}
+	if history, _ := h.store.ListPositionHistory(h.ctx, 10); len(history) != 1 || history[0].LevelID != levelA.ID {
+		t.Errorf("Expected one history row of A, got %+v", history)
+	}
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+func lastHistoryReason(h *TestScenarioHelper) string {
+	history, err := h.store.ListPositionHistory(h.ctx, 1)
+	if err != nil || len(history) == 0 {
+		h.t.Fatalf("Expected a history row, err: %v", err)
+	}
+	return history[0].Reason
+}
+
+func TestTrailingStop_ClosesAfterPullback(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	level := setupProtectiveLevel(h, func(l *domain.Level) {
+		l.TrailingActivationPct = 0.01
+		l.TrailingStopPct = 0.005
+	})
+
+	h.Tick(100)
+	h.Tick(100.5) // Not armed yet (< +1%)
+	if st := h.svc.GetLevelState(level.ID); st.TrailingActive {
+		t.Fatalf("Trailing stop should not be armed below activation")
+	}
+
+	h.Tick(101) // Armed, stop 100.495
+	h.Tick(102) // Peak moves, stop 101.49
+	if st := h.svc.GetLevelState(level.ID); st.TrailingStopPrice < 101.48 || st.TrailingStopPrice > 101.50 {
+		t.Fatalf("Expected trailing stop ~101.49, got %f", st.TrailingStopPrice)
+	}
+
+	h.Tick(101.6) // Above stop
+	if h.mockEx.Position == nil {
+		t.Fatalf("Position closed before the trailing stop was hit")
+	}
+
+	h.Tick(101.4) // Crosses stop
+	if h.mockEx.Position != nil {
+		t.Fatalf("Expected trailing stop to close the position")
+	}
+	if reason := lastHistoryReason(h); reason != "Trailing Stop" {
+		t.Errorf("Expected reason Trailing Stop, got %q", reason)
+	}
+	if st := h.svc.GetLevelState(level.ID); st.TrailingActive || st.TrailingStopPrice != 0 {
+		t.Errorf("Expected trailing state to be cleared after close, got %+v", st)
+	}
+}
+
+func TestBreakEvenStop_ProfitThreshold(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	setupProtectiveLevel(h, func(l *domain.Level) {
+		l.BreakEvenProfitPct = 0.01
+	})
+
+	h.Tick(100)
+	h.Tick(101)   // Armed at entry 100
+	h.Tick(100.5) // Still above entry
+	if h.mockEx.Position == nil {
+		t.Fatalf("Position closed before returning to entry")
+	}
+
+	h.Tick(99.99)
+	if h.mockEx.Position != nil {
+		t.Fatalf("Expected break-even stop to close the position")
+	}
+	if reason := lastHistoryReason(h); reason != "Break-Even Stop" {
+		t.Errorf("Expected reason Break-Even Stop, got %q", reason)
+	}
+}
+
+func TestBreakEvenStop_ExchangeMode(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	setupProtectiveLevel(h, func(l *domain.Level) {
+		l.BreakEvenProfitPct = 0.01
+		l.TrailingStopMode = "exchange"
+	})
+
+	h.Tick(100)
+	h.Tick(101)
+	if h.mockEx.LastTradingStop.StopLoss != 100 {
+		t.Fatalf("Expected trading-stop at entry 100, got %+v", h.mockEx.LastTradingStop)
+	}
+
+	// The exchange owns the stop, the app must not close on its own
+	h.Tick(99.5)
+	if h.mockEx.Position == nil {
+		t.Errorf("App should not close the position in exchange mode")
+	}
+}
+
+func TestBreakEvenStop_ExchangeModeSharedSymbolStaysInApp(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	level := setupProtectiveLevel(h, func(l *domain.Level) {
+		l.BreakEvenProfitPct = 0.01
+		l.TrailingStopMode = "exchange"
+	})
+	// A second live level on the symbol, far from the price
+	other := &domain.Level{
+		ID:                "protective-other",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        80,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		CreatedAt:         time.Now(),
+	}
+	saveLevels(h, other)
+
+	// The exchange stop would close the other level's share too, the app enforces it
+	h.Tick(100)
+	h.Tick(101)
+	if h.mockEx.LastTradingStop != (domain.TradingStop{}) {
+		t.Fatalf("Expected no trading-stop with two live levels, got %+v", h.mockEx.LastTradingStop)
+	}
+	h.Tick(99.99)
+	if h.mockEx.Position != nil {
+		t.Fatalf("Expected the app to close at break-even")
+	}
+	history, _ := h.store.ListPositionHistory(h.ctx, 10)
+	if len(history) != 1 || history[0].LevelID != level.ID || history[0].Reason != "Break-Even Stop" {
+		t.Errorf("Expected one break-even row of %s, got %+v", level.ID, history)
+	}
+}
+
+func TestBreakEvenStop_ExchangeStopFiredIsBooked(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.SetupLevel(10000, false)
+	configureLevel(h, func(l *domain.Level) {
+		l.BreakEvenProfitPct = 0.01
+		l.TrailingStopMode = "exchange"
+	})
+
+	// 1. Short 0.1 at 9960, +1.1% arms break-even on the exchange
+	h.Tick(9900)
+	h.Tick(9960)
+	h.Tick(9850)
+	if h.mockEx.LastTradingStop.StopLoss != 9960 {
+		t.Fatalf("Expected trading-stop at entry 9960, got %+v", h.mockEx.LastTradingStop)
+	}
+
+	// 2. The exchange closes the position, the next tick books the level's share at the stop
+	h.mockEx.Position = nil
+	time.Sleep(1100 * time.Millisecond) // Position cache expiry
+	h.Tick(9900)
+	if share := h.svc.GetLevelPosition(h.levelID); share.Size != 0 {
+		t.Fatalf("Expected the share closed, got %+v", share)
+	}
+	history, _ := h.store.ListPositionHistory(h.ctx, 10)
+	if len(history) != 1 || history[0].LevelID != h.levelID || history[0].Reason != "Break-Even Stop" ||
+		history[0].ExitPrice != 9960 || math.Abs(history[0].Size-0.1) > 1e-9 {
+		t.Errorf("Expected the share booked at the 9960 stop, got %+v", history)
+	}
+	if st := h.svc.GetLevelState(h.levelID); st.Tier1Triggered || st.ExchangeStopSet {
+		t.Errorf("Expected the level reset, got %+v", st)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/infrastructure/storage"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func TestRiskManager_ExposureLimits(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init store: %v", err)
+	}
+	mockEx := &MockExchange{Price: 10000}
+	risk := usecase.NewRiskManager(mockEx, store, domain.RiskLimits{MaxSymbolNotional: 1500, MaxPositions: 1})
+
+	// 1. Within limits
+	if err := risk.CheckOrder(ctx, usecase.RiskOrder{Symbol: "BTCUSDT", Side: domain.SideLong, Size: 0.1}); err != nil {
+		t.Fatalf("Expected order within limits to pass, got %v", err)
+	}
+
+	// 2. Adding to the position breaches the symbol notional
+	mockEx.SetPosition("BTCUSDT", domain.SideLong, 0.1, 10000)
+	err = risk.CheckOrder(ctx, usecase.RiskOrder{Symbol: "BTCUSDT", Side: domain.SideLong, Size: 0.1})
+	if !errors.Is(err, usecase.ErrRiskRejected) {
+		t.Fatalf("Expected symbol notional rejection, got %v", err)
+	}
+
+	// 3. A second symbol breaches the position count
+	err = risk.CheckOrder(ctx, usecase.RiskOrder{Symbol: "ETHUSDT", Side: domain.SideLong, Size: 0.01, Price: 3000})
+	var rejection *usecase.RiskRejection
+	if !errors.As(err, &rejection) || len(rejection.Reasons) != 1 {
+		t.Fatalf("Expected position count rejection, got %v", err)
+	}
+
+	// 4. Reducing the position always passes
+	if err := risk.CheckOrder(ctx, usecase.RiskOrder{Symbol: "BTCUSDT", Side: domain.SideShort, Size: 0.05}); err != nil {
+		t.Errorf("Expected reducing order to pass, got %v", err)
+	}
+
+	// 5. Positions unknown: reduce-only still passes, anything else is refused
+	mockEx.PositionsErr = errors.New("timeout")
+	if err := risk.CheckOrder(ctx, usecase.RiskOrder{Symbol: "BTCUSDT", Side: domain.SideShort, Size: 0.1, ReduceOnly: true}); err != nil {
+		t.Errorf("Expected reduce-only order to pass without positions, got %v", err)
+	}
+	if err := risk.CheckOrder(ctx, usecase.RiskOrder{Symbol: "BTCUSDT", Side: domain.SideShort, Size: 0.05}); !errors.Is(err, usecase.ErrRiskRejected) {
+		t.Errorf("Expected order to be refused without positions, got %v", err)
+	}
+}
+
+func TestRiskManager_DailyLossLatchesUntilReset(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init store: %v", err)
+	}
+	mockEx := &MockExchange{Price: 10000, Equity: &domain.AccountEquity{Equity: 1000, WalletBalance: 1000}}
+	risk := usecase.NewRiskManager(mockEx, store, domain.RiskLimits{MaxDailyLoss: 50})
+	order := usecase.RiskOrder{Symbol: "BTCUSDT", Side: domain.SideShort, Size: 0.01}
+
+	// 1. First check sets the day start balance
+	if err := risk.CheckOrder(ctx, order); err != nil {
+		t.Fatalf("Expected order to pass, got %v", err)
+	}
+
+	// 2. Losing 60 latches the halt
+	mockEx.Equity = &domain.AccountEquity{Equity: 940, WalletBalance: 940}
+	if err := risk.CheckOrder(ctx, order); !errors.Is(err, usecase.ErrRiskRejected) || !risk.IsHalted() {
+		t.Fatalf("Expected daily loss halt, got %v", err)
+	}
+
+	// 3. Recovering does not clear the latch, a restart neither
+	mockEx.Equity = &domain.AccountEquity{Equity: 1000, WalletBalance: 1000}
+	if err := risk.CheckOrder(ctx, order); err == nil {
+		t.Fatal("Expected halt to stay latched")
+	}
+	restarted := usecase.NewRiskManager(mockEx, store, domain.RiskLimits{})
+	if !restarted.IsHalted() || restarted.Limits().MaxDailyLoss != 50 {
+		t.Fatalf("Expected persisted halt and limits, got halted=%v limits=%+v", restarted.IsHalted(), restarted.Limits())
+	}
+
+	// 4. Operator reset resumes trading from the current balance
+	if err := restarted.Reset(ctx, "test"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if err := restarted.CheckOrder(ctx, order); err != nil {
+		t.Errorf("Expected order to pass after reset, got %v", err)
+	}
+}
+
+func TestRiskManager_BlocksLevelEntry(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	risk := usecase.NewRiskManager(h.mockEx, h.store, domain.RiskLimits{MaxSymbolNotional: 500})
+	tradeThrough(h, usecase.NewRiskManagedExchange(h.mockEx, risk))
+	h.SetupLevel(10000, true)
+
+	// 0.1 BTC at 9960 is ~996 notional, over the symbol limit
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(0)
+	if h.mockEx.SellCalled {
+		t.Error("Expected rejected entry to never reach the exchange")
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+// setupRiskSizedLevel turns the scenario level into a risk sized one. With the scenario
+// tiers a short at 10000 has its tiers at 9950/9970/9985, so the full 1/1/2 ladder loses
+// 50 + 30 + 2*15 = 110 per unit if price returns to the level.
+func setupRiskSizedLevel(h *TestScenarioHelper, mode string, riskPct, riskUSD float64) {
+	h.mockEx.Instruments = []domain.Instrument{{Symbol: h.symbol, QtyStep: 0.001, MinOrderQty: 0.001, MaxOrderQty: 100, MinNotional: 5}}
+	h.SetupLevel(10000, true)
+	configureLevel(h, func(l *domain.Level) {
+		l.SizingMode = mode
+		l.RiskPct = riskPct
+		l.RiskUSD = riskUSD
+	})
+}
+
+func TestRiskSizing_PercentOfEquity(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetDecisionRepository(h.store)
+	h.mockEx.Equity = &domain.AccountEquity{Equity: 11000, WalletBalance: 11000}
+	setupRiskSizedLevel(h, domain.SizingRiskPct, 0.005, 0) // 55 USD
+
+	// 1. T1: 55 / 110 = 0.5 per unit
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(1)
+	h.AssertLastTrade(domain.SideShort, 0.5)
+
+	// 2. T2 is one more unit
+	h.Tick(9975)
+	h.AssertTradeCount(2)
+	h.AssertLastTrade(domain.SideShort, 0.5)
+
+	// 3. The sizing inputs are journaled
+	entries, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected two tier decisions, got %d %v", len(entries), err)
+	}
+	if _, ok := entries[0].Inputs["sizing"]; !ok {
+		t.Errorf("Expected sizing in decision inputs, got %v", entries[0].Inputs)
+	}
+}
+
+func TestRiskSizing_FixedUSDRoundsToQtyStep(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	setupRiskSizedLevel(h, domain.SizingRiskUSD, 0, 40) // 40 / 110 = 0.3636...
+
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertLastTrade(domain.SideShort, 0.363)
+}
+
+func TestRiskSizing_BelowMinQtySkipsEntry(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	setupRiskSizedLevel(h, domain.SizingRiskUSD, 0, 0.05) // 0.00045 per unit, under the 0.001 minimum
+
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(0)
+	if h.mockEx.SellCalled {
+		t.Error("Expected undersized entry to never reach the exchange")
+	}
+}
+
+func TestRiskSizing_RiskPctBounds(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.SetupLevel(10000, true)
+	mode := domain.SizingRiskPct
+
+	// risk_pct is entered in percent and must stay inside (0%, 100%)
+	for _, pct := range []float64{0, 100, 150} {
+		_, _, err := h.svc.UpdateLevel(h.ctx, h.levelID, usecase.LevelPatch{SizingMode: &mode, RiskPct: &pct}, "bob")
+		if err == nil || !strings.Contains(err.Error(), "(0%, 100%)") {
+			t.Errorf("Expected risk_pct %v%% to be rejected, got %v", pct, err)
+		}
+	}
+	for _, pct := range []float64{0.5, 99.9} {
+		updated, _, err := h.svc.UpdateLevel(h.ctx, h.levelID, usecase.LevelPatch{SizingMode: &mode, RiskPct: &pct}, "bob")
+		if err != nil || math.Abs(updated.RiskPct-pct/100) > 1e-12 {
+			t.Errorf("Expected risk_pct %v%% to be accepted, got %v", pct, err)
+		}
+	}
+}
@@ -92,6 +92,8 @@ func (h *TestScenarioHelper) Tick(price float64) {
 	if err := h.svc.ProcessTick(h.ctx, h.exchange, h.symbol, price); err != nil {
 		h.t.Fatalf("ProcessTick failed: %v", err)
 	}
+	h.svc.WaitEntries() // Parent orders book in the background
+	h.svc.WaitExits()
 }
 
 func (h *TestScenarioHelper) AssertTradeCount(count int) {
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func setLevelMode(h *TestScenarioHelper, mode string) error {
+	_, _, err := h.svc.UpdateLevel(h.ctx, h.levelID, usecase.LevelPatch{Mode: &mode}, "test")
+	return err
+}
+
+func TestShadowMode_SimulatesFillsWithoutOrders(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetShadowRepository(h.store)
+	h.SetupLevel(10000, true)
+	if err := setLevelMode(h, domain.LevelModeShadow); err != nil {
+		t.Fatalf("Failed to switch to shadow: %v", err)
+	}
+	h.mockEx.OrderBook = &domain.OrderBook{
+		Bids: []domain.OrderBookEntry{{Price: 9955, Size: 10}},
+		Asks: []domain.OrderBookEntry{{Price: 9965, Size: 10}},
+	}
+
+	// 1. Crossing T1 opens a simulated short at the bid, nothing reaches the exchange
+	h.Tick(9900)
+	h.Tick(9960)
+	if h.mockEx.SellCalled || h.mockEx.BuyCalled || h.mockEx.Position != nil {
+		t.Fatalf("Expected no exchange orders in shadow mode")
+	}
+	h.AssertTradeCount(0)
+
+	share := h.svc.GetShadowPosition(h.levelID)
+	if share.Side != domain.SideShort || share.Size <= 0 || share.EntryPrice != 9955 {
+		t.Fatalf("Expected simulated short at 9955, got %+v", share)
+	}
+	if err := setLevelMode(h, domain.LevelModeLive); !errors.Is(err, usecase.ErrLevelHasPosition) {
+		t.Errorf("Expected mode change to be refused with an open shadow share, got %v", err)
+	}
+
+	// 2. Stop loss at base closes the share at the ask
+	h.Tick(10000)
+	if share := h.svc.GetShadowPosition(h.levelID); share.Size != 0 {
+		t.Fatalf("Expected shadow share to be closed, got %+v", share)
+	}
+	if h.mockEx.Position != nil {
+		t.Fatalf("Expected no exchange position")
+	}
+
+	trades, err := h.svc.ListShadowTrades(h.ctx, h.levelID, 10)
+	if err != nil || len(trades) != 2 {
+		t.Fatalf("Expected open and close in the shadow ledger, got %d %v", len(trades), err)
+	}
+	exit := trades[0]
+	wantPnL := (9955 - 9965) * exit.Size
+	if exit.Action != domain.ShadowActionClose || exit.Price != 9965 || math.Abs(exit.RealizedPnL-wantPnL) > 1e-9 {
+		t.Errorf("Expected close at 9965 with PnL %f, got %+v", wantPnL, exit)
+	}
+
+	// 3. Performance keeps shadow and live results apart
+	perf, err := h.svc.LevelPerformance(h.ctx)
+	if err != nil || len(perf) != 1 {
+		t.Fatalf("Expected one performance row, got %+v %v", perf, err)
+	}
+	if perf[0].Mode != domain.LevelModeShadow || perf[0].Shadow.Exits != 1 || perf[0].Live.Exits != 0 ||
+		math.Abs(perf[0].Shadow.RealizedPnL-wantPnL) > 1e-9 {
+		t.Errorf("Unexpected performance %+v", perf[0])
+	}
+}
+
+func TestAlertMode_RecordsSignalsOnly(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetShadowRepository(h.store)
+	h.SetupLevel(10000, false)
+	if err := setLevelMode(h, domain.LevelModeAlert); err != nil {
+		t.Fatalf("Failed to switch to alert: %v", err)
+	}
+
+	h.Tick(9900)
+	h.Tick(9960)
+	if h.mockEx.SellCalled || h.mockEx.Position != nil {
+		t.Fatalf("Expected no exchange orders in alert mode")
+	}
+	if share := h.svc.GetShadowPosition(h.levelID); share.Size != 0 {
+		t.Errorf("Expected no simulated share for alert levels, got %+v", share)
+	}
+
+	trades, err := h.svc.ListShadowTrades(h.ctx, "", 10)
+	if err != nil || len(trades) != 1 {
+		t.Fatalf("Expected one recorded signal, got %d %v", len(trades), err)
+	}
+	if trades[0].Mode != domain.LevelModeAlert || trades[0].Action != domain.ShadowActionOpen || trades[0].Side != domain.SideShort {
+		t.Errorf("Unexpected signal %+v", trades[0])
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+// setupSlippageGuardedLevel gives the scenario level a 0.05% slippage limit over a book whose
+// best bid only holds 0.04. A T1 short of 0.1 walks into 9940 and would slip about 0.11%;
+// within the limit the book takes 0.054 (0.04 @ 9959 + 0.014 @ 9940).
+func setupSlippageGuardedLevel(h *TestScenarioHelper, action string) {
+	h.svc.SetDecisionRepository(h.store)
+	h.mockEx.Instruments = []domain.Instrument{{Symbol: h.symbol, QtyStep: 0.001, MinOrderQty: 0.001}}
+	h.mockEx.OrderBook = &domain.OrderBook{
+		Symbol: h.symbol,
+		Bids:   []domain.OrderBookEntry{{Price: 9959, Size: 0.04}, {Price: 9940, Size: 1}},
+		Asks:   []domain.OrderBookEntry{{Price: 9961, Size: 1}},
+	}
+	h.SetupLevel(10000, true)
+	configureLevel(h, func(l *domain.Level) {
+		l.MaxSlippagePct = 0.0005
+		l.SlippageAction = action
+	})
+}
+
+func TestSlippageGuard_RejectsThinBook(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	setupSlippageGuardedLevel(h, domain.SlippageReject)
+
+	h.Tick(9900)
+	h.Tick(9960)
+	if h.mockEx.SellCalled {
+		t.Fatalf("Expected no order over the slippage limit")
+	}
+	h.AssertTradeCount(0)
+	entries, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one tier decision, got %d %v", len(entries), err)
+	}
+	if entries[0].Outcome != domain.OutcomeRejected || !strings.Contains(entries[0].Reason, "slippage guard") {
+		t.Errorf("Expected the entry rejected by the slippage guard, got %s (%s)", entries[0].Outcome, entries[0].Reason)
+	}
+}
+
+func TestSlippageGuard_DownsizesAndRecordsFills(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	setupSlippageGuardedLevel(h, domain.SlippageDownsize)
+
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(1)
+	h.AssertLastTrade(domain.SideShort, 0.054)
+
+	// Estimated from the book, filled at the mock's tick price
+	trades, _ := h.store.ListTrades(h.ctx, 1)
+	expected := (0.04*9959 + 0.014*9940) / 0.054
+	if math.Abs(trades[0].ExpectedPrice-expected) > 1e-6 || trades[0].FillPrice != 9960 {
+		t.Errorf("Expected fills %f / 9960 recorded, got %f / %f", expected, trades[0].ExpectedPrice, trades[0].FillPrice)
+	}
+
+	report, err := h.svc.SlippageReport(h.ctx, 100)
+	if err != nil || len(report) != 1 || report[0].Measured != 1 {
+		t.Fatalf("Expected one measured entry in the report, got %+v %v", report, err)
+	}
+	if report[0].AvgExpectedPct <= 0 || report[0].AvgActualPct != 0 {
+		t.Errorf("Expected adverse estimate and no actual slippage vs the tick, got %+v", report[0])
+	}
+}
+
+func TestSlippageGuard_SplitsUntilFilled(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	setupSlippageGuardedLevel(h, domain.SlippageSplit)
+
+	h.Tick(9900)
+	h.Tick(9960)
+
+	// The book does not move in the mock, the rest of the order fits on the second slice
+	if h.mockEx.Position == nil || math.Abs(h.mockEx.Position.Size-0.1) > 1e-9 {
+		t.Fatalf("Expected the full 0.1 short in two slices, got %+v", h.mockEx.Position)
+	}
+	h.AssertTradeCount(1)
+	h.AssertLastTrade(domain.SideShort, 0.1)
+	entries, _ := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	exec, ok := entries[0].Inputs["execution"].(map[string]interface{})
+	if !ok || exec["action"] != "split" || len(exec["slices"].([]interface{})) != 2 {
+		t.Errorf("Expected a split execution of two slices in the journal, got %v", entries[0].Inputs["execution"])
+	}
+}
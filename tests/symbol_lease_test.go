@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+	"go.uber.org/zap"
+)
+
+func TestSymbolLease_SpeedBotRefusedOnLevelSymbol(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.SetupLevel(10000, true)
+
+	runtime := usecase.NewStrategyRuntime(h.mockEx, usecase.NewMarketService(h.mockEx, h.store), nil, zap.NewNop())
+	defer runtime.Shutdown(context.Background())
+	runtime.RegisterKind(usecase.StrategyKindLevel, func(spec usecase.StrategySpec) (usecase.Strategy, error) {
+		return usecase.NewLevelStrategy(h.svc, h.exchange), nil
+	})
+	speed := usecase.NewSpeedBotService(runtime, h.mockEx, usecase.NewMarketService(h.mockEx, h.store), zap.NewNop())
+
+	// 1. The level bot leases the symbol of its live level on start
+	levelSpec := usecase.LevelStrategySpec(h.exchange)
+	if err := runtime.Start(h.ctx, levelSpec); err != nil {
+		t.Fatalf("Failed to start level strategy: %v", err)
+	}
+	if !runtime.Leases().Holds(h.symbol, levelSpec.ID) {
+		t.Fatalf("Expected %s to own %s, got %v", levelSpec.ID, h.symbol, runtime.Leases().List())
+	}
+
+	// 2. A speed bot on the same symbol is refused with the owner in the error
+	err := speed.StartBot(h.ctx, usecase.SpeedBotConfig{Symbol: h.symbol, PositionSize: 0.1, Cooldown: time.Minute})
+	if !errors.Is(err, usecase.ErrSymbolLeased) || !strings.Contains(err.Error(), levelSpec.ID) {
+		t.Fatalf("Expected lease conflict naming %s, got %v", levelSpec.ID, err)
+	}
+	if _, running := runtime.Get("speed:" + h.symbol); running {
+		t.Fatalf("Refused speed bot must not be running")
+	}
+
+	// 3. Without live levels the lease is released and the speed bot may start
+	level, _ := h.store.GetLevel(h.ctx, h.levelID)
+	if err := h.svc.DeleteLevel(h.ctx, level); err != nil {
+		t.Fatalf("Failed to delete level: %v", err)
+	}
+	if err := speed.StartBot(h.ctx, usecase.SpeedBotConfig{Symbol: h.symbol, PositionSize: 0.1, Cooldown: time.Minute}); err != nil {
+		t.Fatalf("Expected speed bot to start on the released symbol: %v", err)
+	}
+	if owners := runtime.Leases().Owners(h.symbol); len(owners) != 1 || owners[0] != "speed:"+h.symbol {
+		t.Errorf("Expected speed bot to own %s, got %v", h.symbol, owners)
+	}
+
+	// 4. Stopping the bot frees the symbol again
+	if err := speed.StopBot(h.symbol); err != nil {
+		t.Fatalf("Failed to stop speed bot: %v", err)
+	}
+	if owners := runtime.Leases().Owners(h.symbol); len(owners) != 0 {
+		t.Errorf("Expected no owner after stop, got %v", owners)
+	}
+}
+
+func TestSymbolLease_LevelServiceLeavesForeignPositionAlone(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.svc.SetDecisionRepository(h.store)
+	h.SetupLevel(10000, true)
+
+	// The speed bot got the symbol first and holds a short that is unsafe for the level
+	leases := usecase.NewSymbolLeaseRegistry()
+	if err := leases.Acquire(h.symbol, "speed:"+h.symbol, ""); err != nil {
+		t.Fatalf("Failed to acquire: %v", err)
+	}
+	h.svc.SetSymbolLeases(leases, "level:"+h.exchange)
+	h.mockEx.SetPosition(h.symbol, domain.SideShort, 0.2, 9000)
+
+	// 1. Tier triggers are journaled as skipped, nothing is traded
+	h.Tick(9900)
+	h.Tick(9960)
+	h.AssertTradeCount(0)
+	entries, err := h.svc.ListDecisions(h.ctx, domain.DecisionFilter{LevelID: h.levelID, Kind: domain.DecisionTierTrigger})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one tier decision, got %d %v", len(entries), err)
+	}
+	if entries[0].Outcome != domain.OutcomeSkipped || !strings.Contains(entries[0].Reason, "speed:"+h.symbol) {
+		t.Errorf("Expected skip naming the owner, got %s (%s)", entries[0].Outcome, entries[0].Reason)
+	}
+
+	// 2. The safety check does not close the speed bot position above the level
+	h.Tick(10100)
+	h.svc.CheckSafety(h.ctx)
+	if h.mockEx.Position == nil || h.mockEx.Position.Size != 0.2 {
+		t.Errorf("Expected the speed bot position to stay open, got %+v", h.mockEx.Position)
+	}
+
+	// 3. New live levels on the symbol are refused
+	err = h.svc.CreateLevel(h.ctx, &domain.Level{ID: "second", Exchange: h.exchange, Symbol: h.symbol, LevelPrice: 11000, BaseSize: 0.1})
+	if !errors.Is(err, usecase.ErrSymbolLeased) {
+		t.Errorf("Expected lease conflict on create, got %v", err)
+	}
+}
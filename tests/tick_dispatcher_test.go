@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func TestTickDispatcher_SlowSymbolDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	seen := make(map[string][]float64)
+
+	d := usecase.NewTickDispatcher(func(symbol string, price float64) {
+		if symbol == "BTCUSDT" && price == 1 {
+			<-release // Slow REST call on the first BTC tick
+		}
+		mu.Lock()
+		seen[symbol] = append(seen[symbol], price)
+		mu.Unlock()
+	}, nil)
+	defer d.Close()
+
+	// 1. BTC worker gets stuck, ETH keeps flowing
+	d.Push("BTCUSDT", 1)
+	waitFor(t, func() bool { return statsOf(d, "BTCUSDT").Received == 1 && !statsOf(d, "BTCUSDT").Pending })
+	d.Push("ETHUSDT", 10)
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen["ETHUSDT"]) == 1
+	})
+
+	// 2. Ticks piling up behind the slow one are coalesced to the latest
+	for p := 2.0; p <= 5; p++ {
+		d.Push("BTCUSDT", p)
+	}
+	close(release)
+	waitFor(t, func() bool { return statsOf(d, "BTCUSDT").Processed == 2 })
+
+	mu.Lock()
+	got := append([]float64(nil), seen["BTCUSDT"]...)
+	mu.Unlock()
+	if len(got) != 2 || got[1] != 5 {
+		t.Errorf("Expected the stuck tick and then only the latest price, got %v", got)
+	}
+
+	st := statsOf(d, "BTCUSDT")
+	if st.Received != 5 || st.Dropped != 3 || st.Pending {
+		t.Errorf("Unexpected queue stats %+v", st)
+	}
+	if st.MaxLagMS <= 0 {
+		t.Errorf("Expected queue lag to be measured, got %+v", st)
+	}
+}
+
+func statsOf(d *usecase.TickDispatcher, symbol string) domain.TickQueueStats {
+	for _, st := range d.Stats() {
+		if st.Symbol == symbol {
+			return st
+		}
+	}
+	return domain.TickQueueStats{}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func TestSymbolTiers_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		tiers   domain.SymbolTiers
+		wantErr bool
+	}{
+		{"default", *domain.DefaultSymbolTiers("bybit", "BTCUSDT"), false},
+		{"ascending", domain.SymbolTiers{Tier1Pct: 0.001, Tier2Pct: 0.002, Tier3Pct: 0.003}, true},
+		{"equal", domain.SymbolTiers{Tier1Pct: 0.003, Tier2Pct: 0.003, Tier3Pct: 0.001}, true},
+		{"zero", domain.SymbolTiers{Tier1Pct: 0.003, Tier2Pct: 0.002, Tier3Pct: 0}, true},
+		{"too wide", domain.SymbolTiers{Tier1Pct: 0.2, Tier2Pct: 0.02, Tier3Pct: 0.01}, true},
+	}
+	for _, c := range cases {
+		if err := c.tiers.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", c.name, c.wantErr, err)
+		}
+	}
+	for _, p := range domain.TierPresets {
+		if err := p.Tiers("bybit", "X").Validate(); err != nil {
+			t.Errorf("Preset %s is invalid: %v", p.Name, err)
+		}
+	}
+}
+
+func TestUpdateTiers_RefusedWhileActive(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	level := &domain.Level{
+		ID:                "tiers-level",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        100,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		TakeProfitPct:     0.05,
+		CreatedAt:         time.Now(),
+	}
+	if err := h.store.SaveLevel(h.ctx, level); err != nil {
+		t.Fatalf("Failed to save level: %v", err)
+	}
+	if err := h.svc.UpdateTiers(h.ctx, domain.DefaultSymbolTiers(h.exchange, h.symbol)); err != nil {
+		t.Fatalf("Failed to set tiers: %v", err)
+	}
+
+	saved, err := h.svc.GetTiers(h.ctx, h.exchange, h.symbol)
+	if err != nil || saved.Tier1Pct != 0.005 {
+		t.Fatalf("Expected saved default tiers, got %+v %v", saved, err)
+	}
+
+	h.Tick(101)
+	h.Tick(100.45) // T1 triggers -> tiers are active
+
+	err = h.svc.UpdateTiers(h.ctx, &domain.SymbolTiers{Exchange: h.exchange, Symbol: h.symbol, Tier1Pct: 0.01, Tier2Pct: 0.005, Tier3Pct: 0.002})
+	if !errors.Is(err, usecase.ErrTiersActive) {
+		t.Fatalf("Expected ErrTiersActive, got %v", err)
+	}
+
+	// Preset on many symbols: the active one is skipped, the others are set
+	applied, skipped, err := h.svc.ApplyTierPreset(h.ctx, h.exchange, "volatile", []string{h.symbol, "ETHUSDT", "SOLUSDT"})
+	if err != nil {
+		t.Fatalf("Failed to apply preset: %v", err)
+	}
+	if len(applied) != 2 || skipped[h.symbol] == "" {
+		t.Errorf("Expected ETH and SOL applied and %s skipped, got %v / %v", h.symbol, applied, skipped)
+	}
+	eth, err := h.svc.GetTiers(h.ctx, h.exchange, "ETHUSDT")
+	if err != nil || eth.Tier1Pct != 0.03 || eth.Tier3Pct != 0.01 {
+		t.Errorf("Expected volatile preset on ETHUSDT, got %+v %v", eth, err)
+	}
+
+	if _, _, err := h.svc.ApplyTierPreset(h.ctx, h.exchange, "moon", []string{"ETHUSDT"}); err == nil {
+		t.Errorf("Expected unknown preset to be rejected")
+	}
+}
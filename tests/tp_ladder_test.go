@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+)
+
+func TestTakeProfitLadder_PartialCloses(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+
+	ladder, err := domain.ParseTakeProfitLadder("40@1, 30@2")
+	if err != nil {
+		t.Fatalf("Failed to parse ladder: %v", err)
+	}
+
+	level := &domain.Level{
+		ID:                "ladder-level",
+		Exchange:          h.exchange,
+		Symbol:            h.symbol,
+		LevelPrice:        90,
+		BaseSize:          1,
+		DisableSpeedClose: true,
+		TakeProfitMode:    "fixed",
+		TakeProfitLadder:  ladder,
+		CreatedAt:         time.Now(),
+	}
+	saveLevels(h, level)
+
+	// Ladder survives the round trip through storage
+	saved, err := h.store.GetLevel(h.ctx, level.ID)
+	if err != nil {
+		t.Fatalf("Failed to load level: %v", err)
+	}
+	if got := domain.FormatTakeProfitLadder(saved.TakeProfitLadder); got != "40@1,30@2" {
+		t.Fatalf("Expected ladder 40@1,30@2 after reload, got %q", got)
+	}
+
+	h.mockEx.SetPosition(h.symbol, domain.SideLong, 1.0, 100)
+
+	h.Tick(100)   // Nothing reached
+	h.Tick(101)   // +1% -> close 40%
+	h.Tick(101.5) // Between steps
+	h.Tick(102)   // +2% -> close 30% of the original size
+	h.Tick(103)   // Remainder keeps running (no fixed TP configured)
+
+	if h.mockEx.Position == nil {
+		t.Fatalf("Expected 30%% of the position to keep running")
+	}
+	if math.Abs(h.mockEx.Position.Size-0.3) > 1e-9 {
+		t.Errorf("Expected remaining size 0.3, got %f", h.mockEx.Position.Size)
+	}
+
+	history, err := h.store.ListPositionHistory(h.ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 partial history rows, got %d", len(history))
+	}
+	// DESC order: step 2 first
+	if !history[0].Partial || history[0].LevelID != level.ID {
+		t.Errorf("Expected partial row owned by %s, got %+v", level.ID, history[0])
+	}
+	if math.Abs(history[0].Size-0.3) > 1e-9 || math.Abs(history[1].Size-0.4) > 1e-9 {
+		t.Errorf("Expected partial sizes 0.4 then 0.3, got %f then %f", history[1].Size, history[0].Size)
+	}
+	if math.Abs(history[1].RealizedPnL-0.4) > 1e-9 {
+		t.Errorf("Expected first step PnL 0.4, got %f", history[1].RealizedPnL)
+	}
+}
+
+func TestParseTakeProfitLadder_RejectsOverHundredPercent(t *testing.T) {
+	if _, err := domain.ParseTakeProfitLadder("60@1,50@2"); err == nil {
+		t.Errorf("Expected error for ladder closing more than 100%%")
+	}
+	if _, err := domain.ParseTakeProfitLadder("abc"); err == nil {
+		t.Errorf("Expected error for malformed ladder")
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vitos/crypto_trade_level/internal/domain"
+	"github.com/vitos/crypto_trade_level/internal/usecase"
+)
+
+func TestWebhook_CreateLevelDedupAndRetry(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	wh := usecase.NewWebhookService("s3cret", h.store, h.svc, nil, nil)
+
+	body := []byte(`{"alert_id":"a-1","action":"create_level","exchange":"mock","symbol":"BTCUSDT",
+		"level":{"key":"tv-btc-100","level_price":100,"base_size":1,"leverage":10,"margin_type":"isolated","tier1_pct":0.5,"tier2_pct":0.3,"tier3_pct":0.15}}`)
+
+	// 1. Bad signature is rejected before anything is recorded
+	if _, err := wh.Handle(h.ctx, body, "sha256=deadbeef"); !errors.Is(err, usecase.ErrWebhookSignature) {
+		t.Fatalf("Expected signature error, got %v", err)
+	}
+	if alerts, _ := wh.ListAlerts(h.ctx, 10); len(alerts) != 0 {
+		t.Fatalf("Expected no recorded alerts, got %d", len(alerts))
+	}
+
+	// 2. Signed alert creates the level and its tiers
+	res, err := wh.Handle(h.ctx, body, "sha256="+wh.Sign(body))
+	if err != nil || res.Status != domain.WebhookAlertDone || res.Duplicate {
+		t.Fatalf("Expected level creation, got %+v %v", res, err)
+	}
+	levels, _ := h.store.ListLevels(h.ctx)
+	if len(levels) != 1 || levels[0].ExternalKey != "tv-btc-100" || levels[0].Source != "webhook" {
+		t.Fatalf("Expected one webhook level, got %+v", levels)
+	}
+	if tiers, err := h.store.GetSymbolTiers(h.ctx, "mock", "BTCUSDT"); err != nil || tiers.Tier1Pct != 0.005 {
+		t.Errorf("Expected tiers from the alert, got %+v %v", tiers, err)
+	}
+
+	// 3. Redelivery of the same alert is ignored
+	res, err = wh.Handle(h.ctx, body, wh.Sign(body))
+	if err != nil || !res.Duplicate || res.Status != domain.WebhookAlertDone {
+		t.Fatalf("Expected duplicate, got %+v %v", res, err)
+	}
+	if levels, _ := h.store.ListLevels(h.ctx); len(levels) != 1 {
+		t.Fatalf("Expected no second level, got %d", len(levels))
+	}
+
+	// 4. Invalid levels fail validation, the same alert ID can be sent again once fixed
+	bad := []byte(`{"alert_id":"a-2","action":"create_level","exchange":"mock","symbol":"BTCUSDT",
+		"level":{"level_price":110,"base_size":1,"leverage":10,"margin_type":"bogus"}}`)
+	res, err = wh.Handle(h.ctx, bad, wh.Sign(bad))
+	if !errors.Is(err, usecase.ErrWebhookPayload) || res == nil || res.Status != domain.WebhookAlertFailed {
+		t.Fatalf("Expected failed validation, got %+v %v", res, err)
+	}
+	fixed := []byte(`{"alert_id":"a-2","action":"create_level","exchange":"mock","symbol":"BTCUSDT",
+		"level":{"level_price":110,"base_size":1,"leverage":10,"margin_type":"cross"}}`)
+	res, err = wh.Handle(h.ctx, fixed, wh.Sign(fixed))
+	if err != nil || res.Duplicate || res.Status != domain.WebhookAlertDone {
+		t.Fatalf("Expected retry of failed alert to run, got %+v %v", res, err)
+	}
+
+	// 5. Delete by key
+	del := []byte(`{"alert_id":"a-3","action":"delete_level","key":"tv-btc-100"}`)
+	if res, err := wh.Handle(h.ctx, del, wh.Sign(del)); err != nil || res.Status != domain.WebhookAlertDone {
+		t.Fatalf("Expected delete, got %+v %v", res, err)
+	}
+	if levels, _ := h.store.ListLevels(h.ctx); len(levels) != 1 || levels[0].LevelPrice != 110 {
+		t.Errorf("Expected only the 110 level to remain, got %+v", levels)
+	}
+}
+
+func TestWebhook_PauseSymbolBlocksEntries(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	h.SetupLevel(10000, true)
+	wh := usecase.NewWebhookService("s3cret", h.store, h.svc, nil, nil)
+
+	pause := []byte(`{"alert_id":"p-1","action":"pause_symbol","symbol":"BTCUSDT"}`)
+	if _, err := wh.Handle(h.ctx, pause, wh.Sign(pause)); err != nil {
+		t.Fatalf("Failed to pause: %v", err)
+	}
+	if !h.svc.IsSymbolPaused(h.symbol) {
+		t.Fatalf("Expected symbol to be paused")
+	}
+
+	h.Tick(9900)
+	h.Tick(9960) // Crosses T1, would open a short
+	h.AssertTradeCount(0)
+
+	resume := []byte(`{"alert_id":"p-2","action":"resume_symbol","symbol":"BTCUSDT"}`)
+	if _, err := wh.Handle(h.ctx, resume, wh.Sign(resume)); err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	if len(h.svc.PausedSymbols()) != 0 {
+		t.Errorf("Expected no paused symbols, got %+v", h.svc.PausedSymbols())
+	}
+}
+
+func TestWebhook_DisabledWithoutSecret(t *testing.T) {
+	h := NewTestScenarioHelper(t)
+	wh := usecase.NewWebhookService("", h.store, h.svc, nil, nil)
+
+	body := []byte(`{"alert_id":"x","action":"pause_symbol","symbol":"BTCUSDT"}`)
+	if _, err := wh.Handle(h.ctx, body, wh.Sign(body)); !errors.Is(err, usecase.ErrWebhookDisabled) {
+		t.Fatalf("Expected disabled webhook, got %v", err)
+	}
+}